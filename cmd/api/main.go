@@ -5,13 +5,19 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/domain"
 	"github.com/ialekseychuk/my-place/internal/repository"
 	"github.com/ialekseychuk/my-place/internal/server/handlers"
 	"github.com/ialekseychuk/my-place/internal/server/middleware"
 	"github.com/ialekseychuk/my-place/internal/usecase"
+	"github.com/ialekseychuk/my-place/pkg/jwtkeys"
+	"github.com/ialekseychuk/my-place/pkg/mailer"
+	"github.com/ialekseychuk/my-place/pkg/signedtoken"
+	"github.com/ialekseychuk/my-place/pkg/sms"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -48,37 +54,177 @@ func main() {
 	scheduleRepo := repository.NewScheduleRepository(db)
 	clientRepo := repository.NewClientRepository(db)
 	locationRepo := repository.NewLocationRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	invitationRepo := repository.NewStaffInvitationRepository(db)
+	ownershipRepo := repository.NewOwnershipRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	userTokenRepo := repository.NewUserTokenRepository(db)
+	loginAuditRepo := repository.NewLoginAuditRepository(db)
+	mfaRepo := repository.NewMFARepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	clientLoginCodeRepo := repository.NewClientLoginCodeRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	eventRepo := repository.NewEventRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	calendarFeedRepo := repository.NewCalendarFeedRepository(db)
+	clientMergeRepo := repository.NewClientMergeRepository(db)
+	clientNoteRepo := repository.NewClientNoteRepository(db)
+	clientTagRepo := repository.NewClientTagRepository(db)
+	clientFieldRepo := repository.NewClientFieldRepository(db)
+	clientSegmentRepo := repository.NewClientSegmentRepository(db)
+	clientConsentRepo := repository.NewClientConsentRepository(db)
+	clientJobRepo := repository.NewClientJobRepository(db)
+	serviceCategoryRepo := repository.NewServiceCategoryRepository(db)
+	transactor := repository.NewTransactor(db)
+
+	// Postgres keeps login throttles consistent across API instances
+	loginThrottleStore := repository.NewLoginThrottleRepository(db)
+	if os.Getenv("LOGIN_THROTTLE_STORE") == "memory" {
+		loginThrottleStore = repository.NewMemoryLoginThrottleStore()
+	}
+
+	// X-Forwarded-For is only believed from these proxies
+	trustedProxies, err := middleware.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		logger.Fatal("error parsing TRUSTED_PROXIES", zap.Error(err))
+	}
+
+	// JWT keys: asymmetric keys from JWT_KEYS_DIR when configured. HS256
+	// tokens signed with JWT_SECRET before the switch are only accepted until
+	// JWT_LEGACY_HS256_UNTIL, at most a week ahead
+	jwtKeys := jwtkeys.NewHMAC(os.Getenv("JWT_SECRET"))
+	if keysDir := os.Getenv("JWT_KEYS_DIR"); keysDir != "" {
+		jwtKeys, err = jwtkeys.LoadDir(keysDir, os.Getenv("JWT_ACTIVE_KID"))
+		if err != nil {
+			logger.Fatal("error loading jwt keys", zap.Error(err))
+		}
+		if until := os.Getenv("JWT_LEGACY_HS256_UNTIL"); until != "" {
+			legacyUntil, err := time.Parse(time.RFC3339, until)
+			if err != nil {
+				logger.Fatal("error parsing JWT_LEGACY_HS256_UNTIL", zap.Error(err))
+			}
+			if err := jwtKeys.AcceptLegacyHMAC(os.Getenv("JWT_SECRET"), legacyUntil); err != nil {
+				logger.Fatal("error accepting legacy jwt secret", zap.Error(err))
+			}
+		}
+	}
+
+	// Booking manage links are signed, so clients need no account. The
+	// secret is separate so a leaked one can't forge the other's tokens
+	bookingLinkSecret := os.Getenv("BOOKING_LINK_SECRET")
+	if bookingLinkSecret == "" {
+		logger.Fatal("BOOKING_LINK_SECRET must be set")
+	}
+	if bookingLinkSecret == os.Getenv("JWT_SECRET") {
+		logger.Fatal("BOOKING_LINK_SECRET must differ from JWT_SECRET")
+	}
+
+	// mail
+	var mailSender mailer.Sender = mailer.NewLogSender(logger)
+	switch os.Getenv("MAIL_DRIVER") {
+	case "file":
+		mailDir := os.Getenv("MAIL_FILE_DIR")
+		if mailDir == "" {
+			mailDir = "./tmp/mail"
+		}
+		fileSender, err := mailer.NewFileSender(mailDir)
+		if err != nil {
+			logger.Fatal("error creating mail sender", zap.Error(err))
+		}
+		mailSender = fileSender
+	case "smtp":
+		smtpPort, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		if err != nil {
+			logger.Fatal("invalid SMTP_PORT", zap.Error(err))
+		}
+		mailSender = mailer.NewSMTPSender(os.Getenv("SMTP_HOST"), smtpPort, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("MAIL_FROM"))
+	}
+
+	// sms
+	var smsSender sms.Sender = sms.NewLogSender(logger)
+	if os.Getenv("SMS_DRIVER") == "http" {
+		if os.Getenv("SMS_GATEWAY_URL") == "" {
+			logger.Fatal("SMS_GATEWAY_URL must be set for the http SMS driver")
+		}
+		smsSender = sms.NewHTTPSender(os.Getenv("SMS_GATEWAY_URL"), os.Getenv("SMS_GATEWAY_TOKEN"), os.Getenv("SMS_FROM"))
+	}
 
 	// usecases
 	ucBusines := usecase.NewBusinessUseCase(businesRepo, locationRepo, userRepo, workingHoursRepo)
-	authService := usecase.NewAuthService(userRepo, os.Getenv("JWT_SECRET"))
+	loginGuard := usecase.NewLoginGuard(loginThrottleStore, loginAuditRepo)
+	mfaService := usecase.NewMFAService(mfaRepo, userRepo, "MyPlace")
+	authService := usecase.NewAuthService(userRepo, sessionRepo, roleRepo, userTokenRepo, loginGuard, mfaService, jwtKeys)
 
-	ucService := usecase.NewServiceUseCase(serviceRepo)
-	ucStaff := usecase.NewStaffUseCase(staffRepo, staffServiceRepo, serviceRepo)
-	ucBooking := usecase.NewBookingService(bookingRepo, serviceRepo, staffRepo, clientRepo) 
-	scheduleService := usecase.NewScheduleService(scheduleRepo, staffRepo)
-	clientService := usecase.NewClientService(clientRepo)
+	ucService := usecase.NewServiceUseCase(serviceRepo, serviceCategoryRepo)
+	serviceCategoryService := usecase.NewServiceCategoryService(serviceCategoryRepo)
+	ucStaff := usecase.NewStaffUseCase(staffRepo, staffServiceRepo, serviceRepo, transactor)
+	// domain events
+	eventDispatcher := usecase.NewEventDispatcher(eventRepo)
+	notificationService := usecase.NewNotificationService(notificationRepo, businesRepo, bookingRepo, serviceRepo, staffRepo, clientRepo, locationRepo, userRepo,
+		mailSender, smsSender)
+	notificationService.Subscribe(eventDispatcher)
+	// Webhooks to private addresses are refused unless explicitly allowed, e.g. for local receivers
+	webhookService := usecase.NewWebhookService(webhookRepo, usecase.NewWebhookHTTPClient(os.Getenv("WEBHOOK_ALLOW_PRIVATE_TARGETS") == "true"))
+	webhookService.Subscribe(eventDispatcher)
+	ucBooking := usecase.NewBookingService(bookingRepo, serviceRepo, staffRepo, staffServiceRepo, clientRepo, businesRepo, eventRepo, transactor) 
+	scheduleService := usecase.NewScheduleService(scheduleRepo, staffRepo, ownershipRepo, eventRepo, transactor)
+	clientService := usecase.NewClientService(clientRepo, businesRepo, bookingRepo, serviceRepo, staffRepo, clientTagRepo, clientFieldRepo)
+	clientMergeService := usecase.NewClientMergeService(clientRepo, clientMergeRepo, bookingRepo, clientNoteRepo, clientTagRepo, clientFieldRepo, clientConsentRepo, notificationRepo, eventRepo, transactor)
+	clientNoteService := usecase.NewClientNoteService(clientNoteRepo, clientRepo)
+	clientTagService := usecase.NewClientTagService(clientTagRepo, clientRepo, transactor)
+	clientFieldService := usecase.NewClientFieldService(clientFieldRepo, clientRepo, transactor)
+	clientSegmentService := usecase.NewClientSegmentService(clientSegmentRepo, clientRepo, serviceRepo, clientTagRepo, clientFieldRepo)
+	clientJobService := usecase.NewClientJobService(clientJobRepo, clientRepo, businesRepo, clientFieldRepo, transactor)
+	clientPrivacyService := usecase.NewClientPrivacyService(clientRepo, bookingRepo, serviceRepo, staffRepo, clientNoteRepo, clientTagRepo, clientFieldRepo, clientMergeRepo, clientConsentRepo, notificationRepo, eventRepo, transactor)
 	locationService := usecase.NewLocationService(locationRepo)
+	invitationService := usecase.NewStaffInvitationService(invitationRepo, staffRepo, userRepo)
+	roleService := usecase.NewRoleService(roleRepo, userRepo)
+	apiKeyService := usecase.NewAPIKeyService(apiKeyRepo, userRepo, authService)
+	accountService := usecase.NewAccountService(userRepo, userTokenRepo, sessionRepo, mailSender, os.Getenv("APP_PUBLIC_URL"))
+	publicBookingService := usecase.NewPublicBookingService(businesRepo, serviceRepo, serviceCategoryRepo, staffRepo, staffServiceRepo, bookingRepo, locationRepo, ucBooking,
+		signedtoken.New(bookingLinkSecret), os.Getenv("APP_PUBLIC_URL"))
+	calendarFeedService := usecase.NewCalendarFeedService(calendarFeedRepo, businesRepo, staffRepo, locationRepo, bookingRepo, serviceRepo, clientRepo, scheduleRepo,
+		transactor, os.Getenv("API_PUBLIC_URL"))
+	clientPortalService := usecase.NewClientPortalService(businesRepo, clientRepo, bookingRepo, serviceRepo, clientLoginCodeRepo, publicBookingService,
+		jwtKeys, mailSender, smsSender, os.Getenv("APP_PUBLIC_URL"))
 
 	//handlers
 
-	bh := handlers.NewBusinessHandler(ucBusines)
-	authHandler := handlers.NewAuthHandler(authService)
+	bh := handlers.NewBusinessHandler(ucBusines, accountService)
+	authHandler := handlers.NewAuthHandler(authService, accountService, mfaService)
 
 	sh := handlers.NewServiceHandler(ucService)
+	serviceCategoryHandler := handlers.NewServiceCategoryHandler(serviceCategoryService)
 	sth := handlers.NewStaffHandler(ucStaff)
 	stsh := handlers.NewStaffServiceHandler(ucStaff)
 	bkh := handlers.NewBookingHandler(ucBooking)
 	scheduleHandler := handlers.NewScheduleHandler(scheduleService)
-	clientHandler := handlers.NewClientHandler(clientService)
+	clientHandler := handlers.NewClientHandler(clientService, clientMergeService, clientNoteService, clientTagService, clientFieldService, clientPrivacyService, clientJobService)
+	clientTagHandler := handlers.NewClientTagHandler(clientTagService)
+	clientFieldHandler := handlers.NewClientFieldHandler(clientFieldService)
+	clientSegmentHandler := handlers.NewClientSegmentHandler(clientSegmentService)
+	clientJobHandler := handlers.NewClientJobHandler(clientJobService)
 	locationHandler := handlers.NewLocationHandler(locationService) 
+	invitationHandler := handlers.NewStaffInvitationHandler(invitationService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	calendarFeedHandler := handlers.NewCalendarFeedHandler(calendarFeedService)
+	publicBookingHandler := handlers.NewPublicBookingHandler(publicBookingService)
+	clientPortalHandler := handlers.NewClientPortalHandler(clientPortalService)
 
 	r := chi.NewRouter()
+	r.Use(middleware.RealIP(trustedProxies))
 	r.Use(middleware.Logger(logger))
 	r.Use(middleware.CORS) // Add CORS middleware
 
 	// JWT middleware
 	jwtMiddleware := middleware.JWTMiddleware(authService)
+	// Business routes also accept API keys
+	authenticate := middleware.Authenticate(authService, apiKeyService)
+	ownership := middleware.RequireOwnership(ownershipRepo)
+
+	r.Get("/.well-known/jwks.json", authHandler.JWKS)
 
 	r.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("/docs/swagger.json"),
@@ -101,14 +247,24 @@ func main() {
 			w.Write([]byte("api running"))
 		})
 		v1.Post("/businesses/register", bh.RegisterBusiness)
-		v1.Mount("/auth", authHandler.Routes())
+		v1.Mount("/auth", authHandler.Routes(jwtMiddleware))
+		v1.Post("/invitations/accept", invitationHandler.AcceptInvitation)
+
+		// Online booking pages, rate limited per client IP
+		v1.Mount("/public/businesses/{slug}", middleware.RateLimit(120, time.Minute)(
+			publicBookingHandler.Routes(middleware.RateLimit(10, time.Minute), clientPortalHandler.LoginRoutes()),
+		))
+		// Client portal, authenticated with client tokens instead of staff tokens
+		v1.Mount("/portal", middleware.ClientAuth(clientPortalService)(clientPortalHandler.Routes()))
+		// iCalendar feeds, authenticated by the token in the URL
+		v1.With(middleware.RateLimit(60, time.Minute)).Get("/calendar/{token}.ics", calendarFeedHandler.Feed)
 
 		// Protected routes
 		v1.Group(func(protected chi.Router) {
-			protected.Use(jwtMiddleware)
+			protected.Use(authenticate)
 
 			protected.Route("/businesses", func(br chi.Router) {
-				br.Post("/", bh.CreateBusiness)
+				br.With(middleware.DenyAPIKey()).Post("/", bh.CreateBusiness)
 
 				// Debug endpoint for businesses
 				br.Get("/debug", func(w http.ResponseWriter, r *http.Request) {
@@ -116,29 +272,40 @@ func main() {
 				})
 
 				br.Route("/{businessID}", func(bir chi.Router) {
+					// Tenant routes are only reachable with a token of the same business
+					bir.Use(middleware.RequireTenant())
+
 					// Debug endpoint for specific business
 					bir.Get("/debug", func(w http.ResponseWriter, r *http.Request) {
 						businessID := chi.URLParam(r, "businessID")
 						w.Write([]byte("business " + businessID + " debug endpoint reached"))
 					})
 
-					// Business owner only routes
-					bir.Group(func(owner chi.Router) {
-						owner.Use(middleware.RequireRole("owner"))
-						owner.Get("/", bh.GetBusiness)
-						owner.Mount("/locations", locationHandler.Routes())
-						owner.Mount("/services", sh.Routes())
-						owner.Mount("/staff-services", stsh.Routes())
-						owner.Mount("/schedule", scheduleHandler.Routes())
-						owner.Mount("/clients", clientHandler.Routes())
-					})
+					bir.With(middleware.RequirePermission(domain.PermBusinessRead)).Get("/", bh.GetBusiness)
+					bir.With(middleware.RequirePermission(domain.PermBusinessManage)).Put("/country", bh.SetCountry)
+					bir.Mount("/locations", middleware.RequireMethodPermission(domain.PermLocationsRead, domain.PermLocationsWrite)(locationHandler.Routes(ownership)))
+					bir.Mount("/services", middleware.RequireMethodPermission(domain.PermServicesRead, domain.PermServicesWrite)(sh.Routes(ownership)))
+					bir.Mount("/service-categories", middleware.RequireMethodPermission(domain.PermServicesRead, domain.PermServicesWrite)(serviceCategoryHandler.Routes(ownership)))
+					bir.Mount("/staffs", middleware.RequireMethodPermission(domain.PermStaffRead, domain.PermStaffWrite)(sth.Routes(ownership)))
+					bir.Mount("/staff-services", middleware.RequireMethodPermission(domain.PermStaffRead, domain.PermStaffWrite)(stsh.Routes(ownership)))
+					bir.Mount("/staff-invitations", middleware.RequirePermission(domain.PermStaffInvite)(invitationHandler.Routes()))
+					bir.Mount("/schedule", middleware.RequireMethodPermission(domain.PermScheduleRead, domain.PermScheduleWrite)(scheduleHandler.Routes(ownership)))
+					bir.Mount("/clients", middleware.RequireMethodPermission(domain.PermClientsRead, domain.PermClientsWrite)(clientHandler.Routes(ownership)))
+					bir.Mount("/client-tags", middleware.RequireMethodPermission(domain.PermClientsRead, domain.PermClientsWrite)(clientTagHandler.Routes(ownership)))
+					bir.Mount("/client-fields", middleware.RequireMethodPermission(domain.PermClientsRead, domain.PermClientsWrite)(clientFieldHandler.Routes(ownership)))
+					// Segments and jobs span all clients, beyond staff limited to their own bookings
+					clientsAll := middleware.RequirePermission(domain.PermBookingsReadAll)
+					bir.Mount("/client-segments", clientsAll(middleware.RequireMethodPermission(domain.PermClientsRead, domain.PermClientsWrite)(clientSegmentHandler.Routes(ownership))))
+					bir.Mount("/client-jobs", clientsAll(middleware.RequireMethodPermission(domain.PermClientsRead, domain.PermClientsWrite)(clientJobHandler.Routes(ownership))))
+					bir.Mount("/bookings", middleware.RequireMethodPermission(domain.PermBookingsRead, domain.PermBookingsWrite)(bkh.Routes(ownership)))
+					bir.Mount("/roles", middleware.RequirePermission(domain.PermRolesManage)(roleHandler.Routes(ownership)))
+					bir.Mount("/api-keys", middleware.RequirePermission(domain.PermAPIKeysManage)(apiKeyHandler.Routes(ownership)))
+					bir.Mount("/webhooks", middleware.RequirePermission(domain.PermWebhooksManage)(webhookHandler.Routes(ownership)))
+					bir.Mount("/calendar-feeds", middleware.RequirePermission(domain.PermBookingsRead)(calendarFeedHandler.Routes(ownership)))
+					bir.With(middleware.RequirePermission(domain.PermRolesManage)).Put("/users/{userID}/role", roleHandler.AssignRole)
 
-				
-					bir.Group(func(staff chi.Router) {
-						staff.Use(middleware.RequireAnyRole("owner", "staff"))
-						staff.Mount("/bookings", bkh.Routes())
-						staff.Mount("/staffs", sth.Routes())
-					})
+					// Own data of the staff member linked to the user
+					bir.Get("/me/shifts", scheduleHandler.GetMyShifts)
 				})
 			})
 		})
@@ -158,6 +325,23 @@ func main() {
 		IdleTimeout:  120 * time.Second,
 	}
 
+	// Domain events, notifications and webhooks are delivered from their outboxes in the background, along
+	// with large client imports and exports
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	defer stopWorker()
+	go eventDispatcher.Run(workerCtx, time.Second, func(err error) {
+		logger.Error("event dispatcher", zap.Error(err))
+	})
+	go notificationService.RunWorker(workerCtx, 30*time.Second, func(err error) {
+		logger.Error("notification worker", zap.Error(err))
+	})
+	go webhookService.RunWorker(workerCtx, 10*time.Second, func(err error) {
+		logger.Error("webhook worker", zap.Error(err))
+	})
+	go clientJobService.RunWorker(workerCtx, 5*time.Second, func(err error) {
+		logger.Error("client job worker", zap.Error(err))
+	})
+
 	go func() {
 		logger.Info("starting server", zap.String("address", svr.Addr))
 		err := svr.ListenAndServe()
@@ -170,6 +354,7 @@ func main() {
 	signal.Notify(quit, os.Interrupt)
 	<-quit
 	logger.Info("shutting down server")
+	stopWorker()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := svr.Shutdown(ctx); err != nil {
@@ -0,0 +1,61 @@
+// Command backfill-phones normalises the phone numbers of existing clients to
+// E.164 and reports the numbers it can't read and the ones several clients of
+// a business share afterwards.
+//
+//	go run ./cmd/backfill-phones -default-country RU -dry-run
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ialekseychuk/my-place/internal/repository"
+	"github.com/ialekseychuk/my-place/internal/usecase"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	defaultCountry := flag.String("default-country", "", "ISO 3166-1 alpha-2 country set on businesses that have none")
+	dryRun := flag.Bool("dry-run", false, "report the changes without writing them")
+	flag.Parse()
+
+	_ = godotenv.Load()
+
+	ctx := context.Background()
+	db, err := pgxpool.New(ctx, os.Getenv("POSTGRES_DSN"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error connecting to db:", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	clientService := usecase.NewClientService(
+		repository.NewClientRepository(db),
+		repository.NewBusinessRepository(db),
+		repository.NewBookingRepository(db),
+		repository.NewServiceRepository(db),
+		repository.NewStaffRepository(db),
+		repository.NewClientTagRepository(db),
+		repository.NewClientFieldRepository(db),
+	)
+
+	report, err := clientService.NormalizePhones(ctx, *defaultCountry, *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "backfill failed:", err)
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to write report:", err)
+		os.Exit(1)
+	}
+	if len(report.Collisions) > 0 {
+		fmt.Fprintf(os.Stderr, "%d phone numbers are shared by several clients; review them in the duplicate clients list\n", len(report.Collisions))
+	}
+}
@@ -54,6 +54,7 @@ interface RegistrationData {
   }
   timezone: string
   currency: string
+  country: string
   
   // Additional Settings
   enableOnlineBooking: boolean
@@ -81,6 +82,15 @@ const timezones = [
   { value: 'Asia/Tashkent', label: 'Ташкент (UTC+5)' }
 ]
 
+// Client phone numbers entered without a country code are read in this country
+const countries = [
+  { value: 'RU', label: 'Россия' },
+  { value: 'BY', label: 'Беларусь' },
+  { value: 'UA', label: 'Украина' },
+  { value: 'KZ', label: 'Казахстан' },
+  { value: 'UZ', label: 'Узбекистан' }
+]
+
 const currencies = [
   { value: 'RUB', label: '₽ Российский рубль' },
   { value: 'BYN', label: 'р. Белорусский рубль' },
@@ -151,6 +161,7 @@ export function BusinessRegistration() {
     },
     timezone: 'Europe/Moscow',
     currency: 'RUB',
+    country: 'RU',
     enableOnlineBooking: true,
     enableSMSNotifications: true,
     enableEmailNotifications: true,
@@ -226,7 +237,7 @@ export function BusinessRegistration() {
         return data.ownerFirstName && data.ownerLastName && data.ownerEmail && 
                data.ownerPassword && data.ownerPassword === data.ownerPasswordConfirm
       case 2: // Settings
-        return data.timezone && data.currency
+        return data.timezone && data.currency && data.country
       case 3: // Complete
         return data.acceptTerms
       default:
@@ -604,6 +615,22 @@ function SettingsStep({ data, updateData, updateWorkingHours }: {
               </SelectContent>
             </Select>
           </div>
+
+          <div>
+            <Label htmlFor="country">Страна *</Label>
+            <Select value={data.country} onValueChange={(value) => updateData('country', value)}>
+              <SelectTrigger>
+                <SelectValue placeholder="Выберите страну" />
+              </SelectTrigger>
+              <SelectContent>
+                {countries.map((country) => (
+                  <SelectItem key={country.value} value={country.value}>
+                    {country.label}
+                  </SelectItem>
+                ))}
+              </SelectContent>
+            </Select>
+          </div>
         </div>
       </div>
 
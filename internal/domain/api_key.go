@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// APIKey lets a server of the business call the API without a user login.
+// Requests made with a key act as the user who created it, limited to the
+// key's scopes.
+type APIKey struct {
+	ID         string       `json:"id"`
+	BusinessID string       `json:"business_id"`
+	Name       string       `json:"name"`
+	KeyPrefix  string       `json:"key_prefix"`
+	KeyHash    string       `json:"-"`
+	Scopes     []Permission `json:"scopes"`
+	CreatedBy  string       `json:"created_by"`
+	ExpiresAt  *time.Time   `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time   `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time   `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+func (k *APIKey) IsActive() bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || k.ExpiresAt.After(time.Now()))
+}
@@ -0,0 +1,11 @@
+package domain
+
+import "context"
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *APIKey) error
+	GetByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	ListByBusiness(ctx context.Context, businessID string) ([]*APIKey, error)
+	Revoke(ctx context.Context, id string) error
+	TouchLastUsed(ctx context.Context, id string) error
+}
@@ -18,6 +18,7 @@ type JWTClaims struct {
 	BusinessID string `json:"business_id"`
 	Email      string `json:"email"`
 	Role       string `json:"role"`
+	SessionID  string `json:"sid"`
 	IssuedAt   int64  `json:"iat"`
 	ExpiresAt  int64  `json:"exp"`
 	jwt.RegisteredClaims
@@ -31,12 +32,32 @@ func (c JWTClaims) Valid() error {
 	return nil
 }
 
+// UserSession is a persisted refresh token. Only the SHA-256 hash of the
+// token is stored; every refresh rotates the session and all sessions
+// descending from the same login share a FamilyID.
 type UserSession struct {
-	ID           string    `json:"id"`
-	UserID       string    `json:"user_id"`
-	RefreshToken string    `json:"refresh_token"`
-	ExpiresAt    time.Time `json:"expires_at"`
-	IsRevoked    bool      `json:"is_revoked"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           string     `json:"id"`
+	UserID       string     `json:"user_id"`
+	FamilyID     string     `json:"family_id"`
+	RefreshToken string     `json:"-"` // SHA-256 hash of the refresh token
+	ReplacedBy   string     `json:"-"` // ID of the session issued on rotation
+	UserAgent    string     `json:"user_agent"`
+	IPAddress    string     `json:"ip_address"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	IsRevoked    bool       `json:"is_revoked"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt   time.Time  `json:"last_used_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// IsRotated reports whether the session was already exchanged for a new one.
+func (s *UserSession) IsRotated() bool {
+	return s.ReplacedBy != ""
+}
+
+// ClientInfo describes where an authentication request came from.
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
 }
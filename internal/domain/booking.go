@@ -1,15 +1,42 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+const (
+	BookingStatusConfirmed = "confirmed"
+	BookingStatusCancelled = "cancelled"
+	BookingStatusNoShow    = "no_show"
+)
+
+// ErrBookingOverlap is returned by the repository when a booking would
+// overlap another booking of the same staff member that isn't cancelled
+var ErrBookingOverlap = errors.New("booking overlaps another booking of the staff member")
 
 type Booking struct {
-	ID         string    `json:"id"`
-	ServiceID  string    `json:"service_id"`
-	StaffID    string    `json:"staff_id"`
-	ClientID   string    `json:"client_id"`
-	LocationID string    `json:"location_id"`
-	StartAt    time.Time `json:"start_at"`
-	EndAt      time.Time `json:"end_at"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID          string     `json:"id"`
+	ServiceID   string     `json:"service_id"`
+	StaffID     string     `json:"staff_id"`
+	ClientID    string     `json:"client_id"`
+	LocationID  string     `json:"location_id"`
+	StartAt     time.Time  `json:"start_at"`
+	EndAt       time.Time  `json:"end_at"`
+	Status      string     `json:"status"`
+	PriceCents  int        `json:"price_cents"`  // agreed when the booking was made
+	DurationMin int        `json:"duration_min"` // agreed when the booking was made
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// IsConfirmed reports whether the booking is still to take place or took place
+func (b *Booking) IsConfirmed() bool {
+	return b.Status == BookingStatusConfirmed
+}
+
+// IsCancelled reports whether the booking was cancelled
+func (b *Booking) IsCancelled() bool {
+	return b.Status == BookingStatusCancelled
 }
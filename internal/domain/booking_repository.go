@@ -6,9 +6,28 @@ import (
 )
 
 type BookingRepository interface {
+	// Create returns ErrBookingOverlap when the staff member is already booked
 	Create(ctx context.Context, booking *Booking) error
 	GetById(ctx context.Context, id string) (*Booking, error)
-	GetByBusinessID(ctx context.Context, businessID string, startDate, endDate *time.Time) ([]*Booking, error)
+	GetByBusinessID(ctx context.Context, businessID, staffID string, startDate, endDate *time.Time) ([]*Booking, error)
+	// ListByClient returns all bookings of a client, latest first
+	ListByClient(ctx context.Context, clientID string) ([]*Booking, error)
 	GetByStaffAndTimeRange(ctx context.Context, staffID string, start, end time.Time) ([]*Booking, error)
+	// ListStartingBetween returns the confirmed bookings of all businesses
+	// starting after from and no later than to
+	ListStartingBetween(ctx context.Context, from, to time.Time) ([]*Booking, error)
+	// Cancel marks a confirmed booking as cancelled, pgx.ErrNoRows when it
+	// doesn't exist or is already cancelled
+	Cancel(ctx context.Context, id string) error
+	// Reschedule moves a confirmed booking, pgx.ErrNoRows when it doesn't
+	// exist or is cancelled, ErrBookingOverlap when the staff member is
+	// already booked
+	Reschedule(ctx context.Context, booking *Booking) error
+	// MarkNoShow marks a confirmed booking as a no-show, pgx.ErrNoRows when
+	// it doesn't exist or isn't confirmed
+	MarkNoShow(ctx context.Context, id string) error
+	// ReassignClient moves all bookings of fromClientID to toClientID and
+	// returns how many it moved
+	ReassignClient(ctx context.Context, fromClientID, toClientID string) (int, error)
 	GetAvailableSlots(ctx context.Context, businessID string, staffID *string, day time.Time) ([]*Slot, error)
 }
@@ -5,10 +5,12 @@ import "time"
 type Business struct {
 	ID                       string    `json:"id"`
 	Name                     string    `json:"name"`
+	Slug                     string    `json:"slug"`
 	BusinessType             string    `json:"business_type"`
 	Description              string    `json:"description"`
 	Address                  string    `json:"address"`
 	City                     string    `json:"city"`
+	Country                  string    `json:"country"` // ISO 3166-1 alpha-2, the default region of phone numbers
 	Phone                    string    `json:"phone"`
 	Email                    string    `json:"email"`
 	Website                  string    `json:"website"`
@@ -17,6 +19,13 @@ type Business struct {
 	EnableOnlineBooking      bool      `json:"enable_online_booking"`
 	EnableSMSNotifications   bool      `json:"enable_sms_notifications"`
 	EnableEmailNotifications bool      `json:"enable_email_notifications"`
+	CancellationWindowHours  int       `json:"cancellation_window_hours"`
 	CreatedAt                time.Time `json:"created_at"`
 	UpdatedAt                time.Time `json:"updated_at"`
 }
+
+// CancellationDeadline returns the last moment clients may cancel a booking
+// starting at start by themselves
+func (b *Business) CancellationDeadline(start time.Time) time.Time {
+	return start.Add(-time.Duration(b.CancellationWindowHours) * time.Hour)
+}
@@ -5,4 +5,8 @@ import "context"
 type BusinessRepository interface {
 	Create( ctx context.Context, b *Business) (error)
 	GetById(ctx context.Context, id string) (*Business, error)
+	GetBySlug(ctx context.Context, slug string) (*Business, error)
+	SlugExists(ctx context.Context, slug string) (bool, error)
+	List(ctx context.Context) ([]*Business, error)
+	SetCountry(ctx context.Context, id, country string) error
 }
\ No newline at end of file
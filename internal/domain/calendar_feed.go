@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// CalendarFeed is a revocable iCalendar subscription of the bookings and
+// shifts of a staff member or of a location. Exactly one of StaffID and
+// LocationID is set.
+type CalendarFeed struct {
+	ID             string     `json:"id"`
+	BusinessID     string     `json:"business_id"`
+	StaffID        string     `json:"staff_id,omitempty"`
+	LocationID     string     `json:"location_id,omitempty"`
+	TokenHash      string     `json:"-"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (f *CalendarFeed) IsActive() bool {
+	return f.RevokedAt == nil
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type CalendarFeedRepository interface {
+	Create(ctx context.Context, feed *CalendarFeed) error
+	GetByID(ctx context.Context, id string) (*CalendarFeed, error)
+	GetByHash(ctx context.Context, tokenHash string) (*CalendarFeed, error)
+	// ListActiveByBusiness returns the feeds of a business that weren't revoked
+	ListActiveByBusiness(ctx context.Context, businessID string) ([]*CalendarFeed, error)
+	Revoke(ctx context.Context, id string) error
+	// RevokeByOwner revokes the active feeds of a staff member or location
+	RevokeByOwner(ctx context.Context, staffID, locationID string) error
+	TouchLastAccessed(ctx context.Context, id string) error
+	// Version returns the latest change to the bookings and shifts of a feed
+	// starting between from and to, and how many there are
+	Version(ctx context.Context, feed *CalendarFeed, from, to time.Time) (time.Time, int, error)
+}
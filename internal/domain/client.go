@@ -1,14 +1,43 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrClientPhoneTaken is returned by the repository when another client of
+// the business already has the phone number
+var ErrClientPhoneTaken = errors.New("another client already has this phone number")
 
 type Client struct {
-	ID         string    `json:"id"`
-	BusinessID string    `json:"business_id"`
-	FirstName  string    `json:"first_name"`
-	LastName   string    `json:"last_name"`
-	Email      string    `json:"email"`
-	Phone      string    `json:"phone"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-}
\ No newline at end of file
+	ID                    string     `json:"id"`
+	BusinessID            string     `json:"business_id"`
+	FirstName             string     `json:"first_name"`
+	LastName              string     `json:"last_name"`
+	Email                 string     `json:"email"`
+	Phone                 string     `json:"phone"`
+	MarketingConsent      bool       `json:"marketing_consent"`
+	MarketingConsentAt    *time.Time `json:"marketing_consent_at,omitempty"`
+	NotificationConsent   bool       `json:"notification_consent"`
+	NotificationConsentAt *time.Time `json:"notification_consent_at,omitempty"`
+	ErasedAt              *time.Time `json:"erased_at,omitempty"` // personal data anonymised
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+// ClientFilter narrows a list of clients; empty fields don't filter
+type ClientFilter struct {
+	Search string             // ranked fuzzy match on name, email and phone number
+	TagIDs []string           // clients with all of these tags
+	Fields []ClientFieldMatch // clients with all of these values
+	// Segment is a filter tree over the client and its bookings
+	Segment *SegmentNode
+	StaffID string // clients with bookings with this staff member
+}
+
+// ClientFieldMatch matches clients by the value of a custom field
+type ClientFieldMatch struct {
+	FieldID    string
+	Value      string // in the canonical form of the field type
+	IgnoreCase bool
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// Kinds of consent a client gives or withdraws
+const (
+	ClientConsentMarketing     = "marketing"     // promotional messages, e.g. to segments
+	ClientConsentNotifications = "notifications" // booking confirmations and reminders
+)
+
+// ClientConsent records a client giving or withdrawing consent
+type ClientConsent struct {
+	ID             string
+	BusinessID     string
+	ClientID       string
+	Kind           string
+	Granted        bool
+	Source         string
+	RecordedBy     string // user ID, empty for API keys
+	RecordedByName string // filled when reading
+	CreatedAt      time.Time
+}
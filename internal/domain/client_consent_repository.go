@@ -0,0 +1,11 @@
+package domain
+
+import "context"
+
+type ClientConsentRepository interface {
+	Create(ctx context.Context, consent *ClientConsent) error
+	// ListByClient returns the consent history of a client, latest first
+	ListByClient(ctx context.Context, clientID string) ([]*ClientConsent, error)
+	// ReassignClient moves the consent history of fromClientID to toClientID
+	ReassignClient(ctx context.Context, fromClientID, toClientID string) error
+}
@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+// Types of client custom fields
+const (
+	ClientFieldText   = "text"
+	ClientFieldNumber = "number"
+	ClientFieldDate   = "date"
+	ClientFieldSelect = "select"
+)
+
+// ClientField is a custom field a business defines for its clients. Key
+// names the field in filters and value updates; key and type are fixed once
+// created.
+type ClientField struct {
+	ID         string    `json:"id"`
+	BusinessID string    `json:"business_id"`
+	Key        string    `json:"key"`
+	Label      string    `json:"label"`
+	Type       string    `json:"type"`
+	Options    []string  `json:"options"` // the choices of a select
+	Position   int       `json:"position"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ClientFieldValue is the value of a custom field for a client, in the
+// canonical form of the field type: numbers as formatted by strconv, dates
+// as YYYY-MM-DD
+type ClientFieldValue struct {
+	ClientID  string    `json:"client_id"`
+	FieldID   string    `json:"field_id"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
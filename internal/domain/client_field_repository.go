@@ -0,0 +1,22 @@
+package domain
+
+import "context"
+
+type ClientFieldRepository interface {
+	Create(ctx context.Context, field *ClientField) error
+	GetByID(ctx context.Context, id string) (*ClientField, error)
+	GetByKey(ctx context.Context, businessID, key string) (*ClientField, error)
+	// ListByBusiness returns the fields of a business by position
+	ListByBusiness(ctx context.Context, businessID string) ([]*ClientField, error)
+	Update(ctx context.Context, field *ClientField) error
+	Delete(ctx context.Context, id string) error
+
+	ListValues(ctx context.Context, clientID string) ([]*ClientFieldValue, error)
+	SetValue(ctx context.Context, value *ClientFieldValue) error
+	DeleteValue(ctx context.Context, clientID, fieldID string) error
+	// DeleteValues deletes all values of a client
+	DeleteValues(ctx context.Context, clientID string) error
+	// ReassignValues moves the values of fromClientID to toClientID for the
+	// fields toClientID has no value of
+	ReassignValues(ctx context.Context, fromClientID, toClientID string) error
+}
@@ -0,0 +1,78 @@
+package domain
+
+import "time"
+
+// Kinds of client jobs
+const (
+	ClientJobImport = "import"
+	ClientJobExport = "export"
+)
+
+const (
+	ClientJobPending = "pending"
+	ClientJobRunning = "running"
+	ClientJobDone    = "done"
+	ClientJobFailed  = "failed"
+)
+
+// Formats of client import files
+const (
+	ClientImportCSV  = "csv"
+	ClientImportXLSX = "xlsx"
+)
+
+// ClientJob is a bulk import or export of the clients of a business. Small
+// ones run right away, large ones are picked up by a background worker.
+type ClientJob struct {
+	ID         string
+	BusinessID string
+	Kind       string
+	Status     string
+	Import     *ClientImportOptions // import
+	Filter     *ClientFilter        // export
+	Input      []byte               // the uploaded file of an import, dropped once done
+	Result     []byte               // the CSV of an export
+	Report     *ClientImportReport  // import
+	TotalRows  int
+	DoneRows   int
+	Attempts   int
+	LastError  string
+	CreatedBy  string // user ID, empty for API keys
+	CreatedAt  time.Time
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+// ClientImportOptions say how to read an import file
+type ClientImportOptions struct {
+	Format   string `json:"format"`
+	FileName string `json:"file_name"`
+	// Mapping names the file column of each client column: first_name,
+	// last_name, name (split into first and last name), email, phone and
+	// field.<key> for custom fields
+	Mapping        map[string]string `json:"mapping"`
+	DryRun         bool              `json:"dry_run"`         // validate without writing
+	UpdateExisting bool              `json:"update_existing"` // update clients with the same phone instead of skipping the row
+}
+
+// ClientImportReport is the outcome of an import, or what it would do for
+// a dry run
+type ClientImportReport struct {
+	Rows    int `json:"rows"`
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"` // same phone as an existing client
+	Failed  int `json:"failed"`
+	// Issues lists failed and skipped rows, the first of them if there are
+	// too many
+	Issues          []ClientImportIssue `json:"issues"`
+	IssuesTruncated bool                `json:"issues_truncated"`
+}
+
+// ClientImportIssue is why a row of an import file wasn't imported
+type ClientImportIssue struct {
+	Row      int    `json:"row"` // as numbered by spreadsheet apps, the header is row 1
+	Column   string `json:"column,omitempty"`
+	Message  string `json:"message"`
+	ClientID string `json:"client_id,omitempty"` // the existing client of a skipped row
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type ClientJobRepository interface {
+	// Create stores a job. Jobs run right away are stored finished, with
+	// their report or result
+	Create(ctx context.Context, job *ClientJob) error
+	// GetByID returns a job without its input file
+	GetByID(ctx context.Context, id string) (*ClientJob, error)
+	// ListByBusiness returns the latest jobs of a business without their
+	// files and results
+	ListByBusiness(ctx context.Context, businessID string, limit int) ([]*ClientJob, error)
+	// GetResult returns the CSV of an export job
+	GetResult(ctx context.Context, id string) ([]byte, error)
+	// Claim marks the oldest pending job running and hides it from other
+	// workers for lease. Jobs of a worker that died are picked up again
+	// once the lease ran out, until they were tried maxAttempts times.
+	// It returns nil when there is nothing to do.
+	Claim(ctx context.Context, lease time.Duration, maxAttempts int) (*ClientJob, error)
+	// Extend renews the lease of a running job and records its progress
+	Extend(ctx context.Context, id string, doneRows int, lease time.Duration) error
+	// Finish stores the status, report, result and error of a job and drops
+	// its input file
+	Finish(ctx context.Context, job *ClientJob) error
+	// DeleteFinishedBefore deletes the jobs finished before t and returns
+	// how many
+	DeleteFinishedBefore(ctx context.Context, t time.Time) (int, error)
+}
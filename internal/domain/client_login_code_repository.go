@@ -0,0 +1,16 @@
+package domain
+
+import "context"
+
+type ClientLoginCodeRepository interface {
+	// Create stores a new login code and invalidates the unused codes of the
+	// same client.
+	Create(ctx context.Context, code *ClientLoginCode) error
+	// GetLatestByClient returns the most recent login code of a client.
+	GetLatestByClient(ctx context.Context, clientID string) (*ClientLoginCode, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*ClientLoginCode, error)
+	// RegisterFailedAttempt counts a wrong code and returns the new count.
+	RegisterFailedAttempt(ctx context.Context, id string) (int, error)
+	// Consume marks the code used; it fails when the code was used already.
+	Consume(ctx context.Context, id string) error
+}
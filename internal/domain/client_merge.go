@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// ClientMerge records a duplicate client merged into another one. The merged
+// client is deleted, so MergedClient keeps its details as they were.
+type ClientMerge struct {
+	ID             string    `json:"id"`
+	BusinessID     string    `json:"business_id"`
+	ClientID       string    `json:"client_id"` // the client that was kept
+	MergedClientID string    `json:"merged_client_id"`
+	MergedClient   Client    `json:"merged_client"`
+	BookingsMoved  int       `json:"bookings_moved"`
+	MergedBy       string    `json:"merged_by,omitempty"` // empty when merged with an API key
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "context"
+
+type ClientMergeRepository interface {
+	Create(ctx context.Context, merge *ClientMerge) error
+	// ListByClient returns the merges into a client, latest first
+	ListByClient(ctx context.Context, clientID string) ([]*ClientMerge, error)
+	// Reassign moves the merges into fromClientID to toClientID, so the
+	// trail follows a client that is merged itself
+	Reassign(ctx context.Context, fromClientID, toClientID string) error
+	// DeleteByClient deletes the merges into a client with the snapshots of
+	// the merged clients
+	DeleteByClient(ctx context.Context, clientID string) error
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// ClientNote is a note staff keep on a client, e.g. a colour formula or an
+// allergy
+type ClientNote struct {
+	ID         string    `json:"id"`
+	BusinessID string    `json:"business_id"`
+	ClientID   string    `json:"client_id"`
+	AuthorID   string    `json:"author_id,omitempty"` // empty when written with an API key
+	AuthorName string    `json:"author_name,omitempty"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "context"
+
+type ClientNoteRepository interface {
+	Create(ctx context.Context, note *ClientNote) error
+	GetByID(ctx context.Context, id string) (*ClientNote, error)
+	// ListByClient returns the notes of a client, latest first
+	ListByClient(ctx context.Context, clientID string) ([]*ClientNote, error)
+	Update(ctx context.Context, note *ClientNote) error
+	Delete(ctx context.Context, id string) error
+	DeleteByClient(ctx context.Context, clientID string) error
+	// ReassignClient moves the notes of fromClientID to toClientID
+	ReassignClient(ctx context.Context, fromClientID, toClientID string) error
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClientTokenAudience is the audience of client portal tokens. Staff tokens
+// carrying it are rejected, and the other way round.
+const ClientTokenAudience = "client-portal"
+
+const (
+	ClientLoginChannelEmail = "email"
+	ClientLoginChannelSMS   = "sms"
+)
+
+// ClientLoginCode is a one-time login for the client portal, sent by email or
+// SMS. It can be redeemed either with the numeric code or the magic link
+// token; only hashes of both are stored.
+type ClientLoginCode struct {
+	ID        string     `json:"id"`
+	ClientID  string     `json:"client_id"`
+	Channel   string     `json:"channel"`
+	CodeHash  string     `json:"-"`
+	TokenHash string     `json:"-"`
+	Attempts  int        `json:"attempts"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (c *ClientLoginCode) IsUsable() bool {
+	return c.UsedAt == nil && c.ExpiresAt.After(time.Now())
+}
+
+// ClientClaims are the claims of a client portal access token.
+type ClientClaims struct {
+	ClientID   string `json:"client_id"`
+	BusinessID string `json:"business_id"`
+	jwt.RegisteredClaims
+}
@@ -1,12 +1,41 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type ClientRepository interface {
 	CreateClient(ctx context.Context, client *Client) error
+	// GetOrCreateClientByPhone loads the client of the business with the
+	// phone number of client into it, creating the client when there is none
+	GetOrCreateClientByPhone(ctx context.Context, client *Client) error
 	GetClientByID(ctx context.Context, clientID string) (*Client, error)
+	// GetClientByPhone matches the phone number exactly, so it has to be
+	// normalised to E.164, and returns the most recently updated client when
+	// several share it
 	GetClientByPhone(ctx context.Context, businessID, phone string) (*Client, error)
+	// GetClientByEmail matches the email case-insensitively and returns the
+	// most recently updated client when several share it
+	GetClientByEmail(ctx context.Context, businessID, email string) (*Client, error)
 	UpdateClient(ctx context.Context, client *Client) error
+	// SetConsent stores the current consent of the given kind
+	SetConsent(ctx context.Context, clientID, kind string, granted bool, at time.Time) error
+	// EraseClient overwrites the personal data of a client with the values of
+	// client and marks it erased
+	EraseClient(ctx context.Context, client *Client) error
 	DeleteClient(ctx context.Context, clientID string) error
-	GetClientsByBusiness(ctx context.Context, businessID string, offset, limit int, search string) ([]*Client, int, error)
+	// ListClientsByBusiness returns all clients of a business, oldest first.
+	// Erased clients are left out here and in the lists below.
+	ListClientsByBusiness(ctx context.Context, businessID string) ([]*Client, error)
+	// GetClientsByBusiness returns a page of the clients matching the filter,
+	// best search matches first when it searches, newest first otherwise
+	GetClientsByBusiness(ctx context.Context, businessID string, filter ClientFilter, offset, limit int) ([]*Client, int, error)
+	// SearchClients returns the best matches of a search term across name,
+	// email and phone number without counting them, for autocompletion,
+	// limited to the clients with bookings with staffID when it's not empty
+	SearchClients(ctx context.Context, businessID, staffID, term string, limit int) ([]*Client, error)
+	// HasBookingWithStaff reports whether a client has any booking with the
+	// staff member
+	HasBookingWithStaff(ctx context.Context, clientID, staffID string) (bool, error)
 }
\ No newline at end of file
@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+// Operators of segment nodes. Groups combine their nodes, the others are
+// conditions on a client and its bookings.
+const (
+	SegmentAnd = "and" // all nodes match
+	SegmentOr  = "or"  // any node matches
+	SegmentNot = "not" // its only node doesn't match
+
+	SegmentVisits   = "visits"    // number of past confirmed bookings
+	SegmentUpcoming = "upcoming"  // number of future confirmed bookings
+	SegmentNoShows  = "no_shows"  // number of no-shows
+	SegmentSpent    = "spent"     // price in cents of past visits, as agreed when booked
+	SegmentCreated  = "created"   // client added within the last days
+	SegmentTag      = "tag"       // client has the tag
+	SegmentField    = "field"     // client has the custom field value
+	SegmentSearch   = "search"    // matches the client search
+	SegmentHasEmail = "has_email" // client has an email address
+	SegmentHasPhone = "has_phone" // client has a phone number
+
+	SegmentMarketingConsent = "marketing_consent" // client agreed to marketing
+)
+
+// SegmentNode is a node of the filter tree of a client segment. Which fields
+// apply depends on the operator, e.g. "haven't visited in 60 days" is
+//
+//	{"op": "not", "nodes": [{"op": "visits", "days": 60}]}
+type SegmentNode struct {
+	Op    string        `json:"op"`
+	Nodes []SegmentNode `json:"nodes,omitempty"` // and, or, not
+
+	// Counting and spend conditions look back Days from now, all time when 0,
+	// optionally only at bookings of ServiceIDs, and match when the count or
+	// amount lies within Min and Max. Without both they match at least one.
+	Days       int      `json:"days,omitempty"`
+	ServiceIDs []string `json:"service_ids,omitempty"`
+	Min        *int64   `json:"min,omitempty"`
+	Max        *int64   `json:"max,omitempty"`
+
+	TagID string `json:"tag_id,omitempty"` // tag
+	Key   string `json:"key,omitempty"`    // field
+	Value string `json:"value,omitempty"`  // field in the canonical form of its type, search
+}
+
+// ClientSegment is a saved client filter of a business
+type ClientSegment struct {
+	ID          string
+	BusinessID  string
+	Name        string
+	Description string
+	Definition  SegmentNode
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
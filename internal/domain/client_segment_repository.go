@@ -0,0 +1,11 @@
+package domain
+
+import "context"
+
+type ClientSegmentRepository interface {
+	Create(ctx context.Context, segment *ClientSegment) error
+	GetByID(ctx context.Context, id string) (*ClientSegment, error)
+	ListByBusiness(ctx context.Context, businessID string) ([]*ClientSegment, error)
+	Update(ctx context.Context, segment *ClientSegment) error
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// ClientTag is a label a business defines to group its clients, e.g. "VIP"
+type ClientTag struct {
+	ID         string    `json:"id"`
+	BusinessID string    `json:"business_id"`
+	Name       string    `json:"name"`
+	Color      string    `json:"color"`
+	CreatedAt  time.Time `json:"created_at"`
+}
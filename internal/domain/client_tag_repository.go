@@ -0,0 +1,20 @@
+package domain
+
+import "context"
+
+type ClientTagRepository interface {
+	Create(ctx context.Context, tag *ClientTag) error
+	GetByID(ctx context.Context, id string) (*ClientTag, error)
+	// GetByName matches the name case-insensitively
+	GetByName(ctx context.Context, businessID, name string) (*ClientTag, error)
+	ListByBusiness(ctx context.Context, businessID string) ([]*ClientTag, error)
+	Update(ctx context.Context, tag *ClientTag) error
+	Delete(ctx context.Context, id string) error
+
+	// ListByClient returns the tags of a client by name
+	ListByClient(ctx context.Context, clientID string) ([]*ClientTag, error)
+	// SetClientTags replaces the tags of a client; run it in a transaction
+	SetClientTags(ctx context.Context, clientID string, tagIDs []string) error
+	// ReassignClient adds the tags of fromClientID to toClientID
+	ReassignClient(ctx context.Context, fromClientID, toClientID string) error
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventType names a domain event. Types are part of the public webhook
+// payloads, so existing ones must not be renamed.
+type EventType string
+
+const (
+	EventBookingCreated     EventType = "booking.created"
+	EventBookingRescheduled EventType = "booking.rescheduled"
+	EventBookingCancelled   EventType = "booking.cancelled"
+	EventShiftChanged       EventType = "shift.changed"
+	EventTimeOffApproved    EventType = "time_off.approved"
+	EventClientMerged       EventType = "client.merged"
+	EventClientErased       EventType = "client.erased"
+)
+
+const (
+	EventStatusPending   = "pending"
+	EventStatusDelivered = "delivered"
+	EventStatusFailed    = "failed" // gave up after the last retry
+)
+
+// Event is a state change recorded in the outbox, in the same transaction
+// as the change itself.
+type Event struct {
+	ID            string          `json:"id"`
+	Type          EventType       `json:"type"`
+	BusinessID    string          `json:"business_id"`
+	AggregateID   string          `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"-"`
+	Attempts      int             `json:"-"`
+	LastError     string          `json:"-"`
+	NextAttemptAt time.Time       `json:"-"`
+	DeliveredAt   *time.Time      `json:"-"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+}
+
+// BookingEventPayload is the payload of the booking events
+type BookingEventPayload struct {
+	BookingID       string     `json:"booking_id"`
+	ServiceID       string     `json:"service_id"`
+	StaffID         string     `json:"staff_id"`
+	ClientID        string     `json:"client_id"`
+	LocationID      string     `json:"location_id,omitempty"`
+	StartAt         time.Time  `json:"start_at"`
+	EndAt           time.Time  `json:"end_at"`
+	PreviousStartAt *time.Time `json:"previous_start_at,omitempty"` // set when rescheduled
+}
+
+func NewBookingEventPayload(booking *Booking) BookingEventPayload {
+	return BookingEventPayload{
+		BookingID:  booking.ID,
+		ServiceID:  booking.ServiceID,
+		StaffID:    booking.StaffID,
+		ClientID:   booking.ClientID,
+		LocationID: booking.LocationID,
+		StartAt:    booking.StartAt,
+		EndAt:      booking.EndAt,
+	}
+}
+
+// Booking returns the booking as it was when the event occurred
+func (p BookingEventPayload) Booking() *Booking {
+	return &Booking{
+		ID:         p.BookingID,
+		ServiceID:  p.ServiceID,
+		StaffID:    p.StaffID,
+		ClientID:   p.ClientID,
+		LocationID: p.LocationID,
+		StartAt:    p.StartAt,
+		EndAt:      p.EndAt,
+	}
+}
+
+const (
+	ShiftCreated = "created"
+	ShiftUpdated = "updated"
+	ShiftDeleted = "deleted"
+)
+
+// ShiftEventPayload is the payload of EventShiftChanged
+type ShiftEventPayload struct {
+	ShiftID     string    `json:"shift_id"`
+	StaffID     string    `json:"staff_id"`
+	Change      string    `json:"change"` // created, updated or deleted
+	ShiftDate   time.Time `json:"shift_date"`
+	StartTime   string    `json:"start_time"`
+	EndTime     string    `json:"end_time"`
+	IsAvailable bool      `json:"is_available"`
+}
+
+// TimeOffEventPayload is the payload of EventTimeOffApproved
+type TimeOffEventPayload struct {
+	RequestID string    `json:"request_id"`
+	StaffID   string    `json:"staff_id"`
+	Type      string    `json:"type"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+}
+
+// ClientMergedPayload is the payload of EventClientMerged
+type ClientMergedPayload struct {
+	ClientID       string `json:"client_id"`        // the client that was kept
+	MergedClientID string `json:"merged_client_id"` // the client that was merged into it
+}
+
+// ClientErasedPayload is the payload of EventClientErased. Integrations
+// holding a copy of the client's personal data should delete it.
+type ClientErasedPayload struct {
+	ClientID string `json:"client_id"`
+}
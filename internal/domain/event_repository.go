@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type EventRepository interface {
+	// Append stores events in the outbox. Call it with the context of the
+	// transaction changing the state the events are about.
+	Append(ctx context.Context, events ...*Event) error
+	// ClaimDue returns up to limit pending events that are due, oldest
+	// first, and hides them from other dispatchers for lease.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*Event, error)
+	MarkDelivered(ctx context.Context, id string) error
+	// MarkFailed records a failed delivery. The event is retried at
+	// retryAt, or given up when retryAt is nil.
+	MarkFailed(ctx context.Context, id string, lastError string, retryAt *time.Time) error
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// LoginThrottle is the failed login counter of an email address or an IP address.
+type LoginThrottle struct {
+	Key           string     `json:"key"`
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+}
+
+// Login failure reasons recorded in the audit log
+const (
+	LoginFailureInvalidCredentials = "invalid_credentials"
+	LoginFailureInvalidMFACode     = "invalid_mfa_code"
+	LoginFailureAccountDisabled    = "account_disabled"
+	LoginFailureLocked             = "locked"
+)
+
+// LoginAttempt is an audit record of a successful or failed login.
+type LoginAttempt struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id,omitempty"`
+	Email     string    `json:"email"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// LoginThrottleStore keeps failed login counters. Implementations shared by
+// several API instances must update counters atomically.
+type LoginThrottleStore interface {
+	// Get returns the counter of the key, or nil when there were no failures.
+	Get(ctx context.Context, key string) (*LoginThrottle, error)
+	// RecordFailure increments the counter of the key and returns it. Failures
+	// older than resetAfter are forgotten before counting.
+	RecordFailure(ctx context.Context, key string, resetAfter time.Duration) (*LoginThrottle, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+type LoginAuditRepository interface {
+	Record(ctx context.Context, attempt *LoginAttempt) error
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// UserMFA holds the TOTP second factor of a user.
+type UserMFA struct {
+	UserID       string     `json:"user_id"`
+	TOTPSecret   string     `json:"-"`
+	EnabledAt    *time.Time `json:"enabled_at,omitempty"` // nil while enrollment is not confirmed
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (m *UserMFA) IsEnabled() bool {
+	return m != nil && m.EnabledAt != nil
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "context"
+
+type MFARepository interface {
+	// Get returns the MFA settings of the user, or nil when there are none.
+	Get(ctx context.Context, userID string) (*UserMFA, error)
+	// SaveSecret starts a new enrollment, replacing an unconfirmed one.
+	SaveSecret(ctx context.Context, userID, secret string) error
+	// Enable confirms the enrollment and stores a fresh set of recovery codes.
+	Enable(ctx context.Context, userID string, recoveryCodeHashes []string) error
+	// Disable removes the TOTP secret and all recovery codes.
+	Disable(ctx context.Context, userID string) error
+	// UseStep records a used TOTP time step; it fails when the step is not
+	// newer than the last used one.
+	UseStep(ctx context.Context, userID string, step int64) error
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error
+	// UseRecoveryCode marks an unused recovery code used; it fails when there
+	// is no such code.
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) error
+	CountUnusedRecoveryCodes(ctx context.Context, userID string) (int, error)
+}
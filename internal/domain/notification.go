@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+// NotificationEvent is what a notification is about and picks its template.
+type NotificationEvent string
+
+const (
+	NotificationBookingCreated     NotificationEvent = "booking_created"
+	NotificationBookingRescheduled NotificationEvent = "booking_rescheduled"
+	NotificationBookingCancelled   NotificationEvent = "booking_cancelled"
+	NotificationBookingReminder24h NotificationEvent = "booking_reminder_24h"
+	NotificationBookingReminder2h  NotificationEvent = "booking_reminder_2h"
+	NotificationTimeOffApproved    NotificationEvent = "time_off_approved"
+)
+
+const (
+	NotificationChannelEmail = "email"
+	NotificationChannelSMS   = "sms"
+)
+
+const (
+	NotificationStatusPending = "pending"
+	NotificationStatusSent    = "sent"
+	NotificationStatusFailed  = "failed" // gave up after the last retry
+)
+
+// Notification is a rendered message waiting in the outbox or already sent.
+type Notification struct {
+	ID            string            `json:"id"`
+	BusinessID    string            `json:"business_id"`
+	ClientID      string            `json:"client_id,omitempty"`  // set for notifications to clients
+	BookingID     string            `json:"booking_id,omitempty"` // set for notifications about a booking
+	Event         NotificationEvent `json:"event"`
+	Channel       string            `json:"channel"`
+	Recipient     string            `json:"recipient"`
+	Subject       string            `json:"subject"`
+	Body          string            `json:"body"`
+	DedupeKey     string            `json:"-"`
+	Status        string            `json:"status"`
+	Attempts      int               `json:"attempts"`
+	LastError     string            `json:"last_error,omitempty"`
+	NextAttemptAt time.Time         `json:"next_attempt_at"`
+	ExpiresAt     *time.Time        `json:"expires_at,omitempty"` // given up unsent from then on
+	SentAt        *time.Time        `json:"sent_at,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type NotificationRepository interface {
+	// Enqueue stores a pending notification. It is a no-op when a
+	// notification with the same dedupe key exists.
+	Enqueue(ctx context.Context, n *Notification) error
+	// ClaimDue returns up to limit pending notifications that are due and
+	// hides them from other workers for lease. Notifications of a worker
+	// that died are picked up again once the lease ran out. Expired ones are
+	// given up instead.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*Notification, error)
+	MarkSent(ctx context.Context, id string) error
+	// MarkFailed records a failed attempt. The notification is retried at
+	// retryAt, or given up when retryAt is nil.
+	MarkFailed(ctx context.Context, id string, lastError string, retryAt *time.Time) error
+	// FailBookingReminders gives up the pending reminders of a booking,
+	// except those for a start at keepStartAt when set
+	FailBookingReminders(ctx context.Context, bookingID string, keepStartAt *time.Time, lastError string) error
+
+	// ListByClient returns the notifications to a client, latest first
+	ListByClient(ctx context.Context, clientID string) ([]*Notification, error)
+	// ReassignClient moves the notifications of fromClientID to toClientID
+	ReassignClient(ctx context.Context, fromClientID, toClientID string) error
+	// AnonymiseClient blanks the recipient and content of the notifications
+	// to a client and gives up the pending ones
+	AnonymiseClient(ctx context.Context, clientID string) error
+}
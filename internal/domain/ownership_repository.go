@@ -0,0 +1,34 @@
+package domain
+
+import "context"
+
+// Resource identifies a kind of business-scoped entity that can be referenced
+// by ID in a tenant route.
+type Resource string
+
+const (
+	ResourceStaff            Resource = "staff"
+	ResourceService          Resource = "service"
+	ResourceLocation         Resource = "location"
+	ResourceClient           Resource = "client"
+	ResourceShift            Resource = "shift"
+	ResourceScheduleTemplate Resource = "schedule_template"
+	ResourceTimeOffRequest   Resource = "time_off_request"
+	ResourceBooking          Resource = "booking"
+	ResourceRole             Resource = "role"
+	ResourceAPIKey           Resource = "api_key"
+	ResourceWebhook          Resource = "webhook"
+	ResourceCalendarFeed     Resource = "calendar_feed"
+	ResourceClientNote       Resource = "client_note"
+	ResourceClientTag        Resource = "client_tag"
+	ResourceClientField      Resource = "client_field"
+	ResourceClientSegment    Resource = "client_segment"
+	ResourceClientJob        Resource = "client_job"
+	ResourceServiceCategory  Resource = "service_category"
+)
+
+type OwnershipRepository interface {
+	// BelongsToBusiness reports whether the resource with the given ID exists
+	// and is owned by the business.
+	BelongsToBusiness(ctx context.Context, businessID string, resource Resource, id string) (bool, error)
+}
@@ -0,0 +1,115 @@
+package domain
+
+import "time"
+
+// Permission is a named capability checked by route guards, e.g. "bookings:write".
+type Permission string
+
+const (
+	PermBusinessRead   Permission = "business:read"
+	PermBusinessManage Permission = "business:manage"
+	PermRolesManage    Permission = "roles:manage"
+	PermAPIKeysManage  Permission = "api_keys:manage"
+	PermWebhooksManage Permission = "webhooks:manage"
+
+	PermLocationsRead  Permission = "locations:read"
+	PermLocationsWrite Permission = "locations:write"
+
+	PermServicesRead  Permission = "services:read"
+	PermServicesWrite Permission = "services:write"
+
+	PermStaffRead   Permission = "staff:read"
+	PermStaffWrite  Permission = "staff:write"
+	PermStaffInvite Permission = "staff:invite"
+
+	PermScheduleRead           Permission = "schedule:read"
+	PermScheduleWrite          Permission = "schedule:write"
+	PermScheduleApproveTimeOff Permission = "schedule:approve_time_off"
+
+	PermBookingsRead    Permission = "bookings:read"
+	PermBookingsReadAll Permission = "bookings:read_all" // without it only own bookings are visible
+	PermBookingsWrite   Permission = "bookings:write"
+
+	PermClientsRead   Permission = "clients:read"
+	PermClientsWrite  Permission = "clients:write"
+	PermClientsExport Permission = "clients:export"
+)
+
+// AllPermissions lists every permission known to the API.
+var AllPermissions = []Permission{
+	PermBusinessRead, PermBusinessManage, PermRolesManage, PermAPIKeysManage, PermWebhooksManage,
+	PermLocationsRead, PermLocationsWrite,
+	PermServicesRead, PermServicesWrite,
+	PermStaffRead, PermStaffWrite, PermStaffInvite,
+	PermScheduleRead, PermScheduleWrite, PermScheduleApproveTimeOff,
+	PermBookingsRead, PermBookingsReadAll, PermBookingsWrite,
+	PermClientsRead, PermClientsWrite, PermClientsExport,
+}
+
+// builtinRolePermissions are the permission bundles of the built-in roles.
+// Owners always get AllPermissions.
+var builtinRolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermBusinessRead,
+		PermLocationsRead, PermLocationsWrite,
+		PermServicesRead, PermServicesWrite,
+		PermStaffRead, PermStaffWrite, PermStaffInvite,
+		PermScheduleRead, PermScheduleWrite, PermScheduleApproveTimeOff,
+		PermBookingsRead, PermBookingsReadAll, PermBookingsWrite,
+		PermClientsRead, PermClientsWrite, PermClientsExport,
+	},
+	// Staff without bookings:read_all only get at the clients they have
+	// bookings with, and only write notes on them
+	RoleStaff: {
+		PermBusinessRead,
+		PermServicesRead,
+		PermStaffRead,
+		PermBookingsRead, PermBookingsWrite,
+		PermClientsRead, PermClientsWrite,
+	},
+}
+
+// IsValidPermission reports whether p is a known permission.
+func IsValidPermission(p Permission) bool {
+	for _, known := range AllPermissions {
+		if known == p {
+			return true
+		}
+	}
+	return false
+}
+
+// BuiltinRolePermissions returns the permission bundle of a built-in role.
+func BuiltinRolePermissions(role string) []Permission {
+	if role == RoleOwner {
+		return AllPermissions
+	}
+	return builtinRolePermissions[role]
+}
+
+// BuiltinRoles returns the names of the built-in roles.
+func BuiltinRoles() []string {
+	return []string{RoleOwner, RoleAdmin, RoleStaff}
+}
+
+// Role is a business-defined set of permissions that can be assigned to users
+// instead of the bundle of their built-in role.
+type Role struct {
+	ID          string       `json:"id"`
+	BusinessID  string       `json:"business_id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// HasPermission reports whether perms contains p.
+func HasPermission(perms []Permission, p Permission) bool {
+	for _, granted := range perms {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
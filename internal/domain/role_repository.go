@@ -0,0 +1,12 @@
+package domain
+
+import "context"
+
+type RoleRepository interface {
+	Create(ctx context.Context, role *Role) error
+	GetByID(ctx context.Context, id string) (*Role, error)
+	ListByBusiness(ctx context.Context, businessID string) ([]*Role, error)
+	Update(ctx context.Context, role *Role) error
+	Delete(ctx context.Context, id string) error
+	AssignToUser(ctx context.Context, userID string, roleID string) error
+}
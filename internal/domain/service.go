@@ -6,9 +6,16 @@ type Service struct {
 	ID          string
 	BusinessID  string
 	LocationID  string
+	CategoryID  string
 	Name        string
+	Description string
+	ImageURL    string
 	DurationMin int
 	PriceCents  int
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// VisibleOnline services are offered on the public booking page
+	VisibleOnline bool
+	// Inactive services can't be booked at all
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
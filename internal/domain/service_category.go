@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// ServiceCategory groups the services of a business on its booking menus,
+// which list the categories by position
+type ServiceCategory struct {
+	ID         string    `json:"id"`
+	BusinessID string    `json:"business_id"`
+	Name       string    `json:"name"`
+	Position   int       `json:"position"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ServiceCategoryGroup is a category with its services. Category is nil for
+// the services without one.
+type ServiceCategoryGroup struct {
+	Category *ServiceCategory
+	Services []Service
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "context"
+
+type ServiceCategoryRepository interface {
+	Create(ctx context.Context, category *ServiceCategory) error
+	GetByID(ctx context.Context, id string) (*ServiceCategory, error)
+	// GetByName matches the name case-insensitively
+	GetByName(ctx context.Context, businessID, name string) (*ServiceCategory, error)
+	// ListByBusiness returns the categories of a business by position
+	ListByBusiness(ctx context.Context, businessID string) ([]*ServiceCategory, error)
+	Update(ctx context.Context, category *ServiceCategory) error
+	// Delete deletes a category; its services become uncategorized
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "context"
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *UserSession) error
+	GetByID(ctx context.Context, id string) (*UserSession, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*UserSession, error)
+	ListActiveByUser(ctx context.Context, userID string) ([]*UserSession, error)
+	Rotate(ctx context.Context, oldSessionID string, next *UserSession) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllByUser(ctx context.Context, userID string) error
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrStaffAlreadyLinked is returned by the repository when the staff
+	// member of an accepted invitation already has a user account
+	ErrStaffAlreadyLinked = errors.New("staff member already has a user account")
+	// ErrEmailAlreadyInUse is returned by the repository when another user
+	// has the email of an accepted invitation
+	ErrEmailAlreadyInUse = errors.New("email is already in use")
+)
+
+// StaffInvitation is a single-use invite that lets a staff member create a
+// user account linked to their Staff record.
+type StaffInvitation struct {
+	ID         string
+	BusinessID string
+	StaffID    string
+	Email      string
+	TokenHash  string
+	InvitedBy  string
+	ExpiresAt  time.Time
+	AcceptedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// IsPending reports whether the invitation can still be accepted.
+func (i *StaffInvitation) IsPending() bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && i.ExpiresAt.After(time.Now())
+}
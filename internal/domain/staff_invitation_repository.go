@@ -0,0 +1,12 @@
+package domain
+
+import "context"
+
+type StaffInvitationRepository interface {
+	Create(ctx context.Context, invitation *StaffInvitation) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*StaffInvitation, error)
+	ListByBusiness(ctx context.Context, businessID string) ([]*StaffInvitation, error)
+	RevokePendingByStaff(ctx context.Context, staffID string) error
+	// Accept marks the invitation as used and creates its user in one transaction.
+	Accept(ctx context.Context, invitationID string, user *User) error
+}
@@ -2,12 +2,35 @@ package domain
 
 import "time"
 
+// StaffService assigns a service to a staff member, who may provide it at
+// their own price and duration
 type StaffService struct {
-	ID        string    `json:"id"`
-	StaffID   string    `json:"staff_id"`
-	ServiceID string    `json:"service_id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          string    `json:"id"`
+	StaffID     string    `json:"staff_id"`
+	ServiceID   string    `json:"service_id"`
+	PriceCents  *int      `json:"price_cents,omitempty"`
+	DurationMin *int      `json:"duration_min,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// EffectivePriceCents returns what the staff member charges for the service,
+// ss may be nil when the service isn't assigned to them
+func (ss *StaffService) EffectivePriceCents(service *Service) int {
+	if ss != nil && ss.PriceCents != nil {
+		return *ss.PriceCents
+	}
+	return service.PriceCents
+}
+
+// EffectiveDuration returns how long the staff member takes to provide the
+// service, ss may be nil when the service isn't assigned to them
+func (ss *StaffService) EffectiveDuration(service *Service) time.Duration {
+	minutes := service.DurationMin
+	if ss != nil && ss.DurationMin != nil {
+		minutes = *ss.DurationMin
+	}
+	return time.Duration(minutes) * time.Minute
 }
 
 type StaffServiceWithDetails struct {
@@ -16,6 +39,8 @@ type StaffServiceWithDetails struct {
 	ServiceID   string    `json:"service_id"`
 	StaffName   string    `json:"staff_name"`
 	ServiceName string    `json:"service_name"`
+	PriceCents  *int      `json:"price_cents,omitempty"`
+	DurationMin *int      `json:"duration_min,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
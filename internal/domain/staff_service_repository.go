@@ -5,10 +5,17 @@ import "context"
 type StaffServiceRepository interface {
 	AssignServiceToStaff(ctx context.Context, staffID, serviceID string) error
 	UnassignServiceFromStaff(ctx context.Context, staffID, serviceID string) error
+	// GetStaffService returns pgx.ErrNoRows when the service isn't assigned to the staff member
+	GetStaffService(ctx context.Context, staffID, serviceID string) (*StaffService, error)
+	// SetOverrides sets the price and duration the staff member provides the
+	// service at, nil clears an override. Returns pgx.ErrNoRows when the
+	// service isn't assigned to the staff member
+	SetOverrides(ctx context.Context, staffID, serviceID string, priceCents, durationMin *int) error
 	GetStaffServices(ctx context.Context, staffID string) ([]Service, error)
 	GetServiceStaff(ctx context.Context, serviceID string) ([]Staff, error)
 	GetStaffServicesByBusiness(ctx context.Context, businessID string) ([]StaffServiceWithDetails, error)
 	IsServiceAssignedToStaff(ctx context.Context, staffID, serviceID string) (bool, error)
 	AssignMultipleServicesToStaff(ctx context.Context, staffID string, serviceIDs []string) error
+	// ReplaceStaffServices keeps the overrides of the services that stay assigned
 	ReplaceStaffServices(ctx context.Context, staffID string, serviceIDs []string) error
 }
@@ -0,0 +1,11 @@
+package domain
+
+import "context"
+
+// Transactor runs a function in a database transaction. Repositories called
+// with the context handed to fn take part in the transaction, which commits
+// when fn returns nil and rolls back otherwise. Nested calls join the
+// transaction that is already running.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
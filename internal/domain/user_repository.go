@@ -6,7 +6,11 @@ type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	GetById(ctx context.Context, id string) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
+	// GetByStaffID returns the user account linked to a staff member
+	GetByStaffID(ctx context.Context, staffID string) (*User, error)
 	GetByBusinessID(ctx context.Context, businessID string) ([]*User, error)
 	Update(ctx context.Context, user *User) error
 	Delete(ctx context.Context, id string) error
+	UpdatePassword(ctx context.Context, id string, passwordHash string) error
+	MarkEmailVerified(ctx context.Context, id string) error
 }
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// TokenPurpose tells what a single-use user token may be exchanged for.
+type TokenPurpose string
+
+const (
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
+	TokenPurposeMFAChallenge      TokenPurpose = "mfa_challenge"
+)
+
+// UserToken is a time-limited single-use token sent to the user by email.
+// Only the hash of the token is stored.
+type UserToken struct {
+	ID        string       `json:"id"`
+	UserID    string       `json:"user_id"`
+	Purpose   TokenPurpose `json:"purpose"`
+	TokenHash string       `json:"-"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    *time.Time   `json:"used_at,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+func (t *UserToken) IsUsable() bool {
+	return t.UsedAt == nil && t.ExpiresAt.After(time.Now())
+}
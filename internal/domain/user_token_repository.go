@@ -0,0 +1,12 @@
+package domain
+
+import "context"
+
+type UserTokenRepository interface {
+	// Create stores a new token and invalidates the unused tokens of the same
+	// user and purpose.
+	Create(ctx context.Context, token *UserToken) error
+	GetByTokenHash(ctx context.Context, purpose TokenPurpose, tokenHash string) (*UserToken, error)
+	// Consume marks the token used; it fails when the token was used already.
+	Consume(ctx context.Context, id string) error
+}
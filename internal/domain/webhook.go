@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventWebhookTest is the type of the events sent by "send test event". It
+// is never recorded in the outbox.
+const EventWebhookTest EventType = "webhook.test"
+
+// WebhookEventTypes are the domain events webhook endpoints can subscribe to.
+var WebhookEventTypes = []EventType{
+	EventBookingCreated, EventBookingRescheduled, EventBookingCancelled,
+	EventShiftChanged, EventTimeOffApproved, EventClientMerged, EventClientErased,
+}
+
+// IsWebhookEventType reports whether endpoints can subscribe to t.
+func IsWebhookEventType(t EventType) bool {
+	for _, known := range WebhookEventTypes {
+		if known == t {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEndpoint is a URL of the business receiving the events it is
+// subscribed to, signed with its secret.
+type WebhookEndpoint struct {
+	ID          string      `json:"id"`
+	BusinessID  string      `json:"business_id"`
+	URL         string      `json:"url"`
+	Description string      `json:"description"`
+	EventTypes  []EventType `json:"event_types"`
+	Secret      string      `json:"-"`
+	IsActive    bool        `json:"is_active"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// IsSubscribed reports whether the endpoint wants events of type t.
+func (e *WebhookEndpoint) IsSubscribed(t EventType) bool {
+	for _, subscribed := range e.EventTypes {
+		if subscribed == t {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryDead      = "dead" // gave up, can be retried by hand
+)
+
+// WebhookDelivery is an event on its way to one endpoint.
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	EndpointID     string          `json:"endpoint_id"`
+	EventID        string          `json:"event_id,omitempty"` // empty for test events
+	EventType      EventType       `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"` // the request body
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// WebhookDeliveryAttempt is one request of a delivery, kept as delivery log.
+type WebhookDeliveryAttempt struct {
+	ID           string    `json:"id"`
+	DeliveryID   string    `json:"delivery_id"`
+	Attempt      int       `json:"attempt"`
+	StatusCode   int       `json:"status_code,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	AttemptedAt  time.Time `json:"attempted_at"`
+}
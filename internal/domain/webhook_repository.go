@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type WebhookRepository interface {
+	CreateEndpoint(ctx context.Context, endpoint *WebhookEndpoint) error
+	GetEndpoint(ctx context.Context, id string) (*WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context, businessID string) ([]*WebhookEndpoint, error)
+	// ListSubscribedEndpoints returns the active endpoints of a business
+	// subscribed to eventType
+	ListSubscribedEndpoints(ctx context.Context, businessID string, eventType EventType) ([]*WebhookEndpoint, error)
+	UpdateEndpoint(ctx context.Context, endpoint *WebhookEndpoint) error
+	DeleteEndpoint(ctx context.Context, id string) error
+
+	// CreateDelivery stores a pending delivery. It is a no-op when the event
+	// was already queued for the endpoint.
+	CreateDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	GetDelivery(ctx context.Context, id string) (*WebhookDelivery, error)
+	// ListDeliveries returns the latest deliveries of an endpoint, newest first
+	ListDeliveries(ctx context.Context, endpointID string, limit int) ([]*WebhookDelivery, error)
+	// ClaimDueDeliveries returns up to limit due pending deliveries of active
+	// endpoints and hides them from other workers for lease.
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*WebhookDelivery, error)
+	// RecordAttempt logs an attempt and stores the resulting state of the
+	// delivery (status, attempts, next attempt and last result).
+	RecordAttempt(ctx context.Context, delivery *WebhookDelivery, attempt *WebhookDeliveryAttempt) error
+	ListAttempts(ctx context.Context, deliveryID string) ([]*WebhookDeliveryAttempt, error)
+	// Redeliver makes a delivery pending and due again
+	Redeliver(ctx context.Context, id string) error
+}
@@ -50,6 +50,11 @@ func (r *CreateBookingRequest) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+type RescheduleBookingRequest struct {
+	StartAt time.Time `json:"start_at" validate:"required"`
+	StaffID string    `json:"staff_id" validate:"omitempty,uuid4"` // keeps the staff member when empty
+}
+
 type BookingResponse struct {
 	ID           string    `json:"id"`
 	ServiceID    string    `json:"service_id"`
@@ -58,6 +63,7 @@ type BookingResponse struct {
 	StaffName    string    `json:"staff_name"`
 	StartAt      time.Time `json:"start_at"`
 	EndAt        time.Time `json:"end_at"`
+	Status       string    `json:"status"`
 	ClientID     string    `json:"client_id"`
 	CustomerName string    `json:"customer_name"`
 	LocationID   string    `json:"location_id"`
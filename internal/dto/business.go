@@ -28,6 +28,9 @@ type CreateBusinessRequest struct {
 	Phone   string `json:"phone" validate:"required"`
 	Email   string `json:"email" validate:"required,email"`
 	Website string `json:"website"`
+	// ISO 3166-1 alpha-2 code, e.g. "RU"; client phone numbers entered
+	// without a country code are read in this country
+	Country string `json:"country" validate:"required,iso3166_1_alpha2"`
 
 	// Owner Information
 	OwnerFirstName       string `json:"ownerFirstName" validate:"required,min=2,max=50"`
@@ -47,6 +50,15 @@ type CreateBusinessRequest struct {
 	EnableSMSNotifications   bool `json:"enableSMSNotifications"`
 	EnableEmailNotifications bool `json:"enableEmailNotifications"`
 	AcceptTerms              bool `json:"acceptTerms" validate:"required,eq=true"`
+
+	// Hours before the appointment until which clients may cancel, 24 when omitted
+	CancellationWindowHours *int `json:"cancellationWindowHours" validate:"omitempty,min=0,max=720"`
+}
+
+// UpdateBusinessCountryRequest sets the country client phone numbers entered
+// without a country code are read in
+type UpdateBusinessCountryRequest struct {
+	Country string `json:"country" validate:"required,iso3166_1_alpha2"`
 }
 
 type CreateBusinessResponse struct {
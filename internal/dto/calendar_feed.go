@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+// CreateCalendarFeedRequest names the staff member or the location whose
+// calendar is published
+type CreateCalendarFeedRequest struct {
+	StaffID    string `json:"staff_id" validate:"required_without=LocationID,excluded_with=LocationID,omitempty,uuid4"`
+	LocationID string `json:"location_id" validate:"required_without=StaffID,omitempty,uuid4"`
+}
+
+type CalendarFeedResponse struct {
+	ID             string     `json:"id"`
+	StaffID        string     `json:"staff_id,omitempty"`
+	LocationID     string     `json:"location_id,omitempty"`
+	URL            string     `json:"url,omitempty"` // contains the token, only returned once, on creation
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
@@ -3,13 +3,25 @@ package dto
 import "time"
 
 type ClientResponse struct {
-	ID        string    `json:"id"`
-	FirstName string    `json:"first_name"`
-	LastName  string    `json:"last_name"`
-	Email     string    `json:"email"`
-	Phone     string    `json:"phone"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                    string     `json:"id"`
+	FirstName             string     `json:"first_name"`
+	LastName              string     `json:"last_name"`
+	Email                 string     `json:"email"`
+	Phone                 string     `json:"phone"`
+	MarketingConsent      bool       `json:"marketing_consent"`
+	MarketingConsentAt    *time.Time `json:"marketing_consent_at,omitempty"`
+	NotificationConsent   bool       `json:"notification_consent"`
+	NotificationConsentAt *time.Time `json:"notification_consent_at,omitempty"`
+	ErasedAt              *time.Time `json:"erased_at,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+// ClientListFilter narrows the client list; empty fields don't filter
+type ClientListFilter struct {
+	Search string            `validate:"max=100"`
+	TagIDs []string          `validate:"max=20,dive,uuid4"` // clients with all of these tags
+	Fields map[string]string `validate:"max=20"`            // clients with these custom field values, by field key
 }
 
 type ClientListResponse struct {
@@ -22,4 +34,127 @@ type PaginationInfo struct {
 	Limit int `json:"limit"`
 	Total int `json:"total"`
 	Pages int `json:"pages"`
-}
\ No newline at end of file
+}
+
+type CreateClientRequest struct {
+	FirstName string `json:"first_name" validate:"required,min=1,max=100"`
+	LastName  string `json:"last_name" validate:"max=100"`
+	Email     string `json:"email" validate:"omitempty,email,max=254"`
+	Phone     string `json:"phone" validate:"required,min=5,max=32"`
+}
+
+// UpdateClientRequest changes the fields that are set
+type UpdateClientRequest struct {
+	FirstName *string `json:"first_name" validate:"omitempty,min=1,max=100"`
+	LastName  *string `json:"last_name" validate:"omitempty,max=100"`
+	Email     *string `json:"email" validate:"omitempty,max=254,email|eq="` // empty removes the email
+	Phone     *string `json:"phone" validate:"omitempty,min=5,max=32"`
+}
+
+// ClientProfileResponse is a client with their visit history
+type ClientProfileResponse struct {
+	Client           ClientResponse             `json:"client"`
+	Tags             []ClientTagResponse        `json:"tags"`
+	CustomFields     []ClientFieldValueResponse `json:"custom_fields"`
+	Stats            ClientVisitStats           `json:"stats"`
+	FavouriteStaff   *ClientStaffSummary        `json:"favourite_staff,omitempty"`
+	Services         []ClientServiceSummary     `json:"services"`
+	UpcomingBookings []ClientBookingSummary     `json:"upcoming_bookings"`
+	PastBookings     []ClientBookingSummary     `json:"past_bookings"`
+}
+
+type ClientVisitStats struct {
+	Visits          int        `json:"visits"` // confirmed bookings that started
+	Upcoming        int        `json:"upcoming"`
+	Cancelled       int        `json:"cancelled"`
+	NoShows         int        `json:"no_shows"`
+	TotalSpentCents int        `json:"total_spent_cents"` // price of all visits, as agreed when booked
+	FirstVisitAt    *time.Time `json:"first_visit_at,omitempty"`
+	LastVisitAt     *time.Time `json:"last_visit_at,omitempty"`
+}
+
+type ClientStaffSummary struct {
+	StaffID string `json:"staff_id"`
+	Name    string `json:"name"`
+	Visits  int    `json:"visits"`
+}
+
+type ClientServiceSummary struct {
+	ServiceID  string    `json:"service_id"`
+	Name       string    `json:"name"`
+	Visits     int       `json:"visits"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+type ClientBookingSummary struct {
+	ID          string    `json:"id"`
+	ServiceID   string    `json:"service_id"`
+	ServiceName string    `json:"service_name"`
+	StaffID     string    `json:"staff_id"`
+	StaffName   string    `json:"staff_name"`
+	StartAt     time.Time `json:"start_at"`
+	EndAt       time.Time `json:"end_at"`
+	Status      string    `json:"status"`
+	PriceCents  int       `json:"price_cents"`
+}
+
+// PhoneBackfillReport is the outcome of normalising stored client phone
+// numbers to E.164
+type PhoneBackfillReport struct {
+	Businesses int                      `json:"businesses"`
+	Clients    int                      `json:"clients"`
+	Updated    int                      `json:"updated"`
+	Invalid    []PhoneBackfillInvalid   `json:"invalid"`
+	Collisions []PhoneBackfillCollision `json:"collisions"`
+}
+
+// PhoneBackfillInvalid is a stored number that couldn't be normalised
+type PhoneBackfillInvalid struct {
+	BusinessID string `json:"business_id"`
+	ClientID   string `json:"client_id"`
+	Phone      string `json:"phone"`
+}
+
+// PhoneBackfillCollision is a number several clients of a business share
+// once normalised
+type PhoneBackfillCollision struct {
+	BusinessID string   `json:"business_id"`
+	Phone      string   `json:"phone"`
+	ClientIDs  []string `json:"client_ids"`
+}
+
+// ClientDuplicateResponse is a pair of clients that are probably the same
+// person
+type ClientDuplicateResponse struct {
+	Client    ClientResponse `json:"client"` // the older one, suggested to keep
+	Duplicate ClientResponse `json:"duplicate"`
+	Score     float64        `json:"score"`   // from 0 to 1
+	Reasons   []string       `json:"reasons"` // phone, email and name, as far as they match
+}
+
+// MergeClientsRequest names the duplicate merged into the client of the URL
+type MergeClientsRequest struct {
+	MergedClientID string `json:"merged_client_id" validate:"required,uuid4"`
+	Preview        bool   `json:"preview"` // report what would move without merging
+}
+
+type ClientMergeResponse struct {
+	Preview               bool           `json:"preview"`
+	MergeID               string         `json:"merge_id,omitempty"`
+	Client                ClientResponse `json:"client"`        // the kept client, as it is after the merge
+	MergedClient          ClientResponse `json:"merged_client"` // deleted by the merge
+	FilledFields          []string       `json:"filled_fields"` // empty fields of the kept client taken from the duplicate
+	BookingsMoved         int            `json:"bookings_moved"`
+	UpcomingBookingsMoved int            `json:"upcoming_bookings_moved"`
+	NotesMoved            int            `json:"notes_moved"`
+}
+
+// ClientMergeRecordResponse is an entry of the merge audit trail of a client
+type ClientMergeRecordResponse struct {
+	ID             string         `json:"id"`
+	MergedClientID string         `json:"merged_client_id"`
+	MergedClient   ClientResponse `json:"merged_client"` // as it was when merged
+	BookingsMoved  int            `json:"bookings_moved"`
+	MergedBy       string         `json:"merged_by,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
@@ -0,0 +1,46 @@
+package dto
+
+import "time"
+
+type CreateClientFieldRequest struct {
+	Key      string   `json:"key" validate:"required,max=50"` // lower case letters, digits and underscores
+	Label    string   `json:"label" validate:"required,max=100"`
+	Type     string   `json:"type" validate:"required,oneof=text number date select"`
+	Options  []string `json:"options" validate:"required_if=Type select,max=100,dive,required,max=100"`
+	Position int      `json:"position"`
+}
+
+// UpdateClientFieldRequest changes the fields that are set; key and type
+// are fixed
+type UpdateClientFieldRequest struct {
+	Label    *string  `json:"label" validate:"omitempty,min=1,max=100"`
+	Options  []string `json:"options" validate:"omitempty,max=100,dive,required,max=100"`
+	Position *int     `json:"position"`
+}
+
+type ClientFieldResponse struct {
+	ID        string    `json:"id"`
+	Key       string    `json:"key"`
+	Label     string    `json:"label"`
+	Type      string    `json:"type"`
+	Options   []string  `json:"options"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SetClientFieldValuesRequest sets custom field values of a client by field
+// key. Values are strings: numbers like "42.5", dates like "2025-01-31";
+// null or "" removes a value. Fields left out keep their value.
+type SetClientFieldValuesRequest struct {
+	Values map[string]*string `json:"values" validate:"required,max=100"`
+}
+
+type ClientFieldValueResponse struct {
+	FieldID   string    `json:"field_id"`
+	Key       string    `json:"key"`
+	Label     string    `json:"label"`
+	Type      string    `json:"type"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
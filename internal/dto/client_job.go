@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+)
+
+// ClientImportRequest are the options sent along with an import file
+type ClientImportRequest struct {
+	Format string `validate:"omitempty,oneof=csv xlsx"` // detected from the file when empty
+	// Mapping names the file column of each client column: first_name,
+	// last_name, name, email, phone and field.<key>. Without it columns are
+	// matched by their header.
+	Mapping        map[string]string `validate:"max=50"`
+	DryRun         bool
+	UpdateExisting bool
+}
+
+type ClientJobResponse struct {
+	ID             string                     `json:"id"`
+	Kind           string                     `json:"kind"`   // import or export
+	Status         string                     `json:"status"` // pending, running, done or failed
+	FileName       string                     `json:"file_name,omitempty"`
+	DryRun         bool                       `json:"dry_run,omitempty"`
+	UpdateExisting bool                       `json:"update_existing,omitempty"`
+	TotalRows      int                        `json:"total_rows"`
+	DoneRows       int                        `json:"done_rows"`
+	Report         *domain.ClientImportReport `json:"report,omitempty"`
+	Error          string                     `json:"error,omitempty"`
+	CreatedBy      string                     `json:"created_by,omitempty"`
+	CreatedAt      time.Time                  `json:"created_at"`
+	StartedAt      *time.Time                 `json:"started_at,omitempty"`
+	FinishedAt     *time.Time                 `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+type ClientNoteRequest struct {
+	Body string `json:"body" validate:"required,max=5000"`
+}
+
+type ClientNoteResponse struct {
+	ID         string    `json:"id"`
+	ClientID   string    `json:"client_id"`
+	AuthorID   string    `json:"author_id,omitempty"` // empty when written with an API key
+	AuthorName string    `json:"author_name,omitempty"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -0,0 +1,46 @@
+package dto
+
+import "time"
+
+// ClientLoginRequest starts a client portal login. The code is sent by email
+// when an email is given, by SMS otherwise.
+type ClientLoginRequest struct {
+	Email string `json:"email" validate:"required_without=Phone,omitempty,email"`
+	Phone string `json:"phone" validate:"required_without=Email"`
+}
+
+// ClientLoginVerifyRequest completes a client portal login either with the
+// magic link token or with the code and the email or phone it was sent to.
+type ClientLoginVerifyRequest struct {
+	Token string `json:"token" validate:"required_without=Code"`
+	Email string `json:"email" validate:"omitempty,email"`
+	Phone string `json:"phone"`
+	Code  string `json:"code"  validate:"required_without=Token,omitempty,len=6,numeric"`
+}
+
+type ClientLoginResponse struct {
+	Token     string         `json:"token"`
+	ExpiresAt time.Time      `json:"expires_at"`
+	Client    ClientResponse `json:"client"`
+}
+
+// UpdateClientProfileRequest changes the contact details of the logged-in
+// client. Empty fields are left unchanged.
+type UpdateClientProfileRequest struct {
+	FirstName string `json:"first_name" validate:"omitempty,min=2,max=100"`
+	LastName  string `json:"last_name"  validate:"omitempty,max=100"`
+	Email     string `json:"email"      validate:"omitempty,email"`
+	Phone     string `json:"phone"      validate:"omitempty,min=5,max=20"`
+}
+
+type ClientBookingsResponse struct {
+	Upcoming []*PublicBookingResponse `json:"upcoming"`
+	Past     []*PublicBookingResponse `json:"past"`
+}
+
+// RebookRequest books the service of a past booking again, with the same
+// staff member unless StaffID is given.
+type RebookRequest struct {
+	StartAt time.Time `json:"start_at" validate:"required"`
+	StaffID string    `json:"staff_id" validate:"omitempty,uuid4"`
+}
@@ -0,0 +1,55 @@
+package dto
+
+import "time"
+
+// ClientDataExport is everything stored about a client, for answering data
+// subject access requests
+type ClientDataExport struct {
+	ExportedAt    time.Time                    `json:"exported_at"`
+	Client        ClientResponse               `json:"client"`
+	Bookings      []ClientBookingSummary       `json:"bookings"` // latest first
+	Notes         []ClientNoteResponse         `json:"notes"`
+	Tags          []ClientTagResponse          `json:"tags"`
+	CustomFields  []ClientFieldValueResponse   `json:"custom_fields"`
+	Consents      []ClientConsentResponse      `json:"consents"`
+	Notifications []ClientNotificationResponse `json:"notifications"`
+	Merges        []ClientMergeRecordResponse  `json:"merges"`
+}
+
+// ClientNotificationResponse is a notification sent or queued for a client
+type ClientNotificationResponse struct {
+	ID        string     `json:"id"`
+	Event     string     `json:"event"`
+	Channel   string     `json:"channel"`
+	Recipient string     `json:"recipient"`
+	Subject   string     `json:"subject"`
+	Body      string     `json:"body"`
+	Status    string     `json:"status"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// UpdateClientConsentRequest gives or withdraws consent; kinds left out stay
+// as they are
+type UpdateClientConsentRequest struct {
+	Marketing     *bool  `json:"marketing"`
+	Notifications *bool  `json:"notifications"`
+	Source        string `json:"source" validate:"max=100"` // e.g. "front desk" or "signed form"
+}
+
+// ClientConsentResponse is an entry of the consent history of a client
+type ClientConsentResponse struct {
+	ID             string    `json:"id"`
+	Kind           string    `json:"kind"`
+	Granted        bool      `json:"granted"`
+	Source         string    `json:"source"`
+	RecordedBy     string    `json:"recorded_by,omitempty"` // empty when recorded with an API key
+	RecordedByName string    `json:"recorded_by_name,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ClientConsentsResponse is the current consent of a client with its history
+type ClientConsentsResponse struct {
+	Client  ClientResponse          `json:"client"`
+	History []ClientConsentResponse `json:"history"` // latest first
+}
@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+)
+
+type ClientSegmentRequest struct {
+	Name        string             `json:"name" validate:"required,max=100"`
+	Description string             `json:"description" validate:"max=500"`
+	Definition  domain.SegmentNode `json:"definition"`
+}
+
+type ClientSegmentResponse struct {
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Definition  domain.SegmentNode `json:"definition"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+type ClientSegmentPreviewRequest struct {
+	Definition domain.SegmentNode `json:"definition"`
+}
+
+// ClientSegmentPreviewResponse counts the clients of a segment and shows the
+// newest of them
+type ClientSegmentPreviewResponse struct {
+	Total   int              `json:"total"`
+	Clients []ClientResponse `json:"clients"`
+}
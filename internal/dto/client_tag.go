@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+type ClientTagRequest struct {
+	Name  string `json:"name" validate:"required,max=50"`
+	Color string `json:"color" validate:"omitempty,hexcolor"` // e.g. "#ff8800"
+}
+
+type ClientTagResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SetClientTagsRequest replaces the tags of a client
+type SetClientTagsRequest struct {
+	TagIDs []string `json:"tag_ids" validate:"max=50,dive,uuid4"`
+}
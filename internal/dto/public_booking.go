@@ -0,0 +1,94 @@
+package dto
+
+import "time"
+
+// PublicBusinessResponse is the business profile shown on the public booking page
+type PublicBusinessResponse struct {
+	Name         string `json:"name"`
+	Slug         string `json:"slug"`
+	BusinessType string `json:"business_type"`
+	Description  string `json:"description"`
+	Address      string `json:"address"`
+	City         string `json:"city"`
+	Phone        string `json:"phone"`
+	Email        string `json:"email"`
+	Website      string `json:"website"`
+	Timezone     string `json:"timezone"`
+	Currency     string `json:"currency"`
+}
+
+type PublicServiceResponse struct {
+	ID          string   `json:"id"`
+	CategoryID  string   `json:"category_id,omitempty"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	ImageURL    string   `json:"image_url"`
+	DurationMin int      `json:"duration_min"`
+	PriceCents  int      `json:"price_cents"`
+	LocationID  string   `json:"location_id"`
+	StaffIDs    []string `json:"staff_ids"`
+	// Staff lists what each provider charges and how long they take, which
+	// may differ from the service's own price and duration
+	Staff []PublicServiceStaffResponse `json:"staff"`
+}
+
+type PublicServiceStaffResponse struct {
+	StaffID     string `json:"staff_id"`
+	DurationMin int    `json:"duration_min"`
+	PriceCents  int    `json:"price_cents"`
+}
+
+type PublicServiceCategoryResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// PublicServiceGroupResponse is a category of the booking menu with its
+// services. Category is null for the services without one, which come last.
+type PublicServiceGroupResponse struct {
+	Category *PublicServiceCategoryResponse `json:"category"`
+	Services []*PublicServiceResponse       `json:"services"`
+}
+
+type PublicStaffResponse struct {
+	ID             string   `json:"id"`
+	FirstName      string   `json:"first_name"`
+	LastName       string   `json:"last_name"`
+	Position       string   `json:"position"`
+	Specialization string   `json:"specialization"`
+	Description    string   `json:"description"`
+	ServiceIDs     []string `json:"service_ids"`
+}
+
+type PublicCreateBookingRequest struct {
+	ServiceID     string    `json:"service_id"     validate:"required,uuid4"`
+	StaffID       string    `json:"staff_id"       validate:"required,uuid4"`
+	StartAt       time.Time `json:"start_at"       validate:"required"`
+	CustomerName  string    `json:"customer_name"  validate:"required,min=2,max=100"`
+	CustomerPhone string    `json:"customer_phone" validate:"required"`
+	CustomerEmail string    `json:"customer_email" validate:"omitempty,email"`
+}
+
+// PublicBookingResponse is a booking as seen by the client. ManageToken and
+// ManageURL are only returned when the booking is created.
+type PublicBookingResponse struct {
+	ID              string     `json:"id"`
+	Status          string     `json:"status"`
+	BusinessName    string     `json:"business_name"`
+	ServiceID       string     `json:"service_id"`
+	ServiceName     string     `json:"service_name"`
+	StaffID         string     `json:"staff_id"`
+	StaffName       string     `json:"staff_name"`
+	StartAt         time.Time  `json:"start_at"`
+	EndAt           time.Time  `json:"end_at"`
+	PriceCents      int        `json:"price_cents"`
+	Currency        string     `json:"currency"`
+	LocationID      string     `json:"location_id"`
+	LocationName    string     `json:"location_name"`
+	LocationAddress string     `json:"location_address"`
+	CancelledAt     *time.Time `json:"cancelled_at,omitempty"`
+	// CancellableUntil is the end of the cancellation window
+	CancellableUntil *time.Time `json:"cancellable_until,omitempty"`
+	ManageToken      string     `json:"manage_token,omitempty"`
+	ManageURL        string     `json:"manage_url,omitempty"`
+}
@@ -0,0 +1,31 @@
+package dto
+
+import "time"
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,min=2,max=50"`
+	Description string   `json:"description" validate:"omitempty,max=255"`
+	Permissions []string `json:"permissions" validate:"required,min=1,dive,required"`
+}
+
+type UpdateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,min=2,max=50"`
+	Description string   `json:"description" validate:"omitempty,max=255"`
+	Permissions []string `json:"permissions" validate:"required,min=1,dive,required"`
+}
+
+// AssignRoleRequest sets the custom role of a user; an empty role_id restores
+// the permissions of the user's built-in role.
+type AssignRoleRequest struct {
+	RoleID string `json:"role_id" validate:"omitempty,uuid4"`
+}
+
+type RoleResponse struct {
+	ID          string    `json:"id,omitempty"` // empty for built-in roles
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Builtin     bool      `json:"builtin"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+}
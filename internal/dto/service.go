@@ -3,28 +3,50 @@ package dto
 import "time"
 
 type CreateServiceRequest struct {
-	Name        string `json:"name" validate:"required,min=3,max=100"`
-	DurationMin int    `json:"duration_min" validate:"required,min=1"`
-	PriceCents  int    `json:"price_cents" validate:"required,min=1"`
-	LocationID  string `json:"location_id" validate:"omitempty"`
-	CategoryID  string `json:"category_id" validate:"omitempty"`
+	Name          string `json:"name" validate:"required,min=3,max=100"`
+	Description   string `json:"description" validate:"max=2000"`
+	ImageURL      string `json:"image_url" validate:"omitempty,http_url,max=2000"`
+	DurationMin   int    `json:"duration_min" validate:"required,min=1"`
+	PriceCents    int    `json:"price_cents" validate:"required,min=1"`
+	LocationID    string `json:"location_id" validate:"omitempty"`
+	CategoryID    string `json:"category_id" validate:"omitempty,uuid4"`
+	VisibleOnline *bool  `json:"visible_online"` // true when left out
+	Active        *bool  `json:"active"`         // true when left out
 }
 
+// UpdateServiceRequest changes the fields that are set. An empty category
+// ID, description or image URL clears it.
 type UpdateServiceRequest struct {
-	Name        string `json:"name" validate:"omitempty,min=3,max=100"`
-	DurationMin int    `json:"duration_min" validate:"omitempty,min=1"`
-	PriceCents  int    `json:"price_cents" validate:"omitempty,min=1"`
-	LocationID  string `json:"location_id" validate:"omitempty"`
-	CategoryID  string `json:"category_id" validate:"omitempty"`
+	Name          string  `json:"name" validate:"omitempty,min=3,max=100"`
+	Description   *string `json:"description" validate:"omitempty,max=2000"`
+	ImageURL      *string `json:"image_url" validate:"omitempty,len=0|http_url,max=2000"`
+	DurationMin   int     `json:"duration_min" validate:"omitempty,min=1"`
+	PriceCents    int     `json:"price_cents" validate:"omitempty,min=1"`
+	LocationID    string  `json:"location_id" validate:"omitempty"`
+	CategoryID    *string `json:"category_id" validate:"omitempty,len=0|uuid4"`
+	VisibleOnline *bool   `json:"visible_online"`
+	Active        *bool   `json:"active"`
 }
 
 type ServiceResponse struct {
-	ID          string    `json:"id"`
-	BusinessID  string    `json:"business_id"`
-	LocationID  string    `json:"location_id"`
-	Name        string    `json:"name"`
-	DurationMin int       `json:"duration_min"`
-	PriceCents  int       `json:"price_cents"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            string    `json:"id"`
+	BusinessID    string    `json:"business_id"`
+	LocationID    string    `json:"location_id"`
+	CategoryID    string    `json:"category_id,omitempty"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	ImageURL      string    `json:"image_url"`
+	DurationMin   int       `json:"duration_min"`
+	PriceCents    int       `json:"price_cents"`
+	VisibleOnline bool      `json:"visible_online"`
+	Active        bool      `json:"active"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ServiceCategoryGroupResponse is a category with its services by name.
+// Category is null for the services without one, which come last.
+type ServiceCategoryGroupResponse struct {
+	Category *ServiceCategoryResponse `json:"category"`
+	Services []ServiceResponse        `json:"services"`
 }
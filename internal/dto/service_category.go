@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+type CreateServiceCategoryRequest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Position int    `json:"position"`
+}
+
+// UpdateServiceCategoryRequest changes the fields that are set
+type UpdateServiceCategoryRequest struct {
+	Name     *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Position *int    `json:"position"`
+}
+
+type ServiceCategoryResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
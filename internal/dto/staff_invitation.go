@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+type CreateStaffInvitationRequest struct {
+	StaffID string `json:"staff_id" validate:"required,uuid4"`
+	Email   string `json:"email" validate:"required,email"`
+}
+
+type AcceptStaffInvitationRequest struct {
+	Token           string `json:"token" validate:"required"`
+	Password        string `json:"password" validate:"required,min=8"`
+	PasswordConfirm string `json:"password_confirm" validate:"required,eqfield=Password"`
+	Phone           string `json:"phone" validate:"omitempty,min=10,max=20"`
+}
+
+type StaffInvitationResponse struct {
+	ID         string     `json:"id"`
+	StaffID    string     `json:"staff_id"`
+	Email      string     `json:"email"`
+	Status     string     `json:"status"`          // pending, accepted, revoked, expired
+	Token      string     `json:"token,omitempty"` // only returned once, on creation
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -2,7 +2,16 @@ package dto
 
 // AssignServiceToStaffRequest represents the request to assign a service to staff
 type AssignServiceToStaffRequest struct {
-	ServiceID string `json:"service_id" validate:"required,uuid4"`
+	ServiceID   string `json:"service_id" validate:"required,uuid4"`
+	PriceCents  *int   `json:"price_cents,omitempty" validate:"omitempty,min=1"`
+	DurationMin *int   `json:"duration_min,omitempty" validate:"omitempty,min=1"`
+}
+
+// StaffServiceOverridesRequest sets the price and duration a staff member
+// provides a service at, an omitted or null value falls back to the service's
+type StaffServiceOverridesRequest struct {
+	PriceCents  *int `json:"price_cents" validate:"omitempty,min=1"`
+	DurationMin *int `json:"duration_min" validate:"omitempty,min=1"`
 }
 
 // AssignMultipleServicesToStaffRequest represents the request to assign multiple services to staff
@@ -17,6 +26,8 @@ type StaffServiceResponse struct {
 	ServiceID   string `json:"service_id"`
 	StaffName   string `json:"staff_name"`
 	ServiceName string `json:"service_name"`
+	PriceCents  *int   `json:"price_cents"`
+	DurationMin *int   `json:"duration_min"`
 	CreatedAt   string `json:"created_at"`
 	UpdatedAt   string `json:"updated_at"`
 }
@@ -0,0 +1,65 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type CreateWebhookEndpointRequest struct {
+	URL         string   `json:"url" validate:"required,url,max=2000"`
+	Description string   `json:"description" validate:"max=500"`
+	EventTypes  []string `json:"event_types" validate:"required,min=1,dive,required"`
+}
+
+// UpdateWebhookEndpointRequest changes the fields that are set
+type UpdateWebhookEndpointRequest struct {
+	URL         *string  `json:"url" validate:"omitempty,url,max=2000"`
+	Description *string  `json:"description" validate:"omitempty,max=500"`
+	EventTypes  []string `json:"event_types" validate:"omitempty,min=1,dive,required"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+type WebhookEndpointResponse struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	EventTypes  []string  `json:"event_types"`
+	IsActive    bool      `json:"is_active"`
+	Secret      string    `json:"secret,omitempty"` // only returned on creation and rotation
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             string                           `json:"id"`
+	EventID        string                           `json:"event_id,omitempty"`
+	EventType      string                           `json:"event_type"`
+	Status         string                           `json:"status"` // pending, succeeded or dead
+	Attempts       int                              `json:"attempts"`
+	NextAttemptAt  *time.Time                       `json:"next_attempt_at,omitempty"`
+	LastStatusCode int                              `json:"last_status_code,omitempty"`
+	LastError      string                           `json:"last_error,omitempty"`
+	LastAttemptAt  *time.Time                       `json:"last_attempt_at,omitempty"`
+	DeliveredAt    *time.Time                       `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time                        `json:"created_at"`
+	Payload        json.RawMessage                  `json:"payload,omitempty"`     // only in the delivery details
+	AttemptLog     []WebhookDeliveryAttemptResponse `json:"attempt_log,omitempty"` // only in the delivery details
+}
+
+type WebhookDeliveryAttemptResponse struct {
+	Attempt      int       `json:"attempt"`
+	StatusCode   int       `json:"status_code,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	AttemptedAt  time.Time `json:"attempted_at"`
+}
+
+// WebhookEventPayload is the JSON body posted to webhook endpoints
+type WebhookEventPayload struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	BusinessID string          `json:"business_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type apiKeyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool) domain.APIKeyRepository {
+	return &apiKeyRepository{
+		db: db,
+	}
+}
+
+const apiKeyColumns = `id, business_id, name, key_prefix, key_hash, scopes, created_by,
+	expires_at, last_used_at, revoked_at, created_at`
+
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	var k domain.APIKey
+	var scopes []string
+	err := row.Scan(&k.ID, &k.BusinessID, &k.Name, &k.KeyPrefix, &k.KeyHash, &scopes, &k.CreatedBy,
+		&k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	k.Scopes = stringsToPermissions(scopes)
+	return &k, nil
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	key.CreatedAt = time.Now()
+
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO api_keys (business_id, name, key_prefix, key_hash, scopes, created_by, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id`,
+		key.BusinessID, key.Name, key.KeyPrefix, key.KeyHash, permissionsToStrings(key.Scopes),
+		key.CreatedBy, key.ExpiresAt, key.CreatedAt,
+	).Scan(&key.ID)
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	return scanAPIKey(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`, keyHash))
+}
+
+func (r *apiKeyRepository) ListByBusiness(ctx context.Context, businessID string) ([]*domain.APIKey, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE business_id = $1 ORDER BY created_at DESC`,
+		businessID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*domain.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE api_keys SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`,
+		id, time.Now())
+	return err
+}
+
+// TouchLastUsed updates last_used_at at most once a minute, so busy keys
+// don't write on every request.
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id string) error {
+	now := time.Now()
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE api_keys SET last_used_at = $2
+		 WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $3)`,
+		id, now, now.Add(-time.Minute))
+	return err
+}
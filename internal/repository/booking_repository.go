@@ -2,12 +2,18 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// bookingOverlapConstraint excludes overlapping bookings of a staff member
+const bookingOverlapConstraint = "bookings_staff_no_overlap"
+
 type bookingRepository struct {
 	db *pgxpool.Pool
 }
@@ -21,58 +27,84 @@ func NewBookingRepository(db *pgxpool.Pool) domain.BookingRepository {
 func (r *bookingRepository) Create(ctx context.Context, booking *domain.Booking) error {
 	booking.CreatedAt = time.Now()
 	booking.UpdatedAt = time.Now()
+	if booking.Status == "" {
+		booking.Status = domain.BookingStatusConfirmed
+	}
 
-	err := r.db.QueryRow(ctx,
-		`INSERT INTO bookings (service_id, staff_id, client_id, location_id, start_at, end_at, created_at, updated_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO bookings (service_id, staff_id, client_id, location_id, start_at, end_at, status, price_cents, duration_min, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		 RETURNING id`,
 		booking.ServiceID, booking.StaffID, booking.ClientID, booking.LocationID,
-		booking.StartAt, booking.EndAt, booking.CreatedAt, booking.UpdatedAt).Scan(&booking.ID)
-	return err
+		booking.StartAt, booking.EndAt, booking.Status, booking.PriceCents, booking.DurationMin,
+		booking.CreatedAt, booking.UpdatedAt).Scan(&booking.ID)
+	return bookingWriteError(err)
 }
 
 func (r *bookingRepository) GetById(ctx context.Context, id string) (*domain.Booking, error) {
 	var booking domain.Booking
-	err := r.db.QueryRow(ctx,
-		`SELECT id, service_id, staff_id, client_id, location_id, start_at, end_at, created_at, updated_at
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT id, service_id, staff_id, client_id, location_id, start_at, end_at, status, price_cents, duration_min, cancelled_at, created_at, updated_at
 		 FROM bookings
 		 WHERE id = $1`,
 		id).Scan(&booking.ID, &booking.ServiceID, &booking.StaffID, &booking.ClientID, &booking.LocationID,
-		&booking.StartAt, &booking.EndAt, &booking.CreatedAt, &booking.UpdatedAt)
+		&booking.StartAt, &booking.EndAt, &booking.Status, &booking.PriceCents, &booking.DurationMin, &booking.CancelledAt, &booking.CreatedAt, &booking.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return &booking, nil
 }
 
-func (r *bookingRepository) GetByBusinessID(ctx context.Context, businessID string, startDate, endDate *time.Time) ([]*domain.Booking, error) {
-	var query string
-	var args []interface{}
-
-	baseQuery := `
-		SELECT b.id, b.service_id, b.staff_id, b.client_id, b.location_id, b.start_at, b.end_at, b.created_at, b.updated_at
+func (r *bookingRepository) GetByBusinessID(ctx context.Context, businessID, staffID string, startDate, endDate *time.Time) ([]*domain.Booking, error) {
+	query := `
+		SELECT b.id, b.service_id, b.staff_id, b.client_id, b.location_id, b.start_at, b.end_at, b.status, b.price_cents, b.duration_min, b.cancelled_at, b.created_at, b.updated_at
 		FROM bookings b
 		JOIN services s ON b.service_id = s.id
 		WHERE s.business_id = $1
 	`
+	args := []interface{}{businessID}
 
-	args = append(args, businessID)
-
-	if startDate != nil && endDate != nil {
-		query = baseQuery + ` AND b.start_at >= $2 AND b.start_at <= $3 ORDER BY b.start_at DESC`
-		args = append(args, *startDate, *endDate)
-	} else if startDate != nil {
-		query = baseQuery + ` AND b.start_at >= $2 ORDER BY b.start_at DESC`
+	if staffID != "" {
+		args = append(args, staffID)
+		query += fmt.Sprintf(` AND b.staff_id = $%d`, len(args))
+	}
+	if startDate != nil {
 		args = append(args, *startDate)
-	} else if endDate != nil {
-		query = baseQuery + ` AND b.start_at <= $2 ORDER BY b.start_at DESC`
+		query += fmt.Sprintf(` AND b.start_at >= $%d`, len(args))
+	}
+	if endDate != nil {
 		args = append(args, *endDate)
-	} else {
-		query = baseQuery + ` ORDER BY b.start_at DESC`
+		query += fmt.Sprintf(` AND b.start_at <= $%d`, len(args))
 	}
+	query += ` ORDER BY b.start_at DESC`
 
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	rows, err := r.db.Query(ctx, query, args...)
+	var bookings []*domain.Booking
+	for rows.Next() {
+		var booking domain.Booking
+		err := rows.Scan(&booking.ID, &booking.ServiceID, &booking.StaffID, &booking.ClientID, &booking.LocationID,
+			&booking.StartAt, &booking.EndAt, &booking.Status, &booking.PriceCents, &booking.DurationMin, &booking.CancelledAt, &booking.CreatedAt, &booking.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, &booking)
+	}
+
+	return bookings, rows.Err()
+}
+
+func (r *bookingRepository) ListByClient(ctx context.Context, clientID string) ([]*domain.Booking, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, service_id, staff_id, client_id, location_id, start_at, end_at, status, price_cents, duration_min, cancelled_at, created_at, updated_at
+		 FROM bookings
+		 WHERE client_id = $1
+		 ORDER BY start_at DESC`,
+		clientID)
 	if err != nil {
 		return nil, err
 	}
@@ -82,7 +114,7 @@ func (r *bookingRepository) GetByBusinessID(ctx context.Context, businessID stri
 	for rows.Next() {
 		var booking domain.Booking
 		err := rows.Scan(&booking.ID, &booking.ServiceID, &booking.StaffID, &booking.ClientID, &booking.LocationID,
-			&booking.StartAt, &booking.EndAt, &booking.CreatedAt, &booking.UpdatedAt)
+			&booking.StartAt, &booking.EndAt, &booking.Status, &booking.PriceCents, &booking.DurationMin, &booking.CancelledAt, &booking.CreatedAt, &booking.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -93,10 +125,10 @@ func (r *bookingRepository) GetByBusinessID(ctx context.Context, businessID stri
 }
 
 func (r *bookingRepository) GetByStaffAndTimeRange(ctx context.Context, staffID string, start, end time.Time) ([]*domain.Booking, error) {
-	rows, err := r.db.Query(ctx,
-		`SELECT id, service_id, staff_id, client_id, location_id, start_at, end_at, created_at, updated_at
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, service_id, staff_id, client_id, location_id, start_at, end_at, status, price_cents, duration_min, cancelled_at, created_at, updated_at
 		 FROM bookings
-		 WHERE staff_id = $1 AND start_at < $3 AND end_at > $2
+		 WHERE staff_id = $1 AND start_at < $3 AND end_at > $2 AND status <> 'cancelled'
 		 ORDER BY start_at`,
 		staffID, start, end)
 	if err != nil {
@@ -108,7 +140,33 @@ func (r *bookingRepository) GetByStaffAndTimeRange(ctx context.Context, staffID
 	for rows.Next() {
 		var booking domain.Booking
 		err := rows.Scan(&booking.ID, &booking.ServiceID, &booking.StaffID, &booking.ClientID, &booking.LocationID,
-			&booking.StartAt, &booking.EndAt, &booking.CreatedAt, &booking.UpdatedAt)
+			&booking.StartAt, &booking.EndAt, &booking.Status, &booking.PriceCents, &booking.DurationMin, &booking.CancelledAt, &booking.CreatedAt, &booking.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, &booking)
+	}
+
+	return bookings, rows.Err()
+}
+
+func (r *bookingRepository) ListStartingBetween(ctx context.Context, from, to time.Time) ([]*domain.Booking, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, service_id, staff_id, client_id, location_id, start_at, end_at, status, price_cents, duration_min, cancelled_at, created_at, updated_at
+		 FROM bookings
+		 WHERE start_at > $1 AND start_at <= $2 AND status = 'confirmed'
+		 ORDER BY start_at`,
+		from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bookings []*domain.Booking
+	for rows.Next() {
+		var booking domain.Booking
+		err := rows.Scan(&booking.ID, &booking.ServiceID, &booking.StaffID, &booking.ClientID, &booking.LocationID,
+			&booking.StartAt, &booking.EndAt, &booking.Status, &booking.PriceCents, &booking.DurationMin, &booking.CancelledAt, &booking.CreatedAt, &booking.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -118,6 +176,60 @@ func (r *bookingRepository) GetByStaffAndTimeRange(ctx context.Context, staffID
 	return bookings, rows.Err()
 }
 
+func (r *bookingRepository) Cancel(ctx context.Context, id string) error {
+	var cancelledID string
+	return conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE bookings
+		 SET status = 'cancelled', cancelled_at = now()
+		 WHERE id = $1 AND status <> 'cancelled'
+		 RETURNING id`,
+		id).Scan(&cancelledID)
+}
+
+func (r *bookingRepository) Reschedule(ctx context.Context, booking *domain.Booking) error {
+	booking.UpdatedAt = time.Now()
+
+	var id string
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE bookings
+		 SET staff_id = $2, start_at = $3, end_at = $4, price_cents = $5, duration_min = $6, updated_at = $7
+		 WHERE id = $1 AND status = 'confirmed'
+		 RETURNING id`,
+		booking.ID, booking.StaffID, booking.StartAt, booking.EndAt, booking.PriceCents, booking.DurationMin,
+		booking.UpdatedAt).Scan(&id)
+	return bookingWriteError(err)
+}
+
+// bookingWriteError turns a violation of the overlap constraint into
+// domain.ErrBookingOverlap
+func bookingWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.ConstraintName == bookingOverlapConstraint {
+		return domain.ErrBookingOverlap
+	}
+	return err
+}
+
+func (r *bookingRepository) MarkNoShow(ctx context.Context, id string) error {
+	var markedID string
+	return conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE bookings
+		 SET status = 'no_show', updated_at = now()
+		 WHERE id = $1 AND status = 'confirmed'
+		 RETURNING id`,
+		id).Scan(&markedID)
+}
+
+func (r *bookingRepository) ReassignClient(ctx context.Context, fromClientID, toClientID string) (int, error) {
+	tag, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE bookings SET client_id = $2, updated_at = now() WHERE client_id = $1`,
+		fromClientID, toClientID)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
 func (r *bookingRepository) GetAvailableSlots(ctx context.Context, businessID string, staffID *string, day time.Time) ([]*domain.Slot, error) {
 	// For simplicity, we'll generate slots from 9 AM to 6 PM with 30-minute intervals
 	// In a real application, you would get staff working hours from the database
@@ -137,7 +249,7 @@ func (r *bookingRepository) GetAvailableSlots(ctx context.Context, businessID st
 		args = []interface{}{businessID}
 	}
 
-	staffRows, err := r.db.Query(ctx, staffQuery, args...)
+	staffRows, err := conn(ctx, r.db).Query(ctx, staffQuery, args...)
 	if err != nil {
 		return nil, err
 	}
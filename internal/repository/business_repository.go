@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -18,17 +19,15 @@ func NewBusinessRepository(db *pgxpool.Pool) domain.BusinessRepository {
 	}
 }
 
-func (r *businessRepository) GetById(ctx context.Context, id string) (*domain.Business, error) {
+const businessColumns = `id, name, slug, business_type, description, address, city, country, phone, email, website, timezone, currency,
+	enable_online_booking, enable_sms_notifications, enable_email_notifications, cancellation_window_hours,
+	created_at, updated_at`
+
+func scanBusiness(row pgx.Row) (*domain.Business, error) {
 	var b domain.Business
-	err := r.db.QueryRow(ctx,
-		`SELECT id, name, business_type, description, address, city, phone, email, website, timezone, currency,
-			enable_online_booking, enable_sms_notifications, enable_email_notifications, 
-			created_at, updated_at
-	 FROM businesses
-	 WHERE id = $1`,
-		id).Scan(&b.ID, &b.Name, &b.BusinessType, &b.Description, &b.Address, &b.City, &b.Phone,
+	err := row.Scan(&b.ID, &b.Name, &b.Slug, &b.BusinessType, &b.Description, &b.Address, &b.City, &b.Country, &b.Phone,
 		&b.Email, &b.Website, &b.Timezone, &b.Currency, &b.EnableOnlineBooking,
-		&b.EnableSMSNotifications, &b.EnableEmailNotifications, &b.CreatedAt, &b.UpdatedAt)
+		&b.EnableSMSNotifications, &b.EnableEmailNotifications, &b.CancellationWindowHours, &b.CreatedAt, &b.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -36,18 +35,68 @@ func (r *businessRepository) GetById(ctx context.Context, id string) (*domain.Bu
 	return &b, nil
 }
 
+func (r *businessRepository) GetById(ctx context.Context, id string) (*domain.Business, error) {
+	return scanBusiness(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+businessColumns+`
+	 FROM businesses
+	 WHERE id = $1`,
+		id))
+}
+
+func (r *businessRepository) GetBySlug(ctx context.Context, slug string) (*domain.Business, error) {
+	return scanBusiness(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+businessColumns+`
+	 FROM businesses
+	 WHERE slug = $1`,
+		slug))
+}
+
+func (r *businessRepository) SlugExists(ctx context.Context, slug string) (bool, error) {
+	var exists bool
+	err := conn(ctx, r.db).QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM businesses WHERE slug = $1)`, slug).Scan(&exists)
+	return exists, err
+}
+
 func (r *businessRepository) Create(ctx context.Context, b *domain.Business) error {
 	b.CreatedAt = time.Now()
 	b.UpdatedAt = time.Now()
 
-	err := r.db.QueryRow(ctx,
-		`INSERT INTO businesses (id, name, business_type, description, address, city, phone, email, website, 
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO businesses (id, name, slug, business_type, description, address, city, phone, email, website, 
 							 timezone, currency, enable_online_booking, enable_sms_notifications, 
-							 enable_email_notifications, created_at, updated_at)
-	 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+							 enable_email_notifications, cancellation_window_hours, created_at, updated_at, country)
+	 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 	 RETURNING id`,
-		b.ID, b.Name, b.BusinessType, b.Description, b.Address, b.City, b.Phone, b.Email, b.Website,
+		b.ID, b.Name, b.Slug, b.BusinessType, b.Description, b.Address, b.City, b.Phone, b.Email, b.Website,
 		b.Timezone, b.Currency, b.EnableOnlineBooking, b.EnableSMSNotifications, b.EnableEmailNotifications,
-		b.CreatedAt, b.UpdatedAt).Scan(&b.ID)
+		b.CancellationWindowHours, b.CreatedAt, b.UpdatedAt, b.Country).Scan(&b.ID)
+	return err
+}
+
+func (r *businessRepository) List(ctx context.Context) ([]*domain.Business, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+businessColumns+`
+	 FROM businesses
+	 ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var businesses []*domain.Business
+	for rows.Next() {
+		b, err := scanBusiness(rows)
+		if err != nil {
+			return nil, err
+		}
+		businesses = append(businesses, b)
+	}
+	return businesses, rows.Err()
+}
+
+func (r *businessRepository) SetCountry(ctx context.Context, id, country string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE businesses SET country = $2, updated_at = $3 WHERE id = $1`,
+		id, country, time.Now())
 	return err
 }
@@ -22,7 +22,7 @@ func (r *businessWorkingHoursRepository) Create(ctx context.Context, workingHour
 	workingHours.CreatedAt = time.Now()
 	workingHours.UpdatedAt = time.Now()
 
-	_, err := r.db.Exec(ctx,
+	_, err := conn(ctx, r.db).Exec(ctx,
 		`INSERT INTO business_working_hours (id, business_id, day_of_week, start_time, end_time, is_enabled, created_at, updated_at)
 		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
 		workingHours.ID, workingHours.BusinessID, workingHours.DayOfWeek, workingHours.StartTime,
@@ -31,7 +31,7 @@ func (r *businessWorkingHoursRepository) Create(ctx context.Context, workingHour
 }
 
 func (r *businessWorkingHoursRepository) GetByBusinessID(ctx context.Context, businessID string) ([]*domain.BusinessWorkingHours, error) {
-	rows, err := r.db.Query(ctx,
+	rows, err := conn(ctx, r.db).Query(ctx,
 		`SELECT id, business_id, day_of_week, start_time, end_time, is_enabled, created_at, updated_at
 		 FROM business_working_hours
 		 WHERE business_id = $1
@@ -58,7 +58,7 @@ func (r *businessWorkingHoursRepository) GetByBusinessID(ctx context.Context, bu
 func (r *businessWorkingHoursRepository) Update(ctx context.Context, workingHours *domain.BusinessWorkingHours) error {
 	workingHours.UpdatedAt = time.Now()
 
-	_, err := r.db.Exec(ctx,
+	_, err := conn(ctx, r.db).Exec(ctx,
 		`UPDATE business_working_hours 
 		 SET start_time = $3, end_time = $4, is_enabled = $5, updated_at = $6
 		 WHERE business_id = $1 AND day_of_week = $2`,
@@ -68,7 +68,7 @@ func (r *businessWorkingHoursRepository) Update(ctx context.Context, workingHour
 }
 
 func (r *businessWorkingHoursRepository) DeleteByBusinessID(ctx context.Context, businessID string) error {
-	_, err := r.db.Exec(ctx, "DELETE FROM business_working_hours WHERE business_id = $1", businessID)
+	_, err := conn(ctx, r.db).Exec(ctx, "DELETE FROM business_working_hours WHERE business_id = $1", businessID)
 	return err
 }
 
@@ -77,7 +77,7 @@ func (r *businessWorkingHoursRepository) CreateBatch(ctx context.Context, workin
 		return nil
 	}
 
-	tx, err := r.db.Begin(ctx)
+	tx, err := conn(ctx, r.db).Begin(ctx)
 	if err != nil {
 		return err
 	}
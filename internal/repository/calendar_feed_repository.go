@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type calendarFeedRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCalendarFeedRepository(db *pgxpool.Pool) domain.CalendarFeedRepository {
+	return &calendarFeedRepository{
+		db: db,
+	}
+}
+
+const calendarFeedColumns = `id, business_id, COALESCE(staff_id::text, ''), COALESCE(location_id::text, ''), token_hash,
+	last_accessed_at, revoked_at, created_at`
+
+func scanCalendarFeed(row pgx.Row) (*domain.CalendarFeed, error) {
+	var f domain.CalendarFeed
+	err := row.Scan(&f.ID, &f.BusinessID, &f.StaffID, &f.LocationID, &f.TokenHash,
+		&f.LastAccessedAt, &f.RevokedAt, &f.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *calendarFeedRepository) Create(ctx context.Context, feed *domain.CalendarFeed) error {
+	feed.CreatedAt = time.Now()
+
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO calendar_feeds (business_id, staff_id, location_id, token_hash, created_at)
+		 VALUES ($1, NULLIF($2, '')::uuid, NULLIF($3, '')::uuid, $4, $5)
+		 RETURNING id`,
+		feed.BusinessID, feed.StaffID, feed.LocationID, feed.TokenHash, feed.CreatedAt,
+	).Scan(&feed.ID)
+}
+
+func (r *calendarFeedRepository) GetByID(ctx context.Context, id string) (*domain.CalendarFeed, error) {
+	return scanCalendarFeed(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+calendarFeedColumns+` FROM calendar_feeds WHERE id = $1`, id))
+}
+
+func (r *calendarFeedRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.CalendarFeed, error) {
+	return scanCalendarFeed(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+calendarFeedColumns+` FROM calendar_feeds WHERE token_hash = $1`, tokenHash))
+}
+
+func (r *calendarFeedRepository) ListActiveByBusiness(ctx context.Context, businessID string) ([]*domain.CalendarFeed, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+calendarFeedColumns+` FROM calendar_feeds
+		 WHERE business_id = $1 AND revoked_at IS NULL
+		 ORDER BY created_at DESC`,
+		businessID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feeds []*domain.CalendarFeed
+	for rows.Next() {
+		feed, err := scanCalendarFeed(rows)
+		if err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, feed)
+	}
+	return feeds, rows.Err()
+}
+
+func (r *calendarFeedRepository) Revoke(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE calendar_feeds SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`,
+		id, time.Now())
+	return err
+}
+
+func (r *calendarFeedRepository) RevokeByOwner(ctx context.Context, staffID, locationID string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE calendar_feeds SET revoked_at = $3
+		 WHERE revoked_at IS NULL
+		   AND (staff_id = NULLIF($1, '')::uuid OR location_id = NULLIF($2, '')::uuid)`,
+		staffID, locationID, time.Now())
+	return err
+}
+
+func (r *calendarFeedRepository) TouchLastAccessed(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE calendar_feeds SET last_accessed_at = $2 WHERE id = $1`,
+		id, time.Now())
+	return err
+}
+
+func (r *calendarFeedRepository) Version(ctx context.Context, feed *domain.CalendarFeed, from, to time.Time) (time.Time, int, error) {
+	// The same bookings and shifts the feed renders: of the staff member, or
+	// at the location and of the staff working there. The business is in
+	// for its name and timezone.
+	query := `
+		SELECT COALESCE(max(updated_at), 'epoch'::timestamp), count(*)
+		FROM (
+			SELECT b.updated_at FROM bookings b
+			WHERE b.staff_id = $1 AND b.start_at >= $2 AND b.start_at <= $3
+			UNION ALL
+			SELECT sh.updated_at FROM staff_shifts sh
+			WHERE sh.staff_id = $1 AND sh.shift_date >= $2 AND sh.shift_date <= $3
+			UNION ALL
+			SELECT st.updated_at FROM staff st WHERE st.id = $1
+			UNION ALL
+			SELECT bu.updated_at FROM businesses bu WHERE bu.id = $4
+		) changes`
+	ownerID := feed.StaffID
+	if feed.StaffID == "" {
+		query = `
+		SELECT COALESCE(max(updated_at), 'epoch'::timestamp), count(*)
+		FROM (
+			SELECT b.updated_at FROM bookings b
+			WHERE b.location_id = $1 AND b.start_at >= $2 AND b.start_at <= $3
+			UNION ALL
+			SELECT sh.updated_at FROM staff_shifts sh
+			JOIN staff st ON st.id = sh.staff_id
+			WHERE st.location_id = $1 AND sh.shift_date >= $2 AND sh.shift_date <= $3
+			UNION ALL
+			SELECT bu.updated_at FROM businesses bu WHERE bu.id = $4
+		) changes`
+		ownerID = feed.LocationID
+	}
+
+	var changedAt time.Time
+	var count int
+	err := conn(ctx, r.db).QueryRow(ctx, query, ownerID, from, to, feed.BusinessID).Scan(&changedAt, &count)
+	return changedAt, count, err
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type clientConsentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewClientConsentRepository(db *pgxpool.Pool) domain.ClientConsentRepository {
+	return &clientConsentRepository{
+		db: db,
+	}
+}
+
+func (r *clientConsentRepository) Create(ctx context.Context, consent *domain.ClientConsent) error {
+	if consent.CreatedAt.IsZero() {
+		consent.CreatedAt = time.Now()
+	}
+
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO client_consents (business_id, client_id, kind, granted, source, recorded_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::uuid, $7)
+		 RETURNING id`,
+		consent.BusinessID, consent.ClientID, consent.Kind, consent.Granted, consent.Source, consent.RecordedBy,
+		consent.CreatedAt,
+	).Scan(&consent.ID)
+}
+
+func (r *clientConsentRepository) ListByClient(ctx context.Context, clientID string) ([]*domain.ClientConsent, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT c.id, c.business_id, c.client_id, c.kind, c.granted, c.source, COALESCE(c.recorded_by::text, ''),
+			COALESCE(TRIM(u.first_name || ' ' || u.last_name), ''), c.created_at
+		 FROM client_consents c
+		 LEFT JOIN users u ON u.id = c.recorded_by
+		 WHERE c.client_id = $1
+		 ORDER BY c.created_at DESC`,
+		clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var consents []*domain.ClientConsent
+	for rows.Next() {
+		var c domain.ClientConsent
+		err := rows.Scan(&c.ID, &c.BusinessID, &c.ClientID, &c.Kind, &c.Granted, &c.Source, &c.RecordedBy,
+			&c.RecordedByName, &c.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		consents = append(consents, &c)
+	}
+	return consents, rows.Err()
+}
+
+func (r *clientConsentRepository) ReassignClient(ctx context.Context, fromClientID, toClientID string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE client_consents SET client_id = $2 WHERE client_id = $1`,
+		fromClientID, toClientID)
+	return err
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type clientFieldRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewClientFieldRepository(db *pgxpool.Pool) domain.ClientFieldRepository {
+	return &clientFieldRepository{
+		db: db,
+	}
+}
+
+const clientFieldColumns = `id, business_id, key, label, type, options, position, created_at, updated_at`
+
+func scanClientField(row pgx.Row) (*domain.ClientField, error) {
+	var f domain.ClientField
+	err := row.Scan(&f.ID, &f.BusinessID, &f.Key, &f.Label, &f.Type, &f.Options, &f.Position, &f.CreatedAt, &f.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *clientFieldRepository) Create(ctx context.Context, field *domain.ClientField) error {
+	field.CreatedAt = time.Now()
+	field.UpdatedAt = field.CreatedAt
+	if field.Options == nil {
+		field.Options = []string{}
+	}
+
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO client_custom_fields (business_id, key, label, type, options, position, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id`,
+		field.BusinessID, field.Key, field.Label, field.Type, field.Options, field.Position,
+		field.CreatedAt, field.UpdatedAt,
+	).Scan(&field.ID)
+}
+
+func (r *clientFieldRepository) GetByID(ctx context.Context, id string) (*domain.ClientField, error) {
+	return scanClientField(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+clientFieldColumns+` FROM client_custom_fields WHERE id = $1`, id))
+}
+
+func (r *clientFieldRepository) GetByKey(ctx context.Context, businessID, key string) (*domain.ClientField, error) {
+	return scanClientField(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+clientFieldColumns+` FROM client_custom_fields WHERE business_id = $1 AND key = $2`,
+		businessID, key))
+}
+
+func (r *clientFieldRepository) ListByBusiness(ctx context.Context, businessID string) ([]*domain.ClientField, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+clientFieldColumns+` FROM client_custom_fields
+		 WHERE business_id = $1
+		 ORDER BY position, created_at`,
+		businessID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fields []*domain.ClientField
+	for rows.Next() {
+		field, err := scanClientField(rows)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, rows.Err()
+}
+
+func (r *clientFieldRepository) Update(ctx context.Context, field *domain.ClientField) error {
+	field.UpdatedAt = time.Now()
+	if field.Options == nil {
+		field.Options = []string{}
+	}
+
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE client_custom_fields SET label = $2, options = $3, position = $4, updated_at = $5 WHERE id = $1`,
+		field.ID, field.Label, field.Options, field.Position, field.UpdatedAt)
+	return err
+}
+
+func (r *clientFieldRepository) Delete(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM client_custom_fields WHERE id = $1`, id)
+	return err
+}
+
+func (r *clientFieldRepository) ListValues(ctx context.Context, clientID string) ([]*domain.ClientFieldValue, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT client_id, field_id, value, updated_at FROM client_custom_field_values WHERE client_id = $1`,
+		clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []*domain.ClientFieldValue
+	for rows.Next() {
+		var v domain.ClientFieldValue
+		if err := rows.Scan(&v.ClientID, &v.FieldID, &v.Value, &v.UpdatedAt); err != nil {
+			return nil, err
+		}
+		values = append(values, &v)
+	}
+	return values, rows.Err()
+}
+
+func (r *clientFieldRepository) SetValue(ctx context.Context, value *domain.ClientFieldValue) error {
+	value.UpdatedAt = time.Now()
+
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO client_custom_field_values (client_id, field_id, value, updated_at)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (client_id, field_id) DO UPDATE SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at`,
+		value.ClientID, value.FieldID, value.Value, value.UpdatedAt)
+	return err
+}
+
+func (r *clientFieldRepository) DeleteValue(ctx context.Context, clientID, fieldID string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`DELETE FROM client_custom_field_values WHERE client_id = $1 AND field_id = $2`,
+		clientID, fieldID)
+	return err
+}
+
+func (r *clientFieldRepository) DeleteValues(ctx context.Context, clientID string) error {
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM client_custom_field_values WHERE client_id = $1`, clientID)
+	return err
+}
+
+func (r *clientFieldRepository) ReassignValues(ctx context.Context, fromClientID, toClientID string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO client_custom_field_values (client_id, field_id, value, updated_at)
+		 SELECT $2, field_id, value, updated_at FROM client_custom_field_values WHERE client_id = $1
+		 ON CONFLICT (client_id, field_id) DO NOTHING`,
+		fromClientID, toClientID)
+	return err
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type clientJobRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewClientJobRepository(db *pgxpool.Pool) domain.ClientJobRepository {
+	return &clientJobRepository{
+		db: db,
+	}
+}
+
+// clientJobColumns are the columns scanClientJob reads, all but the files
+const clientJobColumns = `id, business_id, kind, status, import_options, filter, report, total_rows, done_rows,
+	attempts, last_error, COALESCE(created_by::text, ''), created_at, started_at, finished_at`
+
+// scanClientJob reads clientJobColumns followed by extra
+func scanClientJob(row pgx.Row, extra ...any) (*domain.ClientJob, error) {
+	var j domain.ClientJob
+	var importOptions, filter, report []byte
+	dest := []any{&j.ID, &j.BusinessID, &j.Kind, &j.Status, &importOptions, &filter, &report, &j.TotalRows,
+		&j.DoneRows, &j.Attempts, &j.LastError, &j.CreatedBy, &j.CreatedAt, &j.StartedAt, &j.FinishedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	if importOptions != nil {
+		j.Import = &domain.ClientImportOptions{}
+		if err := json.Unmarshal(importOptions, j.Import); err != nil {
+			return nil, err
+		}
+	}
+	if filter != nil {
+		j.Filter = &domain.ClientFilter{}
+		if err := json.Unmarshal(filter, j.Filter); err != nil {
+			return nil, err
+		}
+	}
+	if report != nil {
+		j.Report = &domain.ClientImportReport{}
+		if err := json.Unmarshal(report, j.Report); err != nil {
+			return nil, err
+		}
+	}
+	return &j, nil
+}
+
+func (r *clientJobRepository) Create(ctx context.Context, job *domain.ClientJob) error {
+	job.CreatedAt = time.Now()
+	if job.Status == "" {
+		job.Status = domain.ClientJobPending
+	}
+
+	// Left NULL when they don't apply
+	var importOptions, filter, report []byte
+	var err error
+	if job.Import != nil {
+		if importOptions, err = json.Marshal(job.Import); err != nil {
+			return err
+		}
+	}
+	if job.Filter != nil {
+		if filter, err = json.Marshal(job.Filter); err != nil {
+			return err
+		}
+	}
+	if job.Report != nil {
+		if report, err = json.Marshal(job.Report); err != nil {
+			return err
+		}
+	}
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO client_jobs (business_id, kind, status, import_options, filter, input, result, report, total_rows,
+			done_rows, last_error, created_by, created_at, started_at, finished_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, '')::uuid, $13, $14, $15)
+		 RETURNING id`,
+		job.BusinessID, job.Kind, job.Status, importOptions, filter, job.Input, job.Result, report, job.TotalRows,
+		job.DoneRows, job.LastError, job.CreatedBy, job.CreatedAt, job.StartedAt, job.FinishedAt,
+	).Scan(&job.ID)
+}
+
+func (r *clientJobRepository) GetByID(ctx context.Context, id string) (*domain.ClientJob, error) {
+	return scanClientJob(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+clientJobColumns+` FROM client_jobs WHERE id = $1`, id))
+}
+
+func (r *clientJobRepository) ListByBusiness(ctx context.Context, businessID string, limit int) ([]*domain.ClientJob, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+clientJobColumns+` FROM client_jobs
+		 WHERE business_id = $1
+		 ORDER BY created_at DESC
+		 LIMIT $2`,
+		businessID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*domain.ClientJob
+	for rows.Next() {
+		job, err := scanClientJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *clientJobRepository) GetResult(ctx context.Context, id string) ([]byte, error) {
+	var result []byte
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT COALESCE(result, '') FROM client_jobs WHERE id = $1`, id).Scan(&result)
+	return result, err
+}
+
+func (r *clientJobRepository) Claim(ctx context.Context, lease time.Duration, maxAttempts int) (*domain.ClientJob, error) {
+	now := time.Now()
+	db := conn(ctx, r.db)
+
+	// Give up the jobs whose workers kept dying on them
+	_, err := db.Exec(ctx,
+		`UPDATE client_jobs
+		 SET status = 'failed', last_error = 'the job was interrupted too often', input = NULL, lease_until = NULL,
+			finished_at = $1
+		 WHERE status = 'running' AND lease_until <= $1 AND attempts >= $2`,
+		now, maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	var input []byte
+	job, err := scanClientJob(db.QueryRow(ctx,
+		`UPDATE client_jobs SET status = 'running', attempts = attempts + 1, lease_until = $2,
+			started_at = COALESCE(started_at, $1)
+		 WHERE id = (
+			SELECT id FROM client_jobs
+			WHERE status = 'pending' OR (status = 'running' AND lease_until <= $1)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING `+clientJobColumns+`, input`,
+		now, now.Add(lease)), &input)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	job.Input = input
+	return job, nil
+}
+
+func (r *clientJobRepository) Extend(ctx context.Context, id string, doneRows int, lease time.Duration) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE client_jobs SET done_rows = $2, lease_until = $3 WHERE id = $1`,
+		id, doneRows, time.Now().Add(lease))
+	return err
+}
+
+func (r *clientJobRepository) Finish(ctx context.Context, job *domain.ClientJob) error {
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Input = nil
+
+	var report []byte
+	if job.Report != nil {
+		var err error
+		if report, err = json.Marshal(job.Report); err != nil {
+			return err
+		}
+	}
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE client_jobs
+		 SET status = $2, report = $3, result = $4, total_rows = $5, done_rows = $6, last_error = $7,
+			input = NULL, lease_until = NULL, finished_at = $8
+		 WHERE id = $1`,
+		job.ID, job.Status, report, job.Result, job.TotalRows, job.DoneRows, job.LastError, now)
+	return err
+}
+
+func (r *clientJobRepository) DeleteFinishedBefore(ctx context.Context, t time.Time) (int, error) {
+	tag, err := conn(ctx, r.db).Exec(ctx,
+		`DELETE FROM client_jobs WHERE status IN ('done', 'failed') AND finished_at < $1`, t)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
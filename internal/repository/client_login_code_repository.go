@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type clientLoginCodeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewClientLoginCodeRepository(db *pgxpool.Pool) domain.ClientLoginCodeRepository {
+	return &clientLoginCodeRepository{
+		db: db,
+	}
+}
+
+func (r *clientLoginCodeRepository) Create(ctx context.Context, code *domain.ClientLoginCode) error {
+	code.CreatedAt = time.Now()
+
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Only the most recently sent code stays valid
+	_, err = tx.Exec(ctx,
+		`UPDATE client_login_codes SET used_at = $2
+		 WHERE client_id = $1 AND used_at IS NULL`,
+		code.ClientID, code.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO client_login_codes (client_id, channel, code_hash, token_hash, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id`,
+		code.ClientID, code.Channel, code.CodeHash, code.TokenHash, code.ExpiresAt, code.CreatedAt,
+	).Scan(&code.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *clientLoginCodeRepository) GetLatestByClient(ctx context.Context, clientID string) (*domain.ClientLoginCode, error) {
+	var c domain.ClientLoginCode
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT id, client_id, channel, code_hash, token_hash, attempts, expires_at, used_at, created_at
+		 FROM client_login_codes
+		 WHERE client_id = $1
+		 ORDER BY created_at DESC
+		 LIMIT 1`,
+		clientID).Scan(&c.ID, &c.ClientID, &c.Channel, &c.CodeHash, &c.TokenHash, &c.Attempts,
+		&c.ExpiresAt, &c.UsedAt, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *clientLoginCodeRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.ClientLoginCode, error) {
+	var c domain.ClientLoginCode
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT id, client_id, channel, code_hash, token_hash, attempts, expires_at, used_at, created_at
+		 FROM client_login_codes
+		 WHERE token_hash = $1`,
+		tokenHash).Scan(&c.ID, &c.ClientID, &c.Channel, &c.CodeHash, &c.TokenHash, &c.Attempts,
+		&c.ExpiresAt, &c.UsedAt, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *clientLoginCodeRepository) RegisterFailedAttempt(ctx context.Context, id string) (int, error) {
+	var attempts int
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE client_login_codes SET attempts = attempts + 1
+		 WHERE id = $1
+		 RETURNING attempts`,
+		id).Scan(&attempts)
+	return attempts, err
+}
+
+func (r *clientLoginCodeRepository) Consume(ctx context.Context, id string) error {
+	var consumedID string
+	return conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE client_login_codes SET used_at = $2
+		 WHERE id = $1 AND used_at IS NULL
+		 RETURNING id`,
+		id, time.Now()).Scan(&consumedID)
+}
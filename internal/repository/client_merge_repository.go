@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type clientMergeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewClientMergeRepository(db *pgxpool.Pool) domain.ClientMergeRepository {
+	return &clientMergeRepository{
+		db: db,
+	}
+}
+
+const clientMergeColumns = `id, business_id, client_id, merged_client_id, merged_client, bookings_moved,
+	COALESCE(merged_by::text, ''), created_at`
+
+func scanClientMerge(row pgx.Row) (*domain.ClientMerge, error) {
+	var m domain.ClientMerge
+	var mergedClient []byte
+	err := row.Scan(&m.ID, &m.BusinessID, &m.ClientID, &m.MergedClientID, &mergedClient, &m.BookingsMoved,
+		&m.MergedBy, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(mergedClient, &m.MergedClient); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *clientMergeRepository) Create(ctx context.Context, merge *domain.ClientMerge) error {
+	merge.CreatedAt = time.Now()
+
+	mergedClient, err := json.Marshal(merge.MergedClient)
+	if err != nil {
+		return err
+	}
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO client_merges (business_id, client_id, merged_client_id, merged_client, bookings_moved, merged_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::uuid, $7)
+		 RETURNING id`,
+		merge.BusinessID, merge.ClientID, merge.MergedClientID, mergedClient, merge.BookingsMoved, merge.MergedBy,
+		merge.CreatedAt,
+	).Scan(&merge.ID)
+}
+
+func (r *clientMergeRepository) ListByClient(ctx context.Context, clientID string) ([]*domain.ClientMerge, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+clientMergeColumns+` FROM client_merges
+		 WHERE client_id = $1
+		 ORDER BY created_at DESC`,
+		clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var merges []*domain.ClientMerge
+	for rows.Next() {
+		merge, err := scanClientMerge(rows)
+		if err != nil {
+			return nil, err
+		}
+		merges = append(merges, merge)
+	}
+	return merges, rows.Err()
+}
+
+func (r *clientMergeRepository) Reassign(ctx context.Context, fromClientID, toClientID string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE client_merges SET client_id = $2 WHERE client_id = $1`,
+		fromClientID, toClientID)
+	return err
+}
+
+func (r *clientMergeRepository) DeleteByClient(ctx context.Context, clientID string) error {
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM client_merges WHERE client_id = $1`, clientID)
+	return err
+}
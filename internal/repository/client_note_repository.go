@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type clientNoteRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewClientNoteRepository(db *pgxpool.Pool) domain.ClientNoteRepository {
+	return &clientNoteRepository{
+		db: db,
+	}
+}
+
+const clientNoteSelect = `SELECT n.id, n.business_id, n.client_id, COALESCE(n.author_id::text, ''),
+	COALESCE(TRIM(u.first_name || ' ' || u.last_name), ''), n.body, n.created_at, n.updated_at
+	FROM client_notes n
+	LEFT JOIN users u ON u.id = n.author_id`
+
+func scanClientNote(row pgx.Row) (*domain.ClientNote, error) {
+	var n domain.ClientNote
+	err := row.Scan(&n.ID, &n.BusinessID, &n.ClientID, &n.AuthorID, &n.AuthorName, &n.Body, &n.CreatedAt, &n.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (r *clientNoteRepository) Create(ctx context.Context, note *domain.ClientNote) error {
+	note.CreatedAt = time.Now()
+	note.UpdatedAt = note.CreatedAt
+
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO client_notes (business_id, client_id, author_id, body, created_at, updated_at)
+		 VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, $6)
+		 RETURNING id`,
+		note.BusinessID, note.ClientID, note.AuthorID, note.Body, note.CreatedAt, note.UpdatedAt,
+	).Scan(&note.ID)
+}
+
+func (r *clientNoteRepository) GetByID(ctx context.Context, id string) (*domain.ClientNote, error) {
+	return scanClientNote(conn(ctx, r.db).QueryRow(ctx, clientNoteSelect+` WHERE n.id = $1`, id))
+}
+
+func (r *clientNoteRepository) ListByClient(ctx context.Context, clientID string) ([]*domain.ClientNote, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		clientNoteSelect+` WHERE n.client_id = $1 ORDER BY n.created_at DESC`,
+		clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []*domain.ClientNote
+	for rows.Next() {
+		note, err := scanClientNote(rows)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	return notes, rows.Err()
+}
+
+func (r *clientNoteRepository) Update(ctx context.Context, note *domain.ClientNote) error {
+	note.UpdatedAt = time.Now()
+
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE client_notes SET body = $2, updated_at = $3 WHERE id = $1`,
+		note.ID, note.Body, note.UpdatedAt)
+	return err
+}
+
+func (r *clientNoteRepository) Delete(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM client_notes WHERE id = $1`, id)
+	return err
+}
+
+func (r *clientNoteRepository) DeleteByClient(ctx context.Context, clientID string) error {
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM client_notes WHERE client_id = $1`, clientID)
+	return err
+}
+
+func (r *clientNoteRepository) ReassignClient(ctx context.Context, fromClientID, toClientID string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE client_notes SET client_id = $2 WHERE client_id = $1`,
+		fromClientID, toClientID)
+	return err
+}
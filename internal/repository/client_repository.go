@@ -2,10 +2,14 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -19,90 +23,220 @@ func NewClientRepository(db *pgxpool.Pool) domain.ClientRepository {
 	}
 }
 
+// clientPhoneConstraint keeps phone numbers unique within a business
+const clientPhoneConstraint = "clients_business_phone_key"
+
+// clientColumns are the columns scanClient reads
+const clientColumns = `id, business_id, first_name, COALESCE(last_name, ''), COALESCE(email, ''), phone,
+	marketing_consent, marketing_consent_at, notification_consent, notification_consent_at, erased_at,
+	created_at, updated_at`
+
+func scanClient(row pgx.Row) (*domain.Client, error) {
+	var c domain.Client
+	err := row.Scan(&c.ID, &c.BusinessID, &c.FirstName, &c.LastName, &c.Email, &c.Phone,
+		&c.MarketingConsent, &c.MarketingConsentAt, &c.NotificationConsent, &c.NotificationConsentAt, &c.ErasedAt,
+		&c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
 func (r *clientRepository) CreateClient(ctx context.Context, client *domain.Client) error {
 	client.CreatedAt = time.Now()
 	client.UpdatedAt = time.Now()
 
-	err := r.db.QueryRow(ctx,
+	err := conn(ctx, r.db).QueryRow(ctx,
 		`INSERT INTO clients (business_id, first_name, last_name, email, phone, created_at, updated_at)
 		 VALUES ($1, $2, $3, $4, $5, $6, $7)
 		 RETURNING id`,
 		client.BusinessID, client.FirstName, client.LastName, client.Email, client.Phone,
 		client.CreatedAt, client.UpdatedAt).Scan(&client.ID)
-	return err
+	return clientWriteError(err)
+}
+
+func (r *clientRepository) GetOrCreateClientByPhone(ctx context.Context, client *domain.Client) error {
+	now := time.Now()
+
+	// The no-op update makes RETURNING give back the existing row
+	stored, err := scanClient(conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO clients (business_id, first_name, last_name, email, phone, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $6)
+		 ON CONFLICT (business_id, phone) WHERE phone <> ''
+		 DO UPDATE SET phone = EXCLUDED.phone
+		 RETURNING `+clientColumns,
+		client.BusinessID, client.FirstName, client.LastName, client.Email, client.Phone, now))
+	if err != nil {
+		return err
+	}
+	*client = *stored
+	return nil
 }
 
 func (r *clientRepository) GetClientByID(ctx context.Context, clientID string) (*domain.Client, error) {
-	var client domain.Client
-	err := r.db.QueryRow(ctx,
-		`SELECT id, business_id, first_name, last_name, email, phone, created_at, updated_at
+	return scanClient(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+clientColumns+`
 		 FROM clients
 		 WHERE id = $1`,
-		clientID).Scan(&client.ID, &client.BusinessID, &client.FirstName, &client.LastName,
-		&client.Email, &client.Phone, &client.CreatedAt, &client.UpdatedAt)
-	if err != nil {
-		return nil, err
-	}
-	return &client, nil
+		clientID))
 }
 
 func (r *clientRepository) GetClientByPhone(ctx context.Context, businessID, phone string) (*domain.Client, error) {
-	var client domain.Client
-	err := r.db.QueryRow(ctx,
-		`SELECT id, business_id, first_name, last_name, email, phone, created_at, updated_at
+	return scanClient(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+clientColumns+`
 		 FROM clients
-		 WHERE business_id = $1 AND phone = $2`,
-		businessID, phone).Scan(&client.ID, &client.BusinessID, &client.FirstName, &client.LastName,
-		&client.Email, &client.Phone, &client.CreatedAt, &client.UpdatedAt)
-	if err != nil {
-		return nil, err
-	}
-	return &client, nil
+		 WHERE business_id = $1 AND phone = $2
+		 ORDER BY updated_at DESC
+		 LIMIT 1`,
+		businessID, phone))
+}
+
+func (r *clientRepository) GetClientByEmail(ctx context.Context, businessID, email string) (*domain.Client, error) {
+	return scanClient(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+clientColumns+`
+		 FROM clients
+		 WHERE business_id = $1 AND lower(email) = lower($2)
+		 ORDER BY updated_at DESC
+		 LIMIT 1`,
+		businessID, email))
 }
 
 func (r *clientRepository) UpdateClient(ctx context.Context, client *domain.Client) error {
 	client.UpdatedAt = time.Now()
 	
-	err := r.db.QueryRow(ctx,
+	err := conn(ctx, r.db).QueryRow(ctx,
 		`UPDATE clients 
 		 SET business_id = $1, first_name = $2, last_name = $3, email = $4, phone = $5, updated_at = $6
 		 WHERE id = $7
 		 RETURNING id`,
 		client.BusinessID, client.FirstName, client.LastName, client.Email, client.Phone,
 		client.UpdatedAt, client.ID).Scan(&client.ID)
+	return clientWriteError(err)
+}
+
+func (r *clientRepository) SetConsent(ctx context.Context, clientID, kind string, granted bool, at time.Time) error {
+	column := "notification_consent"
+	if kind == domain.ClientConsentMarketing {
+		column = "marketing_consent"
+	}
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE clients SET `+column+` = $2, `+column+`_at = $3, updated_at = $3 WHERE id = $1`,
+		clientID, granted, at)
+	return err
+}
+
+func (r *clientRepository) EraseClient(ctx context.Context, client *domain.Client) error {
+	client.UpdatedAt = time.Now()
+
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE clients
+		 SET first_name = $2, last_name = $3, email = $4, phone = $5,
+			marketing_consent = $6, marketing_consent_at = $7, notification_consent = $8, notification_consent_at = $9,
+			erased_at = $10, updated_at = $11
+		 WHERE id = $1`,
+		client.ID, client.FirstName, client.LastName, client.Email, client.Phone,
+		client.MarketingConsent, client.MarketingConsentAt, client.NotificationConsent, client.NotificationConsentAt,
+		client.ErasedAt, client.UpdatedAt)
 	return err
 }
 
 func (r *clientRepository) DeleteClient(ctx context.Context, clientID string) error {
-	_, err := r.db.Exec(ctx, `DELETE FROM clients WHERE id = $1`, clientID)
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM clients WHERE id = $1`, clientID)
 	return err
 }
 
-func (r *clientRepository) GetClientsByBusiness(ctx context.Context, businessID string, offset, limit int, search string) ([]*domain.Client, int, error) {
-	// Build the query with search condition
-	query := `SELECT id, business_id, first_name, last_name, email, phone, created_at, updated_at
-	          FROM clients
-	          WHERE business_id = $1`
-	countQuery := `SELECT COUNT(*) FROM clients WHERE business_id = $1`
-	
+func (r *clientRepository) ListClientsByBusiness(ctx context.Context, businessID string) ([]*domain.Client, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+clientColumns+`
+		 FROM clients
+		 WHERE business_id = $1 AND erased_at IS NULL
+		 ORDER BY created_at, id`,
+		businessID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clients []*domain.Client
+	for rows.Next() {
+		client, err := scanClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, rows.Err()
+}
+
+func (r *clientRepository) GetClientsByBusiness(ctx context.Context, businessID string, filter domain.ClientFilter, offset, limit int) ([]*domain.Client, int, error) {
+	// Build the query with the filter conditions
+	where := ` WHERE business_id = $1 AND erased_at IS NULL`
 	args := []interface{}{businessID}
-	countArgs := []interface{}{businessID}
 	argIndex := 2
 	
-	if search != "" {
-		searchPattern := "%" + search + "%"
-		query += ` AND (first_name ILIKE $` + fmt.Sprintf("%d", argIndex) + ` OR last_name ILIKE $` + fmt.Sprintf("%d", argIndex) + ` OR phone ILIKE $` + fmt.Sprintf("%d", argIndex) + `)`
-		countQuery += ` AND (first_name ILIKE $` + fmt.Sprintf("%d", argIndex) + ` OR last_name ILIKE $` + fmt.Sprintf("%d", argIndex) + ` OR phone ILIKE $` + fmt.Sprintf("%d", argIndex) + `)`
-		args = append(args, searchPattern)
-		countArgs = append(countArgs, searchPattern)
+	var country string
+	if filter.Search != "" || filter.Segment != nil {
+		var err error
+		if country, err = r.businessCountry(ctx, businessID); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	orderBy := `created_at DESC`
+	if filter.Search != "" {
+		cond, rank := clientSearch(filter.Search, country, &args)
+		where += ` AND ` + cond
+		orderBy = rank + ` DESC, created_at DESC`
+		argIndex = len(args) + 1
+	}
+
+	if len(filter.TagIDs) > 0 {
+		// Clients with every one of the tags
+		where += ` AND (SELECT COUNT(*) FROM client_tag_assignments a
+			WHERE a.client_id = clients.id AND a.tag_id = ANY($` + fmt.Sprintf("%d", argIndex) + `::uuid[])) = $` + fmt.Sprintf("%d", argIndex+1)
+		args = append(args, filter.TagIDs, len(filter.TagIDs))
+		argIndex += 2
+	}
+
+	for _, match := range filter.Fields {
+		valueCond := `v.value = $` + fmt.Sprintf("%d", argIndex+1)
+		if match.IgnoreCase {
+			valueCond = `lower(v.value) = lower($` + fmt.Sprintf("%d", argIndex+1) + `)`
+		}
+		where += ` AND EXISTS (SELECT 1 FROM client_custom_field_values v
+			WHERE v.client_id = clients.id AND v.field_id = $` + fmt.Sprintf("%d", argIndex) + ` AND ` + valueCond + `)`
+		args = append(args, match.FieldID, match.Value)
+		argIndex += 2
+	}
+
+	if filter.StaffID != "" {
+		where += ` AND EXISTS (SELECT 1 FROM bookings b
+			WHERE b.client_id = clients.id AND b.staff_id = $` + fmt.Sprintf("%d", argIndex) + `)`
+		args = append(args, filter.StaffID)
 		argIndex++
 	}
+
+	if filter.Segment != nil {
+		segment := segmentFilter{args: args, now: time.Now(), country: country}
+		cond, err := segment.condition(*filter.Segment)
+		if err != nil {
+			return nil, 0, err
+		}
+		where += ` AND ` + cond
+		args = segment.args
+		argIndex = len(args) + 1
+	}
+
+	query := `SELECT `+clientColumns+`
+	          FROM clients` + where
+	countQuery := `SELECT COUNT(*) FROM clients` + where
+	countArgs := append([]interface{}{}, args...)
 	
-	query += ` ORDER BY created_at DESC LIMIT $` + fmt.Sprintf("%d", argIndex) + ` OFFSET $` + fmt.Sprintf("%d", argIndex+1)
+	query += ` ORDER BY ` + orderBy + ` LIMIT $` + fmt.Sprintf("%d", argIndex) + ` OFFSET $` + fmt.Sprintf("%d", argIndex+1)
 	args = append(args, limit, offset)
 	
 	// Execute the main query
-	rows, err := r.db.Query(ctx, query, args...)
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -110,21 +244,85 @@ func (r *clientRepository) GetClientsByBusiness(ctx context.Context, businessID
 	
 	var clients []*domain.Client
 	for rows.Next() {
-		var client domain.Client
-		err := rows.Scan(&client.ID, &client.BusinessID, &client.FirstName, &client.LastName,
-			&client.Email, &client.Phone, &client.CreatedAt, &client.UpdatedAt)
+		client, err := scanClient(rows)
 		if err != nil {
 			return nil, 0, err
 		}
-		clients = append(clients, &client)
+		clients = append(clients, client)
 	}
 	
 	// Execute the count query
 	var total int
-	err = r.db.QueryRow(ctx, countQuery, countArgs...).Scan(&total)
+	err = conn(ctx, r.db).QueryRow(ctx, countQuery, countArgs...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
 	
 	return clients, total, nil
-}
\ No newline at end of file
+}
+
+func (r *clientRepository) SearchClients(ctx context.Context, businessID, staffID, term string, limit int) ([]*domain.Client, error) {
+	country, err := r.businessCountry(ctx, businessID)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{businessID}
+	cond, rank := clientSearch(term, country, &args)
+	if staffID != "" {
+		args = append(args, staffID)
+		cond += ` AND EXISTS (SELECT 1 FROM bookings b
+			WHERE b.client_id = clients.id AND b.staff_id = $` + strconv.Itoa(len(args)) + `)`
+	}
+	args = append(args, limit)
+
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+clientColumns+`
+		 FROM clients
+		 WHERE business_id = $1 AND erased_at IS NULL AND `+cond+`
+		 ORDER BY `+rank+` DESC, first_name, last_name
+		 LIMIT $`+strconv.Itoa(len(args)),
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clients []*domain.Client
+	for rows.Next() {
+		client, err := scanClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, rows.Err()
+}
+
+// businessCountry returns the country national phone numbers in search
+// terms are read in
+func (r *clientRepository) businessCountry(ctx context.Context, businessID string) (string, error) {
+	var country string
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT country FROM businesses WHERE id = $1`,
+		businessID).Scan(&country)
+	return country, err
+}
+
+func (r *clientRepository) HasBookingWithStaff(ctx context.Context, clientID, staffID string) (bool, error) {
+	var exists bool
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM bookings WHERE client_id = $1 AND staff_id = $2)`,
+		clientID, staffID).Scan(&exists)
+	return exists, err
+}
+
+// clientWriteError turns a violation of the unique phone index into
+// domain.ErrClientPhoneTaken
+func clientWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.ConstraintName == clientPhoneConstraint {
+		return domain.ErrClientPhoneTaken
+	}
+	return err
+}
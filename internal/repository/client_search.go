@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ialekseychuk/my-place/pkg/phone"
+)
+
+// clientSearchMinDigits is the number of digits, leading zeros of partial
+// numbers left out, a term needs to be matched against phone numbers
+const clientSearchMinDigits = 3
+
+// clientSearch builds a condition matching clients to a search term and an
+// expression ranking the matches, appending the query arguments to args.
+// Terms match name and email by word prefix, as a substring and with typos
+// through trigram word similarity; terms without letters match the digits
+// of the phone number, complete national numbers read in country. The
+// generated search columns are indexed for all.
+func clientSearch(term, country string, args *[]interface{}) (cond, rank string) {
+	param := func(value interface{}) string {
+		*args = append(*args, value)
+		return "$" + strconv.Itoa(len(*args))
+	}
+
+	term = strings.ToLower(strings.TrimSpace(term))
+	text := param(term)
+	conds := []string{
+		`clients.search_text LIKE ` + param("%"+likeEscape(term)+"%"),
+		text + ` <% clients.search_text`,
+	}
+	ranks := []string{`word_similarity(` + text + `, clients.search_text)`}
+
+	if query := prefixTSQuery(term); query != "" {
+		tsquery := `to_tsquery('simple', ` + param(query) + `)`
+		conds = append(conds, `clients.search_vector @@ `+tsquery)
+		ranks = append(ranks, `ts_rank(clients.search_vector, `+tsquery+`)`)
+	}
+
+	if !strings.ContainsFunc(term, unicode.IsLetter) {
+		digits := phoneSearchDigits(term, country)
+		if len(digits) >= clientSearchMinDigits {
+			phone := `clients.phone_digits LIKE ` + param("%"+digits+"%")
+			conds = append(conds, phone)
+			ranks = append(ranks, `CASE WHEN `+phone+` THEN 1 ELSE 0 END`)
+		}
+	}
+
+	return "(" + strings.Join(conds, " OR ") + ")", strings.Join(ranks, " + ")
+}
+
+// phoneSearchDigits returns the digits a term matches in the stored E.164
+// numbers. Complete numbers are normalised, so national ones match with
+// their trunk prefix; partial ones typed so far are taken as they are.
+func phoneSearchDigits(term, country string) string {
+	if normalized, err := phone.Normalize(term, country); err == nil {
+		return strings.TrimPrefix(normalized, "+")
+	}
+	return strings.TrimLeft(strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, term), "0")
+}
+
+// prefixTSQuery turns the words of a term into a tsquery matching words
+// starting with each of them, e.g. "ann smi" into "ann:* & smi:*"
+func prefixTSQuery(term string) string {
+	words := strings.FieldsFunc(term, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// likeEscape escapes the wildcards of a LIKE pattern
+func likeEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
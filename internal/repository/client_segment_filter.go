@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+)
+
+// segmentFilter compiles a segment filter tree into a condition on the
+// clients table, appending the query arguments it needs to args
+type segmentFilter struct {
+	args    []interface{}
+	now     time.Time
+	country string // of the business, for phone numbers in search terms
+}
+
+func (f *segmentFilter) param(value interface{}) string {
+	f.args = append(f.args, value)
+	return "$" + strconv.Itoa(len(f.args))
+}
+
+func (f *segmentFilter) condition(node domain.SegmentNode) (string, error) {
+	switch node.Op {
+	case domain.SegmentAnd, domain.SegmentOr:
+		if len(node.Nodes) == 0 {
+			return "", fmt.Errorf("segment %q node without nodes", node.Op)
+		}
+		conds := make([]string, 0, len(node.Nodes))
+		for _, child := range node.Nodes {
+			cond, err := f.condition(child)
+			if err != nil {
+				return "", err
+			}
+			conds = append(conds, cond)
+		}
+		return "(" + strings.Join(conds, " "+strings.ToUpper(node.Op)+" ") + ")", nil
+	case domain.SegmentNot:
+		if len(node.Nodes) != 1 {
+			return "", fmt.Errorf("segment %q node needs exactly one node", node.Op)
+		}
+		cond, err := f.condition(node.Nodes[0])
+		if err != nil {
+			return "", err
+		}
+		return "(NOT " + cond + ")", nil
+	case domain.SegmentVisits:
+		return f.bookings(node, `COUNT(*)`, domain.BookingStatusConfirmed, false), nil
+	case domain.SegmentUpcoming:
+		return f.bookings(node, `COUNT(*)`, domain.BookingStatusConfirmed, true), nil
+	case domain.SegmentNoShows:
+		return f.bookings(node, `COUNT(*)`, domain.BookingStatusNoShow, false), nil
+	case domain.SegmentSpent:
+		return f.bookings(node, `COALESCE(SUM(b.price_cents), 0)`, domain.BookingStatusConfirmed, false), nil
+	case domain.SegmentCreated:
+		if node.Days <= 0 {
+			return "", fmt.Errorf("segment %q node needs days", node.Op)
+		}
+		return `clients.created_at >= ` + f.param(f.now.AddDate(0, 0, -node.Days)), nil
+	case domain.SegmentTag:
+		return `EXISTS (SELECT 1 FROM client_tag_assignments a
+			WHERE a.client_id = clients.id AND a.tag_id = ` + f.param(node.TagID) + `)`, nil
+	case domain.SegmentField:
+		value := f.param(node.Value)
+		// Text values match ignoring case, the others are stored canonically
+		return `EXISTS (SELECT 1 FROM client_custom_field_values v
+			JOIN client_custom_fields cf ON cf.id = v.field_id
+			WHERE v.client_id = clients.id AND cf.key = ` + f.param(node.Key) + `
+			AND CASE WHEN cf.type = 'text' THEN lower(v.value) = lower(` + value + `) ELSE v.value = ` + value + ` END)`, nil
+	case domain.SegmentSearch:
+		cond, _ := clientSearch(node.Value, f.country, &f.args)
+		return cond, nil
+	case domain.SegmentHasEmail:
+		return `COALESCE(clients.email, '') <> ''`, nil
+	case domain.SegmentHasPhone:
+		return `COALESCE(clients.phone, '') <> ''`, nil
+	case domain.SegmentMarketingConsent:
+		return `clients.marketing_consent`, nil
+	default:
+		return "", fmt.Errorf("unknown segment operator %q", node.Op)
+	}
+}
+
+// bookings compares an aggregate over the bookings of the client with the
+// given status to the range of the node. Past bookings are looked at Days
+// back from now, upcoming ones Days ahead.
+func (f *segmentFilter) bookings(node domain.SegmentNode, aggregate, status string, upcoming bool) string {
+	conds := []string{`b.client_id = clients.id`, `b.status = ` + f.param(status)}
+	if upcoming {
+		conds = append(conds, `b.start_at > `+f.param(f.now))
+		if node.Days > 0 {
+			conds = append(conds, `b.start_at <= `+f.param(f.now.AddDate(0, 0, node.Days)))
+		}
+	} else {
+		conds = append(conds, `b.start_at <= `+f.param(f.now))
+		if node.Days > 0 {
+			conds = append(conds, `b.start_at >= `+f.param(f.now.AddDate(0, 0, -node.Days)))
+		}
+	}
+	if len(node.ServiceIDs) > 0 {
+		conds = append(conds, `b.service_id = ANY(`+f.param(node.ServiceIDs)+`::uuid[])`)
+	}
+
+	value := `(SELECT ` + aggregate + ` FROM bookings b
+		WHERE ` + strings.Join(conds, " AND ") + `)`
+	switch {
+	case node.Min != nil && node.Max != nil:
+		return `(` + value + ` BETWEEN ` + f.param(*node.Min) + ` AND ` + f.param(*node.Max) + `)`
+	case node.Max != nil:
+		return `(` + value + ` <= ` + f.param(*node.Max) + `)`
+	case node.Min != nil:
+		return `(` + value + ` >= ` + f.param(*node.Min) + `)`
+	default:
+		return `(` + value + ` >= 1)`
+	}
+}
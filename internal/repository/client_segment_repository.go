@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type clientSegmentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewClientSegmentRepository(db *pgxpool.Pool) domain.ClientSegmentRepository {
+	return &clientSegmentRepository{
+		db: db,
+	}
+}
+
+const clientSegmentColumns = `id, business_id, name, description, definition, created_at, updated_at`
+
+func scanClientSegment(row pgx.Row) (*domain.ClientSegment, error) {
+	var s domain.ClientSegment
+	var definition []byte
+	err := row.Scan(&s.ID, &s.BusinessID, &s.Name, &s.Description, &definition, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(definition, &s.Definition); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *clientSegmentRepository) Create(ctx context.Context, segment *domain.ClientSegment) error {
+	segment.CreatedAt = time.Now()
+	segment.UpdatedAt = segment.CreatedAt
+
+	definition, err := json.Marshal(segment.Definition)
+	if err != nil {
+		return err
+	}
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO client_segments (business_id, name, description, definition, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id`,
+		segment.BusinessID, segment.Name, segment.Description, definition, segment.CreatedAt, segment.UpdatedAt,
+	).Scan(&segment.ID)
+}
+
+func (r *clientSegmentRepository) GetByID(ctx context.Context, id string) (*domain.ClientSegment, error) {
+	return scanClientSegment(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+clientSegmentColumns+` FROM client_segments WHERE id = $1`, id))
+}
+
+func (r *clientSegmentRepository) ListByBusiness(ctx context.Context, businessID string) ([]*domain.ClientSegment, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+clientSegmentColumns+` FROM client_segments
+		 WHERE business_id = $1
+		 ORDER BY lower(name), created_at`,
+		businessID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var segments []*domain.ClientSegment
+	for rows.Next() {
+		segment, err := scanClientSegment(rows)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+	return segments, rows.Err()
+}
+
+func (r *clientSegmentRepository) Update(ctx context.Context, segment *domain.ClientSegment) error {
+	segment.UpdatedAt = time.Now()
+
+	definition, err := json.Marshal(segment.Definition)
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, r.db).Exec(ctx,
+		`UPDATE client_segments SET name = $2, description = $3, definition = $4, updated_at = $5
+		 WHERE id = $1`,
+		segment.ID, segment.Name, segment.Description, definition, segment.UpdatedAt)
+	return err
+}
+
+func (r *clientSegmentRepository) Delete(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM client_segments WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type clientTagRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewClientTagRepository(db *pgxpool.Pool) domain.ClientTagRepository {
+	return &clientTagRepository{
+		db: db,
+	}
+}
+
+const clientTagColumns = `id, business_id, name, color, created_at`
+
+func scanClientTag(row pgx.Row) (*domain.ClientTag, error) {
+	var t domain.ClientTag
+	if err := row.Scan(&t.ID, &t.BusinessID, &t.Name, &t.Color, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *clientTagRepository) Create(ctx context.Context, tag *domain.ClientTag) error {
+	tag.CreatedAt = time.Now()
+
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO client_tags (business_id, name, color, created_at)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id`,
+		tag.BusinessID, tag.Name, tag.Color, tag.CreatedAt,
+	).Scan(&tag.ID)
+}
+
+func (r *clientTagRepository) GetByID(ctx context.Context, id string) (*domain.ClientTag, error) {
+	return scanClientTag(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+clientTagColumns+` FROM client_tags WHERE id = $1`, id))
+}
+
+func (r *clientTagRepository) GetByName(ctx context.Context, businessID, name string) (*domain.ClientTag, error) {
+	return scanClientTag(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+clientTagColumns+` FROM client_tags WHERE business_id = $1 AND lower(name) = lower($2)`,
+		businessID, name))
+}
+
+func (r *clientTagRepository) ListByBusiness(ctx context.Context, businessID string) ([]*domain.ClientTag, error) {
+	return r.list(ctx,
+		`SELECT `+clientTagColumns+` FROM client_tags WHERE business_id = $1 ORDER BY lower(name)`,
+		businessID)
+}
+
+func (r *clientTagRepository) Update(ctx context.Context, tag *domain.ClientTag) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE client_tags SET name = $2, color = $3 WHERE id = $1`,
+		tag.ID, tag.Name, tag.Color)
+	return err
+}
+
+func (r *clientTagRepository) Delete(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM client_tags WHERE id = $1`, id)
+	return err
+}
+
+func (r *clientTagRepository) ListByClient(ctx context.Context, clientID string) ([]*domain.ClientTag, error) {
+	return r.list(ctx,
+		`SELECT t.id, t.business_id, t.name, t.color, t.created_at
+		 FROM client_tags t
+		 JOIN client_tag_assignments a ON a.tag_id = t.id
+		 WHERE a.client_id = $1
+		 ORDER BY lower(t.name)`,
+		clientID)
+}
+
+func (r *clientTagRepository) SetClientTags(ctx context.Context, clientID string, tagIDs []string) error {
+	if tagIDs == nil {
+		// A nil slice is sent as NULL, which would keep every tag
+		tagIDs = []string{}
+	}
+
+	db := conn(ctx, r.db)
+	_, err := db.Exec(ctx,
+		`DELETE FROM client_tag_assignments WHERE client_id = $1 AND NOT (tag_id = ANY($2::uuid[]))`,
+		clientID, tagIDs)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(ctx,
+		`INSERT INTO client_tag_assignments (client_id, tag_id, created_at)
+		 SELECT $1, unnest($2::uuid[]), now()
+		 ON CONFLICT DO NOTHING`,
+		clientID, tagIDs)
+	return err
+}
+
+func (r *clientTagRepository) ReassignClient(ctx context.Context, fromClientID, toClientID string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO client_tag_assignments (client_id, tag_id, created_at)
+		 SELECT $2, tag_id, created_at FROM client_tag_assignments WHERE client_id = $1
+		 ON CONFLICT DO NOTHING`,
+		fromClientID, toClientID)
+	return err
+}
+
+func (r *clientTagRepository) list(ctx context.Context, query string, args ...any) ([]*domain.ClientTag, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []*domain.ClientTag
+	for rows.Next() {
+		tag, err := scanClientTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
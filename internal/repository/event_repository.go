@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type eventRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewEventRepository(db *pgxpool.Pool) domain.EventRepository {
+	return &eventRepository{
+		db: db,
+	}
+}
+
+func (r *eventRepository) Append(ctx context.Context, events ...*domain.Event) error {
+	for _, e := range events {
+		e.Status = domain.EventStatusPending
+		if e.OccurredAt.IsZero() {
+			e.OccurredAt = time.Now()
+		}
+		e.NextAttemptAt = e.OccurredAt
+
+		err := conn(ctx, r.db).QueryRow(ctx,
+			`INSERT INTO domain_events (type, business_id, aggregate_id, payload, status, next_attempt_at, occurred_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7)
+			 RETURNING id`,
+			e.Type, e.BusinessID, e.AggregateID, e.Payload, e.Status, e.NextAttemptAt, e.OccurredAt).Scan(&e.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *eventRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*domain.Event, error) {
+	now := time.Now()
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`WITH due AS (
+			SELECT id FROM domain_events
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY occurred_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		 )
+		 UPDATE domain_events e SET next_attempt_at = $3
+		 FROM due
+		 WHERE e.id = due.id
+		 RETURNING e.id, e.type, e.business_id, e.aggregate_id, e.payload, e.status, e.attempts, e.last_error,
+			e.next_attempt_at, e.delivered_at, e.occurred_at`,
+		now, limit, now.Add(lease))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*domain.Event
+	for rows.Next() {
+		var e domain.Event
+		err := rows.Scan(&e.ID, &e.Type, &e.BusinessID, &e.AggregateID, &e.Payload, &e.Status, &e.Attempts, &e.LastError,
+			&e.NextAttemptAt, &e.DeliveredAt, &e.OccurredAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+
+	return events, rows.Err()
+}
+
+func (r *eventRepository) MarkDelivered(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE domain_events SET status = 'delivered', attempts = attempts + 1, last_error = '', delivered_at = $2
+		 WHERE id = $1`,
+		id, time.Now())
+	return err
+}
+
+func (r *eventRepository) MarkFailed(ctx context.Context, id string, lastError string, retryAt *time.Time) error {
+	if retryAt == nil {
+		_, err := conn(ctx, r.db).Exec(ctx,
+			`UPDATE domain_events SET status = 'failed', attempts = attempts + 1, last_error = $2
+			 WHERE id = $1`,
+			id, lastError)
+		return err
+	}
+
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE domain_events SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+		 WHERE id = $1`,
+		id, lastError, *retryAt)
+	return err
+}
@@ -21,7 +21,7 @@ func NewLocationRepository(db *pgxpool.Pool) *LocationRepository {
 func (r *LocationRepository) CreateLocation(ctx context.Context, location *domain.Location) error {
 	
 
-	err := r.db.QueryRow(ctx, 
+	err := conn(ctx, r.db).QueryRow(ctx, 
 		`INSERT INTO locations (business_id, name, address, city, contact_info, timezone)
 		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, created_at, updated_at`,
@@ -39,7 +39,7 @@ func (r *LocationRepository) CreateLocation(ctx context.Context, location *domai
 func (r *LocationRepository) GetByID(ctx context.Context, id string) (*domain.Location, error) {
 
 	var location domain.Location
-	err := r.db.QueryRow(ctx, 
+	err := conn(ctx, r.db).QueryRow(ctx, 
 		`SELECT id, business_id, name, address, city, contact_info, timezone, created_at, updated_at
 		FROM locations
 		WHERE id = $1`, id).Scan(
@@ -62,7 +62,7 @@ func (r *LocationRepository) GetByID(ctx context.Context, id string) (*domain.Lo
 }
 
 func (r *LocationRepository) GetByBusinessID(ctx context.Context, businessID string) ([]*domain.Location, error) {
-	rows, err := r.db.Query(ctx, 
+	rows, err := conn(ctx, r.db).Query(ctx, 
 		`SELECT id, business_id, name, address, city, contact_info, timezone, created_at, updated_at
 		FROM locations
 		WHERE business_id = $1
@@ -102,7 +102,7 @@ func (r *LocationRepository) UpdateLocation(ctx context.Context, location *domai
 	}
 	
 	now := time.Now()
-	err := r.db.QueryRow(ctx, `
+	err := conn(ctx, r.db).QueryRow(ctx, `
 		UPDATE locations
 		SET name = $1, address = $2, city = $3, contact_info = $4, timezone = $5, updated_at = $6
 		WHERE id = $7
@@ -121,6 +121,6 @@ func (r *LocationRepository) UpdateLocation(ctx context.Context, location *domai
 
 func (r *LocationRepository) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM locations WHERE id = $1`
-	_, err := r.db.Exec(ctx, query, id)
+	_, err := conn(ctx, r.db).Exec(ctx, query, id)
 	return err
 }
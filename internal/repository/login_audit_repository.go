@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type loginAuditRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLoginAuditRepository(db *pgxpool.Pool) domain.LoginAuditRepository {
+	return &loginAuditRepository{
+		db: db,
+	}
+}
+
+func (r *loginAuditRepository) Record(ctx context.Context, attempt *domain.LoginAttempt) error {
+	attempt.CreatedAt = time.Now()
+
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO login_audit (user_id, email, ip_address, user_agent, success, reason, created_at)
+		 VALUES (NULLIF($1, ''), $2, $3, $4, $5, NULLIF($6, ''), $7)
+		 RETURNING id`,
+		attempt.UserID, attempt.Email, attempt.IPAddress, attempt.UserAgent, attempt.Success,
+		attempt.Reason, attempt.CreatedAt,
+	).Scan(&attempt.ID)
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+)
+
+// memoryLoginThrottleStore keeps counters in process memory. It is only
+// correct with a single API instance and loses its state on restart.
+type memoryLoginThrottleStore struct {
+	mu       sync.Mutex
+	counters map[string]domain.LoginThrottle
+}
+
+func NewMemoryLoginThrottleStore() domain.LoginThrottleStore {
+	return &memoryLoginThrottleStore{
+		counters: make(map[string]domain.LoginThrottle),
+	}
+}
+
+func (s *memoryLoginThrottleStore) Get(ctx context.Context, key string) (*domain.LoginThrottle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.counters[key]
+	if !ok {
+		return nil, nil
+	}
+	return &t, nil
+}
+
+func (s *memoryLoginThrottleStore) RecordFailure(ctx context.Context, key string, resetAfter time.Duration) (*domain.LoginThrottle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	t, ok := s.counters[key]
+	if !ok || t.LastFailureAt.Before(now.Add(-resetAfter)) {
+		t = domain.LoginThrottle{Key: key}
+	}
+	t.Failures++
+	t.LastFailureAt = now
+	s.counters[key] = t
+
+	s.evictExpired(now, resetAfter)
+	return &t, nil
+}
+
+func (s *memoryLoginThrottleStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.counters[key]
+	if !ok {
+		return nil
+	}
+	if t.LockedUntil == nil || t.LockedUntil.Before(until) {
+		t.LockedUntil = &until
+	}
+	s.counters[key] = t
+	return nil
+}
+
+func (s *memoryLoginThrottleStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.counters, key)
+	return nil
+}
+
+// evictExpired drops counters that can no longer affect a login so the map
+// doesn't grow without bounds under a spraying attack.
+func (s *memoryLoginThrottleStore) evictExpired(now time.Time, resetAfter time.Duration) {
+	for key, t := range s.counters {
+		if t.LastFailureAt.Before(now.Add(-resetAfter)) && (t.LockedUntil == nil || t.LockedUntil.Before(now)) {
+			delete(s.counters, key)
+		}
+	}
+}
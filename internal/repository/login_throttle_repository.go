@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type loginThrottleRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewLoginThrottleRepository returns a throttle store shared by all API instances.
+func NewLoginThrottleRepository(db *pgxpool.Pool) domain.LoginThrottleStore {
+	return &loginThrottleRepository{
+		db: db,
+	}
+}
+
+func (r *loginThrottleRepository) Get(ctx context.Context, key string) (*domain.LoginThrottle, error) {
+	var t domain.LoginThrottle
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT key, failures, last_failure_at, locked_until FROM login_throttles WHERE key = $1`,
+		key).Scan(&t.Key, &t.Failures, &t.LastFailureAt, &t.LockedUntil)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *loginThrottleRepository) RecordFailure(ctx context.Context, key string, resetAfter time.Duration) (*domain.LoginThrottle, error) {
+	now := time.Now()
+
+	var t domain.LoginThrottle
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO login_throttles (key, failures, last_failure_at)
+		 VALUES ($1, 1, $2)
+		 ON CONFLICT (key) DO UPDATE SET
+		     failures = CASE WHEN login_throttles.last_failure_at < $3 THEN 1 ELSE login_throttles.failures + 1 END,
+		     locked_until = CASE WHEN login_throttles.last_failure_at < $3 THEN NULL ELSE login_throttles.locked_until END,
+		     last_failure_at = $2
+		 RETURNING key, failures, last_failure_at, locked_until`,
+		key, now, now.Add(-resetAfter)).Scan(&t.Key, &t.Failures, &t.LastFailureAt, &t.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *loginThrottleRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE login_throttles SET locked_until = GREATEST(COALESCE(locked_until, $2), $2) WHERE key = $1`,
+		key, until)
+	return err
+}
+
+func (r *loginThrottleRepository) Reset(ctx context.Context, key string) error {
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM login_throttles WHERE key = $1`, key)
+	return err
+}
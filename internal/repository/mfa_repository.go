@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type mfaRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMFARepository(db *pgxpool.Pool) domain.MFARepository {
+	return &mfaRepository{
+		db: db,
+	}
+}
+
+func (r *mfaRepository) Get(ctx context.Context, userID string) (*domain.UserMFA, error) {
+	var m domain.UserMFA
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT user_id, totp_secret, enabled_at, last_used_step, created_at, updated_at
+		 FROM user_mfa
+		 WHERE user_id = $1`,
+		userID).Scan(&m.UserID, &m.TOTPSecret, &m.EnabledAt, &m.LastUsedStep, &m.CreatedAt, &m.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *mfaRepository) SaveSecret(ctx context.Context, userID, secret string) error {
+	now := time.Now()
+	tag, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO user_mfa (user_id, totp_secret, created_at, updated_at)
+		 VALUES ($1, $2, $3, $3)
+		 ON CONFLICT (user_id) DO UPDATE SET totp_secret = $2, last_used_step = 0, updated_at = $3
+		 WHERE user_mfa.enabled_at IS NULL`,
+		userID, secret, now)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("mfa is already enabled")
+	}
+	return nil
+}
+
+func (r *mfaRepository) Enable(ctx context.Context, userID string, recoveryCodeHashes []string) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE user_mfa SET enabled_at = $2 WHERE user_id = $1 AND enabled_at IS NULL`,
+		userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *mfaRepository) Disable(ctx context.Context, userID string) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *mfaRepository) UseStep(ctx context.Context, userID string, step int64) error {
+	var id string
+	return conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE user_mfa SET last_used_step = $2
+		 WHERE user_id = $1 AND last_used_step < $2
+		 RETURNING user_id`,
+		userID, step).Scan(&id)
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) error {
+	var id string
+	return conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE user_recovery_codes SET used_at = $3
+		 WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+		 RETURNING id`,
+		userID, codeHash, time.Now()).Scan(&id)
+}
+
+func (r *mfaRepository) CountUnusedRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL`,
+		userID).Scan(&count)
+	return count, err
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID string, codeHashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, hash := range codeHashes {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO user_recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, $3)`,
+			userID, hash, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type notificationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewNotificationRepository(db *pgxpool.Pool) domain.NotificationRepository {
+	return &notificationRepository{
+		db: db,
+	}
+}
+
+const notificationColumns = `id, business_id, COALESCE(client_id::text, ''), COALESCE(booking_id::text, ''), event, channel,
+	recipient, subject, body, COALESCE(dedupe_key, ''), status, attempts, last_error, next_attempt_at, expires_at, sent_at, created_at`
+
+func scanNotifications(rows pgx.Rows) ([]*domain.Notification, error) {
+	defer rows.Close()
+
+	var notifications []*domain.Notification
+	for rows.Next() {
+		var n domain.Notification
+		err := rows.Scan(&n.ID, &n.BusinessID, &n.ClientID, &n.BookingID, &n.Event, &n.Channel,
+			&n.Recipient, &n.Subject, &n.Body, &n.DedupeKey, &n.Status, &n.Attempts, &n.LastError, &n.NextAttemptAt, &n.ExpiresAt,
+			&n.SentAt, &n.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, &n)
+	}
+	return notifications, rows.Err()
+}
+
+func (r *notificationRepository) Enqueue(ctx context.Context, n *domain.Notification) error {
+	n.Status = domain.NotificationStatusPending
+	n.CreatedAt = time.Now()
+	if n.NextAttemptAt.IsZero() {
+		n.NextAttemptAt = n.CreatedAt
+	}
+
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO notifications (business_id, client_id, booking_id, event, channel, recipient, subject, body, dedupe_key, status,
+			next_attempt_at, expires_at, created_at)
+		 VALUES ($1, NULLIF($2, '')::uuid, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8, NULLIF($9, ''), $10, $11, $12, $13)
+		 ON CONFLICT (dedupe_key) DO NOTHING`,
+		n.BusinessID, n.ClientID, n.BookingID, n.Event, n.Channel, n.Recipient, n.Subject, n.Body, n.DedupeKey, n.Status,
+		n.NextAttemptAt, n.ExpiresAt, n.CreatedAt)
+	return err
+}
+
+func (r *notificationRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*domain.Notification, error) {
+	now := time.Now()
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE notifications SET status = 'failed', last_error = 'expired'
+		 WHERE status = 'pending' AND expires_at <= $1`,
+		now)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`UPDATE notifications SET next_attempt_at = $3
+		 WHERE id IN (
+			SELECT id FROM notifications
+			WHERE status = 'pending' AND next_attempt_at <= $1 AND (expires_at IS NULL OR expires_at > $1)
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING `+notificationColumns,
+		now, limit, now.Add(lease))
+	if err != nil {
+		return nil, err
+	}
+	return scanNotifications(rows)
+}
+
+func (r *notificationRepository) ListByClient(ctx context.Context, clientID string) ([]*domain.Notification, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+notificationColumns+` FROM notifications
+		 WHERE client_id = $1
+		 ORDER BY created_at DESC`,
+		clientID)
+	if err != nil {
+		return nil, err
+	}
+	return scanNotifications(rows)
+}
+
+func (r *notificationRepository) ReassignClient(ctx context.Context, fromClientID, toClientID string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE notifications SET client_id = $2 WHERE client_id = $1`,
+		fromClientID, toClientID)
+	return err
+}
+
+func (r *notificationRepository) AnonymiseClient(ctx context.Context, clientID string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE notifications
+		 SET recipient = '', subject = '', body = '',
+			status = CASE WHEN status = 'pending' THEN 'failed' ELSE status END,
+			last_error = CASE WHEN status = 'pending' THEN 'client erased' ELSE last_error END
+		 WHERE client_id = $1`,
+		clientID)
+	return err
+}
+
+func (r *notificationRepository) MarkSent(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE notifications SET status = 'sent', attempts = attempts + 1, last_error = '', sent_at = $2
+		 WHERE id = $1`,
+		id, time.Now())
+	return err
+}
+
+func (r *notificationRepository) MarkFailed(ctx context.Context, id string, lastError string, retryAt *time.Time) error {
+	if retryAt == nil {
+		_, err := conn(ctx, r.db).Exec(ctx,
+			`UPDATE notifications SET status = 'failed', attempts = attempts + 1, last_error = $2
+			 WHERE id = $1`,
+			id, lastError)
+		return err
+	}
+
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE notifications SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+		 WHERE id = $1`,
+		id, lastError, *retryAt)
+	return err
+}
+
+func (r *notificationRepository) FailBookingReminders(ctx context.Context, bookingID string, keepStartAt *time.Time, lastError string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE notifications SET status = 'failed', last_error = $3
+		 WHERE booking_id = $1 AND status = 'pending' AND expires_at IS NOT NULL
+			AND ($2::timestamp IS NULL OR expires_at <> $2)`,
+		bookingID, keepStartAt, lastError)
+	return err
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ownershipRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOwnershipRepository(db *pgxpool.Pool) domain.OwnershipRepository {
+	return &ownershipRepository{
+		db: db,
+	}
+}
+
+// ownershipQueries map a resource to a query returning whether a row with
+// id $2 belongs to business $1. Schedule entities are owned through staff.
+var ownershipQueries = map[domain.Resource]string{
+	domain.ResourceStaff:    `SELECT EXISTS(SELECT 1 FROM staff WHERE business_id = $1 AND id = $2)`,
+	domain.ResourceService:  `SELECT EXISTS(SELECT 1 FROM services WHERE business_id = $1 AND id = $2)`,
+	domain.ResourceLocation: `SELECT EXISTS(SELECT 1 FROM locations WHERE business_id = $1 AND id = $2)`,
+	domain.ResourceClient:   `SELECT EXISTS(SELECT 1 FROM clients WHERE business_id = $1 AND id = $2)`,
+	domain.ResourceRole:     `SELECT EXISTS(SELECT 1 FROM business_roles WHERE business_id = $1 AND id = $2)`,
+	domain.ResourceAPIKey:   `SELECT EXISTS(SELECT 1 FROM api_keys WHERE business_id = $1 AND id = $2)`,
+	domain.ResourceWebhook:  `SELECT EXISTS(SELECT 1 FROM webhook_endpoints WHERE business_id = $1 AND id = $2)`,
+	domain.ResourceShift: `SELECT EXISTS(SELECT 1 FROM staff_shifts sh JOIN staff s ON s.id = sh.staff_id
+		WHERE s.business_id = $1 AND sh.id = $2)`,
+	domain.ResourceScheduleTemplate: `SELECT EXISTS(SELECT 1 FROM schedule_templates t JOIN staff s ON s.id = t.staff_id
+		WHERE s.business_id = $1 AND t.id = $2)`,
+	domain.ResourceTimeOffRequest: `SELECT EXISTS(SELECT 1 FROM time_off_requests t JOIN staff s ON s.id = t.staff_id
+		WHERE s.business_id = $1 AND t.id = $2)`,
+	domain.ResourceBooking: `SELECT EXISTS(SELECT 1 FROM bookings b JOIN services s ON s.id = b.service_id
+		WHERE s.business_id = $1 AND b.id = $2)`,
+	domain.ResourceCalendarFeed:    `SELECT EXISTS(SELECT 1 FROM calendar_feeds WHERE business_id = $1 AND id = $2)`,
+	domain.ResourceClientNote:      `SELECT EXISTS(SELECT 1 FROM client_notes WHERE business_id = $1 AND id = $2)`,
+	domain.ResourceClientTag:       `SELECT EXISTS(SELECT 1 FROM client_tags WHERE business_id = $1 AND id = $2)`,
+	domain.ResourceClientField:     `SELECT EXISTS(SELECT 1 FROM client_custom_fields WHERE business_id = $1 AND id = $2)`,
+	domain.ResourceClientSegment:   `SELECT EXISTS(SELECT 1 FROM client_segments WHERE business_id = $1 AND id = $2)`,
+	domain.ResourceClientJob:       `SELECT EXISTS(SELECT 1 FROM client_jobs WHERE business_id = $1 AND id = $2)`,
+	domain.ResourceServiceCategory: `SELECT EXISTS(SELECT 1 FROM service_categories WHERE business_id = $1 AND id = $2)`,
+}
+
+func (r *ownershipRepository) BelongsToBusiness(ctx context.Context, businessID string, resource domain.Resource, id string) (bool, error) {
+	query, ok := ownershipQueries[resource]
+	if !ok {
+		return false, fmt.Errorf("unknown resource %q", resource)
+	}
+
+	var exists bool
+	err := conn(ctx, r.db).QueryRow(ctx, query, businessID, id).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type roleRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRoleRepository(db *pgxpool.Pool) domain.RoleRepository {
+	return &roleRepository{
+		db: db,
+	}
+}
+
+func (r *roleRepository) Create(ctx context.Context, role *domain.Role) error {
+	role.CreatedAt = time.Now()
+	role.UpdatedAt = time.Now()
+
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO business_roles (business_id, name, description, permissions, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id`,
+		role.BusinessID, role.Name, role.Description, permissionsToStrings(role.Permissions),
+		role.CreatedAt, role.UpdatedAt,
+	).Scan(&role.ID)
+}
+
+func (r *roleRepository) GetByID(ctx context.Context, id string) (*domain.Role, error) {
+	var role domain.Role
+	var permissions []string
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT id, business_id, name, COALESCE(description, ''), permissions, created_at, updated_at
+		 FROM business_roles
+		 WHERE id = $1`,
+		id).Scan(&role.ID, &role.BusinessID, &role.Name, &role.Description, &permissions,
+		&role.CreatedAt, &role.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	role.Permissions = stringsToPermissions(permissions)
+	return &role, nil
+}
+
+func (r *roleRepository) ListByBusiness(ctx context.Context, businessID string) ([]*domain.Role, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, business_id, name, COALESCE(description, ''), permissions, created_at, updated_at
+		 FROM business_roles
+		 WHERE business_id = $1
+		 ORDER BY name`,
+		businessID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*domain.Role
+	for rows.Next() {
+		var role domain.Role
+		var permissions []string
+		err := rows.Scan(&role.ID, &role.BusinessID, &role.Name, &role.Description, &permissions,
+			&role.CreatedAt, &role.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		role.Permissions = stringsToPermissions(permissions)
+		roles = append(roles, &role)
+	}
+	return roles, rows.Err()
+}
+
+func (r *roleRepository) Update(ctx context.Context, role *domain.Role) error {
+	role.UpdatedAt = time.Now()
+
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE business_roles
+		 SET name = $2, description = $3, permissions = $4, updated_at = $5
+		 WHERE id = $1`,
+		role.ID, role.Name, role.Description, permissionsToStrings(role.Permissions), role.UpdatedAt)
+	return err
+}
+
+func (r *roleRepository) Delete(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM business_roles WHERE id = $1`, id)
+	return err
+}
+
+// AssignToUser sets the custom role of a user; an empty roleID restores the
+// built-in role bundle.
+func (r *roleRepository) AssignToUser(ctx context.Context, userID string, roleID string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE users SET role_id = NULLIF($2, '')::uuid, updated_at = $3 WHERE id = $1`,
+		userID, roleID, time.Now())
+	return err
+}
+
+func permissionsToStrings(perms []domain.Permission) []string {
+	out := make([]string, len(perms))
+	for i, p := range perms {
+		out[i] = string(p)
+	}
+	return out
+}
+
+func stringsToPermissions(values []string) []domain.Permission {
+	out := make([]domain.Permission, len(values))
+	for i, v := range values {
+		out[i] = domain.Permission(v)
+	}
+	return out
+}
@@ -35,7 +35,7 @@ func (r *scheduleRepository) CreateScheduleTemplate(ctx context.Context, templat
 		return fmt.Errorf("failed to marshal schedule: %w", err)
 	}
 
-	err = r.db.QueryRow(ctx,
+	err = conn(ctx, r.db).QueryRow(ctx,
 		`INSERT INTO schedule_templates 
 		 (staff_id, name, description, is_default, schedule, created_at, updated_at)
 		 VALUES ($1, $2, $3, $4, $5, $6, $7)
@@ -50,7 +50,7 @@ func (r *scheduleRepository) GetScheduleTemplate(ctx context.Context, id string)
 	var template domain.ScheduleTemplate
 	var scheduleJSON []byte
 
-	err := r.db.QueryRow(ctx,
+	err := conn(ctx, r.db).QueryRow(ctx,
 		`SELECT id, staff_id, name, description, is_default, schedule, created_at, updated_at
 		 FROM schedule_templates
 		 WHERE id = $1`,
@@ -112,7 +112,7 @@ func (r *scheduleRepository) CreateShift(ctx context.Context, shift *domain.Staf
 		shift.UpdatedBy,
 	}
 
-	err := r.db.QueryRow(ctx,sql, args...).Scan(&shift.ID, &shift.CreatedAt, &shift.UpdatedAt)
+	err := conn(ctx, r.db).QueryRow(ctx,sql, args...).Scan(&shift.ID, &shift.CreatedAt, &shift.UpdatedAt)
 	if err != nil {
 		t:= rawsql.BuildSQL(sql, args)
 		fmt.Println(t)
@@ -124,7 +124,7 @@ func (r *scheduleRepository) CreateShift(ctx context.Context, shift *domain.Staf
 // Placeholder implementations for remaining interface methods
 func (r *scheduleRepository) GetScheduleTemplatesByStaff(ctx context.Context, staffID string) ([]domain.ScheduleTemplate, error) {
 	var templates []domain.ScheduleTemplate
-	rows, err := r.db.Query(ctx,
+	rows, err := conn(ctx, r.db).Query(ctx,
 		`SELECT id, staff_id, name, description, is_default, schedule, created_at, updated_at
 		 FROM schedule_templates
 		 WHERE staff_id = $1`, staffID)
@@ -153,7 +153,7 @@ func (r *scheduleRepository) GetScheduleTemplatesByStaff(ctx context.Context, st
 }
 
 func (r *scheduleRepository) UpdateScheduleTemplate(ctx context.Context, template *domain.ScheduleTemplate) error {
-	_, err := r.db.Exec(ctx,
+	_, err := conn(ctx, r.db).Exec(ctx,
 		`UPDATE schedule_templates 
 	SET name = $1, description = $2, is_default = $3, schedule = $4, updated_at = $5 
 	WHERE id = $6`,
@@ -163,7 +163,7 @@ func (r *scheduleRepository) UpdateScheduleTemplate(ctx context.Context, templat
 }
 
 func (r *scheduleRepository) DeleteScheduleTemplate(ctx context.Context, id string) error {
-	_, err := r.db.Exec(ctx, `DELETE FROM schedule_templates WHERE id = `, id)
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM schedule_templates WHERE id = `, id)
 	return err
 }
 
@@ -175,11 +175,11 @@ func (r *scheduleRepository) SetDefaultTemplate(ctx context.Context, staffID, te
 
 	defer tx.Rollback(ctx)
 
-	if _, err := r.db.Exec(ctx, `UPDATE staff_templates SET is_default = false WHERE staff_id = $1`, staffID); err != nil {
+	if _, err := conn(ctx, r.db).Exec(ctx, `UPDATE staff_templates SET is_default = false WHERE staff_id = $1`, staffID); err != nil {
 		return fmt.Errorf("failed to set default template: %w", err)
 	}
 
-	if _, err := r.db.Exec(ctx, `UPDATE staff_templates SET is_default = true WHERE id = `, templateID); err != nil {
+	if _, err := conn(ctx, r.db).Exec(ctx, `UPDATE staff_templates SET is_default = true WHERE id = `, templateID); err != nil {
 		return fmt.Errorf("failed to set default template: %w", err)
 	}
 
@@ -195,7 +195,7 @@ func (r *scheduleRepository) GetShift(ctx context.Context, id string) (*domain.S
 }
 
 func (r *scheduleRepository) GetShiftsByStaff(ctx context.Context, staffID string, startDate, endDate time.Time) ([]domain.StaffShift, error) {
-	rows, err := r.db.Query(ctx,
+	rows, err := conn(ctx, r.db).Query(ctx,
 		`SELECT id, staff_id, shift_date, start_time, end_time, break_start_time, break_end_time, 
 		        is_available, is_manually_disabled, manual_disable_reason, shift_type, notes, 
 		        created_at, updated_at, created_by, updated_by
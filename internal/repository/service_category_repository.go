@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type serviceCategoryRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewServiceCategoryRepository(db *pgxpool.Pool) domain.ServiceCategoryRepository {
+	return &serviceCategoryRepository{
+		db: db,
+	}
+}
+
+const serviceCategoryColumns = `id, business_id, name, position, created_at, updated_at`
+
+func scanServiceCategory(row pgx.Row) (*domain.ServiceCategory, error) {
+	var c domain.ServiceCategory
+	if err := row.Scan(&c.ID, &c.BusinessID, &c.Name, &c.Position, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *serviceCategoryRepository) Create(ctx context.Context, category *domain.ServiceCategory) error {
+	category.CreatedAt = time.Now()
+	category.UpdatedAt = category.CreatedAt
+
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO service_categories (business_id, name, position, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id`,
+		category.BusinessID, category.Name, category.Position, category.CreatedAt, category.UpdatedAt,
+	).Scan(&category.ID)
+}
+
+func (r *serviceCategoryRepository) GetByID(ctx context.Context, id string) (*domain.ServiceCategory, error) {
+	return scanServiceCategory(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+serviceCategoryColumns+` FROM service_categories WHERE id = $1`, id))
+}
+
+func (r *serviceCategoryRepository) GetByName(ctx context.Context, businessID, name string) (*domain.ServiceCategory, error) {
+	return scanServiceCategory(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+serviceCategoryColumns+` FROM service_categories WHERE business_id = $1 AND lower(name) = lower($2)`,
+		businessID, name))
+}
+
+func (r *serviceCategoryRepository) ListByBusiness(ctx context.Context, businessID string) ([]*domain.ServiceCategory, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+serviceCategoryColumns+` FROM service_categories
+		 WHERE business_id = $1
+		 ORDER BY position, lower(name)`,
+		businessID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*domain.ServiceCategory
+	for rows.Next() {
+		category, err := scanServiceCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+func (r *serviceCategoryRepository) Update(ctx context.Context, category *domain.ServiceCategory) error {
+	category.UpdatedAt = time.Now()
+
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE service_categories SET name = $2, position = $3, updated_at = $4 WHERE id = $1`,
+		category.ID, category.Name, category.Position, category.UpdatedAt)
+	return err
+}
+
+func (r *serviceCategoryRepository) Delete(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM service_categories WHERE id = $1`, id)
+	return err
+}
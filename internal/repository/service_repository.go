@@ -18,13 +18,23 @@ func NewServiceRepository(db *pgxpool.Pool) *serviceRepository {
 	}
 }
 
+const serviceColumns = `id, business_id, location_id, COALESCE(category_id::text, ''), name, description, image_url,
+	duration_min, price_cents, visible_online, active, created_at, updated_at`
+
+// serviceFields are the scan destinations of serviceColumns
+func serviceFields(s *domain.Service) []any {
+	return []any{&s.ID, &s.BusinessID, &s.LocationID, &s.CategoryID, &s.Name, &s.Description, &s.ImageURL,
+		&s.DurationMin, &s.PriceCents, &s.VisibleOnline, &s.Active, &s.CreatedAt, &s.UpdatedAt}
+}
+
 func (r *serviceRepository) Create(ctx context.Context, s *domain.Service) error {
-	err := r.db.QueryRow(ctx,
+	err := conn(ctx, r.db).QueryRow(ctx,
 	`INSERT INTO services 
-	(business_id, location_id, name, duration_min, price_cents)
-	 VALUES ($1,$2,$3,$4,$5)
+	(business_id, location_id, category_id, name, description, image_url, duration_min, price_cents, visible_online, active)
+	 VALUES ($1,$2,NULLIF($3, '')::uuid,$4,$5,$6,$7,$8,$9,$10)
 	 RETURNING id`,
-		s.BusinessID, s.LocationID,  s.Name, s.DurationMin, s.PriceCents,
+		s.BusinessID, s.LocationID, s.CategoryID, s.Name, s.Description, s.ImageURL, s.DurationMin, s.PriceCents,
+		s.VisibleOnline, s.Active,
 	).Scan(&s.ID)
 
 	return err
@@ -32,15 +42,16 @@ func (r *serviceRepository) Create(ctx context.Context, s *domain.Service) error
 
 func (r *serviceRepository) ListByBusinessId(ctx context.Context, businessId string) ([]domain.Service, error) {
 	var services []domain.Service
-	rows, _ := r.db.Query(ctx,
-		`SELECT id, business_id, location_id, name, duration_min, price_cents, created_at, updated_at
+	rows, _ := conn(ctx, r.db).Query(ctx,
+		`SELECT `+serviceColumns+`
 		 FROM services
-		 WHERE business_id = $1`,
+		 WHERE business_id = $1
+		 ORDER BY lower(name)`,
 		businessId,
 	)
 	for rows.Next() {
 		var s domain.Service
-		rows.Scan(&s.ID, &s.BusinessID, &s.LocationID,  &s.Name, &s.DurationMin, &s.PriceCents, &s.CreatedAt, &s.UpdatedAt)
+		rows.Scan(serviceFields(&s)...)
 		services = append(services, s)
 	}
 
@@ -49,11 +60,11 @@ func (r *serviceRepository) ListByBusinessId(ctx context.Context, businessId str
 
 func (r *serviceRepository) GetById(ctx context.Context, id string) (*domain.Service, error) {
 	var s domain.Service
-	err := r.db.QueryRow(ctx,
-		`SELECT id, business_id, location_id, name, duration_min, price_cents, created_at, updated_at
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+serviceColumns+`
 	 FROM services
 	 WHERE id = $1`,
-		id).Scan(&s.ID, &s.BusinessID, &s.LocationID,  &s.Name, &s.DurationMin, &s.PriceCents, &s.CreatedAt, &s.UpdatedAt)
+		id).Scan(serviceFields(&s)...)
 	if err != nil {
 		return nil, err
 	}
@@ -64,16 +75,18 @@ func (r *serviceRepository) GetById(ctx context.Context, id string) (*domain.Ser
 func (r *serviceRepository) Update(ctx context.Context, s *domain.Service) error {
 	s.UpdatedAt = time.Now()
 
-	_, err := r.db.Exec(ctx,
+	_, err := conn(ctx, r.db).Exec(ctx,
 		`UPDATE services 
-		 SET location_id = $2, name = $4, duration_min = $5, price_cents = $6, updated_at = $7
+		 SET location_id = $2, name = $3, duration_min = $4, price_cents = $5, updated_at = $6,
+		     category_id = NULLIF($7, '')::uuid, description = $8, image_url = $9, visible_online = $10, active = $11
 		 WHERE id = $1`,
-		s.ID, s.LocationID, s.Name, s.DurationMin, s.PriceCents, s.UpdatedAt)
+		s.ID, s.LocationID, s.Name, s.DurationMin, s.PriceCents, s.UpdatedAt,
+		s.CategoryID, s.Description, s.ImageURL, s.VisibleOnline, s.Active)
 
 	return err
 }
 
 func (r *serviceRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.db.Exec(ctx, `DELETE FROM services WHERE id = $1`, id)
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM services WHERE id = $1`, id)
 	return err
 }
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type sessionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSessionRepository(db *pgxpool.Pool) domain.SessionRepository {
+	return &sessionRepository{
+		db: db,
+	}
+}
+
+const sessionColumns = `id, user_id, family_id, refresh_token_hash, COALESCE(replaced_by::text, ''),
+	COALESCE(user_agent, ''), COALESCE(ip_address, ''), expires_at, is_revoked, revoked_at,
+	last_used_at, created_at, updated_at`
+
+type sessionScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSession(row sessionScanner) (*domain.UserSession, error) {
+	var s domain.UserSession
+	err := row.Scan(&s.ID, &s.UserID, &s.FamilyID, &s.RefreshToken, &s.ReplacedBy,
+		&s.UserAgent, &s.IPAddress, &s.ExpiresAt, &s.IsRevoked, &s.RevokedAt,
+		&s.LastUsedAt, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *domain.UserSession) error {
+	session.CreatedAt = time.Now()
+	session.UpdatedAt = time.Now()
+	session.LastUsedAt = time.Now()
+
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO user_sessions (user_id, family_id, refresh_token_hash, user_agent, ip_address,
+		                            expires_at, last_used_at, created_at, updated_at)
+		 VALUES ($1, COALESCE(NULLIF($2, '')::uuid, uuid_generate_v4()), $3, $4, $5, $6, $7, $8, $9)
+		 RETURNING id, family_id`,
+		session.UserID, session.FamilyID, session.RefreshToken, session.UserAgent, session.IPAddress,
+		session.ExpiresAt, session.LastUsedAt, session.CreatedAt, session.UpdatedAt,
+	).Scan(&session.ID, &session.FamilyID)
+}
+
+func (r *sessionRepository) GetByID(ctx context.Context, id string) (*domain.UserSession, error) {
+	return scanSession(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+sessionColumns+` FROM user_sessions WHERE id = $1`, id))
+}
+
+func (r *sessionRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.UserSession, error) {
+	return scanSession(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+sessionColumns+` FROM user_sessions WHERE refresh_token_hash = $1`, tokenHash))
+}
+
+func (r *sessionRepository) ListActiveByUser(ctx context.Context, userID string) ([]*domain.UserSession, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+sessionColumns+`
+		 FROM user_sessions
+		 WHERE user_id = $1 AND is_revoked = false AND expires_at > now()
+		 ORDER BY last_used_at DESC`,
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*domain.UserSession
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// Rotate revokes the old session and inserts its successor in one transaction.
+// It fails with pgx.ErrNoRows if the old session was rotated or revoked concurrently.
+func (r *sessionRepository) Rotate(ctx context.Context, oldSessionID string, next *domain.UserSession) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	next.CreatedAt = now
+	next.UpdatedAt = now
+	next.LastUsedAt = now
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO user_sessions (user_id, family_id, refresh_token_hash, user_agent, ip_address,
+		                            expires_at, last_used_at, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 RETURNING id`,
+		next.UserID, next.FamilyID, next.RefreshToken, next.UserAgent, next.IPAddress,
+		next.ExpiresAt, next.LastUsedAt, next.CreatedAt, next.UpdatedAt,
+	).Scan(&next.ID)
+	if err != nil {
+		return err
+	}
+
+	var id string
+	err = tx.QueryRow(ctx,
+		`UPDATE user_sessions
+		 SET is_revoked = true, revoked_at = $2, replaced_by = $3, last_used_at = $2
+		 WHERE id = $1 AND is_revoked = false
+		 RETURNING id`,
+		oldSessionID, now, next.ID).Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RevokeFamily revokes every session of a login. Rotated sessions lose their
+// replaced_by link too, so access tokens issued for them stop validating.
+func (r *sessionRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE user_sessions SET is_revoked = true, revoked_at = $2, replaced_by = NULL
+		 WHERE family_id = $1 AND (is_revoked = false OR replaced_by IS NOT NULL)`,
+		familyID, time.Now())
+	return err
+}
+
+func (r *sessionRepository) RevokeAllByUser(ctx context.Context, userID string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE user_sessions SET is_revoked = true, revoked_at = $2, replaced_by = NULL
+		 WHERE user_id = $1 AND (is_revoked = false OR replaced_by IS NOT NULL)`,
+		userID, time.Now())
+	return err
+}
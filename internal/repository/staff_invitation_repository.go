@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Unique constraints of users an accepted invitation can run into
+const (
+	userStaffConstraint = "idx_users_staff_id"
+	userEmailConstraint = "users_email_key"
+)
+
+type staffInvitationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewStaffInvitationRepository(db *pgxpool.Pool) domain.StaffInvitationRepository {
+	return &staffInvitationRepository{
+		db: db,
+	}
+}
+
+func (r *staffInvitationRepository) Create(ctx context.Context, invitation *domain.StaffInvitation) error {
+	invitation.CreatedAt = time.Now()
+
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO staff_invitations (business_id, staff_id, email, token_hash, invited_by, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id`,
+		invitation.BusinessID, invitation.StaffID, invitation.Email, invitation.TokenHash,
+		invitation.InvitedBy, invitation.ExpiresAt, invitation.CreatedAt,
+	).Scan(&invitation.ID)
+}
+
+func (r *staffInvitationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.StaffInvitation, error) {
+	var i domain.StaffInvitation
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT id, business_id, staff_id, email, token_hash, invited_by, expires_at, accepted_at, revoked_at, created_at
+		 FROM staff_invitations
+		 WHERE token_hash = $1`,
+		tokenHash).Scan(&i.ID, &i.BusinessID, &i.StaffID, &i.Email, &i.TokenHash, &i.InvitedBy,
+		&i.ExpiresAt, &i.AcceptedAt, &i.RevokedAt, &i.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+func (r *staffInvitationRepository) ListByBusiness(ctx context.Context, businessID string) ([]*domain.StaffInvitation, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, business_id, staff_id, email, token_hash, invited_by, expires_at, accepted_at, revoked_at, created_at
+		 FROM staff_invitations
+		 WHERE business_id = $1
+		 ORDER BY created_at DESC`,
+		businessID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []*domain.StaffInvitation
+	for rows.Next() {
+		var i domain.StaffInvitation
+		err := rows.Scan(&i.ID, &i.BusinessID, &i.StaffID, &i.Email, &i.TokenHash, &i.InvitedBy,
+			&i.ExpiresAt, &i.AcceptedAt, &i.RevokedAt, &i.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, &i)
+	}
+	return invitations, rows.Err()
+}
+
+func (r *staffInvitationRepository) RevokePendingByStaff(ctx context.Context, staffID string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE staff_invitations SET revoked_at = $2
+		 WHERE staff_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL`,
+		staffID, time.Now())
+	return err
+}
+
+func (r *staffInvitationRepository) Accept(ctx context.Context, invitationID string, user *domain.User) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+
+	// Guard against the same token being accepted twice concurrently
+	var id string
+	err = tx.QueryRow(ctx,
+		`UPDATE staff_invitations SET accepted_at = $2
+		 WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > $2
+		 RETURNING id`,
+		invitationID, now).Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	_, err = tx.Exec(ctx,
+		`INSERT INTO users (id, business_id, staff_id, first_name, last_name, email, phone, password, role, is_active, created_at, updated_at)
+		 VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		user.ID, user.BusinessID, user.StaffID, user.FirstName, user.LastName, user.Email, user.Phone,
+		user.Password, user.Role, user.IsActive, user.CreatedAt, user.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.ConstraintName {
+			case userStaffConstraint:
+				return domain.ErrStaffAlreadyLinked
+			case userEmailConstraint:
+				return domain.ErrEmailAlreadyInUse
+			}
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -21,7 +21,7 @@ func NewStaffRepository(db *pgxpool.Pool) *staffRepository {
 func (r *staffRepository) Create(ctx context.Context, s *domain.Staff) error {
 	s.IsActive = true
 
-	err := r.db.QueryRow(ctx,
+	err := conn(ctx, r.db).QueryRow(ctx,
 		`INSERT INTO staff 
 	(business_id, location_id, first_name, last_name, phone, gender, position, description, specialization, is_active)
 	 VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
@@ -47,7 +47,7 @@ func (r *staffRepository) ListByBusinessId(ctx context.Context, businessId, loca
 	}
 
 	sql += " ORDER BY first_name, last_name"	 
-	rows, err := r.db.Query(ctx,
+	rows, err := conn(ctx, r.db).Query(ctx,
 		sql,
 		args...,
 	)
@@ -71,7 +71,7 @@ func (r *staffRepository) ListByBusinessId(ctx context.Context, businessId, loca
 
 func (r *staffRepository) GetById(ctx context.Context, id string) (*domain.Staff, error) {
 	var s domain.Staff
-	err := r.db.QueryRow(ctx,
+	err := conn(ctx, r.db).QueryRow(ctx,
 		`SELECT id, business_id, location_id, first_name, last_name, phone, gender, position, description, specialization, is_active, created_at, updated_at
 	 	FROM staff
 	 	WHERE id = $1`,
@@ -86,7 +86,7 @@ func (r *staffRepository) GetById(ctx context.Context, id string) (*domain.Staff
 func (r *staffRepository) Update(ctx context.Context, s *domain.Staff) error {
 	s.UpdatedAt = time.Now()
 
-	_, err := r.db.Exec(ctx,
+	_, err := conn(ctx, r.db).Exec(ctx,
 		`UPDATE staff 
 		 SET first_name = $2, last_name = $3, phone = $4, gender = $5, position = $6, 
 		     description = $7, specialization = $8, is_active = $9, location_id = $10, updated_at = $11
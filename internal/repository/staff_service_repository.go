@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -19,7 +20,7 @@ func NewStaffServiceRepository(db *pgxpool.Pool) domain.StaffServiceRepository {
 }
 
 func (r *staffServiceRepository) AssignServiceToStaff(ctx context.Context, staffID, serviceID string) error {
-	_, err := r.db.Exec(ctx,
+	_, err := conn(ctx, r.db).Exec(ctx,
 		`INSERT INTO staff_services (staff_id, service_id, created_at, updated_at) 
 		 VALUES ($1, $2, $3, $4)
 		 ON CONFLICT (staff_id, service_id) DO NOTHING`,
@@ -28,17 +29,48 @@ func (r *staffServiceRepository) AssignServiceToStaff(ctx context.Context, staff
 }
 
 func (r *staffServiceRepository) UnassignServiceFromStaff(ctx context.Context, staffID, serviceID string) error {
-	_, err := r.db.Exec(ctx,
+	_, err := conn(ctx, r.db).Exec(ctx,
 		`DELETE FROM staff_services WHERE staff_id = $1 AND service_id = $2`,
 		staffID, serviceID)
 	return err
 }
 
+func (r *staffServiceRepository) GetStaffService(ctx context.Context, staffID, serviceID string) (*domain.StaffService, error) {
+	var ss domain.StaffService
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT id, staff_id, service_id, price_cents, duration_min, created_at, updated_at
+		 FROM staff_services
+		 WHERE staff_id = $1 AND service_id = $2`,
+		staffID, serviceID).Scan(
+		&ss.ID, &ss.StaffID, &ss.ServiceID, &ss.PriceCents, &ss.DurationMin,
+		&ss.CreatedAt, &ss.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &ss, nil
+}
+
+func (r *staffServiceRepository) SetOverrides(ctx context.Context, staffID, serviceID string, priceCents, durationMin *int) error {
+	tag, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE staff_services
+		 SET price_cents = $3, duration_min = $4, updated_at = $5
+		 WHERE staff_id = $1 AND service_id = $2`,
+		staffID, serviceID, priceCents, durationMin, time.Now())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
 func (r *staffServiceRepository) GetStaffServices(ctx context.Context, staffID string) ([]domain.Service, error) {
 	var services []domain.Service
 
-	rows, err := r.db.Query(ctx,
-		`SELECT s.id, s.business_id, s.name, s.duration_min, s.price_cents, s.created_at, s.updated_at
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT s.id, s.business_id, COALESCE(s.category_id::text, ''), s.name, s.description, s.image_url,
+		        s.duration_min, s.price_cents, s.visible_online, s.active, s.created_at, s.updated_at
 		 FROM services s
 		 JOIN staff_services ss ON s.id = ss.service_id
 		 WHERE ss.staff_id = $1
@@ -52,8 +84,10 @@ func (r *staffServiceRepository) GetStaffServices(ctx context.Context, staffID s
 	for rows.Next() {
 		var service domain.Service
 		err := rows.Scan(
-			&service.ID, &service.BusinessID, &service.Name,
+			&service.ID, &service.BusinessID, &service.CategoryID, &service.Name,
+			&service.Description, &service.ImageURL,
 			&service.DurationMin, &service.PriceCents,
+			&service.VisibleOnline, &service.Active,
 			&service.CreatedAt, &service.UpdatedAt)
 		if err != nil {
 			return nil, err
@@ -67,7 +101,7 @@ func (r *staffServiceRepository) GetStaffServices(ctx context.Context, staffID s
 func (r *staffServiceRepository) GetServiceStaff(ctx context.Context, serviceID string) ([]domain.Staff, error) {
 	var staff []domain.Staff
 
-	rows, err := r.db.Query(ctx,
+	rows, err := conn(ctx, r.db).Query(ctx,
 		`SELECT st.id, st.business_id, st.first_name, st.last_name, st.phone, st.gender, 
 		        st.position, st.description, st.specialization, st.is_active, st.created_at, st.updated_at
 		 FROM staff st
@@ -98,10 +132,11 @@ func (r *staffServiceRepository) GetServiceStaff(ctx context.Context, serviceID
 func (r *staffServiceRepository) GetStaffServicesByBusiness(ctx context.Context, businessID string) ([]domain.StaffServiceWithDetails, error) {
 	var staffServices []domain.StaffServiceWithDetails
 
-	rows, err := r.db.Query(ctx,
+	rows, err := conn(ctx, r.db).Query(ctx,
 		`SELECT ss.id, ss.staff_id, ss.service_id, 
 		        CONCAT(st.first_name, ' ', st.last_name) as staff_name,
 		        s.name as service_name,
+		        ss.price_cents, ss.duration_min,
 		        ss.created_at, ss.updated_at
 		 FROM staff_services ss
 		 JOIN staff st ON ss.staff_id = st.id
@@ -119,6 +154,7 @@ func (r *staffServiceRepository) GetStaffServicesByBusiness(ctx context.Context,
 		err := rows.Scan(
 			&ss.ID, &ss.StaffID, &ss.ServiceID,
 			&ss.StaffName, &ss.ServiceName,
+			&ss.PriceCents, &ss.DurationMin,
 			&ss.CreatedAt, &ss.UpdatedAt)
 		if err != nil {
 			return nil, err
@@ -131,14 +167,14 @@ func (r *staffServiceRepository) GetStaffServicesByBusiness(ctx context.Context,
 
 func (r *staffServiceRepository) IsServiceAssignedToStaff(ctx context.Context, staffID, serviceID string) (bool, error) {
 	var exists bool
-	err := r.db.QueryRow(ctx,
+	err := conn(ctx, r.db).QueryRow(ctx,
 		`SELECT EXISTS(SELECT 1 FROM staff_services WHERE staff_id = $1 AND service_id = $2)`,
 		staffID, serviceID).Scan(&exists)
 	return exists, err
 }
 
 func (r *staffServiceRepository) AssignMultipleServicesToStaff(ctx context.Context, staffID string, serviceIDs []string) error {
-	tx, err := r.db.Begin(ctx)
+	tx, err := conn(ctx, r.db).Begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -159,14 +195,20 @@ func (r *staffServiceRepository) AssignMultipleServicesToStaff(ctx context.Conte
 }
 
 func (r *staffServiceRepository) ReplaceStaffServices(ctx context.Context, staffID string, serviceIDs []string) error {
-	tx, err := r.db.Begin(ctx)
+	tx, err := conn(ctx, r.db).Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
 
-	// Remove all existing services for this staff member
-	_, err = tx.Exec(ctx, `DELETE FROM staff_services WHERE staff_id = $1`, staffID)
+	// Remove the services no longer assigned, the ones kept keep their overrides.
+	// A nil slice would be sent as NULL and match nothing
+	if serviceIDs == nil {
+		serviceIDs = []string{}
+	}
+	_, err = tx.Exec(ctx,
+		`DELETE FROM staff_services WHERE staff_id = $1 AND NOT (service_id = ANY($2::uuid[]))`,
+		staffID, serviceIDs)
 	if err != nil {
 		return err
 	}
@@ -175,7 +217,8 @@ func (r *staffServiceRepository) ReplaceStaffServices(ctx context.Context, staff
 	for _, serviceID := range serviceIDs {
 		_, err := tx.Exec(ctx,
 			`INSERT INTO staff_services (staff_id, service_id, created_at, updated_at) 
-			 VALUES ($1, $2, $3, $4)`,
+			 VALUES ($1, $2, $3, $4)
+			 ON CONFLICT (staff_id, service_id) DO NOTHING`,
 			staffID, serviceID, time.Now(), time.Now())
 		if err != nil {
 			return err
@@ -22,22 +22,22 @@ func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
-	_, err := r.db.Exec(ctx,
-		`INSERT INTO users (id, business_id, first_name, last_name, email, phone, password, role, is_active, created_at, updated_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
-		user.ID, user.BusinessID, user.FirstName, user.LastName, user.Email, user.Phone,
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO users (id, business_id, staff_id, first_name, last_name, email, phone, password, role, is_active, created_at, updated_at)
+		 VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		user.ID, user.BusinessID, user.StaffID, user.FirstName, user.LastName, user.Email, user.Phone,
 		user.Password, user.Role, user.IsActive, user.CreatedAt, user.UpdatedAt)
 	return err
 }
 
 func (r *userRepository) GetById(ctx context.Context, id string) (*domain.User, error) {
 	var user domain.User
-	err := r.db.QueryRow(ctx,
-		`SELECT id, business_id, first_name, last_name, email, phone, password, role, is_active, created_at, updated_at
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT id, business_id, COALESCE(staff_id::text, ''), first_name, last_name, email, phone, password, role, COALESCE(role_id::text, ''), is_active, email_verified_at, created_at, updated_at
 		 FROM users
 		 WHERE id = $1`,
-		id).Scan(&user.ID, &user.BusinessID, &user.FirstName, &user.LastName, &user.Email,
-		&user.Phone, &user.Password, &user.Role, &user.IsActive, &user.CreatedAt, &user.UpdatedAt)
+		id).Scan(&user.ID, &user.BusinessID, &user.StaffID, &user.FirstName, &user.LastName, &user.Email,
+		&user.Phone, &user.Password, &user.Role, &user.RoleID, &user.IsActive, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -46,12 +46,26 @@ func (r *userRepository) GetById(ctx context.Context, id string) (*domain.User,
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
-	err := r.db.QueryRow(ctx,
-		`SELECT id, business_id, first_name, last_name, email, phone, password, role, is_active, created_at, updated_at
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT id, business_id, COALESCE(staff_id::text, ''), first_name, last_name, email, phone, password, role, COALESCE(role_id::text, ''), is_active, email_verified_at, created_at, updated_at
 		 FROM users
 		 WHERE email = $1`,
-		email).Scan(&user.ID, &user.BusinessID, &user.FirstName, &user.LastName, &user.Email,
-		&user.Phone, &user.Password, &user.Role, &user.IsActive, &user.CreatedAt, &user.UpdatedAt)
+		email).Scan(&user.ID, &user.BusinessID, &user.StaffID, &user.FirstName, &user.LastName, &user.Email,
+		&user.Phone, &user.Password, &user.Role, &user.RoleID, &user.IsActive, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) GetByStaffID(ctx context.Context, staffID string) (*domain.User, error) {
+	var user domain.User
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT id, business_id, COALESCE(staff_id::text, ''), first_name, last_name, email, phone, password, role, COALESCE(role_id::text, ''), is_active, email_verified_at, created_at, updated_at
+		 FROM users
+		 WHERE staff_id = $1`,
+		staffID).Scan(&user.ID, &user.BusinessID, &user.StaffID, &user.FirstName, &user.LastName, &user.Email,
+		&user.Phone, &user.Password, &user.Role, &user.RoleID, &user.IsActive, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -59,8 +73,8 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.
 }
 
 func (r *userRepository) GetByBusinessID(ctx context.Context, businessID string) ([]*domain.User, error) {
-	rows, err := r.db.Query(ctx,
-		`SELECT id, business_id, first_name, last_name, email, phone, password, role, is_active, created_at, updated_at
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, business_id, COALESCE(staff_id::text, ''), first_name, last_name, email, phone, password, role, COALESCE(role_id::text, ''), is_active, email_verified_at, created_at, updated_at
 		 FROM users
 		 WHERE business_id = $1
 		 ORDER BY created_at DESC`,
@@ -73,8 +87,8 @@ func (r *userRepository) GetByBusinessID(ctx context.Context, businessID string)
 	var users []*domain.User
 	for rows.Next() {
 		var user domain.User
-		err := rows.Scan(&user.ID, &user.BusinessID, &user.FirstName, &user.LastName, &user.Email,
-			&user.Phone, &user.Password, &user.Role, &user.IsActive, &user.CreatedAt, &user.UpdatedAt)
+		err := rows.Scan(&user.ID, &user.BusinessID, &user.StaffID, &user.FirstName, &user.LastName, &user.Email,
+			&user.Phone, &user.Password, &user.Role, &user.RoleID, &user.IsActive, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -86,15 +100,32 @@ func (r *userRepository) GetByBusinessID(ctx context.Context, businessID string)
 func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
 	user.UpdatedAt = time.Now()
 
-	_, err := r.db.Exec(ctx,
+	_, err := conn(ctx, r.db).Exec(ctx,
 		`UPDATE users 
-		 SET first_name = $2, last_name = $3, email = $4, phone = $5, role = $6, is_active = $7, updated_at = $8
+		 SET first_name = $2, last_name = $3, email = $4, phone = $5, role = $6, is_active = $7, updated_at = $8,
+		     staff_id = NULLIF($9, '')::uuid
 		 WHERE id = $1`,
-		user.ID, user.FirstName, user.LastName, user.Email, user.Phone, user.Role, user.IsActive, user.UpdatedAt)
+		user.ID, user.FirstName, user.LastName, user.Email, user.Phone, user.Role, user.IsActive, user.UpdatedAt,
+		user.StaffID)
+	return err
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id string, passwordHash string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE users SET password = $2, updated_at = $3 WHERE id = $1`,
+		id, passwordHash, time.Now())
+	return err
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE users SET email_verified_at = $2, updated_at = $2
+		 WHERE id = $1 AND email_verified_at IS NULL`,
+		id, time.Now())
 	return err
 }
 
 func (r *userRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.db.Exec(ctx, "DELETE FROM users WHERE id = $1", id)
+	_, err := conn(ctx, r.db).Exec(ctx, "DELETE FROM users WHERE id = $1", id)
 	return err
 }
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type userTokenRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUserTokenRepository(db *pgxpool.Pool) domain.UserTokenRepository {
+	return &userTokenRepository{
+		db: db,
+	}
+}
+
+func (r *userTokenRepository) Create(ctx context.Context, token *domain.UserToken) error {
+	token.CreatedAt = time.Now()
+
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Only the most recently sent token stays valid
+	_, err = tx.Exec(ctx,
+		`UPDATE user_tokens SET used_at = $3
+		 WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`,
+		token.UserID, token.Purpose, token.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id`,
+		token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt, token.CreatedAt,
+	).Scan(&token.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *userTokenRepository) GetByTokenHash(ctx context.Context, purpose domain.TokenPurpose, tokenHash string) (*domain.UserToken, error) {
+	var t domain.UserToken
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT id, user_id, purpose, token_hash, expires_at, used_at, created_at
+		 FROM user_tokens
+		 WHERE purpose = $1 AND token_hash = $2`,
+		purpose, tokenHash).Scan(&t.ID, &t.UserID, &t.Purpose, &t.TokenHash, &t.ExpiresAt, &t.UsedAt, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *userTokenRepository) Consume(ctx context.Context, id string) error {
+	var consumedID string
+	return conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE user_tokens SET used_at = $2
+		 WHERE id = $1 AND used_at IS NULL
+		 RETURNING id`,
+		id, time.Now()).Scan(&consumedID)
+}
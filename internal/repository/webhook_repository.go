@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type webhookRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhookRepository(db *pgxpool.Pool) domain.WebhookRepository {
+	return &webhookRepository{
+		db: db,
+	}
+}
+
+const webhookEndpointColumns = `id, business_id, url, description, event_types, secret, is_active, created_at, updated_at`
+
+func scanWebhookEndpoint(row pgx.Row) (*domain.WebhookEndpoint, error) {
+	var e domain.WebhookEndpoint
+	var eventTypes []string
+	err := row.Scan(&e.ID, &e.BusinessID, &e.URL, &e.Description, &eventTypes, &e.Secret, &e.IsActive,
+		&e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range eventTypes {
+		e.EventTypes = append(e.EventTypes, domain.EventType(t))
+	}
+	return &e, nil
+}
+
+func eventTypesToStrings(eventTypes []domain.EventType) []string {
+	out := make([]string, 0, len(eventTypes))
+	for _, t := range eventTypes {
+		out = append(out, string(t))
+	}
+	return out
+}
+
+const webhookDeliveryColumns = `id, endpoint_id, COALESCE(event_id::text, ''), event_type, payload, status, attempts,
+	next_attempt_at, last_status_code, last_error, last_attempt_at, delivered_at, created_at`
+
+func scanWebhookDelivery(row pgx.Row) (*domain.WebhookDelivery, error) {
+	var d domain.WebhookDelivery
+	err := row.Scan(&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.LastAttemptAt, &d.DeliveredAt, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *webhookRepository) CreateEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) error {
+	endpoint.CreatedAt = time.Now()
+	endpoint.UpdatedAt = endpoint.CreatedAt
+
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO webhook_endpoints (business_id, url, description, event_types, secret, is_active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id`,
+		endpoint.BusinessID, endpoint.URL, endpoint.Description, eventTypesToStrings(endpoint.EventTypes),
+		endpoint.Secret, endpoint.IsActive, endpoint.CreatedAt, endpoint.UpdatedAt,
+	).Scan(&endpoint.ID)
+}
+
+func (r *webhookRepository) GetEndpoint(ctx context.Context, id string) (*domain.WebhookEndpoint, error) {
+	return scanWebhookEndpoint(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+webhookEndpointColumns+` FROM webhook_endpoints WHERE id = $1`, id))
+}
+
+func (r *webhookRepository) ListEndpoints(ctx context.Context, businessID string) ([]*domain.WebhookEndpoint, error) {
+	return r.queryEndpoints(ctx,
+		`SELECT `+webhookEndpointColumns+` FROM webhook_endpoints WHERE business_id = $1 ORDER BY created_at`,
+		businessID)
+}
+
+func (r *webhookRepository) ListSubscribedEndpoints(ctx context.Context, businessID string, eventType domain.EventType) ([]*domain.WebhookEndpoint, error) {
+	return r.queryEndpoints(ctx,
+		`SELECT `+webhookEndpointColumns+` FROM webhook_endpoints
+		 WHERE business_id = $1 AND is_active AND $2 = ANY(event_types)
+		 ORDER BY created_at`,
+		businessID, string(eventType))
+}
+
+func (r *webhookRepository) queryEndpoints(ctx context.Context, query string, args ...any) ([]*domain.WebhookEndpoint, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var endpoints []*domain.WebhookEndpoint
+	for rows.Next() {
+		endpoint, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	return endpoints, rows.Err()
+}
+
+func (r *webhookRepository) UpdateEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) error {
+	endpoint.UpdatedAt = time.Now()
+
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE webhook_endpoints
+		 SET url = $2, description = $3, event_types = $4, secret = $5, is_active = $6, updated_at = $7
+		 WHERE id = $1`,
+		endpoint.ID, endpoint.URL, endpoint.Description, eventTypesToStrings(endpoint.EventTypes),
+		endpoint.Secret, endpoint.IsActive, endpoint.UpdatedAt)
+	return err
+}
+
+func (r *webhookRepository) DeleteEndpoint(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM webhook_endpoints WHERE id = $1`, id)
+	return err
+}
+
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	delivery.Status = domain.WebhookDeliveryPending
+	delivery.CreatedAt = time.Now()
+	if delivery.NextAttemptAt.IsZero() {
+		delivery.NextAttemptAt = delivery.CreatedAt
+	}
+
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+		 VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, $6, $7)
+		 ON CONFLICT (endpoint_id, event_id) DO NOTHING
+		 RETURNING id`,
+		delivery.EndpointID, delivery.EventID, delivery.EventType, delivery.Payload, delivery.Status,
+		delivery.NextAttemptAt, delivery.CreatedAt,
+	).Scan(&delivery.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	return scanWebhookDelivery(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id))
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, endpointID string, limit int) ([]*domain.WebhookDelivery, error) {
+	return r.queryDeliveries(ctx,
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		 WHERE endpoint_id = $1
+		 ORDER BY created_at DESC
+		 LIMIT $2`,
+		endpointID, limit)
+}
+
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	now := time.Now()
+	return r.queryDeliveries(ctx,
+		`UPDATE webhook_deliveries SET next_attempt_at = $3
+		 WHERE id IN (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhook_endpoints e ON e.id = d.endpoint_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= $1 AND e.is_active
+			ORDER BY d.next_attempt_at
+			LIMIT $2
+			FOR UPDATE OF d SKIP LOCKED
+		 )
+		 RETURNING `+webhookDeliveryColumns,
+		now, limit, now.Add(lease))
+}
+
+func (r *webhookRepository) queryDeliveries(ctx context.Context, query string, args ...any) ([]*domain.WebhookDelivery, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *webhookRepository) RecordAttempt(ctx context.Context, delivery *domain.WebhookDelivery, attempt *domain.WebhookDeliveryAttempt) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	attempt.DeliveryID = delivery.ID
+	err = tx.QueryRow(ctx,
+		`INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, response_body, duration_ms, attempted_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id`,
+		attempt.DeliveryID, attempt.Attempt, attempt.StatusCode, attempt.Error, attempt.ResponseBody,
+		attempt.DurationMs, attempt.AttemptedAt,
+	).Scan(&attempt.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE webhook_deliveries
+		 SET status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5, last_error = $6,
+			last_attempt_at = $7, delivered_at = $8
+		 WHERE id = $1`,
+		delivery.ID, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastStatusCode,
+		delivery.LastError, delivery.LastAttemptAt, delivery.DeliveredAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *webhookRepository) ListAttempts(ctx context.Context, deliveryID string) ([]*domain.WebhookDeliveryAttempt, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, delivery_id, attempt, status_code, error, response_body, duration_ms, attempted_at
+		 FROM webhook_delivery_attempts
+		 WHERE delivery_id = $1
+		 ORDER BY attempt`,
+		deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []*domain.WebhookDeliveryAttempt
+	for rows.Next() {
+		var a domain.WebhookDeliveryAttempt
+		err := rows.Scan(&a.ID, &a.DeliveryID, &a.Attempt, &a.StatusCode, &a.Error, &a.ResponseBody,
+			&a.DurationMs, &a.AttemptedAt)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, &a)
+	}
+
+	return attempts, rows.Err()
+}
+
+func (r *webhookRepository) Redeliver(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE webhook_deliveries SET status = 'pending', next_attempt_at = $2 WHERE id = $1`,
+		id, time.Now())
+	return err
+}
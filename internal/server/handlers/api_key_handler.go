@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/internal/server/middleware"
+	"github.com/ialekseychuk/my-place/internal/usecase"
+	"github.com/ialekseychuk/my-place/pkg/validate"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *usecase.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *usecase.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+func (h *APIKeyHandler) Routes(ownership func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+	r.Post("/", h.CreateAPIKey)
+	r.Get("/", h.GetAPIKeys)
+
+	owned := r.With(ownership)
+	owned.Delete("/{apiKeyID}", h.RevokeAPIKey)
+	return r
+}
+
+// @Summary Create API key
+// @Description Create a business API key for server-to-server calls. The key is returned only once; send it as "Authorization: ApiKey <key>"
+// @Tags API Keys
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param key body dto.CreateAPIKeyRequest true "API key data"
+// @Success 201 {object} dto.APIKeyResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	var req dto.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	user := middleware.GetUserFromContext(r.Context())
+	key, err := h.apiKeyService.CreateKey(r.Context(), businessID, user.ID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrUnknownPermission), errors.Is(err, usecase.ErrAPIKeyScopeDenied),
+			errors.Is(err, usecase.ErrAPIKeyExpiryInPast):
+			ErrorResponse(w, http.StatusBadRequest, err.Error())
+		default:
+			ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(key); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get API keys
+// @Description Get all API keys of a business, without their secrets
+// @Tags API Keys
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Success 200 {array} dto.APIKeyResponse
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/api-keys [get]
+func (h *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	keys, err := h.apiKeyService.ListKeys(r.Context(), businessID)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(keys); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Revoke API key
+// @Description Revoke an API key; requests using it are rejected immediately
+// @Tags API Keys
+// @Param businessID path string true "Business ID"
+// @Param apiKeyID path string true "API key ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} dto.ErrorResponse "API key not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/api-keys/{apiKeyID} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if err := h.apiKeyService.RevokeKey(r.Context(), chi.URLParam(r, "apiKeyID")); err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,34 +2,61 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/domain"
 	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/internal/server/middleware"
 	"github.com/ialekseychuk/my-place/internal/usecase"
 	"github.com/ialekseychuk/my-place/pkg/validate"
 )
 
 type AuthHandler struct {
-	authService *usecase.AuthService
+	authService    *usecase.AuthService
+	accountService *usecase.AccountService
+	mfaService     *usecase.MFAService
 }
 
-func NewAuthHandler(authService *usecase.AuthService) *AuthHandler {
+func NewAuthHandler(authService *usecase.AuthService, accountService *usecase.AccountService, mfaService *usecase.MFAService) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
+		authService:    authService,
+		accountService: accountService,
+		mfaService:     mfaService,
 	}
 }
 
-func (h *AuthHandler) Routes() chi.Router {
+func (h *AuthHandler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Router {
 	r := chi.NewRouter()
 	r.Post("/login", h.Login)
+	r.Post("/login/mfa", h.LoginMFA)
 	r.Post("/refresh", h.RefreshToken)
-	r.Post("/logout", h.Logout)
+	r.Post("/verify-email", h.VerifyEmail)
+	r.Post("/forgot-password", h.ForgotPassword)
+	r.Post("/reset-password", h.ResetPassword)
+
+	r.Group(func(protected chi.Router) {
+		protected.Use(authMiddleware)
+		protected.Post("/logout", h.Logout)
+		protected.Get("/sessions", h.GetSessions)
+		protected.Delete("/sessions/{sessionID}", h.RevokeSession)
+		protected.Post("/verify-email/resend", h.ResendEmailVerification)
+		protected.Post("/change-password", h.ChangePassword)
+
+		protected.Get("/mfa", h.GetMFAStatus)
+		protected.Post("/mfa/totp/setup", h.SetupTOTP)
+		protected.Post("/mfa/totp/confirm", h.ConfirmTOTP)
+		protected.Post("/mfa/totp/disable", h.DisableTOTP)
+		protected.Post("/mfa/recovery-codes", h.RegenerateRecoveryCodes)
+	})
 	return r
 }
 
 // @Summary User login
-// @Description Authenticate user with email and password
+// @Description Authenticate user with email and password. Users with two-factor authentication get mfa_required and a challenge_token instead of tokens
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -38,6 +65,7 @@ func (h *AuthHandler) Routes() chi.Router {
 // @Failure 400 {object} dto.ErrorResponse "Bad request"
 // @Failure 401 {object} dto.ErrorResponse "Invalid credentials"
 // @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 429 {object} dto.ErrorResponse "Too many failed attempts, see Retry-After"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
 // @Router /api/v1/auth/login [post]
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
@@ -52,9 +80,9 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.authService.Login(r.Context(), req)
+	resp, err := h.authService.Login(r.Context(), req, clientInfo(r))
 	if err != nil {
-		ErrorResponse(w, http.StatusUnauthorized, err.Error())
+		loginErrorResponse(w, err)
 		return
 	}
 
@@ -65,6 +93,43 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// @Summary Complete two-factor login
+// @Description Exchange the challenge token of a login and a TOTP or recovery code for the token pair
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param mfa body dto.MFAVerifyRequest true "Challenge token and code"
+// @Success 200 {object} dto.LoginResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 401 {object} dto.ErrorResponse "Invalid challenge or code"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 429 {object} dto.ErrorResponse "Too many failed attempts, see Retry-After"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/auth/login/mfa [post]
+func (h *AuthHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req dto.MFAVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	resp, err := h.authService.VerifyMFA(r.Context(), req, clientInfo(r))
+	if err != nil {
+		loginErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
 // @Summary Refresh access token
 // @Description Get new access token using refresh token
 // @Tags Auth
@@ -89,7 +154,7 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.authService.RefreshToken(r.Context(), req)
+	resp, err := h.authService.RefreshToken(r.Context(), req, clientInfo(r))
 	if err != nil {
 		ErrorResponse(w, http.StatusUnauthorized, err.Error())
 		return
@@ -103,7 +168,7 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary User logout
-// @Description Logout user (invalidate tokens)
+// @Description Logout user (revoke the session of the access token and its refresh tokens)
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -113,15 +178,17 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 // @Security Bearer
 // @Router /api/v1/auth/logout [post]
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	// In a full implementation, you would:
-	// 1. Extract user from context (set by JWT middleware)
-	// 2. Invalidate refresh tokens in database
-	// 3. Add access token to blacklist (if implementing token blacklisting)
+	claims := middleware.GetClaimsFromContext(r.Context())
+	if claims == nil {
+		ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
 
-	// For now, we'll just return a success response
-	// The client should remove tokens from storage
+	if err := h.authService.Logout(r.Context(), claims.SessionID); err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "Failed to logout")
+		return
+	}
 
-	
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(map[string]string{
 		"message": "Successfully logged out",
@@ -131,6 +198,436 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// @Summary List active sessions
+// @Description Get active sessions (logged in devices) of the current user
+// @Tags Auth
+// @Produce json
+// @Success 200 {array} dto.SessionResponse
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/auth/sessions [get]
+func (h *AuthHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	claims := middleware.GetClaimsFromContext(r.Context())
+	if user == nil || claims == nil {
+		ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(r.Context(), user.ID, claims.SessionID)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "Failed to get sessions")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(sessions); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Revoke session
+// @Description Revoke one of the current user's sessions
+// @Tags Auth
+// @Produce json
+// @Param sessionID path string true "Session ID"
+// @Success 204
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Session not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/auth/sessions/{sessionID} [delete]
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	err := h.authService.RevokeSession(r.Context(), user.ID, chi.URLParam(r, "sessionID"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrSessionNotFound) {
+			ErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		ErrorResponse(w, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Verify email
+// @Description Confirm the email address of a user with the token from the verification email
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param token body dto.VerifyEmailRequest true "Verification token"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse "Invalid or expired token"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req dto.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(r.Context(), req); err != nil {
+		if errors.Is(err, usecase.ErrInvalidUserToken) {
+			ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		ErrorResponse(w, http.StatusInternalServerError, "Failed to verify email")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Resend verification email
+// @Description Send a new email verification link to the current user
+// @Tags Auth
+// @Produce json
+// @Success 204
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 409 {object} dto.ErrorResponse "Email already verified"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/auth/verify-email/resend [post]
+func (h *AuthHandler) ResendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := h.accountService.SendEmailVerification(r.Context(), user.ID); err != nil {
+		if errors.Is(err, usecase.ErrEmailAlreadyVerified) {
+			ErrorResponse(w, http.StatusConflict, err.Error())
+			return
+		}
+		ErrorResponse(w, http.StatusInternalServerError, "Failed to send verification email")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Forgot password
+// @Description Send a password reset link. Always succeeds so registered emails can't be probed
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param email body dto.ForgotPasswordRequest true "Account email"
+// @Success 202
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req dto.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	if err := h.accountService.ForgotPassword(r.Context(), req); err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "Failed to send password reset email")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// @Summary Reset password
+// @Description Set a new password with the token from the password reset email. Signs the user out of all sessions
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param reset body dto.ResetPasswordRequest true "Reset token and new password"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse "Invalid or expired token"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req dto.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	if err := h.accountService.ResetPassword(r.Context(), req); err != nil {
+		if errors.Is(err, usecase.ErrInvalidUserToken) {
+			ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		ErrorResponse(w, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Change password
+// @Description Change the password of the current user. All sessions are revoked, so the user has to log in again
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param password body dto.ChangePasswordRequest true "Current and new password"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse "Current password is incorrect"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/auth/change-password [post]
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req dto.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	if err := h.accountService.ChangePassword(r.Context(), user.ID, req); err != nil {
+		if errors.Is(err, usecase.ErrInvalidCurrentPassword) {
+			ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		ErrorResponse(w, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get two-factor status
+// @Description Get whether two-factor authentication is enabled for the current user
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} dto.MFAStatusResponse
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/auth/mfa [get]
+func (h *AuthHandler) GetMFAStatus(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	status, err := h.mfaService.GetStatus(r.Context(), user.ID)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "Failed to get two-factor status")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Start TOTP setup
+// @Description Generate a TOTP secret and otpauth URI for an authenticator app. It becomes active after confirmation
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} dto.TOTPSetupResponse
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 409 {object} dto.ErrorResponse "Already enabled"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/auth/mfa/totp/setup [post]
+func (h *AuthHandler) SetupTOTP(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	setup, err := h.mfaService.SetupTOTP(r.Context(), user)
+	if err != nil {
+		mfaErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(setup); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Confirm TOTP setup
+// @Description Enable two-factor authentication with a code from the authenticator app. Returns the recovery codes once
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param code body dto.ConfirmTOTPRequest true "TOTP code"
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} dto.ErrorResponse "Invalid code or setup not started"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 409 {object} dto.ErrorResponse "Already enabled"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/auth/mfa/totp/confirm [post]
+func (h *AuthHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req dto.ConfirmTOTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	codes, err := h.mfaService.ConfirmTOTP(r.Context(), user.ID, req)
+	if err != nil {
+		mfaErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(codes); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Disable TOTP
+// @Description Turn two-factor authentication off. Requires the password and a TOTP or recovery code
+// @Tags Auth
+// @Accept json
+// @Param disable body dto.DisableTOTPRequest true "Password and code"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse "Invalid password or code"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/auth/mfa/totp/disable [post]
+func (h *AuthHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req dto.DisableTOTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	if err := h.mfaService.DisableTOTP(r.Context(), user.ID, req); err != nil {
+		mfaErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes of the current user. Returns the new codes once
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param code body dto.RegenerateRecoveryCodesRequest true "TOTP code"
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} dto.ErrorResponse "Invalid code"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/auth/mfa/recovery-codes [post]
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req dto.RegenerateRecoveryCodesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(r.Context(), user.ID, req)
+	if err != nil {
+		mfaErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(codes); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary JSON Web Key Set
+// @Description Public keys access tokens are signed with, selected by the kid header of a token
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} jwtkeys.JWKSet
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := json.NewEncoder(w).Encode(h.authService.JWKS()); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
 // @Summary Get current user profile
 // @Description Get current authenticated user's profile
 // @Tags Auth
@@ -153,3 +650,34 @@ func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// loginErrorResponse maps errors of both login steps to responses
+func loginErrorResponse(w http.ResponseWriter, err error) {
+	var throttled *usecase.LoginThrottledError
+	if errors.As(err, &throttled) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		ErrorResponse(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	ErrorResponse(w, http.StatusUnauthorized, err.Error())
+}
+
+func mfaErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrMFAAlreadyEnabled):
+		ErrorResponse(w, http.StatusConflict, err.Error())
+	case errors.Is(err, usecase.ErrInvalidMFACode), errors.Is(err, usecase.ErrMFANotEnabled),
+		errors.Is(err, usecase.ErrMFANotStarted), errors.Is(err, usecase.ErrInvalidCurrentPassword):
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// clientInfo extracts the caller's user agent and IP address from the request
+func clientInfo(r *http.Request) domain.ClientInfo {
+	return domain.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IPAddress: middleware.ClientIP(r),
+	}
+}
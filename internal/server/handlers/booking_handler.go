@@ -2,12 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/domain"
 	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/internal/server/middleware"
 	"github.com/ialekseychuk/my-place/internal/usecase"
 	"github.com/ialekseychuk/my-place/pkg/validate"
 )
@@ -22,11 +26,16 @@ func NewBookingHandler(bookingService *usecase.BookingService) *BookingHandler {
 	}
 }
 
-func (h *BookingHandler) Routes() chi.Router {
+func (h *BookingHandler) Routes(ownership func(http.Handler) http.Handler) chi.Router {
 	r := chi.NewRouter()
 	r.Post("/", h.CreateBooking)
 	r.Get("/", h.GetBookings)
 	r.Get("/availability", h.GetAvailability)
+
+	owned := r.With(ownership)
+	owned.Post("/{bookingID}/cancel", h.CancelBooking)
+	owned.Post("/{bookingID}/reschedule", h.RescheduleBooking)
+	owned.Post("/{bookingID}/no-show", h.MarkNoShow)
 	return r
 }
 
@@ -42,7 +51,7 @@ func (h *BookingHandler) Routes() chi.Router {
 // @Failure 401 {object} dto.ErrorResponse "Unauthorized"
 // @Failure 403 {object} dto.ErrorResponse "Forbidden"
 // @Failure 404 {object} dto.ErrorResponse "Service or staff not found"
-// @Failure 409 {object} dto.ErrorResponse "Time slot conflict"
+// @Failure 409 {object} dto.ErrorResponse "Time slot conflict or service inactive"
 // @Failure 422 {object} map[string]string "Validation errors"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
 // @Security Bearer
@@ -70,7 +79,7 @@ func (h *BookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.bookingService.CreateBooking(r.Context(), businessID, &req)
+	_, err := h.bookingService.CreateBooking(r.Context(), businessID, &req)
 	if err != nil {
 
 		switch {
@@ -78,8 +87,10 @@ func (h *BookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 			ErrorResponse(w, http.StatusNotFound, err.Error())
 		case err.Error() == "service does not belong to this business" || err.Error() == "staff does not belong to this business":
 			ErrorResponse(w, http.StatusForbidden, err.Error())
-		case err.Error() == "time slot is not available":
+		case err.Error() == "time slot is not available", errors.Is(err, usecase.ErrServiceInactive):
 			ErrorResponse(w, http.StatusConflict, err.Error())
+		case errors.Is(err, usecase.ErrInvalidPhone):
+			ErrorResponse(w, http.StatusBadRequest, err.Error())
 		default:
 			ErrorResponse(w, http.StatusInternalServerError, "internal server error")
 		}
@@ -90,7 +101,7 @@ func (h *BookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Get bookings
-// @Description Get all bookings for a business with optional date filtering
+// @Description Get all bookings for a business with optional date filtering. Staff users only get their own bookings
 // @Tags Booking
 // @Accept json
 // @Produce json
@@ -135,7 +146,12 @@ func (h *BookingHandler) GetBookings(w http.ResponseWriter, r *http.Request) {
 		endDate = &ed
 	}
 
-	bookings, err := h.bookingService.GetBookingsByBusiness(r.Context(), businessID, startDate, endDate)
+	staffID, ok := bookingStaffScope(w, r)
+	if !ok {
+		return
+	}
+
+	bookings, err := h.bookingService.GetBookingsByBusiness(r.Context(), businessID, staffID, startDate, endDate)
 	if err != nil {
 		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
 		return
@@ -197,4 +213,148 @@ func (h *BookingHandler) GetAvailability(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
-}
\ No newline at end of file
+}
+
+// @Summary Cancel a booking
+// @Description Cancels a booking on behalf of the business. The client is notified when notifications are enabled
+// @Tags Booking
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param bookingID path string true "Booking ID"
+// @Success 200 {object} dto.BookingResponse
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "Forbidden"
+// @Failure 404 {object} dto.ErrorResponse "Booking not found"
+// @Failure 409 {object} dto.ErrorResponse "Booking already cancelled"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/bookings/{bookingID}/cancel [post]
+func (h *BookingHandler) CancelBooking(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := bookingStaffScope(w, r)
+	if !ok {
+		return
+	}
+
+	booking, err := h.bookingService.CancelBooking(r.Context(), chi.URLParam(r, "businessID"), staffID, chi.URLParam(r, "bookingID"))
+	if err != nil {
+		bookingErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(booking); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Reschedule a booking
+// @Description Moves a booking to another start time, and to another staff member when one is given. The client is notified when notifications are enabled
+// @Tags Booking
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param bookingID path string true "Booking ID"
+// @Param booking body dto.RescheduleBookingRequest true "New start time and staff member"
+// @Success 200 {object} dto.BookingResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "Forbidden"
+// @Failure 404 {object} dto.ErrorResponse "Booking not found"
+// @Failure 409 {object} dto.ErrorResponse "Booking cancelled or time slot not available"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/bookings/{bookingID}/reschedule [post]
+func (h *BookingHandler) RescheduleBooking(w http.ResponseWriter, r *http.Request) {
+	var req dto.RescheduleBookingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	staffID, ok := bookingStaffScope(w, r)
+	if !ok {
+		return
+	}
+
+	booking, err := h.bookingService.RescheduleBooking(r.Context(), chi.URLParam(r, "businessID"), staffID, chi.URLParam(r, "bookingID"), &req)
+	if err != nil {
+		bookingErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(booking); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Mark a booking as no-show
+// @Description Records that the client didn't turn up for a confirmed booking that already started
+// @Tags Booking
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param bookingID path string true "Booking ID"
+// @Success 200 {object} dto.BookingResponse
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "Forbidden"
+// @Failure 404 {object} dto.ErrorResponse "Booking not found"
+// @Failure 409 {object} dto.ErrorResponse "Booking not confirmed or not started yet"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/bookings/{bookingID}/no-show [post]
+func (h *BookingHandler) MarkNoShow(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := bookingStaffScope(w, r)
+	if !ok {
+		return
+	}
+
+	booking, err := h.bookingService.MarkNoShow(r.Context(), chi.URLParam(r, "businessID"), staffID, chi.URLParam(r, "bookingID"))
+	if err != nil {
+		bookingErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(booking); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// bookingStaffScope returns the staff member whose bookings the user is
+// limited to, empty for users with bookings:read_all. It writes 403 and
+// returns false for users limited to their own bookings without a staff
+// member.
+func bookingStaffScope(w http.ResponseWriter, r *http.Request) (string, bool) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil || middleware.HasPermission(r.Context(), domain.PermBookingsReadAll) {
+		return "", true
+	}
+	if user.StaffID == "" {
+		ErrorResponse(w, http.StatusForbidden, "user is not linked to a staff member")
+		return "", false
+	}
+	return user.StaffID, true
+}
+
+// bookingErrorResponse maps errors of managing a booking to HTTP responses
+func bookingErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrBookingNotFound):
+		ErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, usecase.ErrBookingAlreadyCancelled), errors.Is(err, usecase.ErrTimeSlotUnavailable),
+		errors.Is(err, usecase.ErrBookingNotConfirmed), errors.Is(err, usecase.ErrBookingNotStarted):
+		ErrorResponse(w, http.StatusConflict, err.Error())
+	case strings.HasPrefix(err.Error(), "staff not found"):
+		ErrorResponse(w, http.StatusNotFound, "staff not found")
+	case err.Error() == "staff does not belong to this business", errors.Is(err, usecase.ErrBookingStaffForbidden):
+		ErrorResponse(w, http.StatusForbidden, err.Error())
+	default:
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/ialekseychuk/my-place/internal/dto"
@@ -11,12 +12,14 @@ import (
 )
 
 type BusinessHandler struct {
-	uc *usecase.BusinessUseCase
+	uc             *usecase.BusinessUseCase
+	accountService *usecase.AccountService
 }
 
-func NewBusinessHandler(uc *usecase.BusinessUseCase) *BusinessHandler {
+func NewBusinessHandler(uc *usecase.BusinessUseCase, accountService *usecase.AccountService) *BusinessHandler {
 	return &BusinessHandler{
-		uc: uc,
+		uc:             uc,
+		accountService: accountService,
 	}
 }
 
@@ -52,7 +55,7 @@ func (h *BusinessHandler) CreateBusiness(w http.ResponseWriter, r *http.Request)
 		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
 		return
 	}
-	b, err := h.uc.CreateBusiness(r.Context(), req.BusinessName, req.Timezone)
+	b, err := h.uc.CreateBusiness(r.Context(), req.BusinessName, req.Timezone, req.Country)
 	if err != nil {
 		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
 
@@ -91,6 +94,10 @@ func (h *BusinessHandler) RegisterBusiness(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Registration succeeds even if the mail can't be sent, the owner can
+	// request a new verification email after logging in
+	_ = h.accountService.SendEmailVerification(r.Context(), resp.UserID)
+
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(resp)
 }
@@ -119,3 +126,42 @@ func (h *BusinessHandler) GetBusiness(w http.ResponseWriter, r *http.Request) {
 
 	json.NewEncoder(w).Encode(b)
 }
+
+// @Summary Set business country
+// @Description Set the country client phone numbers entered without a country code are read in
+// @Tags Business
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param country body dto.UpdateBusinessCountryRequest true "ISO 3166-1 alpha-2 country code"
+// @Success 200 {object} domain.Business
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "Forbidden"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/country [put]
+func (h *BusinessHandler) SetCountry(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateBusinessCountryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	b, err := h.uc.SetCountry(r.Context(), chi.URLParam(r, "businessID"), strings.ToUpper(req.Country))
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(b); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/internal/usecase"
+	"github.com/ialekseychuk/my-place/pkg/validate"
+)
+
+type CalendarFeedHandler struct {
+	calendarFeedService *usecase.CalendarFeedService
+}
+
+func NewCalendarFeedHandler(calendarFeedService *usecase.CalendarFeedService) *CalendarFeedHandler {
+	return &CalendarFeedHandler{
+		calendarFeedService: calendarFeedService,
+	}
+}
+
+func (h *CalendarFeedHandler) Routes(ownership func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+	r.Post("/", h.CreateCalendarFeed)
+	r.Get("/", h.GetCalendarFeeds)
+
+	owned := r.With(ownership)
+	owned.Delete("/{feedID}", h.RevokeCalendarFeed)
+	return r
+}
+
+// @Summary Create calendar feed
+// @Description Publish the bookings and shifts of a staff member or a location as an iCalendar feed. The feed URL contains its secret token and is returned only once; creating a feed again revokes the previous URL. Without bookings:read_all only the own calendar can be published
+// @Tags Calendar Feeds
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param feed body dto.CreateCalendarFeedRequest true "Staff member or location"
+// @Success 201 {object} dto.CalendarFeedResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 403 {object} dto.ErrorResponse "Calendar of another staff member or a location"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/calendar-feeds [post]
+func (h *CalendarFeedHandler) CreateCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	var req dto.CreateCalendarFeedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	staffID, ok := bookingStaffScope(w, r)
+	if !ok {
+		return
+	}
+
+	feed, err := h.calendarFeedService.CreateFeed(r.Context(), businessID, staffID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrCalendarFeedOwner):
+			ErrorResponse(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, usecase.ErrCalendarFeedForbidden):
+			ErrorResponse(w, http.StatusForbidden, err.Error())
+		default:
+			ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(feed); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get calendar feeds
+// @Description Get the active calendar feeds of a business, without their URLs. Without bookings:read_all only the own feed is listed
+// @Tags Calendar Feeds
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Success 200 {array} dto.CalendarFeedResponse
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/calendar-feeds [get]
+func (h *CalendarFeedHandler) GetCalendarFeeds(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	staffID, ok := bookingStaffScope(w, r)
+	if !ok {
+		return
+	}
+
+	feeds, err := h.calendarFeedService.ListFeeds(r.Context(), businessID, staffID)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(feeds); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Revoke calendar feed
+// @Description Revoke a calendar feed; its URL stops working immediately
+// @Tags Calendar Feeds
+// @Param businessID path string true "Business ID"
+// @Param feedID path string true "Calendar feed ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} dto.ErrorResponse "Calendar feed not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/calendar-feeds/{feedID} [delete]
+func (h *CalendarFeedHandler) RevokeCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := bookingStaffScope(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.calendarFeedService.RevokeFeed(r.Context(), staffID, chi.URLParam(r, "feedID")); err != nil {
+		if errors.Is(err, usecase.ErrCalendarFeedNotFound) {
+			ErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Calendar feed
+// @Description iCalendar (RFC 5545) feed of bookings and shifts for calendar apps, authenticated by the token in the URL. Supports conditional requests with If-None-Match
+// @Tags Calendar Feeds
+// @Produce text/calendar
+// @Param token path string true "Feed token"
+// @Success 200 {string} string "iCalendar data"
+// @Success 304 "Not Modified"
+// @Failure 404 {object} dto.ErrorResponse "Calendar feed not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/calendar/{token}.ics [get]
+func (h *CalendarFeedHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	// Calendar apps poll often, answer unchanged feeds before rendering them
+	etag, err := h.calendarFeedService.FeedETag(r.Context(), token)
+	if err != nil {
+		calendarFeedErrorResponse(w, err)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, err := h.calendarFeedService.Feed(r.Context(), token)
+	if err != nil {
+		calendarFeedErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="calendar.ics"`)
+	w.Write(body)
+}
+
+// calendarFeedErrorResponse maps errors of serving a feed to HTTP responses
+func calendarFeedErrorResponse(w http.ResponseWriter, err error) {
+	if errors.Is(err, usecase.ErrCalendarFeedNotFound) {
+		ErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag,
+// comparing weakly as RFC 9110 requires for it
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
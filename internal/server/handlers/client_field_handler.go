@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/internal/server/middleware"
+	"github.com/ialekseychuk/my-place/internal/usecase"
+	"github.com/ialekseychuk/my-place/pkg/validate"
+)
+
+type ClientFieldHandler struct {
+	clientFieldService *usecase.ClientFieldService
+}
+
+func NewClientFieldHandler(clientFieldService *usecase.ClientFieldService) *ClientFieldHandler {
+	return &ClientFieldHandler{
+		clientFieldService: clientFieldService,
+	}
+}
+
+func (h *ClientFieldHandler) Routes(ownership func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.GetFields)
+
+	// Staff limited to their own bookings can't define custom fields
+	all := r.With(middleware.RequirePermission(domain.PermBookingsReadAll))
+	all.Post("/", h.CreateField)
+
+	owned := all.With(ownership)
+	owned.Patch("/{fieldID}", h.UpdateField)
+	owned.Delete("/{fieldID}", h.DeleteField)
+	return r
+}
+
+// @Summary Get client custom fields of business
+// @Description Get the custom fields a business defined for its clients, ordered by position
+// @Tags Clients
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Success 200 {array} dto.ClientFieldResponse
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-fields [get]
+func (h *ClientFieldHandler) GetFields(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	fields, err := h.clientFieldService.ListFields(r.Context(), businessID)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(fields); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Create client custom field
+// @Description Define a typed custom field for clients of the business. Select fields need options
+// @Tags Clients
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param field body dto.CreateClientFieldRequest true "Field data"
+// @Success 201 {object} dto.ClientFieldResponse
+// @Failure 400 {object} dto.ErrorResponse "Invalid key or options"
+// @Failure 409 {object} dto.ErrorResponse "Key already taken"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-fields [post]
+func (h *ClientFieldHandler) CreateField(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	var req dto.CreateClientFieldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	field, err := h.clientFieldService.CreateField(r.Context(), businessID, req)
+	if err != nil {
+		clientFieldErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(field); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update client custom field
+// @Description Change label, options or position of a custom field; key and type are fixed
+// @Tags Clients
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param fieldID path string true "Field ID"
+// @Param field body dto.UpdateClientFieldRequest true "Field data"
+// @Success 200 {object} dto.ClientFieldResponse
+// @Failure 400 {object} dto.ErrorResponse "Invalid options"
+// @Failure 404 {object} dto.ErrorResponse "Field not found"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-fields/{fieldID} [patch]
+func (h *ClientFieldHandler) UpdateField(w http.ResponseWriter, r *http.Request) {
+	fieldID := chi.URLParam(r, "fieldID")
+
+	var req dto.UpdateClientFieldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	field, err := h.clientFieldService.UpdateField(r.Context(), fieldID, req)
+	if err != nil {
+		clientFieldErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(field); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Delete client custom field
+// @Description Delete a custom field together with the values stored for it
+// @Tags Clients
+// @Param businessID path string true "Business ID"
+// @Param fieldID path string true "Field ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} dto.ErrorResponse "Field not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-fields/{fieldID} [delete]
+func (h *ClientFieldHandler) DeleteField(w http.ResponseWriter, r *http.Request) {
+	fieldID := chi.URLParam(r, "fieldID")
+
+	if err := h.clientFieldService.DeleteField(r.Context(), fieldID); err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func clientFieldErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrClientFieldNotFound):
+		ErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, usecase.ErrClientFieldKeyTaken):
+		ErrorResponse(w, http.StatusConflict, err.Error())
+	case errors.Is(err, usecase.ErrInvalidClientFieldKey), errors.Is(err, usecase.ErrClientFieldOptions):
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+	}
+}
@@ -2,33 +2,114 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/internal/server/middleware"
 	"github.com/ialekseychuk/my-place/internal/usecase"
-	_ "github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/pkg/validate"
 )
 
+// clientImportMaxBytes caps the size of an uploaded import file
+const clientImportMaxBytes = 10 << 20
 
 type ClientHandler struct {
-	clientService *usecase.ClientService
+	clientService      *usecase.ClientService
+	clientMergeService *usecase.ClientMergeService
+	clientNoteService  *usecase.ClientNoteService
+	clientTagService   *usecase.ClientTagService
+	clientFieldService   *usecase.ClientFieldService
+	clientPrivacyService *usecase.ClientPrivacyService
+	clientJobService     *usecase.ClientJobService
 }
 
-func NewClientHandler(clientService *usecase.ClientService) *ClientHandler {
+func NewClientHandler(clientService *usecase.ClientService, clientMergeService *usecase.ClientMergeService,
+	clientNoteService *usecase.ClientNoteService, clientTagService *usecase.ClientTagService,
+	clientFieldService *usecase.ClientFieldService, clientPrivacyService *usecase.ClientPrivacyService,
+	clientJobService *usecase.ClientJobService) *ClientHandler {
 	return &ClientHandler{
-		clientService: clientService,
+		clientService:        clientService,
+		clientMergeService:   clientMergeService,
+		clientNoteService:    clientNoteService,
+		clientTagService:     clientTagService,
+		clientFieldService:   clientFieldService,
+		clientPrivacyService: clientPrivacyService,
+		clientJobService:     clientJobService,
 	}
 }
 
-func (h *ClientHandler) Routes() chi.Router {
+func (h *ClientHandler) Routes(ownership func(http.Handler) http.Handler) chi.Router {
 	r := chi.NewRouter()
 
+	// Users limited to their own bookings see the clients they have bookings
+	// with and keep notes on them; everything else needs bookings:read_all
+	all := r.With(middleware.RequirePermission(domain.PermBookingsReadAll))
+
 	r.Get("/", h.GetClients)
+	all.Post("/", h.CreateClient)
+	all.Get("/duplicates", h.GetDuplicateClients)
+	r.Get("/search", h.SearchClients)
+	all.Post("/import", h.ImportClients)
+	all.With(middleware.RequirePermission(domain.PermClientsExport)).Post("/export", h.ExportClients)
+
+	owned := r.With(ownership, h.staffClient)
+	ownedAll := all.With(ownership)
+	owned.Get("/{clientID}", h.GetClient)
+	ownedAll.Patch("/{clientID}", h.UpdateClient)
+	ownedAll.Delete("/{clientID}", h.DeleteClient)
+	owned.Get("/{clientID}/profile", h.GetClientProfile)
+	ownedAll.Post("/{clientID}/merge", h.MergeClients)
+	owned.Get("/{clientID}/merges", h.GetClientMerges)
+	owned.Get("/{clientID}/notes", h.GetClientNotes)
+	owned.Post("/{clientID}/notes", h.CreateClientNote)
+	owned.Patch("/{clientID}/notes/{noteID}", h.UpdateClientNote)
+	owned.Delete("/{clientID}/notes/{noteID}", h.DeleteClientNote)
+	owned.Get("/{clientID}/tags", h.GetClientTags)
+	ownedAll.Put("/{clientID}/tags", h.SetClientTags)
+	owned.Get("/{clientID}/custom-fields", h.GetClientFieldValues)
+	ownedAll.Put("/{clientID}/custom-fields", h.SetClientFieldValues)
+	ownedAll.With(middleware.RequirePermission(domain.PermClientsExport)).Get("/{clientID}/export", h.ExportClientData)
+	ownedAll.Post("/{clientID}/erase", h.EraseClient)
+	owned.Get("/{clientID}/consents", h.GetClientConsents)
+	ownedAll.Put("/{clientID}/consents", h.UpdateClientConsents)
 
 	return r
 }
 
+// staffClient middleware answers 404 for clients without bookings with the
+// staff member of users limited to their own bookings
+func (h *ClientHandler) staffClient(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		staffID, ok := bookingStaffScope(w, r)
+		if !ok {
+			return
+		}
+		if staffID != "" {
+			if err := h.clientService.CheckStaffClient(r.Context(), chi.URLParam(r, "clientID"), staffID); err != nil {
+				clientErrorResponse(w, err)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// noteAuthorScope returns the user whose notes the request may change, empty
+// for users with bookings:read_all, who may change any note
+func noteAuthorScope(w http.ResponseWriter, r *http.Request) (string, bool) {
+	staffID, ok := bookingStaffScope(w, r)
+	if !ok || staffID == "" {
+		return "", ok
+	}
+	return middleware.GetUserFromContext(r.Context()).ID, true
+}
+
 // @Summary  Get clients
 // @Description Get paginated list of clients
 // @Tags Clients
@@ -37,7 +118,9 @@ func (h *ClientHandler) Routes() chi.Router {
 // @Param business_id path string true "Business ID"
 // @Param page query int false "Page number"
 // @Param limit query int false "Number of items per page"
-// @Param search query string false "Search query"
+// @Param search query string false "Search across name, email and phone number, tolerating typos; results are ranked by relevance"
+// @Param tag query []string false "Tag ID; clients need all given tags" collectionFormat(multi)
+// @Param field query []string false "Custom field value as key:value, e.g. hair_type:curly; clients need all given values" collectionFormat(multi)
 // @Success 200 {object} dto.ClientListResponse
 // @Failure 422 {object} map[string]string "Validation errors"
 // @Failure 401 {object} dto.ErrorResponse "Unauthorized"
@@ -55,7 +138,15 @@ func (h *ClientHandler) GetClients(w http.ResponseWriter, r *http.Request) {
 
 	pageStr := r.URL.Query().Get("page")
 	limitStr := r.URL.Query().Get("limit")
-	search := r.URL.Query().Get("search")
+	filter, err := clientListFilter(r)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errs := validate.Struct(filter); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
 
 	page := 1
 	limit := 10
@@ -72,10 +163,15 @@ func (h *ClientHandler) GetClients(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	staffID, ok := bookingStaffScope(w, r)
+	if !ok {
+		return
+	}
+
 	// Call service to get clients
-	response, err := h.clientService.GetClients(r.Context(), businessID, page, limit, search)
+	response, err := h.clientService.GetClients(r.Context(), businessID, staffID, page, limit, filter)
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, "Failed to get clients")
+		clientErrorResponse(w, err)
 		return
 	}
 
@@ -84,3 +180,864 @@ func (h *ClientHandler) GetClients(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// @Summary Create client
+// @Description Create a client of the business. Phone numbers are unique within a business
+// @Tags Clients
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param client body dto.CreateClientRequest true "Client data"
+// @Success 201 {object} dto.ClientResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 409 {object} dto.ErrorResponse "Phone number already in use"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients [post]
+func (h *ClientHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	var req dto.CreateClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	client, err := h.clientService.CreateClient(r.Context(), businessID, req)
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(client); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get client
+// @Description Get a client of the business
+// @Tags Clients
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Success 200 {object} dto.ClientResponse
+// @Failure 404 {object} dto.ErrorResponse "Client not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID} [get]
+func (h *ClientHandler) GetClient(w http.ResponseWriter, r *http.Request) {
+	client, err := h.clientService.GetClient(r.Context(), chi.URLParam(r, "clientID"))
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(client); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update client
+// @Description Change the fields of a client that are set in the request
+// @Tags Clients
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Param client body dto.UpdateClientRequest true "Client changes"
+// @Success 200 {object} dto.ClientResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Client not found"
+// @Failure 409 {object} dto.ErrorResponse "Phone number already in use"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID} [patch]
+func (h *ClientHandler) UpdateClient(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	client, err := h.clientService.UpdateClient(r.Context(), chi.URLParam(r, "clientID"), req)
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(client); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Delete client
+// @Description Delete a client together with their booking history. Clients with upcoming bookings can't be deleted
+// @Tags Clients
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} dto.ErrorResponse "Client not found"
+// @Failure 409 {object} dto.ErrorResponse "Client has upcoming bookings"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID} [delete]
+func (h *ClientHandler) DeleteClient(w http.ResponseWriter, r *http.Request) {
+	if err := h.clientService.DeleteClient(r.Context(), chi.URLParam(r, "clientID")); err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get client profile
+// @Description Get a client with their visit history: upcoming and past bookings, services used, favourite staff member, total spent, no-shows and last visit
+// @Tags Clients
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Success 200 {object} dto.ClientProfileResponse
+// @Failure 404 {object} dto.ErrorResponse "Client not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID}/profile [get]
+func (h *ClientHandler) GetClientProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.clientService.GetClientProfile(r.Context(), chi.URLParam(r, "clientID"))
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(profile); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Search clients
+// @Description Autocomplete clients by name, email or phone number, best matches first. Terms shorter than 2 characters return no clients
+// @Tags Clients
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param q query string true "Search term"
+// @Param limit query int false "Number of clients, up to 20; 10 by default"
+// @Success 200 {array} dto.ClientResponse
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/search [get]
+func (h *ClientHandler) SearchClients(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	staffID, ok := bookingStaffScope(w, r)
+	if !ok {
+		return
+	}
+
+	clients, err := h.clientService.SearchClients(r.Context(), chi.URLParam(r, "businessID"), staffID, r.URL.Query().Get("q"), limit)
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(clients); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Find duplicate clients
+// @Description Find pairs of clients that are probably the same person. Pairs are scored from 0 to 1 on a shared phone number, a shared email and a similar name, best matches first; the first client of a pair is the older one
+// @Tags Clients
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param min_score query number false "Lowest score returned, 0.3 when omitted, which includes pairs with just a similar name"
+// @Param limit query int false "Number of pairs returned, 50 when omitted, at most 200"
+// @Success 200 {array} dto.ClientDuplicateResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/duplicates [get]
+func (h *ClientHandler) GetDuplicateClients(w http.ResponseWriter, r *http.Request) {
+	var minScore float64
+	if v := r.URL.Query().Get("min_score"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			ErrorResponse(w, http.StatusBadRequest, "min_score must be a number from 0 to 1")
+			return
+		}
+		minScore = parsed
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	duplicates, err := h.clientMergeService.FindDuplicates(r.Context(), chi.URLParam(r, "businessID"), minScore, limit)
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(duplicates); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Merge clients
+// @Description Merge a duplicate into the client: its bookings and merge history move over, empty fields of the client are filled from it and it is deleted, in one transaction. With preview set only what would move is returned
+// @Tags Clients
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "ID of the client to keep"
+// @Param merge body dto.MergeClientsRequest true "Duplicate to merge"
+// @Success 200 {object} dto.ClientMergeResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Client not found"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID}/merge [post]
+func (h *ClientHandler) MergeClients(w http.ResponseWriter, r *http.Request) {
+	var req dto.MergeClientsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	var mergedBy string
+	if user := middleware.GetUserFromContext(r.Context()); user != nil {
+		mergedBy = user.ID
+	}
+
+	merge, err := h.clientMergeService.MergeClients(r.Context(), chi.URLParam(r, "clientID"), req, mergedBy)
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(merge); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get client merges
+// @Description Get the audit trail of the clients merged into a client, latest first, with their details as they were when merged
+// @Tags Clients
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Success 200 {array} dto.ClientMergeRecordResponse
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID}/merges [get]
+func (h *ClientHandler) GetClientMerges(w http.ResponseWriter, r *http.Request) {
+	merges, err := h.clientMergeService.ListMerges(r.Context(), chi.URLParam(r, "clientID"))
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(merges); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get client notes
+// @Description Get the notes kept on a client, latest first
+// @Tags Clients
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Success 200 {array} dto.ClientNoteResponse
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID}/notes [get]
+func (h *ClientHandler) GetClientNotes(w http.ResponseWriter, r *http.Request) {
+	notes, err := h.clientNoteService.ListNotes(r.Context(), chi.URLParam(r, "clientID"))
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(notes); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Create client note
+// @Description Add a note to a client, e.g. a colour formula, an allergy or a preference. The current user is recorded as its author
+// @Tags Clients
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Param note body dto.ClientNoteRequest true "Note"
+// @Success 201 {object} dto.ClientNoteResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Client not found"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID}/notes [post]
+func (h *ClientHandler) CreateClientNote(w http.ResponseWriter, r *http.Request) {
+	var req dto.ClientNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	var authorID string
+	if user := middleware.GetUserFromContext(r.Context()); user != nil {
+		authorID = user.ID
+	}
+
+	note, err := h.clientNoteService.CreateNote(r.Context(), chi.URLParam(r, "clientID"), authorID, req)
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(note); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update client note
+// @Description Change the text of a client note
+// @Tags Clients
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Param noteID path string true "Note ID"
+// @Param note body dto.ClientNoteRequest true "Note"
+// @Success 200 {object} dto.ClientNoteResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Note not found"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID}/notes/{noteID} [patch]
+func (h *ClientHandler) UpdateClientNote(w http.ResponseWriter, r *http.Request) {
+	var req dto.ClientNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	authorID, ok := noteAuthorScope(w, r)
+	if !ok {
+		return
+	}
+
+	note, err := h.clientNoteService.UpdateNote(r.Context(), chi.URLParam(r, "clientID"), authorID, chi.URLParam(r, "noteID"), req)
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(note); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Delete client note
+// @Description Delete a client note
+// @Tags Clients
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Param noteID path string true "Note ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} dto.ErrorResponse "Note not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID}/notes/{noteID} [delete]
+func (h *ClientHandler) DeleteClientNote(w http.ResponseWriter, r *http.Request) {
+	authorID, ok := noteAuthorScope(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.clientNoteService.DeleteNote(r.Context(), chi.URLParam(r, "clientID"), authorID, chi.URLParam(r, "noteID")); err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get client tags
+// @Description Get the tags of a client
+// @Tags Clients
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Success 200 {array} dto.ClientTagResponse
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID}/tags [get]
+func (h *ClientHandler) GetClientTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.clientTagService.ListClientTags(r.Context(), chi.URLParam(r, "clientID"))
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(tags); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Set client tags
+// @Description Replace the tags of a client with tags defined by the business
+// @Tags Clients
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Param tags body dto.SetClientTagsRequest true "Tag IDs"
+// @Success 200 {array} dto.ClientTagResponse
+// @Failure 400 {object} dto.ErrorResponse "Unknown tag"
+// @Failure 404 {object} dto.ErrorResponse "Client not found"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID}/tags [put]
+func (h *ClientHandler) SetClientTags(w http.ResponseWriter, r *http.Request) {
+	var req dto.SetClientTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	tags, err := h.clientTagService.SetClientTags(r.Context(), chi.URLParam(r, "clientID"), req)
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(tags); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get client custom fields
+// @Description Get the custom field values of a client in the order of the fields
+// @Tags Clients
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Success 200 {array} dto.ClientFieldValueResponse
+// @Failure 404 {object} dto.ErrorResponse "Client not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID}/custom-fields [get]
+func (h *ClientHandler) GetClientFieldValues(w http.ResponseWriter, r *http.Request) {
+	values, err := h.clientFieldService.ListClientValues(r.Context(), chi.URLParam(r, "clientID"))
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(values); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Set client custom fields
+// @Description Set custom field values of a client by field key; null or an empty string removes a value and fields left out keep theirs. Values are checked against the field type: numbers like "42.5", dates like "2025-01-31", selects one of the options
+// @Tags Clients
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Param values body dto.SetClientFieldValuesRequest true "Values by field key"
+// @Success 200 {array} dto.ClientFieldValueResponse
+// @Failure 400 {object} dto.ErrorResponse "Unknown field or invalid value"
+// @Failure 404 {object} dto.ErrorResponse "Client not found"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID}/custom-fields [put]
+func (h *ClientHandler) SetClientFieldValues(w http.ResponseWriter, r *http.Request) {
+	var req dto.SetClientFieldValuesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	values, err := h.clientFieldService.SetClientValues(r.Context(), chi.URLParam(r, "clientID"), req)
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(values); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// clientErrorResponse maps errors of managing clients to HTTP responses
+// @Summary Export client data
+// @Description Export everything stored about a client for a data subject access request: the client record, bookings, notes,
+// @Description tags, custom field values, consent history, notifications and merged clients. As JSON, or as a zip of CSV files
+// @Tags Clients
+// @Produce json
+// @Produce application/zip
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} dto.ClientDataExport
+// @Failure 400 {object} dto.ErrorResponse "Unknown format"
+// @Failure 404 {object} dto.ErrorResponse "Client not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID}/export [get]
+func (h *ClientHandler) ExportClientData(w http.ResponseWriter, r *http.Request) {
+	clientID := chi.URLParam(r, "clientID")
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		export, err := h.clientPrivacyService.ExportClientData(r.Context(), clientID)
+		if err != nil {
+			clientErrorResponse(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Disposition", `attachment; filename="client-`+clientID+`.json"`)
+		if err := json.NewEncoder(w).Encode(export); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	case "csv":
+		body, err := h.clientPrivacyService.ExportClientDataCSV(r.Context(), clientID)
+		if err != nil {
+			clientErrorResponse(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="client-`+clientID+`.zip"`)
+		w.Write(body)
+	default:
+		ErrorResponse(w, http.StatusBadRequest, "format must be json or csv")
+	}
+}
+
+// @Summary Erase client
+// @Description Anonymise a client for the right to be forgotten. Name, contact details and consent are cleared, notes, tags,
+// @Description custom field values and merge history deleted and their notifications blanked. Bookings are kept for statistics.
+// @Description Clients with upcoming bookings can't be erased
+// @Tags Clients
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Success 200 {object} dto.ClientResponse
+// @Failure 404 {object} dto.ErrorResponse "Client not found"
+// @Failure 409 {object} dto.ErrorResponse "Client already erased or has upcoming bookings"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID}/erase [post]
+func (h *ClientHandler) EraseClient(w http.ResponseWriter, r *http.Request) {
+	client, err := h.clientPrivacyService.EraseClient(r.Context(), chi.URLParam(r, "clientID"))
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(client); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get client consents
+// @Description Get the current marketing and notification consent of a client with its history, latest first
+// @Tags Clients
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Success 200 {object} dto.ClientConsentsResponse
+// @Failure 404 {object} dto.ErrorResponse "Client not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID}/consents [get]
+func (h *ClientHandler) GetClientConsents(w http.ResponseWriter, r *http.Request) {
+	consents, err := h.clientPrivacyService.GetConsents(r.Context(), chi.URLParam(r, "clientID"))
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(consents); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update client consents
+// @Description Record a client giving or withdrawing marketing or notification consent. Without notification consent no booking
+// @Description confirmations or reminders are sent to them. The current user is recorded with each change
+// @Tags Clients
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param clientID path string true "Client ID"
+// @Param consents body dto.UpdateClientConsentRequest true "Consent changes"
+// @Success 200 {object} dto.ClientConsentsResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Client not found"
+// @Failure 409 {object} dto.ErrorResponse "Client erased"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/{clientID}/consents [put]
+func (h *ClientHandler) UpdateClientConsents(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateClientConsentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	var recordedBy string
+	if user := middleware.GetUserFromContext(r.Context()); user != nil {
+		recordedBy = user.ID
+	}
+
+	consents, err := h.clientPrivacyService.UpdateConsents(r.Context(), chi.URLParam(r, "clientID"), recordedBy, req)
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(consents); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Import clients
+// @Description Import clients from a CSV or XLSX file whose first row names the columns. Without a mapping columns are matched by
+// @Description their header. Rows are matched to existing clients by phone number; those are skipped, or with update_existing
+// @Description have their non-empty cells applied. A dry run only checks the rows. Files of up to 1000 rows are imported right away,
+// @Description larger ones by a background job to follow under client-jobs. Row problems are listed in the job's report
+// @Tags Clients
+// @Accept multipart/form-data
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param file formData file true "CSV or XLSX file, at most 10 MB"
+// @Param format formData string false "csv or xlsx, detected from the file when empty"
+// @Param mapping formData string false "JSON object naming the file column of each client column: first_name, last_name, name, email, phone and field.<key>"
+// @Param dry_run formData bool false "Only check the rows"
+// @Param update_existing formData bool false "Update clients whose phone number is already known"
+// @Success 200 {object} dto.ClientJobResponse "Imported"
+// @Success 202 {object} dto.ClientJobResponse "Queued"
+// @Failure 400 {object} dto.ErrorResponse "Invalid file or mapping"
+// @Failure 413 {object} dto.ErrorResponse "File too large"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/import [post]
+func (h *ClientHandler) ImportClients(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, clientImportMaxBytes)
+	if err := r.ParseMultipartForm(clientImportMaxBytes); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ErrorResponse(w, http.StatusRequestEntityTooLarge, "file too large")
+			return
+		}
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "file is required")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req := dto.ClientImportRequest{
+		Format:         r.FormValue("format"),
+		DryRun:         r.FormValue("dry_run") == "true",
+		UpdateExisting: r.FormValue("update_existing") == "true",
+	}
+	if mapping := r.FormValue("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &req.Mapping); err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "mapping must be a JSON object of column names")
+			return
+		}
+	}
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	var createdBy string
+	if user := middleware.GetUserFromContext(r.Context()); user != nil {
+		createdBy = user.ID
+	}
+
+	job, err := h.clientJobService.ImportClients(r.Context(), chi.URLParam(r, "businessID"), createdBy, header.Filename, data, req)
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if job.Status == domain.ClientJobPending {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Export clients
+// @Description Export the clients matching the same search and filters as the client list as CSV. Up to 1000 clients are
+// @Description sent right away; for more an export job is queued, whose file is downloaded from client-jobs when done
+// @Tags Clients
+// @Produce text/csv
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param search query string false "Search across name, email and phone number"
+// @Param tag query []string false "Tag ID; clients need all given tags" collectionFormat(multi)
+// @Param field query []string false "Custom field value as key:value; clients need all given values" collectionFormat(multi)
+// @Success 200 {string} string "CSV file"
+// @Success 202 {object} dto.ClientJobResponse "Queued"
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/clients/export [post]
+func (h *ClientHandler) ExportClients(w http.ResponseWriter, r *http.Request) {
+	filter, err := clientListFilter(r)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errs := validate.Struct(filter); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	var createdBy string
+	if user := middleware.GetUserFromContext(r.Context()); user != nil {
+		createdBy = user.ID
+	}
+
+	job, write, err := h.clientJobService.ExportClients(r.Context(), chi.URLParam(r, "businessID"), createdBy, filter)
+	if err != nil {
+		clientErrorResponse(w, err)
+		return
+	}
+
+	if job != nil {
+		w.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(w).Encode(job); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="clients.csv"`)
+	// Once streaming has started the status can't change; a failed export
+	// ends up as a truncated file
+	write(w)
+}
+
+// clientListFilter reads the search and filters of the client list from the
+// query string
+func clientListFilter(r *http.Request) (dto.ClientListFilter, error) {
+	filter := dto.ClientListFilter{
+		Search: r.URL.Query().Get("search"),
+		TagIDs: r.URL.Query()["tag"],
+		Fields: make(map[string]string),
+	}
+	for _, field := range r.URL.Query()["field"] {
+		key, value, ok := strings.Cut(field, ":")
+		if !ok || key == "" {
+			return filter, errors.New("field filters must look like key:value")
+		}
+		filter.Fields[key] = value
+	}
+	return filter, nil
+}
+
+func clientErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrClientNotFound), errors.Is(err, usecase.ErrClientNoteNotFound):
+		ErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, usecase.ErrInvalidPhone), errors.Is(err, usecase.ErrMergeSameClient),
+		errors.Is(err, usecase.ErrClientTagNotFound), errors.Is(err, usecase.ErrClientFieldNotFound),
+		errors.Is(err, usecase.ErrInvalidClientFieldValue), errors.Is(err, usecase.ErrInvalidClientImport):
+		// Tags and fields are referenced in the request, not the URL
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, usecase.ErrClientPhoneTaken), errors.Is(err, usecase.ErrClientHasUpcomingBookings),
+		errors.Is(err, usecase.ErrClientErased):
+		ErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+	}
+}
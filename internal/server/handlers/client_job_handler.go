@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/server/middleware"
+	"github.com/ialekseychuk/my-place/internal/usecase"
+)
+
+type ClientJobHandler struct {
+	clientJobService *usecase.ClientJobService
+}
+
+func NewClientJobHandler(clientJobService *usecase.ClientJobService) *ClientJobHandler {
+	return &ClientJobHandler{
+		clientJobService: clientJobService,
+	}
+}
+
+func (h *ClientJobHandler) Routes(ownership func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.GetJobs)
+
+	owned := r.With(ownership)
+	owned.Get("/{jobID}", h.GetJob)
+	owned.Get("/{jobID}/report", h.GetImportReport)
+	owned.With(middleware.RequirePermission(domain.PermClientsExport)).Get("/{jobID}/download", h.DownloadExport)
+	return r
+}
+
+// @Summary Get client jobs
+// @Description Get the latest client imports and exports of a business, newest first. Finished jobs are kept for 7 days
+// @Tags Clients
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Success 200 {array} dto.ClientJobResponse
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-jobs [get]
+func (h *ClientJobHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.clientJobService.ListJobs(r.Context(), chi.URLParam(r, "businessID"))
+	if err != nil {
+		clientJobErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(jobs); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get client job
+// @Description Get a client import or export with its progress and, for finished imports, the report
+// @Tags Clients
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param jobID path string true "Job ID"
+// @Success 200 {object} dto.ClientJobResponse
+// @Failure 404 {object} dto.ErrorResponse "Job not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-jobs/{jobID} [get]
+func (h *ClientJobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.clientJobService.GetJob(r.Context(), chi.URLParam(r, "jobID"))
+	if err != nil {
+		clientJobErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get client import report
+// @Description Get the row problems of a finished import as CSV, one line per problem
+// @Tags Clients
+// @Produce text/csv
+// @Param businessID path string true "Business ID"
+// @Param jobID path string true "Job ID"
+// @Success 200 {string} string "CSV file"
+// @Failure 404 {object} dto.ErrorResponse "Job not found"
+// @Failure 409 {object} dto.ErrorResponse "Not a finished import"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-jobs/{jobID}/report [get]
+func (h *ClientJobHandler) GetImportReport(w http.ResponseWriter, r *http.Request) {
+	body, err := h.clientJobService.GetImportReport(r.Context(), chi.URLParam(r, "jobID"))
+	if err != nil {
+		clientJobErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="import-report.csv"`)
+	w.Write(body)
+}
+
+// @Summary Download client export
+// @Description Download the CSV file of a finished client export
+// @Tags Clients
+// @Produce text/csv
+// @Param businessID path string true "Business ID"
+// @Param jobID path string true "Job ID"
+// @Success 200 {string} string "CSV file"
+// @Failure 404 {object} dto.ErrorResponse "Job not found"
+// @Failure 409 {object} dto.ErrorResponse "Not a finished export"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-jobs/{jobID}/download [get]
+func (h *ClientJobHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	body, err := h.clientJobService.GetExportResult(r.Context(), chi.URLParam(r, "jobID"))
+	if err != nil {
+		clientJobErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="clients.csv"`)
+	w.Write(body)
+}
+
+func clientJobErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrClientJobNotFound):
+		ErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, usecase.ErrClientJobNotReady):
+		ErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+	}
+}
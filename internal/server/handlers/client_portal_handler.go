@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/internal/server/middleware"
+	"github.com/ialekseychuk/my-place/internal/usecase"
+	"github.com/ialekseychuk/my-place/pkg/validate"
+)
+
+type ClientPortalHandler struct {
+	portalService *usecase.ClientPortalService
+}
+
+func NewClientPortalHandler(portalService *usecase.ClientPortalService) *ClientPortalHandler {
+	return &ClientPortalHandler{
+		portalService: portalService,
+	}
+}
+
+// LoginRoutes are mounted below the public booking page of a business
+func (h *ClientPortalHandler) LoginRoutes() chi.Router {
+	r := chi.NewRouter()
+	r.Post("/login", h.RequestLogin)
+	r.Post("/login/verify", h.VerifyLogin)
+	return r
+}
+
+// Routes of the logged-in client, expecting middleware.ClientAuth in front
+func (h *ClientPortalHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/me", h.GetProfile)
+	r.Patch("/me", h.UpdateProfile)
+	r.Get("/bookings", h.GetBookings)
+	r.Post("/bookings/{bookingID}/cancel", h.CancelBooking)
+	r.Post("/bookings/{bookingID}/rebook", h.Rebook)
+	return r
+}
+
+// @Summary Request client login
+// @Description Sends a one-time login code and magic link by email, or a code by SMS when only a phone is given. Always answers 202 so it can't be used to find out who is a client
+// @Tags Client portal
+// @Accept json
+// @Produce json
+// @Param slug path string true "Business slug"
+// @Param login body dto.ClientLoginRequest true "Email or phone of the client"
+// @Success 202
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Business not found"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/public/businesses/{slug}/portal/login [post]
+func (h *ClientPortalHandler) RequestLogin(w http.ResponseWriter, r *http.Request) {
+	var req dto.ClientLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	if err := h.portalService.RequestLogin(r.Context(), chi.URLParam(r, "slug"), req); err != nil {
+		clientPortalErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// @Summary Complete client login
+// @Description Exchanges a magic link token, or a login code with the email or phone it was sent to, for a client access token
+// @Tags Client portal
+// @Accept json
+// @Produce json
+// @Param slug path string true "Business slug"
+// @Param login body dto.ClientLoginVerifyRequest true "Magic link token or login code"
+// @Success 200 {object} dto.ClientLoginResponse
+// @Failure 400 {object} dto.ErrorResponse "Invalid or expired code"
+// @Failure 404 {object} dto.ErrorResponse "Business not found"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/public/businesses/{slug}/portal/login/verify [post]
+func (h *ClientPortalHandler) VerifyLogin(w http.ResponseWriter, r *http.Request) {
+	var req dto.ClientLoginVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	resp, err := h.portalService.VerifyLogin(r.Context(), chi.URLParam(r, "slug"), req)
+	if err != nil {
+		clientPortalErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get client profile
+// @Description Returns the contact details of the logged-in client
+// @Tags Client portal
+// @Produce json
+// @Success 200 {object} dto.ClientResponse
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Security Bearer
+// @Router /api/v1/portal/me [get]
+func (h *ClientPortalHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	client := middleware.GetClientFromContext(r.Context())
+
+	resp := dto.ClientResponse{
+		ID:        client.ID,
+		FirstName: client.FirstName,
+		LastName:  client.LastName,
+		Email:     client.Email,
+		Phone:     client.Phone,
+		CreatedAt: client.CreatedAt,
+		UpdatedAt: client.UpdatedAt,
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update client profile
+// @Description Updates the contact details of the logged-in client
+// @Tags Client portal
+// @Accept json
+// @Produce json
+// @Param profile body dto.UpdateClientProfileRequest true "Contact details"
+// @Success 200 {object} dto.ClientResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 409 {object} dto.ErrorResponse "Phone number already in use"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/portal/me [patch]
+func (h *ClientPortalHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateClientProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	resp, err := h.portalService.UpdateProfile(r.Context(), middleware.GetClientFromContext(r.Context()), req)
+	if err != nil {
+		clientPortalErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary List client bookings
+// @Description Returns the upcoming and past bookings of the logged-in client across all locations
+// @Tags Client portal
+// @Produce json
+// @Success 200 {object} dto.ClientBookingsResponse
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/portal/bookings [get]
+func (h *ClientPortalHandler) GetBookings(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.portalService.ListBookings(r.Context(), middleware.GetClientFromContext(r.Context()))
+	if err != nil {
+		clientPortalErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Cancel client booking
+// @Description Cancels a booking of the logged-in client within the cancellation window of the business
+// @Tags Client portal
+// @Produce json
+// @Param bookingID path string true "Booking ID"
+// @Success 200 {object} dto.PublicBookingResponse
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Booking not found"
+// @Failure 409 {object} dto.ErrorResponse "Booking already cancelled or cancellation window over"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/portal/bookings/{bookingID}/cancel [post]
+func (h *ClientPortalHandler) CancelBooking(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.portalService.CancelBooking(r.Context(), middleware.GetClientFromContext(r.Context()), chi.URLParam(r, "bookingID"))
+	if err != nil {
+		clientPortalErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Rebook a service
+// @Description Books the service of an earlier booking of the logged-in client again, with the same staff member unless another one is given
+// @Tags Client portal
+// @Accept json
+// @Produce json
+// @Param bookingID path string true "Booking ID"
+// @Param booking body dto.RebookRequest true "New start time"
+// @Success 201 {object} dto.PublicBookingResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "Online booking disabled"
+// @Failure 404 {object} dto.ErrorResponse "Booking not found"
+// @Failure 409 {object} dto.ErrorResponse "Time slot not available"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/portal/bookings/{bookingID}/rebook [post]
+func (h *ClientPortalHandler) Rebook(w http.ResponseWriter, r *http.Request) {
+	var req dto.RebookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	resp, err := h.portalService.Rebook(r.Context(), middleware.GetClientFromContext(r.Context()), chi.URLParam(r, "bookingID"), req)
+	if err != nil {
+		clientPortalErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// clientPortalErrorResponse maps client portal errors to HTTP responses,
+// falling back to the public booking errors
+func clientPortalErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidLoginCode):
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, usecase.ErrBookingNotFound):
+		ErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, usecase.ErrPhoneAlreadyInUse):
+		ErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		publicBookingErrorResponse(w, err)
+	}
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/internal/server/middleware"
+	"github.com/ialekseychuk/my-place/internal/usecase"
+	"github.com/ialekseychuk/my-place/pkg/validate"
+)
+
+type ClientSegmentHandler struct {
+	clientSegmentService *usecase.ClientSegmentService
+}
+
+func NewClientSegmentHandler(clientSegmentService *usecase.ClientSegmentService) *ClientSegmentHandler {
+	return &ClientSegmentHandler{
+		clientSegmentService: clientSegmentService,
+	}
+}
+
+func (h *ClientSegmentHandler) Routes(ownership func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.GetSegments)
+	r.Post("/", h.CreateSegment)
+	r.Post("/preview", h.PreviewSegment)
+
+	owned := r.With(ownership)
+	owned.Get("/{segmentID}", h.GetSegment)
+	owned.Put("/{segmentID}", h.UpdateSegment)
+	owned.Delete("/{segmentID}", h.DeleteSegment)
+	owned.Get("/{segmentID}/clients", h.GetSegmentClients)
+	owned.With(middleware.RequirePermission(domain.PermClientsExport)).Get("/{segmentID}/export", h.ExportSegment)
+	return r
+}
+
+// @Summary Get client segments
+// @Description Get the saved client segments of a business
+// @Tags Client Segments
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Success 200 {array} dto.ClientSegmentResponse
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-segments [get]
+func (h *ClientSegmentHandler) GetSegments(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	segments, err := h.clientSegmentService.ListSegments(r.Context(), businessID)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(segments); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Create client segment
+// @Description Save a client segment. The definition is a filter tree: groups {"op": "and"|"or"|"not", "nodes": [...]} over conditions
+// @Description visits, upcoming, no_shows and spent (cents) with optional days, service_ids, min and max; created with days;
+// @Description tag with tag_id; field with key and value; search with value; has_email, has_phone and marketing_consent
+// @Tags Client Segments
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param segment body dto.ClientSegmentRequest true "Segment data"
+// @Success 201 {object} dto.ClientSegmentResponse
+// @Failure 400 {object} dto.ErrorResponse "Invalid definition"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-segments [post]
+func (h *ClientSegmentHandler) CreateSegment(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	var req dto.ClientSegmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	segment, err := h.clientSegmentService.CreateSegment(r.Context(), businessID, req)
+	if err != nil {
+		clientSegmentErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(segment); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Preview client segment
+// @Description Count the clients matching a segment definition without saving it and show the newest of them
+// @Tags Client Segments
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param segment body dto.ClientSegmentPreviewRequest true "Segment definition"
+// @Success 200 {object} dto.ClientSegmentPreviewResponse
+// @Failure 400 {object} dto.ErrorResponse "Invalid definition"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-segments/preview [post]
+func (h *ClientSegmentHandler) PreviewSegment(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	var req dto.ClientSegmentPreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	preview, err := h.clientSegmentService.PreviewSegment(r.Context(), businessID, req)
+	if err != nil {
+		clientSegmentErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(preview); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get client segment
+// @Description Get a saved client segment
+// @Tags Client Segments
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param segmentID path string true "Segment ID"
+// @Success 200 {object} dto.ClientSegmentResponse
+// @Failure 404 {object} dto.ErrorResponse "Segment not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-segments/{segmentID} [get]
+func (h *ClientSegmentHandler) GetSegment(w http.ResponseWriter, r *http.Request) {
+	segment, err := h.clientSegmentService.GetSegment(r.Context(), chi.URLParam(r, "segmentID"))
+	if err != nil {
+		clientSegmentErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(segment); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update client segment
+// @Description Replace name, description and definition of a saved client segment
+// @Tags Client Segments
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param segmentID path string true "Segment ID"
+// @Param segment body dto.ClientSegmentRequest true "Segment data"
+// @Success 200 {object} dto.ClientSegmentResponse
+// @Failure 400 {object} dto.ErrorResponse "Invalid definition"
+// @Failure 404 {object} dto.ErrorResponse "Segment not found"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-segments/{segmentID} [put]
+func (h *ClientSegmentHandler) UpdateSegment(w http.ResponseWriter, r *http.Request) {
+	var req dto.ClientSegmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	segment, err := h.clientSegmentService.UpdateSegment(r.Context(), chi.URLParam(r, "segmentID"), req)
+	if err != nil {
+		clientSegmentErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(segment); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Delete client segment
+// @Description Delete a saved client segment
+// @Tags Client Segments
+// @Param businessID path string true "Business ID"
+// @Param segmentID path string true "Segment ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} dto.ErrorResponse "Segment not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-segments/{segmentID} [delete]
+func (h *ClientSegmentHandler) DeleteSegment(w http.ResponseWriter, r *http.Request) {
+	if err := h.clientSegmentService.DeleteSegment(r.Context(), chi.URLParam(r, "segmentID")); err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get clients of segment
+// @Description Get a page of the clients matching a saved segment, newest first
+// @Tags Client Segments
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param segmentID path string true "Segment ID"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Success 200 {object} dto.ClientListResponse
+// @Failure 404 {object} dto.ErrorResponse "Segment not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-segments/{segmentID}/clients [get]
+func (h *ClientSegmentHandler) GetSegmentClients(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	clients, err := h.clientSegmentService.GetSegmentClients(r.Context(), chi.URLParam(r, "segmentID"), page, limit)
+	if err != nil {
+		clientSegmentErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(clients); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Export clients of segment
+// @Description Export all clients matching a saved segment as CSV
+// @Tags Client Segments
+// @Produce text/csv
+// @Param businessID path string true "Business ID"
+// @Param segmentID path string true "Segment ID"
+// @Success 200 {string} string "CSV data"
+// @Failure 404 {object} dto.ErrorResponse "Segment not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-segments/{segmentID}/export [get]
+func (h *ClientSegmentHandler) ExportSegment(w http.ResponseWriter, r *http.Request) {
+	body, err := h.clientSegmentService.ExportSegment(r.Context(), chi.URLParam(r, "segmentID"))
+	if err != nil {
+		clientSegmentErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="clients.csv"`)
+	w.Write(body)
+}
+
+func clientSegmentErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrClientSegmentNotFound):
+		ErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, usecase.ErrInvalidSegment):
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+	}
+}
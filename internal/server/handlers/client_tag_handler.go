@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/internal/server/middleware"
+	"github.com/ialekseychuk/my-place/internal/usecase"
+	"github.com/ialekseychuk/my-place/pkg/validate"
+)
+
+type ClientTagHandler struct {
+	clientTagService *usecase.ClientTagService
+}
+
+func NewClientTagHandler(clientTagService *usecase.ClientTagService) *ClientTagHandler {
+	return &ClientTagHandler{
+		clientTagService: clientTagService,
+	}
+}
+
+func (h *ClientTagHandler) Routes(ownership func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.GetTags)
+
+	// Staff limited to their own bookings can't define tags
+	all := r.With(middleware.RequirePermission(domain.PermBookingsReadAll))
+	all.Post("/", h.CreateTag)
+
+	owned := all.With(ownership)
+	owned.Put("/{tagID}", h.UpdateTag)
+	owned.Delete("/{tagID}", h.DeleteTag)
+	return r
+}
+
+// @Summary Get client tags of business
+// @Description Get the tags a business defined for its clients
+// @Tags Clients
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Success 200 {array} dto.ClientTagResponse
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-tags [get]
+func (h *ClientTagHandler) GetTags(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	tags, err := h.clientTagService.ListTags(r.Context(), businessID)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(tags); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Create client tag
+// @Description Define a tag for clients of the business, e.g. "VIP" or "no-show risk". Names are unique ignoring case
+// @Tags Clients
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param tag body dto.ClientTagRequest true "Tag data"
+// @Success 201 {object} dto.ClientTagResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 409 {object} dto.ErrorResponse "Name already taken"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-tags [post]
+func (h *ClientTagHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	var req dto.ClientTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	tag, err := h.clientTagService.CreateTag(r.Context(), businessID, req)
+	if err != nil {
+		clientTagErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(tag); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update client tag
+// @Description Rename or recolour a client tag
+// @Tags Clients
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param tagID path string true "Tag ID"
+// @Param tag body dto.ClientTagRequest true "Tag data"
+// @Success 200 {object} dto.ClientTagResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Tag not found"
+// @Failure 409 {object} dto.ErrorResponse "Name already taken"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-tags/{tagID} [put]
+func (h *ClientTagHandler) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	tagID := chi.URLParam(r, "tagID")
+
+	var req dto.ClientTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	tag, err := h.clientTagService.UpdateTag(r.Context(), tagID, req)
+	if err != nil {
+		clientTagErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(tag); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Delete client tag
+// @Description Delete a client tag and remove it from all clients
+// @Tags Clients
+// @Param businessID path string true "Business ID"
+// @Param tagID path string true "Tag ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} dto.ErrorResponse "Tag not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/client-tags/{tagID} [delete]
+func (h *ClientTagHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	tagID := chi.URLParam(r, "tagID")
+
+	if err := h.clientTagService.DeleteTag(r.Context(), tagID); err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func clientTagErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrClientTagNotFound):
+		ErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, usecase.ErrClientTagNameTaken):
+		ErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+	}
+}
@@ -21,14 +21,16 @@ func NewLocationHandler(locationService *usecase.LocationService) *LocationHandl
 	}
 }
 
-func (h *LocationHandler) Routes() chi.Router {
+func (h *LocationHandler) Routes(ownership func(http.Handler) http.Handler) chi.Router {
 	r := chi.NewRouter()
 
 	r.Get("/", h.GetLocations)
 	r.Post("/", h.CreateLocation)
-	r.Get("/{locationID}", h.GetLocation)
-	r.Put("/{locationID}", h.UpdateLocation)
-	r.Delete("/{locationID}", h.DeleteLocation)
+
+	owned := r.With(ownership)
+	owned.Get("/{locationID}", h.GetLocation)
+	owned.Put("/{locationID}", h.UpdateLocation)
+	owned.Delete("/{locationID}", h.DeleteLocation)
 
 	return r
 }
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/internal/usecase"
+	"github.com/ialekseychuk/my-place/pkg/validate"
+)
+
+type PublicBookingHandler struct {
+	publicBookingService *usecase.PublicBookingService
+}
+
+func NewPublicBookingHandler(publicBookingService *usecase.PublicBookingService) *PublicBookingHandler {
+	return &PublicBookingHandler{
+		publicBookingService: publicBookingService,
+	}
+}
+
+// Routes of the public booking page of a business. writeLimit guards the
+// endpoints creating or cancelling bookings and the client portal login.
+func (h *PublicBookingHandler) Routes(writeLimit func(http.Handler) http.Handler, portalLogin http.Handler) chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.GetBusiness)
+	r.Get("/services", h.ListServices)
+	r.Get("/services/grouped", h.ListServiceGroups)
+	r.Get("/staff", h.ListStaff)
+	r.Get("/availability", h.GetAvailability)
+	r.With(writeLimit).Post("/bookings", h.CreateBooking)
+	r.Get("/bookings/{token}", h.GetBooking)
+	r.With(writeLimit).Post("/bookings/{token}/cancel", h.CancelBooking)
+	r.Mount("/portal", writeLimit(portalLogin))
+	return r
+}
+
+// @Summary Get public business profile
+// @Description Returns the profile of a business accepting online bookings
+// @Tags Public booking
+// @Produce json
+// @Param slug path string true "Business slug"
+// @Success 200 {object} dto.PublicBusinessResponse
+// @Failure 403 {object} dto.ErrorResponse "Online booking disabled"
+// @Failure 404 {object} dto.ErrorResponse "Business not found"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/public/businesses/{slug} [get]
+func (h *PublicBookingHandler) GetBusiness(w http.ResponseWriter, r *http.Request) {
+	business, err := h.publicBookingService.GetBusiness(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		publicBookingErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(business); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary List bookable services
+// @Description Returns the services that can be booked online, with the staff members providing them
+// @Tags Public booking
+// @Produce json
+// @Param slug path string true "Business slug"
+// @Success 200 {array} dto.PublicServiceResponse
+// @Failure 403 {object} dto.ErrorResponse "Online booking disabled"
+// @Failure 404 {object} dto.ErrorResponse "Business not found"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/public/businesses/{slug}/services [get]
+func (h *PublicBookingHandler) ListServices(w http.ResponseWriter, r *http.Request) {
+	services, err := h.publicBookingService.ListServices(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		publicBookingErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(services); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary List bookable services by category
+// @Description Returns the services that can be booked online grouped by category for the booking menu, in the order of the
+// @Description categories and by name within them. Services without a category come last, in a group whose category is null
+// @Tags Public booking
+// @Produce json
+// @Param slug path string true "Business slug"
+// @Success 200 {array} dto.PublicServiceGroupResponse
+// @Failure 403 {object} dto.ErrorResponse "Online booking disabled"
+// @Failure 404 {object} dto.ErrorResponse "Business not found"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/public/businesses/{slug}/services/grouped [get]
+func (h *PublicBookingHandler) ListServiceGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.publicBookingService.ListServiceGroups(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		publicBookingErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(groups); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary List bookable staff
+// @Description Returns the staff members that can be booked online
+// @Tags Public booking
+// @Produce json
+// @Param slug path string true "Business slug"
+// @Param service_id query string false "Only staff members providing this service"
+// @Success 200 {array} dto.PublicStaffResponse
+// @Failure 403 {object} dto.ErrorResponse "Online booking disabled"
+// @Failure 404 {object} dto.ErrorResponse "Business not found"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/public/businesses/{slug}/staff [get]
+func (h *PublicBookingHandler) ListStaff(w http.ResponseWriter, r *http.Request) {
+	staff, err := h.publicBookingService.ListStaff(r.Context(), chi.URLParam(r, "slug"), r.URL.Query().Get("service_id"))
+	if err != nil {
+		publicBookingErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(staff); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Search availability
+// @Description Returns the start times at which a service can be booked on a day
+// @Tags Public booking
+// @Produce json
+// @Param slug path string true "Business slug"
+// @Param service_id query string true "Service ID"
+// @Param day query string true "Date in YYYY-MM-DD format"
+// @Param staff_id query string false "Staff ID to filter availability"
+// @Success 200 {array} dto.SlotResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 403 {object} dto.ErrorResponse "Online booking disabled"
+// @Failure 404 {object} dto.ErrorResponse "Business, service or staff not found"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/public/businesses/{slug}/availability [get]
+func (h *PublicBookingHandler) GetAvailability(w http.ResponseWriter, r *http.Request) {
+	serviceID := r.URL.Query().Get("service_id")
+	if serviceID == "" {
+		ErrorResponse(w, http.StatusBadRequest, "service_id parameter is required")
+		return
+	}
+
+	dayParam := r.URL.Query().Get("day")
+	if dayParam == "" {
+		ErrorResponse(w, http.StatusBadRequest, "day parameter is required")
+		return
+	}
+	day, err := time.Parse("2006-01-02", dayParam)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid date format, use YYYY-MM-DD")
+		return
+	}
+
+	slots, err := h.publicBookingService.GetAvailability(r.Context(), chi.URLParam(r, "slug"), serviceID, r.URL.Query().Get("staff_id"), day)
+	if err != nil {
+		publicBookingErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(slots); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Book online
+// @Description Books a free slot for a client. The response contains the link the client manages the booking with
+// @Tags Public booking
+// @Accept json
+// @Produce json
+// @Param slug path string true "Business slug"
+// @Param booking body dto.PublicCreateBookingRequest true "Booking and client details"
+// @Success 201 {object} dto.PublicBookingResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 403 {object} dto.ErrorResponse "Online booking disabled"
+// @Failure 404 {object} dto.ErrorResponse "Business, service or staff not found"
+// @Failure 409 {object} dto.ErrorResponse "Time slot not available"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/public/businesses/{slug}/bookings [post]
+func (h *PublicBookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
+	var req dto.PublicCreateBookingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	booking, err := h.publicBookingService.CreateBooking(r.Context(), chi.URLParam(r, "slug"), req)
+	if err != nil {
+		publicBookingErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(booking); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get booking by manage link
+// @Description Returns the booking referenced by the token of a manage link
+// @Tags Public booking
+// @Produce json
+// @Param slug path string true "Business slug"
+// @Param token path string true "Manage token"
+// @Success 200 {object} dto.PublicBookingResponse
+// @Failure 403 {object} dto.ErrorResponse "Online booking disabled"
+// @Failure 404 {object} dto.ErrorResponse "Invalid or expired link"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/public/businesses/{slug}/bookings/{token} [get]
+func (h *PublicBookingHandler) GetBooking(w http.ResponseWriter, r *http.Request) {
+	booking, err := h.publicBookingService.GetBooking(r.Context(), chi.URLParam(r, "slug"), chi.URLParam(r, "token"))
+	if err != nil {
+		publicBookingErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(booking); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Cancel booking by manage link
+// @Description Cancels the booking referenced by the token of a manage link, as long as the cancellation window of the business isn't over
+// @Tags Public booking
+// @Produce json
+// @Param slug path string true "Business slug"
+// @Param token path string true "Manage token"
+// @Success 200 {object} dto.PublicBookingResponse
+// @Failure 403 {object} dto.ErrorResponse "Online booking disabled"
+// @Failure 404 {object} dto.ErrorResponse "Invalid or expired link"
+// @Failure 409 {object} dto.ErrorResponse "Booking already cancelled or cancellation window over"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/public/businesses/{slug}/bookings/{token}/cancel [post]
+func (h *PublicBookingHandler) CancelBooking(w http.ResponseWriter, r *http.Request) {
+	booking, err := h.publicBookingService.CancelBooking(r.Context(), chi.URLParam(r, "slug"), chi.URLParam(r, "token"))
+	if err != nil {
+		publicBookingErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(booking); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// publicBookingErrorResponse maps public booking errors to HTTP responses
+func publicBookingErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrBusinessNotFound), errors.Is(err, usecase.ErrServiceNotBookable),
+		errors.Is(err, usecase.ErrStaffNotBookable), errors.Is(err, usecase.ErrInvalidManageToken):
+		ErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, usecase.ErrOnlineBookingDisabled):
+		ErrorResponse(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, usecase.ErrBookingInPast), errors.Is(err, usecase.ErrInvalidPhone):
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, usecase.ErrTimeSlotUnavailable), errors.Is(err, usecase.ErrBookingAlreadyCancelled),
+		errors.Is(err, usecase.ErrBookingAlreadyStarted), errors.Is(err, usecase.ErrCancellationWindowOver):
+		ErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+	}
+}
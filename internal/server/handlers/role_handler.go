@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/internal/server/middleware"
+	"github.com/ialekseychuk/my-place/internal/usecase"
+	"github.com/ialekseychuk/my-place/pkg/validate"
+)
+
+type RoleHandler struct {
+	roleService *usecase.RoleService
+}
+
+func NewRoleHandler(roleService *usecase.RoleService) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+	}
+}
+
+func (h *RoleHandler) Routes(ownership func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.GetRoles)
+	r.Post("/", h.CreateRole)
+
+	owned := r.With(ownership)
+	owned.Put("/{roleID}", h.UpdateRole)
+	owned.Delete("/{roleID}", h.DeleteRole)
+	return r
+}
+
+// roleCaller returns the built-in role and the permissions of the user
+// managing roles, which bound what they can grant
+func roleCaller(r *http.Request) (string, []domain.Permission) {
+	return middleware.GetUserFromContext(r.Context()).Role, middleware.GetPermissionsFromContext(r.Context())
+}
+
+// roleErrorResponse maps errors of managing roles to HTTP responses
+func roleErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrUnknownPermission), errors.Is(err, usecase.ErrOwnerRoleFixed):
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, usecase.ErrRoleOwnerOnly), errors.Is(err, usecase.ErrRoleBeyondCaller):
+		ErrorResponse(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, usecase.ErrUserNotFound), errors.Is(err, usecase.ErrRoleNotFound):
+		ErrorResponse(w, http.StatusNotFound, err.Error())
+	default:
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// @Summary Get roles
+// @Description Get the built-in roles and the custom roles of a business with their permissions
+// @Tags Roles
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Success 200 {array} dto.RoleResponse
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/roles [get]
+func (h *RoleHandler) GetRoles(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	roles, err := h.roleService.ListRoles(r.Context(), businessID)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(roles); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Create custom role
+// @Description Create a business specific role from a set of permissions
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param role body dto.CreateRoleRequest true "Role data"
+// @Success 201 {object} dto.RoleResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 403 {object} dto.ErrorResponse "Permission beyond the caller's own"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/roles [post]
+func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	var req dto.CreateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	callerRole, callerPermissions := roleCaller(r)
+	role, err := h.roleService.CreateRole(r.Context(), businessID, callerRole, callerPermissions, req)
+	if err != nil {
+		roleErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(role); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update custom role
+// @Description Update name, description and permissions of a custom role
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param roleID path string true "Role ID"
+// @Param role body dto.UpdateRoleRequest true "Role data"
+// @Success 200 {object} dto.RoleResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 403 {object} dto.ErrorResponse "Permission beyond the caller's own"
+// @Failure 404 {object} dto.ErrorResponse "Role not found"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/roles/{roleID} [put]
+func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	roleID := chi.URLParam(r, "roleID")
+
+	var req dto.UpdateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	callerRole, callerPermissions := roleCaller(r)
+	role, err := h.roleService.UpdateRole(r.Context(), roleID, callerRole, callerPermissions, req)
+	if err != nil {
+		roleErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(role); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Delete custom role
+// @Description Delete a custom role; its users fall back to their built-in role
+// @Tags Roles
+// @Param businessID path string true "Business ID"
+// @Param roleID path string true "Role ID"
+// @Success 204 "No Content"
+// @Failure 403 {object} dto.ErrorResponse "Role grants beyond the caller's own permissions"
+// @Failure 404 {object} dto.ErrorResponse "Role not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/roles/{roleID} [delete]
+func (h *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	roleID := chi.URLParam(r, "roleID")
+
+	callerRole, callerPermissions := roleCaller(r)
+	if err := h.roleService.DeleteRole(r.Context(), roleID, callerRole, callerPermissions); err != nil {
+		roleErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Assign role to user
+// @Description Assign a custom role to a user of the business, or clear it with an empty role_id
+// @Tags Roles
+// @Accept json
+// @Param businessID path string true "Business ID"
+// @Param userID path string true "User ID"
+// @Param role body dto.AssignRoleRequest true "Role assignment"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 403 {object} dto.ErrorResponse "Role grants beyond the caller's own permissions"
+// @Failure 404 {object} dto.ErrorResponse "User or role not found"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/users/{userID}/role [put]
+func (h *RoleHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+	userID := chi.URLParam(r, "userID")
+
+	var req dto.AssignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	callerRole, callerPermissions := roleCaller(r)
+	if err := h.roleService.AssignRole(r.Context(), businessID, userID, callerRole, callerPermissions, req); err != nil {
+		roleErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
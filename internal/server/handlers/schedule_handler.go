@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/domain"
 	"github.com/ialekseychuk/my-place/internal/dto"
 	"github.com/ialekseychuk/my-place/internal/server/middleware"
 	"github.com/ialekseychuk/my-place/internal/usecase"
@@ -22,79 +24,97 @@ func NewScheduleHandler(scheduleService *usecase.ScheduleService) *ScheduleHandl
 	}
 }
 
-func (h *ScheduleHandler) Routes() chi.Router {
+// Routes registers the schedule routes. Every route referencing a staff
+// member, shift, template or time off request is guarded by ownership.
+func (h *ScheduleHandler) Routes(ownership func(http.Handler) http.Handler) chi.Router {
 	r := chi.NewRouter()
 
 	// Schedule Templates
 	r.Route("/templates", func(r chi.Router) {
 		r.Post("/", h.CreateScheduleTemplate)
-		r.Get("/{templateID}", h.GetScheduleTemplate)
-		r.Put("/{templateID}", h.UpdateScheduleTemplate)
-		r.Delete("/{templateID}", h.DeleteScheduleTemplate)
+
+		owned := r.With(ownership)
+		owned.Get("/{templateID}", h.GetScheduleTemplate)
+		owned.Put("/{templateID}", h.UpdateScheduleTemplate)
+		owned.Delete("/{templateID}", h.DeleteScheduleTemplate)
 	})
 
 	// Staff-specific schedule template routes
 	r.Route("/staff/{staffID}/templates", func(r chi.Router) {
-		r.Get("/", h.GetStaffScheduleTemplates)
-		r.Post("/{templateID}/set-default", h.SetDefaultTemplate)
+		owned := r.With(ownership)
+		owned.Get("/", h.GetStaffScheduleTemplates)
+		owned.Post("/{templateID}/set-default", h.SetDefaultTemplate)
 	})
 
 	// Shifts Management
 	r.Route("/shifts", func(r chi.Router) {
 		r.Post("/", h.CreateShift)
-		r.Get("/{shiftID}", h.GetShift)
-		r.Put("/{shiftID}", h.UpdateShift)
-		r.Delete("/{shiftID}", h.DeleteShift)
-		r.Put("/{shiftID}/availability", h.UpdateShiftAvailability)
 
 		// Bulk operations
 		r.Post("/bulk", h.BulkCreateShifts)
 		r.Put("/bulk", h.BulkUpdateShifts)
 		r.Delete("/bulk", h.BulkDeleteShifts)
+
+		owned := r.With(ownership)
+		owned.Get("/{shiftID}", h.GetShift)
+		owned.Put("/{shiftID}", h.UpdateShift)
+		owned.Delete("/{shiftID}", h.DeleteShift)
+		owned.Put("/{shiftID}/availability", h.UpdateShiftAvailability)
 	})
 
 	// Staff shifts
 	r.Route("/staff/{staffID}/shifts", func(r chi.Router) {
-		r.Get("/", h.GetStaffShifts)
-		r.Post("/generate", h.GenerateStaffSchedule)
+		owned := r.With(ownership)
+		owned.Get("/", h.GetStaffShifts)
+		owned.Post("/generate", h.GenerateStaffSchedule)
 	})
 
 	// Schedule Views
 	r.Route("/views", func(r chi.Router) {
 		r.Get("/weekly", h.GetWeeklyScheduleView)
 		r.Get("/monthly", h.GetMonthlyScheduleView)
-		r.Get("/staff/{staffID}/weekly", h.GetStaffWeeklySchedule)
-		r.Get("/staff/{staffID}/day", h.GetStaffDaySchedule)
+
+		owned := r.With(ownership)
+		owned.Get("/staff/{staffID}/weekly", h.GetStaffWeeklySchedule)
+		owned.Get("/staff/{staffID}/day", h.GetStaffDaySchedule)
 	})
 
 	// Time Off Management
 	r.Route("/time-off", func(r chi.Router) {
 		r.Post("/", h.CreateTimeOffRequest)
-		r.Get("/{requestID}", h.GetTimeOffRequest)
-		r.Put("/{requestID}", h.UpdateTimeOffRequest)
-		r.Delete("/{requestID}", h.DeleteTimeOffRequest)
-		r.Get("/staff/{staffID}", h.GetStaffTimeOffRequests)
 		r.Get("/business", h.GetBusinessTimeOffRequests)
+
+		owned := r.With(ownership)
+		owned.Get("/{requestID}", h.GetTimeOffRequest)
+		owned.Put("/{requestID}", h.UpdateTimeOffRequest)
+		owned.Delete("/{requestID}", h.DeleteTimeOffRequest)
+		owned.Get("/staff/{staffID}", h.GetStaffTimeOffRequests)
 	})
 
 	// Availability
 	r.Route("/availability", func(r chi.Router) {
-		r.Get("/staff/{staffID}/check", h.CheckStaffAvailability)
 		r.Get("/available-staff", h.GetAvailableStaff)
-		r.Get("/logs/{staffID}", h.GetAvailabilityLogs)
+
+		owned := r.With(ownership)
+		owned.Get("/staff/{staffID}/check", h.CheckStaffAvailability)
+		owned.Get("/logs/{staffID}", h.GetAvailabilityLogs)
 	})
 
 	// Quick Actions
 	r.Route("/quick-actions", func(r chi.Router) {
-		r.Post("/staff/{staffID}/enable", h.QuickEnableStaff)
-		r.Post("/staff/{staffID}/disable", h.QuickDisableStaff)
 		r.Post("/copy-schedule", h.CopySchedule)
+
+		owned := r.With(ownership)
+		owned.Post("/staff/{staffID}/enable", h.QuickEnableStaff)
+		owned.Post("/staff/{staffID}/disable", h.QuickDisableStaff)
 	})
 
 	// Statistics
 	r.Route("/stats", func(r chi.Router) {
-		r.Get("/staff/{staffID}", h.GetStaffScheduleStats)
 		r.Get("/business", h.GetBusinessScheduleStats)
+
+		owned := r.With(ownership)
+		owned.Get("/staff/{staffID}", h.GetStaffScheduleStats)
 	})
 
 	return r
@@ -113,6 +133,7 @@ func (h *ScheduleHandler) Routes() chi.Router {
 // @Param template body dto.CreateScheduleTemplateRequest true "Schedule template data"
 // @Success 201 {object} dto.ScheduleTemplateResponse
 // @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Staff not found"
 // @Failure 422 {object} map[string]string "Validation errors"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
 // @Security Bearer
@@ -129,9 +150,9 @@ func (h *ScheduleHandler) CreateScheduleTemplate(w http.ResponseWriter, r *http.
 		return
 	}
 
-	template, err := h.scheduleService.CreateScheduleTemplate(r.Context(), req)
+	template, err := h.scheduleService.CreateScheduleTemplate(r.Context(), chi.URLParam(r, "businessID"), req)
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, err.Error())
+		scheduleErrorResponse(w, err)
 		return
 	}
 
@@ -186,6 +207,7 @@ func (h *ScheduleHandler) GetStaffScheduleTemplates(w http.ResponseWriter, r *ht
 // @Param shift body dto.CreateShiftRequest true "Shift data"
 // @Success 201 {object} dto.ShiftResponse
 // @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Staff not found"
 // @Failure 422 {object} map[string]string "Validation errors"
 // @Failure 409 {object} dto.ErrorResponse "Schedule conflict"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
@@ -205,14 +227,9 @@ func (h *ScheduleHandler) CreateShift(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	shift, err := h.scheduleService.CreateShift(r.Context(), req)
+	shift, err := h.scheduleService.CreateShift(r.Context(), chi.URLParam(r, "businessID"), req)
 	if err != nil {
-		// Check for specific error types
-		if isConflictError(err) {
-			ErrorResponse(w, http.StatusConflict, err.Error())
-		} else {
-			ErrorResponse(w, http.StatusInternalServerError, err.Error())
-		}
+		scheduleErrorResponse(w, err)
 		return
 	}
 
@@ -280,6 +297,51 @@ func (h *ScheduleHandler) GetStaffShifts(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// @Summary Get my shifts
+// @Description Get shifts of the staff member linked to the current user
+// @Tags Schedule
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param start_date query string true "Start date (YYYY-MM-DD)"
+// @Param end_date query string true "End date (YYYY-MM-DD)"
+// @Success 200 {array} dto.ShiftResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 403 {object} dto.ErrorResponse "User is not linked to a staff member"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/me/shifts [get]
+func (h *ScheduleHandler) GetMyShifts(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil || user.StaffID == "" {
+		ErrorResponse(w, http.StatusForbidden, "User is not linked to a staff member")
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", r.URL.Query().Get("start_date"))
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid start_date format, use YYYY-MM-DD")
+		return
+	}
+
+	endDate, err := time.Parse("2006-01-02", r.URL.Query().Get("end_date"))
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid end_date format, use YYYY-MM-DD")
+		return
+	}
+
+	shifts, err := h.scheduleService.GetStaffShifts(r.Context(), user.StaffID, startDate, endDate)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(shifts); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
 // @Summary Update shift availability
 // @Description Update the availability status of a shift
 // @Tags Schedule
@@ -341,6 +403,7 @@ func (h *ScheduleHandler) UpdateShiftAvailability(w http.ResponseWriter, r *http
 // @Param generation body dto.GenerateScheduleRequest true "Schedule generation parameters"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Staff or template not found"
 // @Failure 422 {object} map[string]string "Validation errors"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
 // @Security Bearer
@@ -375,9 +438,9 @@ func (h *ScheduleHandler) GenerateStaffSchedule(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	err := h.scheduleService.GenerateSchedule(r.Context(), req)
+	err := h.scheduleService.GenerateSchedule(r.Context(), chi.URLParam(r, "businessID"), req)
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, err.Error())
+		scheduleErrorResponse(w, err)
 		return
 	}
 
@@ -402,6 +465,7 @@ func (h *ScheduleHandler) GenerateStaffSchedule(w http.ResponseWriter, r *http.R
 // @Param timeOff body dto.CreateTimeOffRequest true "Time off request data"
 // @Success 201 {object} dto.TimeOffResponse
 // @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Staff not found"
 // @Failure 422 {object} map[string]string "Validation errors"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
 // @Security Bearer
@@ -418,9 +482,9 @@ func (h *ScheduleHandler) CreateTimeOffRequest(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	timeOff, err := h.scheduleService.CreateTimeOffRequest(r.Context(), req)
+	timeOff, err := h.scheduleService.CreateTimeOffRequest(r.Context(), chi.URLParam(r, "businessID"), req)
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, err.Error())
+		scheduleErrorResponse(w, err)
 		return
 	}
 
@@ -748,6 +812,7 @@ func (h *ScheduleHandler) DeleteShift(w http.ResponseWriter, r *http.Request) {
 // @Param shifts body dto.BulkCreateShiftsRequest true "Bulk shifts data"
 // @Success 201 {array} dto.ShiftResponse
 // @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Staff not found"
 // @Failure 422 {object} map[string]string "Validation errors"
 // @Failure 409 {object} dto.ErrorResponse "Schedule conflict"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
@@ -765,13 +830,9 @@ func (h *ScheduleHandler) BulkCreateShifts(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	shifts, err := h.scheduleService.BulkCreateShifts(r.Context(), req)
+	shifts, err := h.scheduleService.BulkCreateShifts(r.Context(), chi.URLParam(r, "businessID"), req)
 	if err != nil {
-		if isConflictError(err) {
-			ErrorResponse(w, http.StatusConflict, err.Error())
-		} else {
-			ErrorResponse(w, http.StatusInternalServerError, err.Error())
-		}
+		scheduleErrorResponse(w, err)
 		return
 	}
 
@@ -791,6 +852,7 @@ func (h *ScheduleHandler) BulkCreateShifts(w http.ResponseWriter, r *http.Reques
 // @Param shifts body dto.BulkUpdateShiftsRequest true "Bulk shift updates"
 // @Success 200 {array} dto.ShiftResponse
 // @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Shift not found"
 // @Failure 422 {object} map[string]string "Validation errors"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
 // @Security Bearer
@@ -807,9 +869,9 @@ func (h *ScheduleHandler) BulkUpdateShifts(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	shifts, err := h.scheduleService.BulkUpdateShifts(r.Context(), req)
+	shifts, err := h.scheduleService.BulkUpdateShifts(r.Context(), chi.URLParam(r, "businessID"), req)
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, err.Error())
+		scheduleErrorResponse(w, err)
 		return
 	}
 
@@ -828,6 +890,7 @@ func (h *ScheduleHandler) BulkUpdateShifts(w http.ResponseWriter, r *http.Reques
 // @Param shifts body dto.BulkDeleteShiftsRequest true "Bulk shift deletion"
 // @Success 204 "No Content"
 // @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Shift not found"
 // @Failure 422 {object} map[string]string "Validation errors"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
 // @Security Bearer
@@ -844,8 +907,8 @@ func (h *ScheduleHandler) BulkDeleteShifts(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if err := h.scheduleService.BulkDeleteShifts(r.Context(), req); err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, err.Error())
+	if err := h.scheduleService.BulkDeleteShifts(r.Context(), chi.URLParam(r, "businessID"), req); err != nil {
+		scheduleErrorResponse(w, err)
 		return
 	}
 
@@ -1095,6 +1158,7 @@ func (h *ScheduleHandler) GetTimeOffRequest(w http.ResponseWriter, r *http.Reque
 // @Param request body dto.UpdateTimeOffRequest true "Time off update data"
 // @Success 200 {object} dto.TimeOffResponse
 // @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 403 {object} dto.ErrorResponse "Missing schedule:approve_time_off permission"
 // @Failure 404 {object} dto.ErrorResponse "Request not found"
 // @Failure 422 {object} map[string]string "Validation errors"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
@@ -1118,6 +1182,13 @@ func (h *ScheduleHandler) UpdateTimeOffRequest(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// Deciding on a request needs its own permission on top of schedule:write
+	if (req.Status == "approved" || req.Status == "rejected") &&
+		!middleware.HasPermission(r.Context(), domain.PermScheduleApproveTimeOff) {
+		ErrorResponse(w, http.StatusForbidden, "Insufficient permissions")
+		return
+	}
+
 	timeOff, err := h.scheduleService.UpdateTimeOffRequest(r.Context(), requestID, req)
 	if err != nil {
 		ErrorResponse(w, http.StatusInternalServerError, err.Error())
@@ -1498,6 +1569,7 @@ func (h *ScheduleHandler) QuickDisableStaff(w http.ResponseWriter, r *http.Reque
 // @Param copy body dto.CopyScheduleRequest true "Schedule copy data"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Staff not found"
 // @Failure 422 {object} map[string]string "Validation errors"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
 // @Security Bearer
@@ -1522,7 +1594,7 @@ func (h *ScheduleHandler) CopySchedule(w http.ResponseWriter, r *http.Request) {
 
 	copiedShifts, err := h.scheduleService.CopySchedule(r.Context(), businessID, req)
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, err.Error())
+		scheduleErrorResponse(w, err)
 		return
 	}
 
@@ -1653,6 +1725,20 @@ func (h *ScheduleHandler) GetBusinessScheduleStats(w http.ResponseWriter, r *htt
 // Helper Functions
 // =======================
 
+// scheduleErrorResponse writes the response for a schedule usecase error,
+// 404 for staff, shifts and templates of another business
+func scheduleErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrStaffNotFound), errors.Is(err, usecase.ErrShiftNotFound),
+		errors.Is(err, usecase.ErrScheduleTemplateNotFound):
+		ErrorResponse(w, http.StatusNotFound, err.Error())
+	case isConflictError(err):
+		ErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		ErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
 func isConflictError(err error) bool {
 	// Simple check for conflict errors - in production, you'd use typed errors
 	return err != nil && (containsString(err.Error(), "conflict") ||
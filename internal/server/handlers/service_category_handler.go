@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/internal/usecase"
+	"github.com/ialekseychuk/my-place/pkg/validate"
+)
+
+type ServiceCategoryHandler struct {
+	serviceCategoryService *usecase.ServiceCategoryService
+}
+
+func NewServiceCategoryHandler(serviceCategoryService *usecase.ServiceCategoryService) *ServiceCategoryHandler {
+	return &ServiceCategoryHandler{
+		serviceCategoryService: serviceCategoryService,
+	}
+}
+
+func (h *ServiceCategoryHandler) Routes(ownership func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.GetCategories)
+	r.Post("/", h.CreateCategory)
+
+	owned := r.With(ownership)
+	owned.Patch("/{categoryID}", h.UpdateCategory)
+	owned.Delete("/{categoryID}", h.DeleteCategory)
+	return r
+}
+
+// @Summary Get service categories of business
+// @Description Get the categories grouping the services of a business, ordered by position
+// @Tags Service
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Success 200 {array} dto.ServiceCategoryResponse
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/service-categories [get]
+func (h *ServiceCategoryHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	categories, err := h.serviceCategoryService.ListCategories(r.Context(), businessID)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(categories); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Create service category
+// @Description Define a category for services of the business, e.g. "Haircuts". Names are unique ignoring case;
+// @Description categories are listed by position, then name
+// @Tags Service
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param category body dto.CreateServiceCategoryRequest true "Category data"
+// @Success 201 {object} dto.ServiceCategoryResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 409 {object} dto.ErrorResponse "Name already taken"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/service-categories [post]
+func (h *ServiceCategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	var req dto.CreateServiceCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	category, err := h.serviceCategoryService.CreateCategory(r.Context(), businessID, req)
+	if err != nil {
+		serviceCategoryErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(category); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update service category
+// @Description Rename a service category or change its position
+// @Tags Service
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param categoryID path string true "Category ID"
+// @Param category body dto.UpdateServiceCategoryRequest true "Category data"
+// @Success 200 {object} dto.ServiceCategoryResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Category not found"
+// @Failure 409 {object} dto.ErrorResponse "Name already taken"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/service-categories/{categoryID} [patch]
+func (h *ServiceCategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	categoryID := chi.URLParam(r, "categoryID")
+
+	var req dto.UpdateServiceCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	category, err := h.serviceCategoryService.UpdateCategory(r.Context(), categoryID, req)
+	if err != nil {
+		serviceCategoryErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(category); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Delete service category
+// @Description Delete a service category; its services stay, without a category
+// @Tags Service
+// @Param businessID path string true "Business ID"
+// @Param categoryID path string true "Category ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} dto.ErrorResponse "Category not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/service-categories/{categoryID} [delete]
+func (h *ServiceCategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	categoryID := chi.URLParam(r, "categoryID")
+
+	if err := h.serviceCategoryService.DeleteCategory(r.Context(), categoryID); err != nil {
+		serviceCategoryErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func serviceCategoryErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrServiceCategoryNotFound):
+		ErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, usecase.ErrServiceCategoryNameTaken):
+		ErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -21,25 +22,29 @@ func NewServiceHandler(uc *usecase.ServiceService) *ServiceHandler {
 	}
 }
 
-func (h *ServiceHandler) Routes() chi.Router {
+func (h *ServiceHandler) Routes(ownership func(http.Handler) http.Handler) chi.Router {
 	r := chi.NewRouter()
 	r.Post("/", h.createService)
 	r.Get("/", h.getServicesByBusiness)
-	r.Get("/{serviceID}", h.getService)
-	r.Put("/{serviceID}", h.updateService)
-	r.Delete("/{serviceID}", h.deleteService)
+	r.Get("/grouped", h.getServicesByCategory)
+
+	owned := r.With(ownership)
+	owned.Get("/{serviceID}", h.getService)
+	owned.Put("/{serviceID}", h.updateService)
+	owned.Delete("/{serviceID}", h.deleteService)
 	return r
 }
 
 // @Summary Create a new service
-// @Description Creates a new service for business
+// @Description Creates a new service for business. Services are shown online and active unless visible_online or active is false.
+// @Description Inactive services can't be booked; services not shown online can only be booked by staff
 // @Tags Service
 // @Accept json
 // @Produce json
 // @Param service body dto.CreateServiceRequest true "Service object"
 // @Param businessID path string true "Business ID"
 // @Success 201 {object} dto.ServiceResponse
-// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 400 {object} dto.ErrorResponse "Bad request or unknown category"
 // @Failure 422 {object} map[string]string "Validation errors"
 // @Failure 401 {object} dto.ErrorResponse "Unauthorized"
 // @Failure 403 {object} dto.ErrorResponse "Forbidden"
@@ -60,15 +65,20 @@ func (h *ServiceHandler) createService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	svc := &domain.Service{
-		BusinessID:  businessID,
-		LocationID:  req.LocationID,
-		Name:        req.Name,
-		DurationMin: req.DurationMin,
-		PriceCents:  req.PriceCents,
+		BusinessID:    businessID,
+		LocationID:    req.LocationID,
+		CategoryID:    req.CategoryID,
+		Name:          req.Name,
+		Description:   req.Description,
+		ImageURL:      req.ImageURL,
+		DurationMin:   req.DurationMin,
+		PriceCents:    req.PriceCents,
+		VisibleOnline: req.VisibleOnline == nil || *req.VisibleOnline,
+		Active:        req.Active == nil || *req.Active,
 	}
 
 	if err := h.uc.CreateService(r.Context(), svc); err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		serviceErrorResponse(w, err)
 		return
 	}
 
@@ -108,6 +118,51 @@ func (h *ServiceHandler) getServicesByBusiness(w http.ResponseWriter, r *http.Re
 	json.NewEncoder(w).Encode(responses)
 }
 
+// @Summary Get services by category
+// @Description Get the services of a business grouped by category, in the order of the categories and by name within them.
+// @Description Services without a category come last, in a group whose category is null; categories without services are left out
+// @Tags Service
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Success 200 {array} dto.ServiceCategoryGroupResponse
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "Forbidden"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/services/grouped [get]
+func (h *ServiceHandler) getServicesByCategory(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.uc.ListByCategory(r.Context(), chi.URLParam(r, "businessID"))
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	responses := make([]dto.ServiceCategoryGroupResponse, 0, len(groups))
+	for _, group := range groups {
+		response := dto.ServiceCategoryGroupResponse{
+			Services: make([]dto.ServiceResponse, 0, len(group.Services)),
+		}
+		if group.Category != nil {
+			response.Category = &dto.ServiceCategoryResponse{
+				ID:        group.Category.ID,
+				Name:      group.Category.Name,
+				Position:  group.Category.Position,
+				CreatedAt: group.Category.CreatedAt,
+				UpdatedAt: group.Category.UpdatedAt,
+			}
+		}
+		for _, service := range group.Services {
+			response.Services = append(response.Services, h.convertToServiceResponse(&service))
+		}
+		responses = append(responses, response)
+	}
+
+	if err := json.NewEncoder(w).Encode(responses); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
 // @Summary Get service by ID
 // @Description Get a specific service by ID
 // @Tags Service
@@ -141,7 +196,7 @@ func (h *ServiceHandler) getService(w http.ResponseWriter, r *http.Request) {
 // @Param serviceID path string true "Service ID"
 // @Param service body dto.UpdateServiceRequest true "Service update data"
 // @Success 200 {object} dto.ServiceResponse
-// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 400 {object} dto.ErrorResponse "Bad request or unknown category"
 // @Failure 404 {object} dto.ErrorResponse "Service not found"
 // @Failure 422 {object} map[string]string "Validation errors"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
@@ -181,10 +236,24 @@ func (h *ServiceHandler) updateService(w http.ResponseWriter, r *http.Request) {
 	if req.LocationID != "" {
 		service.LocationID = req.LocationID
 	}
-	
+	if req.CategoryID != nil {
+		service.CategoryID = *req.CategoryID
+	}
+	if req.Description != nil {
+		service.Description = *req.Description
+	}
+	if req.ImageURL != nil {
+		service.ImageURL = *req.ImageURL
+	}
+	if req.VisibleOnline != nil {
+		service.VisibleOnline = *req.VisibleOnline
+	}
+	if req.Active != nil {
+		service.Active = *req.Active
+	}
 
 	if err := h.uc.UpdateService(r.Context(), service); err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+		serviceErrorResponse(w, err)
 		return
 	}
 
@@ -218,13 +287,28 @@ func (h *ServiceHandler) deleteService(w http.ResponseWriter, r *http.Request) {
 // Helper method to convert domain.Service to dto.ServiceResponse
 func (h *ServiceHandler) convertToServiceResponse(service *domain.Service) dto.ServiceResponse {
 	return dto.ServiceResponse{
-		ID:          service.ID,
-		BusinessID:  service.BusinessID,
-		LocationID:  service.LocationID,
-		Name:        service.Name,
-		DurationMin: service.DurationMin,
-		PriceCents:  service.PriceCents,
-		CreatedAt:   service.CreatedAt,
-		UpdatedAt:   service.UpdatedAt,
+		ID:            service.ID,
+		BusinessID:    service.BusinessID,
+		LocationID:    service.LocationID,
+		CategoryID:    service.CategoryID,
+		Name:          service.Name,
+		Description:   service.Description,
+		ImageURL:      service.ImageURL,
+		DurationMin:   service.DurationMin,
+		PriceCents:    service.PriceCents,
+		VisibleOnline: service.VisibleOnline,
+		Active:        service.Active,
+		CreatedAt:     service.CreatedAt,
+		UpdatedAt:     service.UpdatedAt,
+	}
+}
+
+func serviceErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrServiceCategoryNotFound):
+		// The category is referenced in the request, not the URL
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
 	}
 }
@@ -21,12 +21,14 @@ func NewStaffHandler(uc *usecase.StaffService) *StaffHandler {
 	}
 }
 
-func (h *StaffHandler) Routes() chi.Router {
+func (h *StaffHandler) Routes(ownership func(http.Handler) http.Handler) chi.Router {
 	r := chi.NewRouter()
 	r.Post("/", h.CreateStaff)
 	r.Get("/", h.GetStaffsByBusiness)
-	r.Get("/{staffID}", h.GetStaff)
-	r.Put("/{staffID}", h.UpdateStaff)
+
+	owned := r.With(ownership)
+	owned.Get("/{staffID}", h.GetStaff)
+	owned.Put("/{staffID}", h.UpdateStaff)
 	return r
 }
 
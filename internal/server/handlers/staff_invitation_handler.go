@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/internal/server/middleware"
+	"github.com/ialekseychuk/my-place/internal/usecase"
+	"github.com/ialekseychuk/my-place/pkg/validate"
+)
+
+type StaffInvitationHandler struct {
+	invitationService *usecase.StaffInvitationService
+}
+
+func NewStaffInvitationHandler(invitationService *usecase.StaffInvitationService) *StaffInvitationHandler {
+	return &StaffInvitationHandler{
+		invitationService: invitationService,
+	}
+}
+
+func (h *StaffInvitationHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Post("/", h.CreateInvitation)
+	r.Get("/", h.GetInvitations)
+	return r
+}
+
+// @Summary Invite staff member
+// @Description Creates a single-use invitation that lets a staff member create a user account
+// @Tags Staff
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param invitation body dto.CreateStaffInvitationRequest true "Invitation data"
+// @Success 201 {object} dto.StaffInvitationResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Staff not found"
+// @Failure 409 {object} dto.ErrorResponse "Email already in use or staff already has an account"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/staff-invitations [post]
+func (h *StaffInvitationHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	var req dto.CreateStaffInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	user := middleware.GetUserFromContext(r.Context())
+	invitation, err := h.invitationService.InviteStaff(r.Context(), businessID, user.ID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrEmailAlreadyInUse), errors.Is(err, usecase.ErrStaffAlreadyLinked):
+			ErrorResponse(w, http.StatusConflict, err.Error())
+		case errors.Is(err, usecase.ErrStaffNotFound):
+			ErrorResponse(w, http.StatusNotFound, err.Error())
+		default:
+			ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(invitation); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get staff invitations
+// @Description Get all staff invitations of a business
+// @Tags Staff
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Success 200 {array} dto.StaffInvitationResponse
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/staff-invitations [get]
+func (h *StaffInvitationHandler) GetInvitations(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	invitations, err := h.invitationService.ListInvitations(r.Context(), businessID)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(invitations); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Accept staff invitation
+// @Description Creates a staff user account from an invitation token
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param invitation body dto.AcceptStaffInvitationRequest true "Invitation token and credentials"
+// @Success 201 {object} domain.User
+// @Failure 400 {object} dto.ErrorResponse "Invalid or expired invitation"
+// @Failure 409 {object} dto.ErrorResponse "Account already exists"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/v1/invitations/accept [post]
+func (h *StaffInvitationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var req dto.AcceptStaffInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	user, err := h.invitationService.AcceptInvitation(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidInvitation):
+			ErrorResponse(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, usecase.ErrEmailAlreadyInUse), errors.Is(err, usecase.ErrStaffAlreadyLinked):
+			ErrorResponse(w, http.StatusConflict, err.Error())
+		default:
+			ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/domain"
 	"github.com/ialekseychuk/my-place/internal/dto"
 	"github.com/ialekseychuk/my-place/internal/usecase"
 	"github.com/ialekseychuk/my-place/pkg/validate"
@@ -21,18 +23,20 @@ func NewStaffServiceHandler(staffService *usecase.StaffService) *StaffServiceHan
 	}
 }
 
-func (h *StaffServiceHandler) Routes() chi.Router {
+func (h *StaffServiceHandler) Routes(ownership func(http.Handler) http.Handler) chi.Router {
 	r := chi.NewRouter()
-	r.Post("/{staffID}/services", h.assignServiceToStaff)
-	r.Delete("/{staffID}/services/{serviceID}", h.unassignServiceFromStaff)
-	r.Get("/{staffID}/services", h.getStaffServices)
-	r.Put("/{staffID}/services", h.replaceStaffServices)
+	owned := r.With(ownership)
+	owned.Post("/{staffID}/services", h.assignServiceToStaff)
+	owned.Put("/{staffID}/services/{serviceID}", h.setServiceOverrides)
+	owned.Delete("/{staffID}/services/{serviceID}", h.unassignServiceFromStaff)
+	owned.Get("/{staffID}/services", h.getStaffServices)
+	owned.Put("/{staffID}/services", h.replaceStaffServices)
 	r.Get("/", h.getAllStaffServices) // New endpoint
 	return r
 }
 
 // @Summary Assign service to staff
-// @Description Assigns a service to a staff member
+// @Description Assigns a service to a staff member, optionally at their own price and duration
 // @Tags StaffServices
 // @Accept json
 // @Produce json
@@ -62,38 +66,82 @@ func (h *StaffServiceHandler) assignServiceToStaff(w http.ResponseWriter, r *htt
 		return
 	}
 
-	err := h.staffService.AssignServiceToStaff(r.Context(), staffID, req.ServiceID)
+	err := h.staffService.AssignServiceToStaff(r.Context(), staffID, req.ServiceID, req.PriceCents, req.DurationMin)
 	if err != nil {
 		ErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	// Get the assignment details for response
-	assignments, err := h.staffService.GetStaffServicesByBusiness(r.Context(), businessID)
+	assignment, err := h.findAssignment(r, businessID, staffID, req.ServiceID)
 	if err != nil {
 		ErrorResponse(w, http.StatusInternalServerError, "Failed to get assignment details")
 		return
 	}
 
-	// Find the created assignment
-	for _, assignment := range assignments {
-		if assignment.StaffID == staffID && assignment.ServiceID == req.ServiceID {
-			response := dto.StaffServiceResponse{
-				ID:          assignment.ID,
-				StaffID:     assignment.StaffID,
-				ServiceID:   assignment.ServiceID,
-				StaffName:   assignment.StaffName,
-				ServiceName: assignment.ServiceName,
-				CreatedAt:   assignment.CreatedAt.Format(time.RFC3339),
-				UpdatedAt:   assignment.UpdatedAt.Format(time.RFC3339),
-			}
-			w.WriteHeader(http.StatusCreated)
-			json.NewEncoder(w).Encode(response)
+	w.WriteHeader(http.StatusCreated)
+	if assignment != nil {
+		json.NewEncoder(w).Encode(staffServiceResponse(assignment))
+	}
+}
+
+// @Summary Set staff service price and duration
+// @Description Sets the price and duration a staff member provides an assigned service at, null falls back to the service's
+// @Tags StaffServices
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param staffID path string true "Staff ID"
+// @Param serviceID path string true "Service ID"
+// @Param request body dto.StaffServiceOverridesRequest true "Price and duration overrides"
+// @Success 200 {object} dto.StaffServiceResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Service is not assigned to the staff member"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/staff-services/{staffID}/services/{serviceID} [put]
+func (h *StaffServiceHandler) setServiceOverrides(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+	staffID := chi.URLParam(r, "staffID")
+	serviceID := chi.URLParam(r, "serviceID")
+
+	var req dto.StaffServiceOverridesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	err := h.staffService.SetServiceOverrides(r.Context(), staffID, serviceID, req.PriceCents, req.DurationMin)
+	if err != nil {
+		if errors.Is(err, usecase.ErrServiceNotAssigned) {
+			ErrorResponse(w, http.StatusNotFound, err.Error())
 			return
 		}
+		ErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	assignment, err := h.findAssignment(r, businessID, staffID, serviceID)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "Failed to get assignment details")
+		return
+	}
+	if assignment == nil {
+		// The assignments of inactive staff aren't listed
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(staffServiceResponse(assignment)); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
 }
 
 // @Summary Unassign service from staff
@@ -145,13 +193,18 @@ func (h *StaffServiceHandler) getStaffServices(w http.ResponseWriter, r *http.Re
 	var responses []dto.ServiceResponse
 	for _, service := range services {
 		responses = append(responses, dto.ServiceResponse{
-			ID:          service.ID,
-			BusinessID:  service.BusinessID,
-			Name:        service.Name,
-			DurationMin: service.DurationMin,
-			PriceCents:  service.PriceCents,
-			CreatedAt:   service.CreatedAt,
-			UpdatedAt:   service.UpdatedAt,
+			ID:            service.ID,
+			BusinessID:    service.BusinessID,
+			CategoryID:    service.CategoryID,
+			Name:          service.Name,
+			Description:   service.Description,
+			ImageURL:      service.ImageURL,
+			DurationMin:   service.DurationMin,
+			PriceCents:    service.PriceCents,
+			VisibleOnline: service.VisibleOnline,
+			Active:        service.Active,
+			CreatedAt:     service.CreatedAt,
+			UpdatedAt:     service.UpdatedAt,
 		})
 	}
 
@@ -204,13 +257,18 @@ func (h *StaffServiceHandler) replaceStaffServices(w http.ResponseWriter, r *htt
 	var responses []dto.ServiceResponse
 	for _, service := range services {
 		responses = append(responses, dto.ServiceResponse{
-			ID:          service.ID,
-			BusinessID:  service.BusinessID,
-			Name:        service.Name,
-			DurationMin: service.DurationMin,
-			PriceCents:  service.PriceCents,
-			CreatedAt:   service.CreatedAt,
-			UpdatedAt:   service.UpdatedAt,
+			ID:            service.ID,
+			BusinessID:    service.BusinessID,
+			CategoryID:    service.CategoryID,
+			Name:          service.Name,
+			Description:   service.Description,
+			ImageURL:      service.ImageURL,
+			DurationMin:   service.DurationMin,
+			PriceCents:    service.PriceCents,
+			VisibleOnline: service.VisibleOnline,
+			Active:        service.Active,
+			CreatedAt:     service.CreatedAt,
+			UpdatedAt:     service.UpdatedAt,
 		})
 	}
 
@@ -237,17 +295,38 @@ func (h *StaffServiceHandler) getAllStaffServices(w http.ResponseWriter, r *http
 	}
 
 	var responses []dto.StaffServiceResponse
-	for _, ss := range staffServices {
-		responses = append(responses, dto.StaffServiceResponse{
-			ID:          ss.ID,
-			StaffID:     ss.StaffID,
-			ServiceID:   ss.ServiceID,
-			StaffName:   ss.StaffName,
-			ServiceName: ss.ServiceName,
-			CreatedAt:   ss.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:   ss.UpdatedAt.Format(time.RFC3339),
-		})
+	for i := range staffServices {
+		responses = append(responses, staffServiceResponse(&staffServices[i]))
 	}
 
 	json.NewEncoder(w).Encode(responses)
 }
+
+// findAssignment returns the details of a staff-service assignment, nil when
+// it isn't listed
+func (h *StaffServiceHandler) findAssignment(r *http.Request, businessID, staffID, serviceID string) (*domain.StaffServiceWithDetails, error) {
+	assignments, err := h.staffService.GetStaffServicesByBusiness(r.Context(), businessID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range assignments {
+		if assignments[i].StaffID == staffID && assignments[i].ServiceID == serviceID {
+			return &assignments[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func staffServiceResponse(ss *domain.StaffServiceWithDetails) dto.StaffServiceResponse {
+	return dto.StaffServiceResponse{
+		ID:          ss.ID,
+		StaffID:     ss.StaffID,
+		ServiceID:   ss.ServiceID,
+		StaffName:   ss.StaffName,
+		ServiceName: ss.ServiceName,
+		PriceCents:  ss.PriceCents,
+		DurationMin: ss.DurationMin,
+		CreatedAt:   ss.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   ss.UpdatedAt.Format(time.RFC3339),
+	}
+}
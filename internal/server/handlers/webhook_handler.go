@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/internal/usecase"
+	"github.com/ialekseychuk/my-place/pkg/validate"
+)
+
+type WebhookHandler struct {
+	webhookService *usecase.WebhookService
+}
+
+func NewWebhookHandler(webhookService *usecase.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+func (h *WebhookHandler) Routes(ownership func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+	r.Post("/", h.CreateWebhook)
+	r.Get("/", h.GetWebhooks)
+
+	owned := r.With(ownership)
+	owned.Get("/{webhookID}", h.GetWebhook)
+	owned.Patch("/{webhookID}", h.UpdateWebhook)
+	owned.Delete("/{webhookID}", h.DeleteWebhook)
+	owned.Post("/{webhookID}/rotate-secret", h.RotateWebhookSecret)
+	owned.Post("/{webhookID}/test", h.SendTestEvent)
+	owned.Get("/{webhookID}/deliveries", h.GetDeliveries)
+	owned.Get("/{webhookID}/deliveries/{deliveryID}", h.GetDelivery)
+	owned.Post("/{webhookID}/deliveries/{deliveryID}/retry", h.RetryDelivery)
+	return r
+}
+
+// @Summary Create webhook
+// @Description Register an endpoint receiving the subscribed events as signed POST requests. The signing secret is returned only once; verify the X-MyPlace-Signature header ("t=<unix>,v1=<hex HMAC-SHA256 of "<t>.<body>">") with it
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param webhook body dto.CreateWebhookEndpointRequest true "Webhook data"
+// @Success 201 {object} dto.WebhookEndpointResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	var req dto.CreateWebhookEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	webhook, err := h.webhookService.CreateEndpoint(r.Context(), businessID, req)
+	if err != nil {
+		webhookErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(webhook); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get webhooks
+// @Description Get all webhook endpoints of a business, without their secrets
+// @Tags Webhooks
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Success 200 {array} dto.WebhookEndpointResponse
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/webhooks [get]
+func (h *WebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	businessID := chi.URLParam(r, "businessID")
+
+	webhooks, err := h.webhookService.ListEndpoints(r.Context(), businessID)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(webhooks); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get webhook
+// @Description Get a webhook endpoint
+// @Tags Webhooks
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param webhookID path string true "Webhook ID"
+// @Success 200 {object} dto.WebhookEndpointResponse
+// @Failure 404 {object} dto.ErrorResponse "Webhook not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/webhooks/{webhookID} [get]
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, err := h.webhookService.GetEndpoint(r.Context(), chi.URLParam(r, "webhookID"))
+	if err != nil {
+		webhookErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(webhook); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update webhook
+// @Description Change the URL, description, subscribed events or active flag of a webhook endpoint. Inactive endpoints get no new deliveries
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param webhookID path string true "Webhook ID"
+// @Param webhook body dto.UpdateWebhookEndpointRequest true "Webhook changes"
+// @Success 200 {object} dto.WebhookEndpointResponse
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 404 {object} dto.ErrorResponse "Webhook not found"
+// @Failure 422 {object} map[string]string "Validation errors"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/webhooks/{webhookID} [patch]
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateWebhookEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validate.Struct(req); errs != nil {
+		ValidationErrorsResponse(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateEndpoint(r.Context(), chi.URLParam(r, "webhookID"), req)
+	if err != nil {
+		webhookErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(webhook); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Delete webhook
+// @Description Delete a webhook endpoint together with its delivery log
+// @Tags Webhooks
+// @Param businessID path string true "Business ID"
+// @Param webhookID path string true "Webhook ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} dto.ErrorResponse "Webhook not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/webhooks/{webhookID} [delete]
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := h.webhookService.DeleteEndpoint(r.Context(), chi.URLParam(r, "webhookID")); err != nil {
+		webhookErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Rotate webhook secret
+// @Description Replace the signing secret of a webhook endpoint. The new secret is returned only once
+// @Tags Webhooks
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param webhookID path string true "Webhook ID"
+// @Success 200 {object} dto.WebhookEndpointResponse
+// @Failure 404 {object} dto.ErrorResponse "Webhook not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/webhooks/{webhookID}/rotate-secret [post]
+func (h *WebhookHandler) RotateWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	webhook, err := h.webhookService.RotateSecret(r.Context(), chi.URLParam(r, "webhookID"))
+	if err != nil {
+		webhookErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(webhook); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Send test event
+// @Description Deliver a webhook.test event to the endpoint right away and return the delivery with its result. Test events aren't retried
+// @Tags Webhooks
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param webhookID path string true "Webhook ID"
+// @Success 200 {object} dto.WebhookDeliveryResponse
+// @Failure 404 {object} dto.ErrorResponse "Webhook not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/webhooks/{webhookID}/test [post]
+func (h *WebhookHandler) SendTestEvent(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.webhookService.SendTestEvent(r.Context(), chi.URLParam(r, "webhookID"))
+	if err != nil {
+		webhookErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(delivery); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get webhook deliveries
+// @Description Get the latest 100 deliveries of a webhook endpoint, newest first
+// @Tags Webhooks
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param webhookID path string true "Webhook ID"
+// @Success 200 {array} dto.WebhookDeliveryResponse
+// @Failure 404 {object} dto.ErrorResponse "Webhook not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/webhooks/{webhookID}/deliveries [get]
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	deliveries, err := h.webhookService.ListDeliveries(r.Context(), chi.URLParam(r, "webhookID"))
+	if err != nil {
+		webhookErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(deliveries); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get webhook delivery
+// @Description Get a delivery with its payload and the log of its attempts
+// @Tags Webhooks
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param webhookID path string true "Webhook ID"
+// @Param deliveryID path string true "Delivery ID"
+// @Success 200 {object} dto.WebhookDeliveryResponse
+// @Failure 404 {object} dto.ErrorResponse "Delivery not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/webhooks/{webhookID}/deliveries/{deliveryID} [get]
+func (h *WebhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.webhookService.GetDelivery(r.Context(), chi.URLParam(r, "webhookID"), chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		webhookErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(delivery); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Retry webhook delivery
+// @Description Queue a delivery again, e.g. a dead one after the receiver was fixed
+// @Tags Webhooks
+// @Produce json
+// @Param businessID path string true "Business ID"
+// @Param webhookID path string true "Webhook ID"
+// @Param deliveryID path string true "Delivery ID"
+// @Success 200 {object} dto.WebhookDeliveryResponse
+// @Failure 404 {object} dto.ErrorResponse "Delivery not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Security Bearer
+// @Router /api/v1/businesses/{businessID}/webhooks/{webhookID}/deliveries/{deliveryID}/retry [post]
+func (h *WebhookHandler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.webhookService.RetryDelivery(r.Context(), chi.URLParam(r, "webhookID"), chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		webhookErrorResponse(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(delivery); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func webhookErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrWebhookNotFound), errors.Is(err, usecase.ErrWebhookDeliveryNotFound):
+		ErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, usecase.ErrUnknownEventType), errors.Is(err, usecase.ErrInvalidWebhookURL):
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+	}
+}
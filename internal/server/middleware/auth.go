@@ -12,7 +12,10 @@ import (
 type contextKey string
 
 const (
-	UserContextKey contextKey = "user"
+	UserContextKey        contextKey = "user"
+	ClaimsContextKey      contextKey = "claims"
+	PermissionsContextKey contextKey = "permissions"
+	APIKeyContextKey      contextKey = "api_key"
 )
 
 // JWTMiddleware validates JWT tokens and adds user to request context
@@ -34,31 +37,72 @@ func JWTMiddleware(authService *usecase.AuthService) func(http.Handler) http.Han
 			}
 
 			// Validate JWT token
-			user, err := authService.ValidateToken(tokenParts[1])
+			user, claims, err := authService.ValidateToken(tokenParts[1])
 			if err != nil {
 				ErrorResponse(w, http.StatusUnauthorized, "Invalid token")
 				return
 			}
 
-			// Add user to request context
+			permissions, err := authService.EffectivePermissions(r.Context(), user)
+			if err != nil {
+				ErrorResponse(w, http.StatusInternalServerError, "Failed to resolve permissions")
+				return
+			}
+
+			// Add user, token claims and permissions to request context
 			ctx := context.WithValue(r.Context(), UserContextKey, user)
+			ctx = context.WithValue(ctx, ClaimsContextKey, claims)
+			ctx = context.WithValue(ctx, PermissionsContextKey, permissions)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-// RequireRole middleware checks if user has required role
-func RequireRole(role string) func(http.Handler) http.Handler {
+// Authenticate middleware accepts either a JWT access token
+// ("Authorization: Bearer ...") or an API key ("Authorization: ApiKey ...").
+// Requests with an API key act as the user who created the key, with the
+// key's scopes as permissions.
+func Authenticate(authService *usecase.AuthService, apiKeyService *usecase.APIKeyService) func(http.Handler) http.Handler {
+	jwtMiddleware := JWTMiddleware(authService)
 	return func(next http.Handler) http.Handler {
+		jwtNext := jwtMiddleware(next)
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			user := GetUserFromContext(r.Context())
-			if user == nil {
-				ErrorResponse(w, http.StatusUnauthorized, "User not found in context")
+			secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "ApiKey ")
+			if !ok {
+				jwtNext.ServeHTTP(w, r)
 				return
 			}
 
-			if user.Role != role {
-				ErrorResponse(w, http.StatusForbidden, "Insufficient permissions")
+			user, key, permissions, err := apiKeyService.Authenticate(r.Context(), strings.TrimSpace(secret))
+			if err != nil {
+				ErrorResponse(w, http.StatusUnauthorized, "Invalid API key")
+				return
+			}
+
+			// Claims carry the key's business for the tenant checks
+			claims := &domain.JWTClaims{
+				UserID:     user.ID,
+				BusinessID: key.BusinessID,
+				Email:      user.Email,
+				Role:       user.Role,
+			}
+
+			ctx := context.WithValue(r.Context(), UserContextKey, user)
+			ctx = context.WithValue(ctx, ClaimsContextKey, claims)
+			ctx = context.WithValue(ctx, PermissionsContextKey, permissions)
+			ctx = context.WithValue(ctx, APIKeyContextKey, key)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// DenyAPIKey middleware rejects requests authenticated with an API key, for
+// routes that only make sense for a logged in user
+func DenyAPIKey() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if GetAPIKeyFromContext(r.Context()) != nil {
+				ErrorResponse(w, http.StatusForbidden, "API keys are not accepted for this resource")
 				return
 			}
 
@@ -67,63 +111,63 @@ func RequireRole(role string) func(http.Handler) http.Handler {
 	}
 }
 
-// RequireAnyRole middleware checks if user has any of the specified roles
-func RequireAnyRole(roles ...string) func(http.Handler) http.Handler {
+// RequirePermission middleware checks if user holds all of the given permissions
+func RequirePermission(permissions ...domain.Permission) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			user := GetUserFromContext(r.Context())
-			if user == nil {
+			if GetUserFromContext(r.Context()) == nil {
 				ErrorResponse(w, http.StatusUnauthorized, "User not found in context")
 				return
 			}
 
-			hasRole := false
-			for _, role := range roles {
-				if user.Role == role {
-					hasRole = true
-					break
+			for _, permission := range permissions {
+				if !HasPermission(r.Context(), permission) {
+					ErrorResponse(w, http.StatusForbidden, "Insufficient permissions")
+					return
 				}
 			}
 
-			if !hasRole {
-				ErrorResponse(w, http.StatusForbidden, "Insufficient permissions")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireMethodPermission middleware checks the read permission for safe
+// methods (GET, HEAD) and the write permission for everything else
+func RequireMethodPermission(read, write domain.Permission) func(http.Handler) http.Handler {
+	readGuard := RequirePermission(read)
+	writeGuard := RequirePermission(write)
+	return func(next http.Handler) http.Handler {
+		readNext := readGuard(next)
+		writeNext := writeGuard(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				readNext.ServeHTTP(w, r)
 				return
 			}
-
-			next.ServeHTTP(w, r)
+			writeNext.ServeHTTP(w, r)
 		})
 	}
 }
 
-// RequireBusinessOwner middleware checks if user is owner of the business
+// RequireBusinessOwner middleware checks if user is owner of the business in the path
 func RequireBusinessOwner() func(http.Handler) http.Handler {
+	tenant := RequireTenant()
 	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		return tenant(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			user := GetUserFromContext(r.Context())
 			if user == nil {
 				ErrorResponse(w, http.StatusUnauthorized, "User not found in context")
 				return
 			}
 
-			// Extract business ID from URL parameters
-			businessID := r.URL.Query().Get("businessID")
-			if businessID == "" {
-				// Try to get from path parameters (this would need chi router context)
-				// For now, we'll check if user is an owner
-				if user.Role != "owner" {
-					ErrorResponse(w, http.StatusForbidden, "Only business owners can access this resource")
-					return
-				}
-			} else {
-				// Check if user owns this specific business
-				if user.BusinessID != businessID && user.Role != "owner" {
-					ErrorResponse(w, http.StatusForbidden, "Access denied to this business")
-					return
-				}
+			if user.Role != domain.RoleOwner {
+				ErrorResponse(w, http.StatusForbidden, "Only business owners can access this resource")
+				return
 			}
 
 			next.ServeHTTP(w, r)
-		})
+		}))
 	}
 }
 
@@ -136,6 +180,39 @@ func GetUserFromContext(ctx context.Context) *domain.User {
 	return user
 }
 
+// GetClaimsFromContext extracts JWT claims from request context
+func GetClaimsFromContext(ctx context.Context) *domain.JWTClaims {
+	claims, ok := ctx.Value(ClaimsContextKey).(*domain.JWTClaims)
+	if !ok {
+		return nil
+	}
+	return claims
+}
+
+// GetAPIKeyFromContext extracts the API key of the request, nil for requests
+// authenticated with an access token
+func GetAPIKeyFromContext(ctx context.Context) *domain.APIKey {
+	key, ok := ctx.Value(APIKeyContextKey).(*domain.APIKey)
+	if !ok {
+		return nil
+	}
+	return key
+}
+
+// GetPermissionsFromContext extracts the effective permissions of the user from request context
+func GetPermissionsFromContext(ctx context.Context) []domain.Permission {
+	permissions, ok := ctx.Value(PermissionsContextKey).([]domain.Permission)
+	if !ok {
+		return nil
+	}
+	return permissions
+}
+
+// HasPermission reports whether the user in request context holds the permission
+func HasPermission(ctx context.Context, permission domain.Permission) bool {
+	return domain.HasPermission(GetPermissionsFromContext(ctx), permission)
+}
+
 // ErrorResponse sends JSON error response
 func ErrorResponse(w http.ResponseWriter, code int, message string) {
 	w.Header().Set("Content-Type", "application/json")
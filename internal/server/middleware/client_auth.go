@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/usecase"
+)
+
+const ClientContextKey contextKey = "client"
+
+// ClientAuth middleware validates client portal tokens and adds the client to
+// request context. Staff tokens are rejected.
+func ClientAuth(portalService *usecase.ClientPortalService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				ErrorResponse(w, http.StatusUnauthorized, "Missing authorization header")
+				return
+			}
+
+			tokenParts := strings.Split(authHeader, " ")
+			if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+				ErrorResponse(w, http.StatusUnauthorized, "Invalid authorization header format")
+				return
+			}
+
+			client, _, err := portalService.ValidateToken(r.Context(), tokenParts[1])
+			if err != nil {
+				ErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), ClientContextKey, client)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetClientFromContext extracts the logged-in client from request context
+func GetClientFromContext(ctx context.Context) *domain.Client {
+	client, ok := ctx.Value(ClientContextKey).(*domain.Client)
+	if !ok {
+		return nil
+	}
+	return client
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type rateWindow struct {
+	count   int
+	resetAt time.Time
+}
+
+// RateLimit middleware allows at most limit requests per window from a single
+// client IP, as resolved by RealIP, and answers 429 with a Retry-After header
+// beyond that. Counters are kept in memory, so every API instance limits on
+// its own.
+func RateLimit(limit int, window time.Duration) func(http.Handler) http.Handler {
+	var (
+		mu        sync.Mutex
+		windows   = make(map[string]*rateWindow)
+		lastSweep = time.Now()
+	)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := ClientIP(r)
+			now := time.Now()
+
+			mu.Lock()
+			// Forget expired windows so idle clients don't pile up
+			if now.Sub(lastSweep) > window {
+				for key, win := range windows {
+					if !now.Before(win.resetAt) {
+						delete(windows, key)
+					}
+				}
+				lastSweep = now
+			}
+
+			win, ok := windows[ip]
+			if !ok || !now.Before(win.resetAt) {
+				win = &rateWindow{resetAt: now.Add(window)}
+				windows[ip] = win
+			}
+			win.count++
+			allowed := win.count <= limit
+			retryAfter := win.resetAt.Sub(now)
+			mu.Unlock()
+
+			if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+				ErrorResponse(w, http.StatusTooManyRequests, "Too many requests, try again later")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
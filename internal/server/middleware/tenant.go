@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ialekseychuk/my-place/internal/domain"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ownedParams maps chi URL parameters of tenant routes to the resource they reference
+var ownedParams = map[string]domain.Resource{
+	"staffID":    domain.ResourceStaff,
+	"serviceID":  domain.ResourceService,
+	"locationID": domain.ResourceLocation,
+	"clientID":   domain.ResourceClient,
+	"shiftID":    domain.ResourceShift,
+	"templateID": domain.ResourceScheduleTemplate,
+	"requestID":  domain.ResourceTimeOffRequest,
+	"bookingID":  domain.ResourceBooking,
+	"roleID":     domain.ResourceRole,
+	"apiKeyID":   domain.ResourceAPIKey,
+	"webhookID":  domain.ResourceWebhook,
+	"feedID":     domain.ResourceCalendarFeed,
+	"noteID":     domain.ResourceClientNote,
+	"tagID":      domain.ResourceClientTag,
+	"fieldID":    domain.ResourceClientField,
+	"segmentID":  domain.ResourceClientSegment,
+	"jobID":      domain.ResourceClientJob,
+	"categoryID": domain.ResourceServiceCategory,
+}
+
+// RequireTenant middleware checks that the businessID path parameter matches
+// the business the access token was issued for
+func RequireTenant() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := GetClaimsFromContext(r.Context())
+			if claims == nil {
+				ErrorResponse(w, http.StatusUnauthorized, "User not found in context")
+				return
+			}
+
+			businessID := chi.URLParam(r, "businessID")
+			if businessID == "" || businessID != claims.BusinessID {
+				ErrorResponse(w, http.StatusForbidden, "Access denied to this business")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireOwnership middleware checks that every known child ID in the path
+// (staffID, serviceID, ...) belongs to the business from the path. It has to
+// be applied on the routes declaring those parameters, since chi only parses
+// them once the route is matched. Foreign IDs are reported as not found.
+func RequireOwnership(repo domain.OwnershipRepository) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rctx := chi.RouteContext(r.Context())
+			if rctx == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			businessID := chi.URLParam(r, "businessID")
+			for i, key := range rctx.URLParams.Keys {
+				resource, ok := ownedParams[key]
+				if !ok {
+					continue
+				}
+
+				id := rctx.URLParams.Values[i]
+				if !uuidPattern.MatchString(id) {
+					ErrorResponse(w, http.StatusNotFound, "Resource not found")
+					return
+				}
+
+				owned, err := repo.BelongsToBusiness(r.Context(), businessID, resource, id)
+				if err != nil {
+					ErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+					return
+				}
+				if !owned {
+					ErrorResponse(w, http.StatusNotFound, "Resource not found")
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/pkg/mailer"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidUserToken       = errors.New("token is invalid or expired")
+	ErrEmailAlreadyVerified   = errors.New("email is already verified")
+	ErrInvalidCurrentPassword = errors.New("current password is incorrect")
+)
+
+// AccountService handles the email based account flows: email verification,
+// password reset and password change.
+type AccountService struct {
+	userRepo       domain.UserRepository
+	tokenRepo      domain.UserTokenRepository
+	sessionRepo    domain.SessionRepository
+	mailSender     mailer.Sender
+	appURL         string
+	verifyTokenTTL time.Duration
+	resetTokenTTL  time.Duration
+}
+
+func NewAccountService(
+	userRepo domain.UserRepository,
+	tokenRepo domain.UserTokenRepository,
+	sessionRepo domain.SessionRepository,
+	mailSender mailer.Sender,
+	appURL string,
+) *AccountService {
+	return &AccountService{
+		userRepo:       userRepo,
+		tokenRepo:      tokenRepo,
+		sessionRepo:    sessionRepo,
+		mailSender:     mailSender,
+		appURL:         strings.TrimRight(appURL, "/"),
+		verifyTokenTTL: 72 * time.Hour, // 3 days
+		resetTokenTTL:  time.Hour,
+	}
+}
+
+// SendEmailVerification emails a new verification link to the user.
+func (s *AccountService) SendEmailVerification(ctx context.Context, userID string) error {
+	user, err := s.userRepo.GetById(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := s.issueToken(ctx, user.ID, domain.TokenPurposeEmailVerification, s.verifyTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, user.Email, "Confirm your email address", fmt.Sprintf(
+		"Hello %s,\n\nplease confirm your email address by opening the link below:\n\n%s/verify-email?token=%s\n\nThe link is valid for %d hours.\n",
+		user.FirstName, s.appURL, token, int(s.verifyTokenTTL.Hours())))
+}
+
+func (s *AccountService) VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) error {
+	token, err := s.consumeToken(ctx, domain.TokenPurposeEmailVerification, req.Token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.MarkEmailVerified(ctx, token.UserID); err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+	return nil
+}
+
+// ForgotPassword emails a password reset link. Unknown or disabled accounts
+// are ignored silently so the endpoint can't be used to probe emails.
+func (s *AccountService) ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) error {
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil || !user.IsActive {
+		return nil
+	}
+
+	token, err := s.issueToken(ctx, user.ID, domain.TokenPurposePasswordReset, s.resetTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, user.Email, "Reset your password", fmt.Sprintf(
+		"Hello %s,\n\nsomeone requested a password reset for your account. Open the link below to choose a new password:\n\n%s/reset-password?token=%s\n\nThe link is valid for %d minutes. If you didn't request it, just ignore this email.\n",
+		user.FirstName, s.appURL, token, int(s.resetTokenTTL.Minutes())))
+}
+
+// ResetPassword sets a new password using a reset token and signs the user
+// out everywhere.
+func (s *AccountService) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error {
+	token, err := s.consumeToken(ctx, domain.TokenPurposePasswordReset, req.Token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.setPassword(ctx, token.UserID, req.Password); err != nil {
+		return err
+	}
+
+	// The reset link proves ownership of the mailbox as well
+	if err := s.userRepo.MarkEmailVerified(ctx, token.UserID); err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+	return nil
+}
+
+// ChangePassword replaces the password of an authenticated user after
+// checking the current one. All sessions, including the current one, are
+// revoked.
+func (s *AccountService) ChangePassword(ctx context.Context, userID string, req dto.ChangePasswordRequest) error {
+	user, err := s.userRepo.GetById(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)) != nil {
+		return ErrInvalidCurrentPassword
+	}
+
+	return s.setPassword(ctx, user.ID, req.NewPassword)
+}
+
+func (s *AccountService) setPassword(ctx context.Context, userID, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, userID, string(hashedPassword)); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if err := s.sessionRepo.RevokeAllByUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+func (s *AccountService) issueToken(ctx context.Context, userID string, purpose domain.TokenPurpose, ttl time.Duration) (string, error) {
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	userToken := &domain.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.tokenRepo.Create(ctx, userToken); err != nil {
+		return "", fmt.Errorf("failed to create token: %w", err)
+	}
+	return token, nil
+}
+
+func (s *AccountService) consumeToken(ctx context.Context, purpose domain.TokenPurpose, token string) (*domain.UserToken, error) {
+	userToken, err := s.tokenRepo.GetByTokenHash(ctx, purpose, hashToken(token))
+	if err != nil || !userToken.IsUsable() {
+		return nil, ErrInvalidUserToken
+	}
+
+	// Consume is conditional, so a token can't be redeemed twice concurrently
+	if err := s.tokenRepo.Consume(ctx, userToken.ID); err != nil {
+		return nil, ErrInvalidUserToken
+	}
+	return userToken, nil
+}
+
+func (s *AccountService) send(ctx context.Context, to, subject, body string) error {
+	if err := s.mailSender.Send(ctx, mailer.Message{To: to, Subject: subject, Body: body}); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+)
+
+var (
+	ErrInvalidAPIKey      = errors.New("invalid api key")
+	ErrAPIKeyScopeDenied  = errors.New("scope can't be granted to an api key")
+	ErrAPIKeyExpiryInPast = errors.New("expires_at must be in the future")
+)
+
+const (
+	apiKeyPrefix       = "mpk_"
+	apiKeyDisplayChars = 12
+)
+
+// apiKeyForbiddenScopes can't be delegated to keys, so a leaked key can't
+// mint new keys or escalate its own permissions.
+var apiKeyForbiddenScopes = []domain.Permission{domain.PermAPIKeysManage, domain.PermRolesManage}
+
+type APIKeyService struct {
+	apiKeyRepo  domain.APIKeyRepository
+	userRepo    domain.UserRepository
+	authService *AuthService
+}
+
+func NewAPIKeyService(apiKeyRepo domain.APIKeyRepository, userRepo domain.UserRepository, authService *AuthService) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo:  apiKeyRepo,
+		userRepo:    userRepo,
+		authService: authService,
+	}
+}
+
+// CreateKey issues a new key. The secret is only part of this response; just
+// its hash is persisted.
+func (s *APIKeyService) CreateKey(ctx context.Context, businessID, createdBy string, req dto.CreateAPIKeyRequest) (*dto.APIKeyResponse, error) {
+	scopes, err := parsePermissions(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	for _, scope := range scopes {
+		if domain.HasPermission(apiKeyForbiddenScopes, scope) {
+			return nil, fmt.Errorf("%w: %s", ErrAPIKeyScopeDenied, scope)
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrAPIKeyExpiryInPast
+	}
+
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	secret := apiKeyPrefix + token
+
+	key := &domain.APIKey{
+		BusinessID: businessID,
+		Name:       req.Name,
+		KeyPrefix:  secret[:apiKeyDisplayChars],
+		KeyHash:    hashToken(secret),
+		Scopes:     scopes,
+		CreatedBy:  createdBy,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	response := convertAPIKeyToResponse(key)
+	response.Key = secret
+	return &response, nil
+}
+
+func (s *APIKeyService) ListKeys(ctx context.Context, businessID string) ([]dto.APIKeyResponse, error) {
+	keys, err := s.apiKeyRepo.ListByBusiness(ctx, businessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+
+	responses := make([]dto.APIKeyResponse, len(keys))
+	for i, key := range keys {
+		responses[i] = convertAPIKeyToResponse(key)
+	}
+	return responses, nil
+}
+
+func (s *APIKeyService) RevokeKey(ctx context.Context, keyID string) error {
+	if err := s.apiKeyRepo.Revoke(ctx, keyID); err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return nil
+}
+
+// Authenticate resolves a presented key to the user who created it and the
+// permissions of the request: the key's scopes, as far as the creator still
+// holds them.
+func (s *APIKeyService) Authenticate(ctx context.Context, secret string) (*domain.User, *domain.APIKey, []domain.Permission, error) {
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, nil, nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetByHash(ctx, hashToken(secret))
+	if err != nil || !key.IsActive() {
+		return nil, nil, nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.userRepo.GetById(ctx, key.CreatedBy)
+	if err != nil || !user.IsActive || user.BusinessID != key.BusinessID {
+		return nil, nil, nil, ErrInvalidAPIKey
+	}
+
+	granted, err := s.authService.EffectivePermissions(ctx, user)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	permissions := make([]domain.Permission, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		if domain.HasPermission(granted, scope) {
+			permissions = append(permissions, scope)
+		}
+	}
+
+	if err := s.apiKeyRepo.TouchLastUsed(ctx, key.ID); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to update api key usage: %w", err)
+	}
+
+	user.Password = ""
+	return user, key, permissions, nil
+}
+
+func convertAPIKeyToResponse(key *domain.APIKey) dto.APIKeyResponse {
+	status := "active"
+	switch {
+	case key.RevokedAt != nil:
+		status = "revoked"
+	case !key.IsActive():
+		status = "expired"
+	}
+
+	return dto.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		KeyPrefix:  key.KeyPrefix,
+		Scopes:     permissionStrings(key.Scopes),
+		Status:     status,
+		CreatedBy:  key.CreatedBy,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
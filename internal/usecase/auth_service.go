@@ -3,131 +3,402 @@ package usecase
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/ialekseychuk/my-place/internal/domain"
 	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/pkg/jwtkeys"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, all sessions of this login were revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrInvalidMFAChallenge = errors.New("login challenge is invalid or expired")
+)
+
 type AuthService struct {
 	userRepo               domain.UserRepository
-	jwtSecret              string
+	sessionRepo            domain.SessionRepository
+	roleRepo               domain.RoleRepository
+	tokenRepo              domain.UserTokenRepository
+	loginGuard             *LoginGuard
+	mfaService             *MFAService
+	keys                   *jwtkeys.KeySet
 	accessTokenExpiryTime  time.Duration
 	refreshTokenExpiryTime time.Duration
+	mfaChallengeExpiryTime time.Duration
 }
 
-func NewAuthService(userRepo domain.UserRepository, jwtSecret string) *AuthService {
+func NewAuthService(
+	userRepo domain.UserRepository,
+	sessionRepo domain.SessionRepository,
+	roleRepo domain.RoleRepository,
+	tokenRepo domain.UserTokenRepository,
+	loginGuard *LoginGuard,
+	mfaService *MFAService,
+	keys *jwtkeys.KeySet,
+) *AuthService {
 	return &AuthService{
 		userRepo:               userRepo,
-		jwtSecret:              jwtSecret,
+		sessionRepo:            sessionRepo,
+		roleRepo:               roleRepo,
+		tokenRepo:              tokenRepo,
+		loginGuard:             loginGuard,
+		mfaService:             mfaService,
+		keys:                   keys,
 		accessTokenExpiryTime:  24 * time.Hour,      // 24 hours
 		refreshTokenExpiryTime: 30 * 24 * time.Hour, // 30 days
+		mfaChallengeExpiryTime: 5 * time.Minute,
 	}
 }
 
-func (s *AuthService) Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error) {
+func (s *AuthService) Login(ctx context.Context, req dto.LoginRequest, client domain.ClientInfo) (*dto.LoginResponse, error) {
+	if err := s.loginGuard.Check(ctx, req.Email, client); err != nil {
+		return nil, err
+	}
+
 	// Find user by email
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
+		if err := s.loginGuard.Fail(ctx, req.Email, "", domain.LoginFailureInvalidCredentials, client); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("invalid email or password")
+	}
+
+	// Verify password
+	if !s.verifyPassword(req.Password, user.Password) {
+		if err := s.loginGuard.Fail(ctx, req.Email, user.ID, domain.LoginFailureInvalidCredentials, client); err != nil {
+			return nil, err
+		}
 		return nil, errors.New("invalid email or password")
 	}
 
 	// Check if user is active
 	if !user.IsActive {
+		if err := s.loginGuard.Fail(ctx, req.Email, user.ID, domain.LoginFailureAccountDisabled, client); err != nil {
+			return nil, err
+		}
 		return nil, errors.New("account is disabled")
 	}
 
-	// Verify password
-	if !s.verifyPassword(req.Password, user.Password) {
-		return nil, errors.New("invalid email or password")
+	mfaEnabled, err := s.mfaService.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfaEnabled {
+		return s.issueMFAChallenge(ctx, user)
 	}
 
-	// Generate tokens
-	accessToken, err := s.generateAccessToken(user)
+	return s.completeLogin(ctx, user, client)
+}
+
+// VerifyMFA is the second login step of users with two-factor
+// authentication. It exchanges the challenge token from Login and a TOTP or
+// recovery code for the token pair. Wrong codes count as failed logins.
+func (s *AuthService) VerifyMFA(ctx context.Context, req dto.MFAVerifyRequest, client domain.ClientInfo) (*dto.LoginResponse, error) {
+	challenge, err := s.tokenRepo.GetByTokenHash(ctx, domain.TokenPurposeMFAChallenge, hashToken(req.ChallengeToken))
+	if err != nil || !challenge.IsUsable() {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	user, err := s.userRepo.GetById(ctx, challenge.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
+		return nil, ErrInvalidMFAChallenge
+	}
+	if !user.IsActive {
+		return nil, errors.New("account is disabled")
 	}
 
-	refreshToken, err := s.generateRefreshToken()
+	if err := s.loginGuard.Check(ctx, user.Email, client); err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaService.Verify(ctx, user.ID, req.Code); err != nil {
+		if !errors.Is(err, ErrInvalidMFACode) {
+			return nil, err
+		}
+		if err := s.loginGuard.Fail(ctx, user.Email, user.ID, domain.LoginFailureInvalidMFACode, client); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+
+	if err := s.tokenRepo.Consume(ctx, challenge.ID); err != nil {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	return s.completeLogin(ctx, user, client)
+}
+
+// issueMFAChallenge answers the password step of a login that still needs a
+// second factor.
+func (s *AuthService) issueMFAChallenge(ctx context.Context, user *domain.User) (*dto.LoginResponse, error) {
+	token, err := generateOpaqueToken()
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+		return nil, err
+	}
+
+	challenge := &domain.UserToken{
+		UserID:    user.ID,
+		Purpose:   domain.TokenPurposeMFAChallenge,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.mfaChallengeExpiryTime),
+	}
+	if err := s.tokenRepo.Create(ctx, challenge); err != nil {
+		return nil, fmt.Errorf("failed to create login challenge: %w", err)
+	}
+
+	return &dto.LoginResponse{
+		MFARequired:        true,
+		ChallengeToken:     token,
+		ChallengeExpiresAt: &challenge.ExpiresAt,
+	}, nil
+}
+
+// completeLogin records the successful login and issues the token pair.
+func (s *AuthService) completeLogin(ctx context.Context, user *domain.User, client domain.ClientInfo) (*dto.LoginResponse, error) {
+	if err := s.loginGuard.Succeed(ctx, user.Email, user.ID, client); err != nil {
+		return nil, err
+	}
+
+	// A fresh login starts a new session family
+	token, err := s.issueTokens(ctx, user, "", "", client)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.EffectivePermissions(ctx, user)
+	if err != nil {
+		return nil, err
 	}
 
 	// Don't return password in response
 	user.Password = ""
 
 	return &dto.LoginResponse{
-		User: user,
-		Token: &domain.AuthToken{
-			AccessToken:  accessToken,
-			RefreshToken: refreshToken,
-			ExpiresAt:    time.Now().Add(s.accessTokenExpiryTime),
-			TokenType:    "Bearer",
-		},
+		User:        user,
+		Token:       token,
+		Permissions: permissions,
 	}, nil
 }
 
-func (s *AuthService) RefreshToken(ctx context.Context, req dto.RefreshTokenRequest) (*dto.RefreshTokenResponse, error) {
-	// In a production system, you would validate the refresh token against stored sessions
-	// For now, we'll implement a simple refresh mechanism
+// EffectivePermissions resolves what a user may do: owners always hold every
+// permission, other users get their custom role when one is assigned and the
+// bundle of their built-in role otherwise.
+func (s *AuthService) EffectivePermissions(ctx context.Context, user *domain.User) ([]domain.Permission, error) {
+	if user.Role == domain.RoleOwner || user.RoleID == "" {
+		return domain.BuiltinRolePermissions(user.Role), nil
+	}
+
+	role, err := s.roleRepo.GetByID(ctx, user.RoleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	return role.Permissions, nil
+}
 
-	// Parse the refresh token to get user info (this is simplified)
-	// In production, store refresh tokens in database with expiry
+// RefreshToken exchanges a refresh token for a new token pair. The presented
+// session is rotated; presenting an already rotated token revokes the whole
+// session family since the token must have leaked.
+func (s *AuthService) RefreshToken(ctx context.Context, req dto.RefreshTokenRequest, client domain.ClientInfo) (*dto.RefreshTokenResponse, error) {
 	if req.RefreshToken == "" {
 		return nil, errors.New("refresh token is required")
 	}
 
-	// For demonstration, we'll generate a new access token
-	// In production, validate refresh token and get user from database
-	return nil, errors.New("refresh token functionality not fully implemented")
+	session, err := s.sessionRepo.GetByTokenHash(ctx, hashToken(req.RefreshToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if session.IsRotated() {
+		if err := s.sessionRepo.RevokeFamily(ctx, session.FamilyID); err != nil {
+			return nil, fmt.Errorf("failed to revoke session family: %w", err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	if session.IsRevoked || session.ExpiresAt.Before(time.Now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetById(ctx, session.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if !user.IsActive {
+		return nil, errors.New("account is disabled")
+	}
+
+	token, err := s.issueTokens(ctx, user, session.ID, session.FamilyID, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.RefreshTokenResponse{Token: token}, nil
 }
 
-func (s *AuthService) ValidateToken(tokenString string) (*domain.User, error) {
-	// Parse the token
-	token, err := jwt.ParseWithClaims(tokenString, &domain.JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+// Logout revokes the session the access token was issued for, together with
+// the sessions it was rotated from.
+func (s *AuthService) Logout(ctx context.Context, sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+
+	if err := s.sessionRepo.RevokeFamily(ctx, session.FamilyID); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+func (s *AuthService) ListSessions(ctx context.Context, userID, currentSessionID string) ([]dto.SessionResponse, error) {
+	sessions, err := s.sessionRepo.ListActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	responses := make([]dto.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		responses[i] = dto.SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			Current:    session.ID == currentSessionID,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			CreatedAt:  session.CreatedAt,
 		}
-		return []byte(s.jwtSecret), nil
-	})
+	}
+
+	return responses, nil
+}
+
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	if err := s.sessionRepo.RevokeFamily(ctx, session.FamilyID); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+func (s *AuthService) ValidateToken(tokenString string) (*domain.User, *domain.JWTClaims, error) {
+	// Parse the token
+	// The key set picks the key by kid and checks it matches the algorithm
+	token, err := jwt.ParseWithClaims(tokenString, &domain.JWTClaims{}, s.keys.Keyfunc,
+		jwt.WithValidMethods(s.keys.ValidMethods()))
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse token: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse token: %w", err)
 	}
 
 	// Validate token and extract claims
 	claims, ok := token.Claims.(*domain.JWTClaims)
 	if !ok || !token.Valid {
-		return nil, errors.New("invalid token")
+		return nil, nil, errors.New("invalid token")
+	}
+
+	// Client portal tokens are signed with the same keys
+	if slices.Contains(claims.Audience, domain.ClientTokenAudience) {
+		return nil, nil, errors.New("invalid token")
 	}
 
 	// Check if token is expired
 	if time.Unix(claims.ExpiresAt, 0).Before(time.Now()) {
-		return nil, errors.New("token expired")
+		return nil, nil, errors.New("token expired")
+	}
+
+	// Access tokens die together with the session they were issued for. A
+	// rotated session stays usable until its family is revoked.
+	if claims.SessionID != "" {
+		session, err := s.sessionRepo.GetByID(context.Background(), claims.SessionID)
+		if err != nil || (session.IsRevoked && !session.IsRotated()) {
+			return nil, nil, errors.New("session revoked")
+		}
 	}
 
 	// Get user from database to ensure user still exists and is active
 	user, err := s.userRepo.GetById(context.Background(), claims.UserID)
 	if err != nil {
-		return nil, errors.New("user not found")
+		return nil, nil, errors.New("user not found")
 	}
 
 	if !user.IsActive {
-		return nil, errors.New("user account is disabled")
+		return nil, nil, errors.New("user account is disabled")
 	}
 
 	// Don't return password
 	user.Password = ""
-	return user, nil
+	return user, claims, nil
+}
+
+// JWKS returns the public keys access tokens can be verified with.
+func (s *AuthService) JWKS() jwtkeys.JWKSet {
+	return s.keys.JWKS()
 }
 
-func (s *AuthService) generateAccessToken(user *domain.User) (string, error) {
+// issueTokens creates a session holding a new refresh token and signs an
+// access token bound to it. When previousSessionID is set the previous
+// session is rotated into the new one within the same family.
+func (s *AuthService) issueTokens(ctx context.Context, user *domain.User, previousSessionID, familyID string, client domain.ClientInfo) (*domain.AuthToken, error) {
+	refreshToken, err := s.generateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	session := &domain.UserSession{
+		UserID:       user.ID,
+		FamilyID:     familyID,
+		RefreshToken: hashToken(refreshToken),
+		UserAgent:    client.UserAgent,
+		IPAddress:    client.IPAddress,
+		ExpiresAt:    time.Now().Add(s.refreshTokenExpiryTime),
+	}
+
+	if previousSessionID == "" {
+		err = s.sessionRepo.Create(ctx, session)
+	} else {
+		err = s.sessionRepo.Rotate(ctx, previousSessionID, session)
+	}
+	if err != nil {
+		if previousSessionID != "" {
+			// Lost a race against another refresh with the same token
+			_ = s.sessionRepo.RevokeFamily(ctx, familyID)
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	accessToken, err := s.generateAccessToken(user, session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	return &domain.AuthToken{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    time.Now().Add(s.accessTokenExpiryTime),
+		TokenType:    "Bearer",
+	}, nil
+}
+
+func (s *AuthService) generateAccessToken(user *domain.User, sessionID string) (string, error) {
 	now := time.Now()
 	expiryTime := now.Add(s.accessTokenExpiryTime)
 
@@ -136,12 +407,12 @@ func (s *AuthService) generateAccessToken(user *domain.User) (string, error) {
 		BusinessID: user.BusinessID,
 		Email:      user.Email,
 		Role:       user.Role,
+		SessionID:  sessionID,
 		IssuedAt:   now.Unix(),
 		ExpiresAt:  expiryTime.Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+	tokenString, err := s.keys.Sign(claims)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -150,16 +421,27 @@ func (s *AuthService) generateAccessToken(user *domain.User) (string, error) {
 }
 
 func (s *AuthService) generateRefreshToken() (string, error) {
-	// Generate a random refresh token
-	bytes := make([]byte, 32)
-	_, err := rand.Read(bytes)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate refresh token: %w", err)
-	}
-	return hex.EncodeToString(bytes), nil
+	return generateOpaqueToken()
 }
 
 func (s *AuthService) verifyPassword(plainPassword, hashedPassword string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(plainPassword))
 	return err == nil
 }
+
+// generateOpaqueToken returns a random 256-bit token for refresh tokens,
+// invitations and other one-time secrets.
+func generateOpaqueToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// hashToken returns the hex encoded SHA-256 of an opaque token, which is what
+// gets persisted instead of the token itself.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
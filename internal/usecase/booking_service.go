@@ -2,98 +2,271 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/ialekseychuk/my-place/internal/domain"
 	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrTimeSlotUnavailable = errors.New("time slot is not available")
+	ErrBookingNotConfirmed = errors.New("booking is not confirmed")
+	ErrBookingNotStarted   = errors.New("booking has not started yet")
+	ErrServiceInactive     = errors.New("service is not active")
+	// ErrBookingStaffForbidden is returned when a user limited to their own
+	// bookings tries to move one to another staff member
+	ErrBookingStaffForbidden = errors.New("not allowed to move the booking to another staff member")
 )
 
 type BookingService struct {
-	bookingRepo domain.BookingRepository
-	serviceRepo domain.ServiceRepository
-	staffRepo   domain.StaffRepository
-	clientRepo  domain.ClientRepository
+	bookingRepo      domain.BookingRepository
+	serviceRepo      domain.ServiceRepository
+	staffRepo        domain.StaffRepository
+	staffServiceRepo domain.StaffServiceRepository
+	clientRepo       domain.ClientRepository
+	businessRepo     domain.BusinessRepository
+	eventRepo        domain.EventRepository
+	transactor       domain.Transactor
 }
 
 func NewBookingService(
 	bookingRepo domain.BookingRepository,
 	serviceRepo domain.ServiceRepository,
 	staffRepo domain.StaffRepository,
-	clientRepo domain.ClientRepository) *BookingService {
+	staffServiceRepo domain.StaffServiceRepository,
+	clientRepo domain.ClientRepository,
+	businessRepo domain.BusinessRepository,
+	eventRepo domain.EventRepository,
+	transactor domain.Transactor) *BookingService {
 	return &BookingService{
-		bookingRepo: bookingRepo,
-		serviceRepo: serviceRepo,
-		staffRepo:   staffRepo,
-		clientRepo:  clientRepo,
+		bookingRepo:      bookingRepo,
+		serviceRepo:      serviceRepo,
+		staffRepo:        staffRepo,
+		staffServiceRepo: staffServiceRepo,
+		clientRepo:       clientRepo,
+		businessRepo:     businessRepo,
+		eventRepo:        eventRepo,
+		transactor:       transactor,
 	}
 }
 
-func (s *BookingService) CreateBooking(ctx context.Context, businessID string, req *dto.CreateBookingRequest) error {
+func (s *BookingService) CreateBooking(ctx context.Context, businessID string, req *dto.CreateBookingRequest) (*domain.Booking, error) {
 	// Validate that the service exists and belongs to the business
 	service, err := s.serviceRepo.GetById(ctx, req.ServiceID)
 	if err != nil {
-		return fmt.Errorf("service not found: %w", err)
+		return nil, fmt.Errorf("service not found: %w", err)
 	}
 	if service.BusinessID != businessID {
-		return fmt.Errorf("service does not belong to this business")
+		return nil, fmt.Errorf("service does not belong to this business")
+	}
+	if !service.Active {
+		return nil, ErrServiceInactive
 	}
 
 	// Validate that the staff exists and belongs to the business
 	staff, err := s.staffRepo.GetById(ctx, req.StaffID)
 	if err != nil {
-		return fmt.Errorf("staff not found: %w", err)
+		return nil, fmt.Errorf("staff not found: %w", err)
 	}
 	if staff.BusinessID != businessID {
-		return fmt.Errorf("staff does not belong to this business")
+		return nil, fmt.Errorf("staff does not belong to this business")
+	}
+
+	// Clients are identified by their number in E.164, however it was typed
+	business, err := s.businessRepo.GetById(ctx, businessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get business: %w", err)
+	}
+	customerPhone, err := normalizePhone(req.CustomerPhone, business)
+	if err != nil {
+		return nil, err
 	}
 
-	// Look up client by phone number within the business
-	client, err := s.clientRepo.GetClientByPhone(ctx, businessID, req.CustomerPhone)
+	// Calculate end time based on how long the staff member takes
+	link, err := staffServiceLink(ctx, s.staffServiceRepo, req.StaffID, req.ServiceID)
 	if err != nil {
-		// Client doesn't exist, create new client
-		client = &domain.Client{
+		return nil, err
+	}
+	endAt := req.StartAt.Add(link.EffectiveDuration(service))
+
+	// Determine location ID - use service's location if not provided
+	locationID := req.LocationID
+	if locationID == "" {
+		locationID = service.LocationID
+	}
+
+	booking := &domain.Booking{
+		ServiceID:   req.ServiceID,
+		StaffID:     req.StaffID,
+		LocationID:  locationID,
+		StartAt:     req.StartAt,
+		EndAt:       endAt,
+		PriceCents:  link.EffectivePriceCents(service),
+		DurationMin: int(link.EffectiveDuration(service) / time.Minute),
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// Find the client by phone number within the business, creating a
+		// new one for unknown numbers
+		client := &domain.Client{
 			BusinessID: businessID,
-			Phone:      req.CustomerPhone,
+			Phone:      customerPhone,
 			FirstName:  req.CustomerName,
 			Email:      req.CustomerEmail,
-			CreatedAt:  time.Now(),
-			UpdatedAt:  time.Now(),
 		}
-		if err := s.clientRepo.CreateClient(ctx, client); err != nil {
-			return fmt.Errorf("failed to create client: %w", err)
+		if err := s.clientRepo.GetOrCreateClientByPhone(ctx, client); err != nil {
+			return fmt.Errorf("failed to get or create client: %w", err)
+		}
+
+		// Check for overlapping bookings
+		existingBookings, err := s.bookingRepo.GetByStaffAndTimeRange(ctx, req.StaffID, req.StartAt, endAt)
+		if err != nil {
+			return fmt.Errorf("failed to check existing bookings: %w", err)
+		}
+		if len(existingBookings) > 0 {
+			return ErrTimeSlotUnavailable
+		}
+
+		booking.ClientID = client.ID
+		// Concurrent bookings both pass the check above, the database rejects the later one
+		if err := s.bookingRepo.Create(ctx, booking); err != nil {
+			if errors.Is(err, domain.ErrBookingOverlap) {
+				return ErrTimeSlotUnavailable
+			}
+			return err
 		}
+		return recordEvent(ctx, s.eventRepo, domain.EventBookingCreated, businessID, booking.ID, domain.NewBookingEventPayload(booking))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+// CancelBooking cancels a booking of the business on behalf of the staff,
+// regardless of the cancellation window clients are bound to. A non-empty
+// staffID limits it to the bookings of that staff member.
+func (s *BookingService) CancelBooking(ctx context.Context, businessID, staffID, bookingID string) (*dto.BookingResponse, error) {
+	booking, err := s.businessBooking(ctx, businessID, staffID, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.IsCancelled() {
+		return nil, ErrBookingAlreadyCancelled
 	}
 
-	// Calculate end time based on service duration
-	endAt := req.StartAt.Add(time.Duration(service.DurationMin) * time.Minute)
+	if err := s.cancel(ctx, businessID, booking); err != nil {
+		return nil, err
+	}
+	return s.bookingResponse(ctx, booking)
+}
 
-	// Check for overlapping bookings
-	existingBookings, err := s.bookingRepo.GetByStaffAndTimeRange(ctx, req.StaffID, req.StartAt, endAt)
+// RescheduleBooking moves a booking of the business to another start time,
+// and to another staff member providing the service when one is given. A
+// non-empty staffID limits it to the bookings of that staff member, who
+// can't hand them to someone else.
+func (s *BookingService) RescheduleBooking(ctx context.Context, businessID, staffID, bookingID string, req *dto.RescheduleBookingRequest) (*dto.BookingResponse, error) {
+	booking, err := s.businessBooking(ctx, businessID, staffID, bookingID)
 	if err != nil {
-		return fmt.Errorf("failed to check existing bookings: %w", err)
+		return nil, err
 	}
-	if len(existingBookings) > 0 {
-		return fmt.Errorf("time slot is not available")
+	if booking.IsCancelled() {
+		return nil, ErrBookingAlreadyCancelled
 	}
 
-	// Determine location ID - use service's location if not provided
-	locationID := req.LocationID
-	if locationID == "" {
-		locationID = service.LocationID
+	service, err := s.serviceRepo.GetById(ctx, booking.ServiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service: %w", err)
 	}
 
-	// Create the booking
-	booking := &domain.Booking{
-		ServiceID:  req.ServiceID,
-		StaffID:    req.StaffID,
-		ClientID:   client.ID,
-		LocationID: locationID,
-		StartAt:    req.StartAt,
-		EndAt:      endAt,
+	newStaffID := booking.StaffID
+	if req.StaffID != "" && req.StaffID != booking.StaffID {
+		if staffID != "" {
+			return nil, ErrBookingStaffForbidden
+		}
+		staff, err := s.staffRepo.GetById(ctx, req.StaffID)
+		if err != nil {
+			return nil, fmt.Errorf("staff not found: %w", err)
+		}
+		if staff.BusinessID != businessID {
+			return nil, fmt.Errorf("staff does not belong to this business")
+		}
+		newStaffID = staff.ID
+	}
+
+	// The booking keeps the price and duration it was made with unless
+	// another staff member takes it over at their own terms
+	if newStaffID != booking.StaffID {
+		link, err := staffServiceLink(ctx, s.staffServiceRepo, newStaffID, booking.ServiceID)
+		if err != nil {
+			return nil, err
+		}
+		booking.PriceCents = link.EffectivePriceCents(service)
+		booking.DurationMin = int(link.EffectiveDuration(service) / time.Minute)
 	}
 
-	return s.bookingRepo.Create(ctx, booking)
+	previousStartAt := booking.StartAt
+	endAt := req.StartAt.Add(time.Duration(booking.DurationMin) * time.Minute)
+
+	existingBookings, err := s.bookingRepo.GetByStaffAndTimeRange(ctx, newStaffID, req.StartAt, endAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing bookings: %w", err)
+	}
+	for _, existing := range existingBookings {
+		if existing.ID != booking.ID {
+			return nil, ErrTimeSlotUnavailable
+		}
+	}
+
+	booking.StaffID = newStaffID
+	booking.StartAt = req.StartAt
+	booking.EndAt = endAt
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.bookingRepo.Reschedule(ctx, booking); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrBookingAlreadyCancelled
+			}
+			if errors.Is(err, domain.ErrBookingOverlap) {
+				return ErrTimeSlotUnavailable
+			}
+			return fmt.Errorf("failed to reschedule booking: %w", err)
+		}
+
+		payload := domain.NewBookingEventPayload(booking)
+		payload.PreviousStartAt = &previousStartAt
+		return recordEvent(ctx, s.eventRepo, domain.EventBookingRescheduled, businessID, booking.ID, payload)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.bookingResponse(ctx, booking)
+}
+
+// MarkNoShow records that the client didn't turn up for a booking that
+// already started, limited to the bookings of staffID when it's not empty
+func (s *BookingService) MarkNoShow(ctx context.Context, businessID, staffID, bookingID string) (*dto.BookingResponse, error) {
+	booking, err := s.businessBooking(ctx, businessID, staffID, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if !booking.IsConfirmed() {
+		return nil, ErrBookingNotConfirmed
+	}
+	if booking.StartAt.After(time.Now()) {
+		return nil, ErrBookingNotStarted
+	}
+
+	if err := s.bookingRepo.MarkNoShow(ctx, booking.ID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBookingNotConfirmed
+		}
+		return nil, fmt.Errorf("failed to mark booking as no-show: %w", err)
+	}
+	booking.Status = domain.BookingStatusNoShow
+	return s.bookingResponse(ctx, booking)
 }
 
 func (s *BookingService) GetAvailableSlots(ctx context.Context, businessID string, staffID *string, day time.Time) ([]*dto.SlotResponse, error) {
@@ -114,7 +287,9 @@ func (s *BookingService) GetAvailableSlots(ctx context.Context, businessID strin
 	return slotResponses, nil
 }
 
-func (s *BookingService) GetBookingsByBusiness(ctx context.Context, businessID string, startDate, endDate *time.Time) ([]*dto.BookingResponse, error) {
+// GetBookingsByBusiness returns bookings of a business, limited to a single
+// staff member when staffID is not empty.
+func (s *BookingService) GetBookingsByBusiness(ctx context.Context, businessID, staffID string, startDate, endDate *time.Time) ([]*dto.BookingResponse, error) {
 	// If endDate is provided, set it to the end of the day (23:59:59.999999999)
 	var adjustedEndDate *time.Time
 	if endDate != nil {
@@ -122,7 +297,7 @@ func (s *BookingService) GetBookingsByBusiness(ctx context.Context, businessID s
 		adjustedEndDate = &endOfDay
 	}
 
-	bookings, err := s.bookingRepo.GetByBusinessID(ctx, businessID, startDate, adjustedEndDate)
+	bookings, err := s.bookingRepo.GetByBusinessID(ctx, businessID, staffID, startDate, adjustedEndDate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bookings: %w", err)
 	}
@@ -158,6 +333,7 @@ func (s *BookingService) GetBookingsByBusiness(ctx context.Context, businessID s
 			StaffName:    fmt.Sprintf("%s %s", staff.FirstName, staff.LastName),
 			StartAt:      booking.StartAt,
 			EndAt:        booking.EndAt,
+			Status:       booking.Status,
 			CustomerName: clientName,
 			LocationID:   booking.LocationID,
 			CreatedAt:    booking.CreatedAt,
@@ -167,3 +343,81 @@ func (s *BookingService) GetBookingsByBusiness(ctx context.Context, businessID s
 
 	return bookingResponses, nil
 }
+
+// cancel cancels a booking and records the event, ErrBookingAlreadyCancelled
+// when someone else cancelled it first
+func (s *BookingService) cancel(ctx context.Context, businessID string, booking *domain.Booking) error {
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.bookingRepo.Cancel(ctx, booking.ID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrBookingAlreadyCancelled
+			}
+			return fmt.Errorf("failed to cancel booking: %w", err)
+		}
+		return recordEvent(ctx, s.eventRepo, domain.EventBookingCancelled, businessID, booking.ID, domain.NewBookingEventPayload(booking))
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	booking.Status = domain.BookingStatusCancelled
+	booking.CancelledAt = &now
+	return nil
+}
+
+// businessBooking loads a booking, ErrBookingNotFound when it doesn't belong
+// to the business, or to the staff member when staffID is not empty
+func (s *BookingService) businessBooking(ctx context.Context, businessID, staffID, bookingID string) (*domain.Booking, error) {
+	booking, err := s.bookingRepo.GetById(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBookingNotFound
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+
+	service, err := s.serviceRepo.GetById(ctx, booking.ServiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	if service.BusinessID != businessID || (staffID != "" && booking.StaffID != staffID) {
+		return nil, ErrBookingNotFound
+	}
+	return booking, nil
+}
+
+func (s *BookingService) bookingResponse(ctx context.Context, booking *domain.Booking) (*dto.BookingResponse, error) {
+	service, err := s.serviceRepo.GetById(ctx, booking.ServiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	staff, err := s.staffRepo.GetById(ctx, booking.StaffID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get staff: %w", err)
+	}
+
+	var clientName string
+	if booking.ClientID != "" {
+		client, err := s.clientRepo.GetClientByID(ctx, booking.ClientID)
+		if err == nil {
+			clientName = fmt.Sprintf("%s %s", client.FirstName, client.LastName)
+		}
+	}
+
+	return &dto.BookingResponse{
+		ID:           booking.ID,
+		ServiceID:    booking.ServiceID,
+		ServiceName:  service.Name,
+		StaffID:      booking.StaffID,
+		StaffName:    fmt.Sprintf("%s %s", staff.FirstName, staff.LastName),
+		StartAt:      booking.StartAt,
+		EndAt:        booking.EndAt,
+		Status:       booking.Status,
+		ClientID:     booking.ClientID,
+		CustomerName: clientName,
+		LocationID:   booking.LocationID,
+		CreatedAt:    booking.CreatedAt,
+		UpdatedAt:    booking.UpdatedAt,
+	}, nil
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -13,6 +14,9 @@ import (
 	"github.com/ialekseychuk/my-place/internal/dto"
 )
 
+// defaultCancellationWindowHours applies when a business doesn't set its own
+const defaultCancellationWindowHours = 24
+
 type BusinessUseCase struct {
 	businessRepo     domain.BusinessRepository
 	locationRepo     domain.LocationRepository
@@ -35,10 +39,19 @@ func NewBusinessUseCase(
 }
 
 // CreateBusiness creates a new business (legacy method for backward compatibility)
-func (uc *BusinessUseCase) CreateBusiness(ctx context.Context, name, tz string) (*domain.Business, error) {
+func (uc *BusinessUseCase) CreateBusiness(ctx context.Context, name, tz, country string) (*domain.Business, error) {
+	slug, err := uc.uniqueSlug(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
 	b := &domain.Business{
 		Name:     name,
+		Slug:     slug,
 		Timezone: tz,
+		Country:  country,
+
+		CancellationWindowHours: defaultCancellationWindowHours,
 	}
 	if err := uc.businessRepo.Create(ctx, b); err != nil {
 		return nil, err
@@ -58,14 +71,22 @@ func (uc *BusinessUseCase) RegisterBusiness(ctx context.Context, req dto.CreateB
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
+	// Public booking pages are addressed by the slug
+	slug, err := uc.uniqueSlug(ctx, req.BusinessName)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create business
 	business := &domain.Business{
 		ID:                       businessID,
 		Name:                     req.BusinessName,
+		Slug:                     slug,
 		BusinessType:             req.BusinessType,
 		Description:              req.Description,
 		Address:                  req.Address,
 		City:                     req.City,
+		Country:                  req.Country,
 		Phone:                    req.Phone,
 		Email:                    req.Email,
 		Website:                  req.Website,
@@ -74,6 +95,10 @@ func (uc *BusinessUseCase) RegisterBusiness(ctx context.Context, req dto.CreateB
 		EnableOnlineBooking:      req.EnableOnlineBooking,
 		EnableSMSNotifications:   req.EnableSMSNotifications,
 		EnableEmailNotifications: req.EnableEmailNotifications,
+		CancellationWindowHours:  defaultCancellationWindowHours,
+	}
+	if req.CancellationWindowHours != nil {
+		business.CancellationWindowHours = *req.CancellationWindowHours
 	}
 
 	if err := uc.businessRepo.Create(ctx, business); err != nil {
@@ -89,7 +114,7 @@ func (uc *BusinessUseCase) RegisterBusiness(ctx context.Context, req dto.CreateB
 		Email:      req.OwnerEmail,
 		Phone:      req.OwnerPhone,
 		Password:   string(hashedPassword),
-		Role:       "owner",
+		Role:       domain.RoleOwner,
 		IsActive:   true,
 	}
 
@@ -129,6 +154,58 @@ func (uc *BusinessUseCase) GetById(ctx context.Context, id string) (*domain.Busi
 	return uc.businessRepo.GetById(ctx, id)
 }
 
+// SetCountry changes the country client phone numbers entered without a
+// country code are read in. Numbers already stored are in E.164 and stay.
+func (uc *BusinessUseCase) SetCountry(ctx context.Context, id, country string) (*domain.Business, error) {
+	if err := uc.businessRepo.SetCountry(ctx, id, country); err != nil {
+		return nil, fmt.Errorf("failed to set business country: %w", err)
+	}
+	return uc.businessRepo.GetById(ctx, id)
+}
+
+// uniqueSlug derives a URL slug from the business name, adding a random
+// suffix when the plain slug is already taken
+func (uc *BusinessUseCase) uniqueSlug(ctx context.Context, name string) (string, error) {
+	base := slugify(name)
+	slug := base
+	for attempt := 0; attempt < 5; attempt++ {
+		exists, err := uc.businessRepo.SlugExists(ctx, slug)
+		if err != nil {
+			return "", fmt.Errorf("failed to check slug: %w", err)
+		}
+		if !exists {
+			return slug, nil
+		}
+		slug = base + "-" + generateID()[:6]
+	}
+	return "", fmt.Errorf("failed to find a free slug for %q", name)
+}
+
+// slugify lowercases name and replaces everything but ASCII letters and
+// digits with single dashes
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+
+	slug := strings.TrimRight(b.String(), "-")
+	if len(slug) > 50 {
+		slug = strings.TrimRight(slug[:50], "-")
+	}
+	if slug == "" {
+		slug = "business"
+	}
+	return slug
+}
+
 // convertWorkingHours converts DTO working hours to domain working hours
 func (uc *BusinessUseCase) convertWorkingHours(businessID string, hours dto.WorkingHoursWeekDTO) []*domain.BusinessWorkingHours {
 	workingHours := []*domain.BusinessWorkingHours{}
@@ -0,0 +1,507 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/pkg/ical"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrCalendarFeedNotFound = errors.New("calendar feed not found")
+	ErrCalendarFeedOwner    = errors.New("staff member or location not found")
+	// ErrCalendarFeedForbidden is returned when a user limited to their own
+	// bookings asks for the calendar of someone else or of a location
+	ErrCalendarFeedForbidden = errors.New("only the own calendar can be published")
+)
+
+const (
+	calendarProdID       = "-//MyPlace//Calendar Feed//EN"
+	calendarFeedPast     = 30 * 24 * time.Hour
+	calendarFeedUpcoming = 180 * 24 * time.Hour
+	// calendarFeedTouchInterval limits how often polling a feed records the
+	// access; calendar apps poll every few minutes
+	calendarFeedTouchInterval = 15 * time.Minute
+)
+
+// CalendarFeedService publishes the bookings and shifts of staff members and
+// locations as iCalendar feeds, authenticated by the token in the feed URL.
+type CalendarFeedService struct {
+	feedRepo     domain.CalendarFeedRepository
+	businessRepo domain.BusinessRepository
+	staffRepo    domain.StaffRepository
+	locationRepo domain.LocationRepository
+	bookingRepo  domain.BookingRepository
+	serviceRepo  domain.ServiceRepository
+	clientRepo   domain.ClientRepository
+	scheduleRepo domain.ScheduleRepository
+	transactor   domain.Transactor
+	apiURL       string
+}
+
+// NewCalendarFeedService returns a service building feed URLs on apiURL,
+// the public base URL of the API
+func NewCalendarFeedService(feedRepo domain.CalendarFeedRepository, businessRepo domain.BusinessRepository, staffRepo domain.StaffRepository,
+	locationRepo domain.LocationRepository, bookingRepo domain.BookingRepository, serviceRepo domain.ServiceRepository,
+	clientRepo domain.ClientRepository, scheduleRepo domain.ScheduleRepository, transactor domain.Transactor, apiURL string) *CalendarFeedService {
+	return &CalendarFeedService{
+		feedRepo:     feedRepo,
+		businessRepo: businessRepo,
+		staffRepo:    staffRepo,
+		locationRepo: locationRepo,
+		bookingRepo:  bookingRepo,
+		serviceRepo:  serviceRepo,
+		clientRepo:   clientRepo,
+		scheduleRepo: scheduleRepo,
+		transactor:   transactor,
+		apiURL:       strings.TrimRight(apiURL, "/"),
+	}
+}
+
+// CreateFeed publishes the calendar of a staff member or location. An
+// existing feed of the same calendar is revoked, so this also rotates the
+// URL. The URL is only part of this response. A non-empty staffID limits it
+// to the calendar of that staff member.
+func (s *CalendarFeedService) CreateFeed(ctx context.Context, businessID, staffID string, req dto.CreateCalendarFeedRequest) (*dto.CalendarFeedResponse, error) {
+	if staffID != "" && req.StaffID != staffID {
+		return nil, ErrCalendarFeedForbidden
+	}
+	if err := s.checkOwner(ctx, businessID, req); err != nil {
+		return nil, err
+	}
+
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	feed := &domain.CalendarFeed{
+		BusinessID: businessID,
+		StaffID:    req.StaffID,
+		LocationID: req.LocationID,
+		TokenHash:  hashToken(token),
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.feedRepo.RevokeByOwner(ctx, feed.StaffID, feed.LocationID); err != nil {
+			return fmt.Errorf("failed to revoke calendar feed: %w", err)
+		}
+		if err := s.feedRepo.Create(ctx, feed); err != nil {
+			return fmt.Errorf("failed to create calendar feed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := calendarFeedResponse(feed)
+	response.URL = s.apiURL + "/api/v1/calendar/" + token + ".ics"
+	return &response, nil
+}
+
+// ListFeeds returns the active feeds of a business, without their URLs,
+// limited to the feed of staffID when it's not empty
+func (s *CalendarFeedService) ListFeeds(ctx context.Context, businessID, staffID string) ([]dto.CalendarFeedResponse, error) {
+	feeds, err := s.feedRepo.ListActiveByBusiness(ctx, businessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendar feeds: %w", err)
+	}
+
+	responses := make([]dto.CalendarFeedResponse, 0, len(feeds))
+	for _, feed := range feeds {
+		if staffID != "" && feed.StaffID != staffID {
+			continue
+		}
+		responses = append(responses, calendarFeedResponse(feed))
+	}
+	return responses, nil
+}
+
+// RevokeFeed revokes a feed; calendar apps polling it get 404 from now on. A
+// non-empty staffID limits it to the feed of that staff member.
+func (s *CalendarFeedService) RevokeFeed(ctx context.Context, staffID, feedID string) error {
+	if staffID != "" {
+		feed, err := s.feedRepo.GetByID(ctx, feedID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrCalendarFeedNotFound
+			}
+			return fmt.Errorf("failed to get calendar feed: %w", err)
+		}
+		if feed.StaffID != staffID {
+			return ErrCalendarFeedNotFound
+		}
+	}
+	if err := s.feedRepo.Revoke(ctx, feedID); err != nil {
+		return fmt.Errorf("failed to revoke calendar feed: %w", err)
+	}
+	return nil
+}
+
+// FeedETag returns the entity tag of the feed with token without rendering
+// it, so unchanged feeds can be answered with 304. It changes whenever a
+// booking or shift of the feed does. Polling the feed is recorded here.
+func (s *CalendarFeedService) FeedETag(ctx context.Context, token string) (string, error) {
+	feed, err := s.activeFeed(ctx, token)
+	if err != nil {
+		return "", err
+	}
+
+	if feed.LastAccessedAt == nil || time.Since(*feed.LastAccessedAt) >= calendarFeedTouchInterval {
+		if err := s.feedRepo.TouchLastAccessed(ctx, feed.ID); err != nil {
+			return "", fmt.Errorf("failed to update calendar feed: %w", err)
+		}
+	}
+
+	now := time.Now()
+	changedAt, count, err := s.feedRepo.Version(ctx, feed, now.Add(-calendarFeedPast), now.Add(calendarFeedUpcoming))
+	if err != nil {
+		return "", fmt.Errorf("failed to get calendar feed version: %w", err)
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d:%d", feed.ID, changedAt.UnixNano(), count)))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// Feed renders the calendar of the feed with token: bookings and shifts
+// from 30 days ago until 180 days ahead, in the timezone of the location.
+func (s *CalendarFeedService) Feed(ctx context.Context, token string) ([]byte, error) {
+	feed, err := s.activeFeed(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	business, err := s.businessRepo.GetById(ctx, feed.BusinessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get business: %w", err)
+	}
+
+	now := time.Now()
+	b := &calendarBuilder{
+		service:   s,
+		from:      now.Add(-calendarFeedPast),
+		to:        now.Add(calendarFeedUpcoming),
+		tz:        businessLocation(business),
+		services:  make(map[string]*domain.Service),
+		clients:   make(map[string]*domain.Client),
+		locations: make(map[string]*domain.Location),
+		staff:     make(map[string]*domain.Staff),
+	}
+
+	var name string
+	if feed.StaffID != "" {
+		name, err = b.addStaff(ctx, business.ID, feed.StaffID)
+	} else {
+		name, err = b.addLocation(ctx, business.ID, feed.LocationID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(b.events, func(i, j int) bool { return b.events[i].Start.Before(b.events[j].Start) })
+	calendar := &ical.Calendar{
+		ProdID:   calendarProdID,
+		Name:     name + " · " + business.Name,
+		Location: b.tz,
+		Events:   b.events,
+	}
+	return calendar.Marshal(), nil
+}
+
+func (s *CalendarFeedService) activeFeed(ctx context.Context, token string) (*domain.CalendarFeed, error) {
+	feed, err := s.feedRepo.GetByHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCalendarFeedNotFound
+		}
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+	if !feed.IsActive() {
+		return nil, ErrCalendarFeedNotFound
+	}
+	return feed, nil
+}
+
+func (s *CalendarFeedService) checkOwner(ctx context.Context, businessID string, req dto.CreateCalendarFeedRequest) error {
+	if req.StaffID != "" {
+		staff, err := s.staffRepo.GetById(ctx, req.StaffID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrCalendarFeedOwner
+			}
+			return fmt.Errorf("failed to get staff: %w", err)
+		}
+		if staff.BusinessID != businessID {
+			return ErrCalendarFeedOwner
+		}
+		return nil
+	}
+
+	location, err := s.locationRepo.GetByID(ctx, req.LocationID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrCalendarFeedOwner
+		}
+		return fmt.Errorf("failed to get location: %w", err)
+	}
+	if location.BusinessID != businessID {
+		return ErrCalendarFeedOwner
+	}
+	return nil
+}
+
+// calendarBuilder collects the events of one feed, caching what several
+// events refer to
+type calendarBuilder struct {
+	service  *CalendarFeedService
+	from, to time.Time
+	tz       *time.Location
+	events   []ical.Event
+
+	services  map[string]*domain.Service
+	clients   map[string]*domain.Client
+	locations map[string]*domain.Location
+	staff     map[string]*domain.Staff
+}
+
+// addStaff adds the bookings and shifts of a staff member and returns the
+// calendar name
+func (b *calendarBuilder) addStaff(ctx context.Context, businessID, staffID string) (string, error) {
+	staff, err := b.service.staffRepo.GetById(ctx, staffID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get staff: %w", err)
+	}
+	b.staff[staff.ID] = staff
+	if staff.LocationID != "" {
+		location, err := b.location(ctx, staff.LocationID)
+		if err != nil {
+			return "", err
+		}
+		b.useTimezone(location)
+	}
+
+	bookings, err := b.service.bookingRepo.GetByBusinessID(ctx, businessID, staff.ID, &b.from, &b.to)
+	if err != nil {
+		return "", fmt.Errorf("failed to get bookings: %w", err)
+	}
+	if err := b.addBookings(ctx, bookings, false); err != nil {
+		return "", err
+	}
+	if err := b.addShifts(ctx, staff, false); err != nil {
+		return "", err
+	}
+	return staffName(staff), nil
+}
+
+// addLocation adds the bookings at a location and the shifts of its staff
+// and returns the calendar name
+func (b *calendarBuilder) addLocation(ctx context.Context, businessID, locationID string) (string, error) {
+	location, err := b.location(ctx, locationID)
+	if err != nil {
+		return "", err
+	}
+	b.useTimezone(location)
+
+	bookings, err := b.service.bookingRepo.GetByBusinessID(ctx, businessID, "", &b.from, &b.to)
+	if err != nil {
+		return "", fmt.Errorf("failed to get bookings: %w", err)
+	}
+	atLocation := make([]*domain.Booking, 0, len(bookings))
+	for _, booking := range bookings {
+		if booking.LocationID == location.ID {
+			atLocation = append(atLocation, booking)
+		}
+	}
+	if err := b.addBookings(ctx, atLocation, true); err != nil {
+		return "", err
+	}
+
+	staffList, err := b.service.staffRepo.ListByBusinessId(ctx, businessID, location.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to list staff: %w", err)
+	}
+	for i := range staffList {
+		staff := &staffList[i]
+		b.staff[staff.ID] = staff
+		if err := b.addShifts(ctx, staff, true); err != nil {
+			return "", err
+		}
+	}
+	return location.Name, nil
+}
+
+// addBookings adds bookings as "<service> · <client first name>". Cancelled
+// bookings stay in the feed as cancelled, so calendars drop them.
+func (b *calendarBuilder) addBookings(ctx context.Context, bookings []*domain.Booking, withStaff bool) error {
+	for _, booking := range bookings {
+		service, ok := b.services[booking.ServiceID]
+		if !ok {
+			var err error
+			if service, err = b.service.serviceRepo.GetById(ctx, booking.ServiceID); err != nil {
+				return fmt.Errorf("failed to get service: %w", err)
+			}
+			b.services[service.ID] = service
+		}
+		client, ok := b.clients[booking.ClientID]
+		if !ok {
+			var err error
+			if client, err = b.service.clientRepo.GetClientByID(ctx, booking.ClientID); err != nil {
+				return fmt.Errorf("failed to get client: %w", err)
+			}
+			b.clients[client.ID] = client
+		}
+
+		event := ical.Event{
+			UID:          "booking-" + booking.ID + "@myplace",
+			Summary:      service.Name,
+			Status:       ical.StatusConfirmed,
+			Start:        booking.StartAt,
+			End:          booking.EndAt,
+			Stamp:        booking.UpdatedAt,
+			LastModified: booking.UpdatedAt,
+		}
+		if client.FirstName != "" {
+			event.Summary += " · " + client.FirstName
+		}
+		if booking.IsCancelled() {
+			event.Status = ical.StatusCancelled
+		}
+		if withStaff {
+			staff, err := b.staffMember(ctx, booking.StaffID)
+			if err != nil {
+				return err
+			}
+			event.Description = "With " + staffName(staff)
+		}
+		if booking.LocationID != "" {
+			location, err := b.location(ctx, booking.LocationID)
+			if err != nil {
+				return err
+			}
+			event.Location = locationLine(location)
+		}
+		b.events = append(b.events, event)
+	}
+	return nil
+}
+
+// addShifts adds the available shifts of a staff member
+func (b *calendarBuilder) addShifts(ctx context.Context, staff *domain.Staff, withStaff bool) error {
+	shifts, err := b.service.scheduleRepo.GetShiftsByStaff(ctx, staff.ID, b.from, b.to)
+	if err != nil {
+		return fmt.Errorf("failed to get shifts: %w", err)
+	}
+
+	for _, shift := range shifts {
+		if !shift.IsAvailable || shift.IsManuallyDisabled {
+			continue
+		}
+		start, okStart := shiftTime(shift.ShiftDate, shift.StartTime, b.tz)
+		end, okEnd := shiftTime(shift.ShiftDate, shift.EndTime, b.tz)
+		if !okStart || !okEnd {
+			continue
+		}
+		if !end.After(start) {
+			// Overnight shift
+			end = end.AddDate(0, 0, 1)
+		}
+
+		summary := "Shift"
+		if shift.ShiftType != "" && shift.ShiftType != "regular" {
+			summary += " (" + shift.ShiftType + ")"
+		}
+		if withStaff {
+			summary += ": " + staffName(staff)
+		}
+		b.events = append(b.events, ical.Event{
+			UID:          "shift-" + shift.ID + "@myplace",
+			Summary:      summary,
+			Description:  shift.Notes,
+			Status:       ical.StatusConfirmed,
+			Start:        start,
+			End:          end,
+			Stamp:        shift.UpdatedAt,
+			LastModified: shift.UpdatedAt,
+		})
+	}
+	return nil
+}
+
+func (b *calendarBuilder) location(ctx context.Context, id string) (*domain.Location, error) {
+	if location, ok := b.locations[id]; ok {
+		return location, nil
+	}
+	location, err := b.service.locationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+	b.locations[id] = location
+	return location, nil
+}
+
+func (b *calendarBuilder) staffMember(ctx context.Context, id string) (*domain.Staff, error) {
+	if staff, ok := b.staff[id]; ok {
+		return staff, nil
+	}
+	staff, err := b.service.staffRepo.GetById(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get staff: %w", err)
+	}
+	b.staff[id] = staff
+	return staff, nil
+}
+
+// useTimezone switches the feed to the timezone of location, if it has a
+// valid one
+func (b *calendarBuilder) useTimezone(location *domain.Location) {
+	if location.Timezone == "" {
+		return
+	}
+	if tz, err := time.LoadLocation(location.Timezone); err == nil {
+		b.tz = tz
+	}
+}
+
+// shiftTime combines the date of a shift with a "15:04" or "15:04:05" wall
+// clock time in tz
+func shiftTime(date time.Time, clock string, tz *time.Location) (time.Time, bool) {
+	t, err := time.Parse("15:04:05", clock)
+	if err != nil {
+		if t, err = time.Parse("15:04", clock); err != nil {
+			return time.Time{}, false
+		}
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, tz), true
+}
+
+func staffName(staff *domain.Staff) string {
+	return strings.TrimSpace(staff.FirstName + " " + staff.LastName)
+}
+
+func locationLine(location *domain.Location) string {
+	parts := []string{location.Name}
+	for _, part := range []string{location.Address, location.City} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func calendarFeedResponse(feed *domain.CalendarFeed) dto.CalendarFeedResponse {
+	return dto.CalendarFeedResponse{
+		ID:             feed.ID,
+		StaffID:        feed.StaffID,
+		LocationID:     feed.LocationID,
+		LastAccessedAt: feed.LastAccessedAt,
+		CreatedAt:      feed.CreatedAt,
+	}
+}
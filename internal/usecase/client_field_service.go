@@ -0,0 +1,321 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrClientFieldNotFound     = errors.New("client field not found")
+	ErrClientFieldKeyTaken     = errors.New("a client field with this key already exists")
+	ErrInvalidClientFieldKey   = errors.New("field key must start with a letter and contain only lower case letters, digits and underscores")
+	ErrClientFieldOptions      = errors.New("select fields need distinct options, other fields none")
+	ErrInvalidClientFieldValue = errors.New("invalid client field value")
+)
+
+const (
+	clientFieldDateLayout   = "2006-01-02"
+	clientFieldTextMaxChars = 1000
+)
+
+var clientFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ClientFieldService manages the custom fields a business defines for its
+// clients and their values
+type ClientFieldService struct {
+	fieldRepo  domain.ClientFieldRepository
+	clientRepo domain.ClientRepository
+	transactor domain.Transactor
+}
+
+func NewClientFieldService(fieldRepo domain.ClientFieldRepository, clientRepo domain.ClientRepository, transactor domain.Transactor) *ClientFieldService {
+	return &ClientFieldService{
+		fieldRepo:  fieldRepo,
+		clientRepo: clientRepo,
+		transactor: transactor,
+	}
+}
+
+func (s *ClientFieldService) CreateField(ctx context.Context, businessID string, req dto.CreateClientFieldRequest) (*dto.ClientFieldResponse, error) {
+	if !clientFieldKeyPattern.MatchString(req.Key) {
+		return nil, ErrInvalidClientFieldKey
+	}
+	options, err := clientFieldOptions(req.Type, req.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.fieldRepo.GetByKey(ctx, businessID, req.Key)
+	if err == nil {
+		return nil, ErrClientFieldKeyTaken
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to check client field key: %w", err)
+	}
+
+	field := &domain.ClientField{
+		BusinessID: businessID,
+		Key:        req.Key,
+		Label:      strings.TrimSpace(req.Label),
+		Type:       req.Type,
+		Options:    options,
+		Position:   req.Position,
+	}
+	if err := s.fieldRepo.Create(ctx, field); err != nil {
+		return nil, fmt.Errorf("failed to create client field: %w", err)
+	}
+
+	resp := clientFieldResponse(field)
+	return &resp, nil
+}
+
+func (s *ClientFieldService) ListFields(ctx context.Context, businessID string) ([]dto.ClientFieldResponse, error) {
+	fields, err := s.fieldRepo.ListByBusiness(ctx, businessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client fields: %w", err)
+	}
+
+	resp := make([]dto.ClientFieldResponse, 0, len(fields))
+	for _, field := range fields {
+		resp = append(resp, clientFieldResponse(field))
+	}
+	return resp, nil
+}
+
+// UpdateField changes the label, options and position of a field. Values
+// that are no longer an option of a select stay until they are changed.
+func (s *ClientFieldService) UpdateField(ctx context.Context, fieldID string, req dto.UpdateClientFieldRequest) (*dto.ClientFieldResponse, error) {
+	field, err := s.field(ctx, fieldID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Label != nil {
+		field.Label = strings.TrimSpace(*req.Label)
+	}
+	if req.Options != nil {
+		if field.Options, err = clientFieldOptions(field.Type, req.Options); err != nil {
+			return nil, err
+		}
+	}
+	if req.Position != nil {
+		field.Position = *req.Position
+	}
+
+	if err := s.fieldRepo.Update(ctx, field); err != nil {
+		return nil, fmt.Errorf("failed to update client field: %w", err)
+	}
+
+	resp := clientFieldResponse(field)
+	return &resp, nil
+}
+
+// DeleteField deletes a field together with its values
+func (s *ClientFieldService) DeleteField(ctx context.Context, fieldID string) error {
+	if err := s.fieldRepo.Delete(ctx, fieldID); err != nil {
+		return fmt.Errorf("failed to delete client field: %w", err)
+	}
+	return nil
+}
+
+// ListClientValues returns the custom field values of a client in the order
+// of the fields
+func (s *ClientFieldService) ListClientValues(ctx context.Context, clientID string) ([]dto.ClientFieldValueResponse, error) {
+	client, err := s.client(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	return clientFieldValues(ctx, s.fieldRepo, client)
+}
+
+// SetClientValues sets the custom field values of a client given by field
+// key, all or none of them
+func (s *ClientFieldService) SetClientValues(ctx context.Context, clientID string, req dto.SetClientFieldValuesRequest) ([]dto.ClientFieldValueResponse, error) {
+	client, err := s.client(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client.ErasedAt != nil {
+		return nil, ErrClientErased
+	}
+
+	values := make(map[*domain.ClientField]string, len(req.Values))
+	for key, raw := range req.Values {
+		field, err := s.fieldRepo.GetByKey(ctx, client.BusinessID, key)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, fmt.Errorf("%w: %s", ErrClientFieldNotFound, key)
+			}
+			return nil, fmt.Errorf("failed to get client field: %w", err)
+		}
+
+		var value string
+		if raw != nil && strings.TrimSpace(*raw) != "" {
+			if value, err = canonicalFieldValue(field, *raw); err != nil {
+				return nil, err
+			}
+		}
+		values[field] = value
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		for field, value := range values {
+			if value == "" {
+				if err := s.fieldRepo.DeleteValue(ctx, client.ID, field.ID); err != nil {
+					return fmt.Errorf("failed to delete client field value: %w", err)
+				}
+				continue
+			}
+			err := s.fieldRepo.SetValue(ctx, &domain.ClientFieldValue{
+				ClientID: client.ID,
+				FieldID:  field.ID,
+				Value:    value,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to set client field value: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return clientFieldValues(ctx, s.fieldRepo, client)
+}
+
+func (s *ClientFieldService) field(ctx context.Context, fieldID string) (*domain.ClientField, error) {
+	field, err := s.fieldRepo.GetByID(ctx, fieldID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrClientFieldNotFound
+		}
+		return nil, fmt.Errorf("failed to get client field: %w", err)
+	}
+	return field, nil
+}
+
+func (s *ClientFieldService) client(ctx context.Context, clientID string) (*domain.Client, error) {
+	client, err := s.clientRepo.GetClientByID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrClientNotFound
+		}
+		return nil, fmt.Errorf("failed to get client: %w", err)
+	}
+	return client, nil
+}
+
+// clientFieldValues returns the custom field values of a client in the order
+// of the fields of its business
+func clientFieldValues(ctx context.Context, fieldRepo domain.ClientFieldRepository, client *domain.Client) ([]dto.ClientFieldValueResponse, error) {
+	fields, err := fieldRepo.ListByBusiness(ctx, client.BusinessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client fields: %w", err)
+	}
+	values, err := fieldRepo.ListValues(ctx, client.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client field values: %w", err)
+	}
+
+	byField := make(map[string]*domain.ClientFieldValue, len(values))
+	for _, value := range values {
+		byField[value.FieldID] = value
+	}
+
+	resp := []dto.ClientFieldValueResponse{}
+	for _, field := range fields {
+		value, ok := byField[field.ID]
+		if !ok {
+			continue
+		}
+		resp = append(resp, dto.ClientFieldValueResponse{
+			FieldID:   field.ID,
+			Key:       field.Key,
+			Label:     field.Label,
+			Type:      field.Type,
+			Value:     value.Value,
+			UpdatedAt: value.UpdatedAt,
+		})
+	}
+	return resp, nil
+}
+
+// canonicalFieldValue checks raw against the type of the field and returns
+// it in the form values are stored and matched in
+func canonicalFieldValue(field *domain.ClientField, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	invalid := fmt.Errorf("%w for %s", ErrInvalidClientFieldValue, field.Key)
+
+	switch field.Type {
+	case domain.ClientFieldNumber:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return "", invalid
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case domain.ClientFieldDate:
+		date, err := time.Parse(clientFieldDateLayout, raw)
+		if err != nil {
+			return "", invalid
+		}
+		return date.Format(clientFieldDateLayout), nil
+	case domain.ClientFieldSelect:
+		if !containsString(field.Options, raw) {
+			return "", invalid
+		}
+		return raw, nil
+	default:
+		if utf8.RuneCountInString(raw) > clientFieldTextMaxChars {
+			return "", invalid
+		}
+		return raw, nil
+	}
+}
+
+// clientFieldOptions checks the options of a field of the given type
+func clientFieldOptions(fieldType string, options []string) ([]string, error) {
+	if fieldType != domain.ClientFieldSelect {
+		if len(options) > 0 {
+			return nil, ErrClientFieldOptions
+		}
+		return []string{}, nil
+	}
+
+	cleaned := make([]string, 0, len(options))
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		if option == "" || containsString(cleaned, option) {
+			return nil, ErrClientFieldOptions
+		}
+		cleaned = append(cleaned, option)
+	}
+	if len(cleaned) == 0 {
+		return nil, ErrClientFieldOptions
+	}
+	return cleaned, nil
+}
+
+func clientFieldResponse(field *domain.ClientField) dto.ClientFieldResponse {
+	return dto.ClientFieldResponse{
+		ID:        field.ID,
+		Key:       field.Key,
+		Label:     field.Label,
+		Type:      field.Type,
+		Options:   field.Options,
+		Position:  field.Position,
+		CreatedAt: field.CreatedAt,
+		UpdatedAt: field.UpdatedAt,
+	}
+}
@@ -0,0 +1,414 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/pkg/validate"
+	"github.com/ialekseychuk/my-place/pkg/xlsx"
+)
+
+var ErrInvalidClientImport = errors.New("invalid client import")
+
+const (
+	// clientImportMaxRows caps the rows of an import file, not counting the
+	// header
+	clientImportMaxRows = 50000
+	// clientImportMaxIssues caps the issues listed in an import report
+	clientImportMaxIssues = 1000
+)
+
+// Client columns an import file can be mapped to. name holds the full name
+// and is split at the first space when there are no separate columns.
+const (
+	importFirstName   = "first_name"
+	importLastName    = "last_name"
+	importName        = "name"
+	importEmail       = "email"
+	importPhone       = "phone"
+	importFieldPrefix = "field."
+)
+
+// importColumn is a column of an import file mapped to a client column
+type importColumn struct {
+	target string
+	header string
+	index  int
+	field  *domain.ClientField // field.<key> columns
+}
+
+// detectImportFormat tells XLSX files, which are zip archives, from CSV
+func detectImportFormat(file []byte) string {
+	if bytes.HasPrefix(file, []byte("PK\x03\x04")) {
+		return domain.ClientImportXLSX
+	}
+	return domain.ClientImportCSV
+}
+
+// readImportFile returns the rows of an import file, the header first
+func readImportFile(format string, file []byte) ([][]string, error) {
+	var rows [][]string
+	var err error
+	switch format {
+	case domain.ClientImportXLSX:
+		rows, err = xlsx.Read(file, clientImportMaxRows+1)
+		if errors.Is(err, xlsx.ErrTooManyRows) {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidClientImport, clientImportMaxRows)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidClientImport, err)
+		}
+	case domain.ClientImportCSV:
+		if rows, err = readImportCSV(file); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidClientImport, format)
+	}
+
+	if len(rows) == 0 || isEmptyRow(rows[0]) {
+		return nil, fmt.Errorf("%w: the first row has to name the columns", ErrInvalidClientImport)
+	}
+	return rows, nil
+}
+
+// readImportCSV reads a CSV file separated by commas, semicolons, as
+// spreadsheet apps write them in many locales, or tabs
+func readImportCSV(file []byte) ([][]string, error) {
+	file = bytes.TrimPrefix(file, []byte("\xef\xbb\xbf"))
+
+	header, _, _ := bytes.Cut(file, []byte("\n"))
+	r := csv.NewReader(bytes.NewReader(file))
+	r.Comma = ','
+	for _, sep := range []rune{';', '\t'} {
+		if bytes.Count(header, []byte(string(sep))) > bytes.Count(header, []byte(string(r.Comma))) {
+			r.Comma = sep
+		}
+	}
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	var rows [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidClientImport, err)
+		}
+		if len(rows) > clientImportMaxRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidClientImport, clientImportMaxRows)
+		}
+		rows = append(rows, record)
+	}
+}
+
+// importColumns maps the columns of an import file to client columns. An
+// empty mapping matches headers named like client columns or custom field
+// keys, e.g. "First name" or "hair_type".
+func (s *ClientJobService) importColumns(ctx context.Context, businessID string, header []string, mapping map[string]string) ([]importColumn, error) {
+	fields, err := s.fieldRepo.ListByBusiness(ctx, businessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client fields: %w", err)
+	}
+	fieldsByKey := make(map[string]*domain.ClientField, len(fields))
+	for _, field := range fields {
+		fieldsByKey[field.Key] = field
+	}
+
+	target := func(name string) (importColumn, bool) {
+		switch name {
+		case importFirstName, importLastName, importName, importEmail, importPhone:
+			return importColumn{target: name}, true
+		}
+		if field, ok := fieldsByKey[strings.TrimPrefix(name, importFieldPrefix)]; ok {
+			return importColumn{target: importFieldPrefix + field.Key, field: field}, true
+		}
+		return importColumn{}, false
+	}
+
+	var columns []importColumn
+	if len(mapping) == 0 {
+		for i, h := range header {
+			column, ok := target(headerKey(h))
+			if ok && !hasImportTarget(columns, column.target) {
+				column.header, column.index = strings.TrimSpace(h), i
+				columns = append(columns, column)
+			}
+		}
+	} else {
+		for name, h := range mapping {
+			column, ok := target(name)
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown client column %q", ErrInvalidClientImport, name)
+			}
+			column.index = -1
+			for i, fileHeader := range header {
+				if strings.EqualFold(strings.TrimSpace(fileHeader), strings.TrimSpace(h)) {
+					column.header, column.index = strings.TrimSpace(fileHeader), i
+					break
+				}
+			}
+			if column.index < 0 {
+				return nil, fmt.Errorf("%w: the file has no column %q", ErrInvalidClientImport, h)
+			}
+			columns = append(columns, column)
+		}
+	}
+
+	if !hasImportTarget(columns, importPhone) {
+		return nil, fmt.Errorf("%w: a column has to be mapped to phone", ErrInvalidClientImport)
+	}
+	if !hasImportTarget(columns, importFirstName) && !hasImportTarget(columns, importName) {
+		return nil, fmt.Errorf("%w: a column has to be mapped to first_name or name", ErrInvalidClientImport)
+	}
+	return columns, nil
+}
+
+// importRows imports the rows of a file, or only checks them for a dry run.
+// Rows are matched to existing clients and to each other by the normalised
+// phone number. Each row is written in its own transaction, so a failure
+// leaves the rows before it imported; importing the file again skips them.
+func (s *ClientJobService) importRows(ctx context.Context, job *domain.ClientJob, rows [][]string, columns []importColumn, progress func(int) error) (*domain.ClientImportReport, error) {
+	options := job.Import
+	business, err := s.businessRepo.GetById(ctx, job.BusinessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get business: %w", err)
+	}
+
+	existing, err := s.clientRepo.ListClientsByBusiness(ctx, job.BusinessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clients: %w", err)
+	}
+	byPhone := make(map[string]*domain.Client, len(existing))
+	for _, client := range existing {
+		byPhone[client.Phone] = client
+	}
+
+	report := &domain.ClientImportReport{Issues: []domain.ClientImportIssue{}}
+	addIssue := func(issue domain.ClientImportIssue) {
+		if len(report.Issues) < clientImportMaxIssues {
+			report.Issues = append(report.Issues, issue)
+		} else {
+			report.IssuesTruncated = true
+		}
+	}
+	seen := make(map[string]int) // row by phone number
+
+	for i, record := range rows[1:] {
+		if progress != nil && i > 0 && i%clientJobProgressRows == 0 {
+			if err := progress(i); err != nil {
+				return nil, err
+			}
+		}
+		if isEmptyRow(record) {
+			continue
+		}
+		report.Rows++
+		rowNum := i + 2
+
+		row, issues := importRow(record, columns, business)
+		if len(issues) > 0 {
+			report.Failed++
+			for _, issue := range issues {
+				issue.Row = rowNum
+				addIssue(issue)
+			}
+			continue
+		}
+
+		if first, ok := seen[row.client.Phone]; ok {
+			report.Failed++
+			addIssue(domain.ClientImportIssue{
+				Row:     rowNum,
+				Column:  row.phoneHeader,
+				Message: fmt.Sprintf("same phone number as row %d", first),
+			})
+			continue
+		}
+		seen[row.client.Phone] = rowNum
+
+		client, exists := byPhone[row.client.Phone]
+		if exists && !options.UpdateExisting {
+			report.Skipped++
+			addIssue(domain.ClientImportIssue{
+				Row:      rowNum,
+				Column:   row.phoneHeader,
+				Message:  "a client with this phone number exists",
+				ClientID: client.ID,
+			})
+			continue
+		}
+
+		if exists {
+			// Empty cells keep what the client has
+			if row.client.FirstName != "" {
+				client.FirstName = row.client.FirstName
+			}
+			if row.client.LastName != "" {
+				client.LastName = row.client.LastName
+			}
+			if row.client.Email != "" {
+				client.Email = row.client.Email
+			}
+			report.Updated++
+		} else {
+			client = row.client
+			client.BusinessID = job.BusinessID
+			report.Created++
+		}
+		if options.DryRun {
+			continue
+		}
+
+		err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+			if exists {
+				if err := s.clientRepo.UpdateClient(ctx, client); err != nil {
+					return fmt.Errorf("failed to update client: %w", err)
+				}
+			} else if err := s.clientRepo.CreateClient(ctx, client); err != nil {
+				return fmt.Errorf("failed to create client: %w", err)
+			}
+			for _, value := range row.values {
+				value.ClientID = client.ID
+				if err := s.fieldRepo.SetValue(ctx, value); err != nil {
+					return fmt.Errorf("failed to set client field value: %w", err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", rowNum, err)
+		}
+	}
+	return report, nil
+}
+
+// importedRow is a row of an import file read into a client
+type importedRow struct {
+	client      *domain.Client
+	values      []*domain.ClientFieldValue
+	phoneHeader string
+}
+
+// importRow reads a row of an import file, checking it like a client
+// created through the API
+func importRow(record []string, columns []importColumn, business *domain.Business) (*importedRow, []domain.ClientImportIssue) {
+	row := &importedRow{}
+	var req dto.CreateClientRequest
+	headers := make(map[string]string)
+	var name string
+	var issues []domain.ClientImportIssue
+
+	for _, column := range columns {
+		var value string
+		if column.index < len(record) {
+			value = strings.TrimSpace(record[column.index])
+		}
+		headers[column.target] = column.header
+
+		switch column.target {
+		case importFirstName:
+			req.FirstName = value
+		case importLastName:
+			req.LastName = value
+		case importName:
+			name = strings.Join(strings.Fields(value), " ")
+		case importEmail:
+			req.Email = value
+		case importPhone:
+			req.Phone = value
+			row.phoneHeader = column.header
+		default:
+			if value == "" {
+				continue
+			}
+			canonical, err := canonicalFieldValue(column.field, value)
+			if err != nil {
+				issues = append(issues, domain.ClientImportIssue{Column: column.header, Message: "invalid value for the field type"})
+				continue
+			}
+			row.values = append(row.values, &domain.ClientFieldValue{FieldID: column.field.ID, Value: canonical})
+		}
+	}
+
+	if req.FirstName == "" && name != "" {
+		first, last, _ := strings.Cut(name, " ")
+		req.FirstName = first
+		if req.LastName == "" {
+			req.LastName = last
+		}
+	}
+	// Issues with names read from the full name point at its column
+	for _, target := range []string{importFirstName, importLastName} {
+		if _, ok := headers[target]; !ok {
+			headers[target] = headers[importName]
+		}
+	}
+
+	errs := validate.Struct(req)
+	for _, check := range []struct{ field, target string }{
+		{"FirstName", importFirstName},
+		{"LastName", importLastName},
+		{"Email", importEmail},
+		{"Phone", importPhone},
+	} {
+		if msg, ok := errs[check.field]; ok {
+			column := headers[check.target]
+			if column == "" {
+				column = check.target
+			}
+			issues = append(issues, domain.ClientImportIssue{Column: column, Message: msg})
+		}
+	}
+	if len(issues) > 0 {
+		return nil, issues
+	}
+
+	clientPhone, err := normalizePhone(req.Phone, business)
+	if err != nil {
+		return nil, []domain.ClientImportIssue{{Column: row.phoneHeader, Message: err.Error()}}
+	}
+
+	row.client = &domain.Client{
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Email:     req.Email,
+		Phone:     clientPhone,
+	}
+	return row, nil
+}
+
+// headerKey turns a header like "First name" into a column name like
+// first_name
+func headerKey(header string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(header), func(r rune) bool {
+		return r == ' ' || r == '-' || r == '_'
+	}), "_")
+}
+
+func hasImportTarget(columns []importColumn, target string) bool {
+	for _, column := range columns {
+		if column.target == target {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
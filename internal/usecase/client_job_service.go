@@ -0,0 +1,376 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrClientJobNotFound = errors.New("client job not found")
+	ErrClientJobNotReady = errors.New("client job has no result yet")
+)
+
+const (
+	// clientJobInlineRows is the size up to which imports and exports run
+	// right away instead of in the background
+	clientJobInlineRows = 1000
+	// clientJobProgressRows is how often a running job reports progress and
+	// renews its lease
+	clientJobProgressRows  = 500
+	clientJobLease         = 2 * time.Minute
+	clientJobMaxAttempts   = 3
+	clientJobErrorMaxBytes = 500
+	// clientJobRetention is how long finished jobs, and with them exported
+	// personal data, are kept
+	clientJobRetention = 7 * 24 * time.Hour
+	clientJobListLimit = 50
+
+	clientExportPageSize = 500
+)
+
+// ClientJobService imports clients from CSV and XLSX files and exports them
+// as CSV. Large businesses get a job run by a background worker; small
+// imports and exports are done right away.
+type ClientJobService struct {
+	jobRepo      domain.ClientJobRepository
+	clientRepo   domain.ClientRepository
+	businessRepo domain.BusinessRepository
+	fieldRepo    domain.ClientFieldRepository
+	transactor   domain.Transactor
+}
+
+func NewClientJobService(jobRepo domain.ClientJobRepository, clientRepo domain.ClientRepository,
+	businessRepo domain.BusinessRepository, fieldRepo domain.ClientFieldRepository, transactor domain.Transactor) *ClientJobService {
+	return &ClientJobService{
+		jobRepo:      jobRepo,
+		clientRepo:   clientRepo,
+		businessRepo: businessRepo,
+		fieldRepo:    fieldRepo,
+		transactor:   transactor,
+	}
+}
+
+// ImportClients checks an import file and its column mapping and imports
+// it. Files of up to clientJobInlineRows rows are imported right away and
+// the job comes back done; larger ones are queued.
+func (s *ClientJobService) ImportClients(ctx context.Context, businessID, createdBy, fileName string, file []byte, req dto.ClientImportRequest) (*dto.ClientJobResponse, error) {
+	options := &domain.ClientImportOptions{
+		Format:         req.Format,
+		FileName:       fileName,
+		Mapping:        req.Mapping,
+		DryRun:         req.DryRun,
+		UpdateExisting: req.UpdateExisting,
+	}
+	if options.Format == "" {
+		options.Format = detectImportFormat(file)
+	}
+
+	rows, err := readImportFile(options.Format, file)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := s.importColumns(ctx, businessID, rows[0], options.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &domain.ClientJob{
+		BusinessID: businessID,
+		Kind:       domain.ClientJobImport,
+		Import:     options,
+		TotalRows:  len(rows) - 1,
+		CreatedBy:  createdBy,
+	}
+	if job.TotalRows > clientJobInlineRows {
+		job.Input = file
+		if err := s.jobRepo.Create(ctx, job); err != nil {
+			return nil, fmt.Errorf("failed to create client job: %w", err)
+		}
+		return clientJobResponse(job), nil
+	}
+
+	startedAt := time.Now()
+	report, err := s.importRows(ctx, job, rows, columns, nil)
+	if err != nil {
+		return nil, err
+	}
+	finishedAt := time.Now()
+	job.Status = domain.ClientJobDone
+	job.Report = report
+	job.DoneRows = job.TotalRows
+	job.StartedAt, job.FinishedAt = &startedAt, &finishedAt
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create client job: %w", err)
+	}
+	return clientJobResponse(job), nil
+}
+
+// ExportClients exports the clients of a business matching the filter as
+// CSV. Up to clientJobInlineRows clients are streamed by the returned
+// function; for more an export job is queued and returned instead.
+func (s *ClientJobService) ExportClients(ctx context.Context, businessID, createdBy string, listFilter dto.ClientListFilter) (*dto.ClientJobResponse, func(io.Writer) error, error) {
+	filter, err := clientFilter(ctx, s.fieldRepo, businessID, listFilter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, total, err := s.clientRepo.GetClientsByBusiness(ctx, businessID, filter, 0, 1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count clients: %w", err)
+	}
+	if total <= clientJobInlineRows {
+		return nil, func(w io.Writer) error {
+			return writeClientsCSV(ctx, s.clientRepo, businessID, filter, w, nil)
+		}, nil
+	}
+
+	job := &domain.ClientJob{
+		BusinessID: businessID,
+		Kind:       domain.ClientJobExport,
+		Filter:     &filter,
+		TotalRows:  total,
+		CreatedBy:  createdBy,
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, nil, fmt.Errorf("failed to create client job: %w", err)
+	}
+	return clientJobResponse(job), nil, nil
+}
+
+// ListJobs returns the latest imports and exports of a business
+func (s *ClientJobService) ListJobs(ctx context.Context, businessID string) ([]dto.ClientJobResponse, error) {
+	jobs, err := s.jobRepo.ListByBusiness(ctx, businessID, clientJobListLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client jobs: %w", err)
+	}
+
+	resp := make([]dto.ClientJobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		resp = append(resp, *clientJobResponse(job))
+	}
+	return resp, nil
+}
+
+func (s *ClientJobService) GetJob(ctx context.Context, jobID string) (*dto.ClientJobResponse, error) {
+	job, err := s.job(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return clientJobResponse(job), nil
+}
+
+// GetImportReport renders the issues of a finished import as CSV
+func (s *ClientJobService) GetImportReport(ctx context.Context, jobID string) ([]byte, error) {
+	job, err := s.job(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Kind != domain.ClientJobImport || job.Report == nil {
+		return nil, ErrClientJobNotReady
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"row", "column", "message", "client_id"})
+	for _, issue := range job.Report.Issues {
+		w.Write([]string{strconv.Itoa(issue.Row), csvText(issue.Column), csvText(issue.Message), issue.ClientID})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write import report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GetExportResult returns the CSV of a finished export job
+func (s *ClientJobService) GetExportResult(ctx context.Context, jobID string) ([]byte, error) {
+	job, err := s.job(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Kind != domain.ClientJobExport || job.Status != domain.ClientJobDone {
+		return nil, ErrClientJobNotReady
+	}
+
+	result, err := s.jobRepo.GetResult(ctx, job.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get export result: %w", err)
+	}
+	return result, nil
+}
+
+// RunPending runs queued jobs one after another until none is left, and
+// deletes the jobs past clientJobRetention
+func (s *ClientJobService) RunPending(ctx context.Context) error {
+	if _, err := s.jobRepo.DeleteFinishedBefore(ctx, time.Now().Add(-clientJobRetention)); err != nil {
+		return fmt.Errorf("failed to delete old client jobs: %w", err)
+	}
+
+	for ctx.Err() == nil {
+		job, err := s.jobRepo.Claim(ctx, clientJobLease, clientJobMaxAttempts)
+		if err != nil {
+			return fmt.Errorf("failed to claim client job: %w", err)
+		}
+		if job == nil {
+			return nil
+		}
+
+		if err := s.run(ctx, job); err != nil {
+			if ctx.Err() != nil {
+				// Shutting down; the job is picked up again after the lease
+				return nil
+			}
+			job.Status = domain.ClientJobFailed
+			job.LastError = truncateError(err, clientJobErrorMaxBytes)
+		} else {
+			job.Status = domain.ClientJobDone
+		}
+		if err := s.jobRepo.Finish(ctx, job); err != nil {
+			return fmt.Errorf("failed to finish client job: %w", err)
+		}
+	}
+	return nil
+}
+
+// RunWorker runs queued jobs every interval until ctx is cancelled. Errors
+// are handed to onError and don't stop the worker.
+func (s *ClientJobService) RunWorker(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunPending(ctx); err != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ClientJobService) run(ctx context.Context, job *domain.ClientJob) error {
+	progress := func(done int) error {
+		job.DoneRows = done
+		return s.jobRepo.Extend(ctx, job.ID, done, clientJobLease)
+	}
+
+	switch job.Kind {
+	case domain.ClientJobImport:
+		if job.Import == nil {
+			return errors.New("import job without options")
+		}
+		rows, err := readImportFile(job.Import.Format, job.Input)
+		if err != nil {
+			return err
+		}
+		columns, err := s.importColumns(ctx, job.BusinessID, rows[0], job.Import.Mapping)
+		if err != nil {
+			return err
+		}
+		job.TotalRows = len(rows) - 1
+		if job.Report, err = s.importRows(ctx, job, rows, columns, progress); err != nil {
+			return err
+		}
+		job.DoneRows = job.TotalRows
+		return nil
+	case domain.ClientJobExport:
+		if job.Filter == nil {
+			return errors.New("export job without filter")
+		}
+		var buf bytes.Buffer
+		if err := writeClientsCSV(ctx, s.clientRepo, job.BusinessID, *job.Filter, &buf, progress); err != nil {
+			return err
+		}
+		job.Result = buf.Bytes()
+		job.DoneRows = job.TotalRows
+		return nil
+	default:
+		return fmt.Errorf("unknown client job kind %q", job.Kind)
+	}
+}
+
+func (s *ClientJobService) job(ctx context.Context, jobID string) (*domain.ClientJob, error) {
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrClientJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get client job: %w", err)
+	}
+	return job, nil
+}
+
+// writeClientsCSV writes the clients of a business matching the filter as
+// CSV, a page at a time, calling progress with the number written after
+// each page when it is set
+func writeClientsCSV(ctx context.Context, clientRepo domain.ClientRepository, businessID string, filter domain.ClientFilter, out io.Writer, progress func(int) error) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"id", "first_name", "last_name", "email", "phone", "marketing_consent", "notification_consent", "created_at"})
+
+	for offset := 0; ; offset += clientExportPageSize {
+		clients, _, err := clientRepo.GetClientsByBusiness(ctx, businessID, filter, offset, clientExportPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to get clients: %w", err)
+		}
+		for _, client := range clients {
+			w.Write([]string{
+				client.ID,
+				csvText(client.FirstName),
+				csvText(client.LastName),
+				csvText(client.Email),
+				client.Phone,
+				strconv.FormatBool(client.MarketingConsent),
+				strconv.FormatBool(client.NotificationConsent),
+				client.CreatedAt.Format(time.RFC3339),
+			})
+		}
+
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return fmt.Errorf("failed to write clients: %w", err)
+		}
+		if progress != nil {
+			if err := progress(offset + len(clients)); err != nil {
+				return err
+			}
+		}
+		if len(clients) < clientExportPageSize {
+			return nil
+		}
+	}
+}
+
+func clientJobResponse(job *domain.ClientJob) *dto.ClientJobResponse {
+	resp := &dto.ClientJobResponse{
+		ID:         job.ID,
+		Kind:       job.Kind,
+		Status:     job.Status,
+		TotalRows:  job.TotalRows,
+		DoneRows:   job.DoneRows,
+		Report:     job.Report,
+		Error:      job.LastError,
+		CreatedBy:  job.CreatedBy,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+	if job.Import != nil {
+		resp.FileName = job.Import.FileName
+		resp.DryRun = job.Import.DryRun
+		resp.UpdateExisting = job.Import.UpdateExisting
+	}
+	return resp
+}
@@ -0,0 +1,369 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrMergeSameClient = errors.New("a client can't be merged into itself")
+
+// Duplicate scores add up from the details two clients share, capped at 1
+const (
+	duplicateScorePhone = 0.6
+	duplicateScoreEmail = 0.5
+	duplicateScoreName  = 0.4 // times the similarity of the names
+
+	// duplicateMinNameSimilarity is the similarity from which names count
+	// as matching, so "Anna Ivanova" matches "Ana Ivanova"
+	duplicateMinNameSimilarity = 0.75
+
+	// defaultDuplicateMinScore lets matching names alone through, the
+	// weakest sign of a duplicate
+	defaultDuplicateMinScore = duplicateScoreName * duplicateMinNameSimilarity
+	defaultDuplicateLimit    = 50
+	maxDuplicateLimit        = 200
+)
+
+// ClientMergeService finds clients that are probably the same person, mostly
+// created by bookings made with a different email or spelling, and merges
+// them.
+type ClientMergeService struct {
+	clientRepo       domain.ClientRepository
+	mergeRepo        domain.ClientMergeRepository
+	bookingRepo      domain.BookingRepository
+	noteRepo         domain.ClientNoteRepository
+	tagRepo          domain.ClientTagRepository
+	fieldRepo        domain.ClientFieldRepository
+	consentRepo      domain.ClientConsentRepository
+	notificationRepo domain.NotificationRepository
+	eventRepo        domain.EventRepository
+	transactor       domain.Transactor
+}
+
+func NewClientMergeService(clientRepo domain.ClientRepository, mergeRepo domain.ClientMergeRepository,
+	bookingRepo domain.BookingRepository, noteRepo domain.ClientNoteRepository, tagRepo domain.ClientTagRepository,
+	fieldRepo domain.ClientFieldRepository, consentRepo domain.ClientConsentRepository, notificationRepo domain.NotificationRepository,
+	eventRepo domain.EventRepository, transactor domain.Transactor) *ClientMergeService {
+	return &ClientMergeService{
+		clientRepo:       clientRepo,
+		mergeRepo:        mergeRepo,
+		bookingRepo:      bookingRepo,
+		noteRepo:         noteRepo,
+		tagRepo:          tagRepo,
+		fieldRepo:        fieldRepo,
+		consentRepo:      consentRepo,
+		notificationRepo: notificationRepo,
+		eventRepo:        eventRepo,
+		transactor:       transactor,
+	}
+}
+
+// FindDuplicates returns the pairs of clients of a business scoring at least
+// minScore, best matches first. Only clients sharing a phone number, an email
+// or the start of their name are compared.
+func (s *ClientMergeService) FindDuplicates(ctx context.Context, businessID string, minScore float64, limit int) ([]dto.ClientDuplicateResponse, error) {
+	if minScore <= 0 {
+		minScore = defaultDuplicateMinScore
+	}
+	if limit < 1 || limit > maxDuplicateLimit {
+		limit = defaultDuplicateLimit
+	}
+
+	// Oldest first, so the first client of a pair is the one to keep
+	clients, err := s.clientRepo.ListClientsByBusiness(ctx, businessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clients: %w", err)
+	}
+
+	names := make([]string, len(clients))
+	blocks := make(map[string][]int)
+	for i, client := range clients {
+		names[i] = normalizeName(client.FirstName + " " + client.LastName)
+		if client.Phone != "" {
+			blocks["phone:"+client.Phone] = append(blocks["phone:"+client.Phone], i)
+		}
+		if email := strings.ToLower(strings.TrimSpace(client.Email)); email != "" {
+			blocks["email:"+email] = append(blocks["email:"+email], i)
+		}
+		if prefix := []rune(names[i]); len(prefix) >= 2 {
+			blocks["name:"+string(prefix[:2])] = append(blocks["name:"+string(prefix[:2])], i)
+		}
+	}
+
+	type pair struct{ a, b int }
+	seen := make(map[pair]bool)
+	duplicates := []dto.ClientDuplicateResponse{}
+	for _, block := range blocks {
+		for i := 0; i < len(block); i++ {
+			for j := i + 1; j < len(block); j++ {
+				p := pair{block[i], block[j]}
+				if seen[p] {
+					continue
+				}
+				seen[p] = true
+
+				score, reasons := duplicateScore(clients[p.a], clients[p.b], names[p.a], names[p.b])
+				if score < minScore {
+					continue
+				}
+				duplicates = append(duplicates, dto.ClientDuplicateResponse{
+					Client:    clientResponse(clients[p.a]),
+					Duplicate: clientResponse(clients[p.b]),
+					Score:     score,
+					Reasons:   reasons,
+				})
+			}
+		}
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool {
+		a, b := duplicates[i], duplicates[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Client.ID != b.Client.ID {
+			return a.Client.CreatedAt.Before(b.Client.CreatedAt)
+		}
+		return a.Duplicate.CreatedAt.Before(b.Duplicate.CreatedAt)
+	})
+	if len(duplicates) > limit {
+		duplicates = duplicates[:limit]
+	}
+	return duplicates, nil
+}
+
+// MergeClients merges the duplicate of the request into a client: its
+// bookings, notes, tags, consent history, notifications and merge history
+// move over, empty fields and custom field values of the client are filled
+// from it, and it is deleted, all in one transaction. The merge is kept in
+// the audit trail of the client. With req.Preview only what would move is
+// reported.
+func (s *ClientMergeService) MergeClients(ctx context.Context, clientID string, req dto.MergeClientsRequest, mergedBy string) (*dto.ClientMergeResponse, error) {
+	if req.MergedClientID == clientID {
+		return nil, ErrMergeSameClient
+	}
+	client, err := s.client(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := s.client(ctx, req.MergedClientID)
+	if err != nil {
+		return nil, err
+	}
+	if merged.BusinessID != client.BusinessID {
+		return nil, ErrClientNotFound
+	}
+	if client.ErasedAt != nil || merged.ErasedAt != nil {
+		return nil, ErrClientErased
+	}
+
+	bookings, err := s.bookingRepo.ListByClient(ctx, merged.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bookings: %w", err)
+	}
+
+	notes, err := s.noteRepo.ListByClient(ctx, merged.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+
+	resp := &dto.ClientMergeResponse{
+		Preview:       req.Preview,
+		MergedClient:  clientResponse(merged),
+		FilledFields:  fillClientFields(client, merged),
+		BookingsMoved: len(bookings),
+		NotesMoved:    len(notes),
+	}
+	now := time.Now()
+	for _, booking := range bookings {
+		if booking.IsConfirmed() && booking.StartAt.After(now) {
+			resp.UpcomingBookingsMoved++
+		}
+	}
+	if req.Preview {
+		resp.Client = clientResponse(client)
+		return resp, nil
+	}
+
+	merge := &domain.ClientMerge{
+		BusinessID:     client.BusinessID,
+		ClientID:       client.ID,
+		MergedClientID: merged.ID,
+		MergedClient:   *merged,
+		MergedBy:       mergedBy,
+	}
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// Bookings first, deleting the client would delete them too
+		moved, err := s.bookingRepo.ReassignClient(ctx, merged.ID, client.ID)
+		if err != nil {
+			return fmt.Errorf("failed to move bookings: %w", err)
+		}
+		merge.BookingsMoved = moved
+		if err := s.noteRepo.ReassignClient(ctx, merged.ID, client.ID); err != nil {
+			return fmt.Errorf("failed to move notes: %w", err)
+		}
+		if err := s.tagRepo.ReassignClient(ctx, merged.ID, client.ID); err != nil {
+			return fmt.Errorf("failed to move tags: %w", err)
+		}
+		if err := s.fieldRepo.ReassignValues(ctx, merged.ID, client.ID); err != nil {
+			return fmt.Errorf("failed to move custom field values: %w", err)
+		}
+		if err := s.mergeRepo.Reassign(ctx, merged.ID, client.ID); err != nil {
+			return fmt.Errorf("failed to move merge history: %w", err)
+		}
+		if err := s.consentRepo.ReassignClient(ctx, merged.ID, client.ID); err != nil {
+			return fmt.Errorf("failed to move consent history: %w", err)
+		}
+		if err := s.notificationRepo.ReassignClient(ctx, merged.ID, client.ID); err != nil {
+			return fmt.Errorf("failed to move notifications: %w", err)
+		}
+
+		if err := s.clientRepo.DeleteClient(ctx, merged.ID); err != nil {
+			return fmt.Errorf("failed to delete merged client: %w", err)
+		}
+		if err := s.clientRepo.UpdateClient(ctx, client); err != nil {
+			return fmt.Errorf("failed to update client: %w", err)
+		}
+
+		if err := s.mergeRepo.Create(ctx, merge); err != nil {
+			return fmt.Errorf("failed to record merge: %w", err)
+		}
+		return recordEvent(ctx, s.eventRepo, domain.EventClientMerged, client.BusinessID, client.ID,
+			domain.ClientMergedPayload{ClientID: client.ID, MergedClientID: merged.ID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp.MergeID = merge.ID
+	resp.Client = clientResponse(client)
+	resp.BookingsMoved = merge.BookingsMoved
+	return resp, nil
+}
+
+// ListMerges returns the audit trail of the clients merged into a client,
+// latest first
+func (s *ClientMergeService) ListMerges(ctx context.Context, clientID string) ([]dto.ClientMergeRecordResponse, error) {
+	merges, err := s.mergeRepo.ListByClient(ctx, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merges: %w", err)
+	}
+
+	records := make([]dto.ClientMergeRecordResponse, 0, len(merges))
+	for _, merge := range merges {
+		records = append(records, clientMergeRecordResponse(merge))
+	}
+	return records, nil
+}
+
+func (s *ClientMergeService) client(ctx context.Context, clientID string) (*domain.Client, error) {
+	client, err := s.clientRepo.GetClientByID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrClientNotFound
+		}
+		return nil, fmt.Errorf("failed to get client: %w", err)
+	}
+	return client, nil
+}
+
+// fillClientFields copies the fields client lacks from merged and returns
+// their names
+func fillClientFields(client, merged *domain.Client) []string {
+	filled := []string{}
+	fill := func(name string, field *string, value string) {
+		if strings.TrimSpace(*field) == "" && strings.TrimSpace(value) != "" {
+			*field = value
+			filled = append(filled, name)
+		}
+	}
+	fill("first_name", &client.FirstName, merged.FirstName)
+	fill("last_name", &client.LastName, merged.LastName)
+	fill("email", &client.Email, merged.Email)
+	fill("phone", &client.Phone, merged.Phone)
+	return filled
+}
+
+// duplicateScore scores how likely two clients are the same person and
+// names the details they share. Names are compared normalised.
+func duplicateScore(a, b *domain.Client, nameA, nameB string) (float64, []string) {
+	var score float64
+	reasons := []string{}
+	if a.Phone != "" && a.Phone == b.Phone {
+		score += duplicateScorePhone
+		reasons = append(reasons, "phone")
+	}
+	if a.Email != "" && strings.EqualFold(strings.TrimSpace(a.Email), strings.TrimSpace(b.Email)) {
+		score += duplicateScoreEmail
+		reasons = append(reasons, "email")
+	}
+	if similarity := nameSimilarity(nameA, nameB); similarity >= duplicateMinNameSimilarity {
+		score += duplicateScoreName * similarity
+		reasons = append(reasons, "name")
+	}
+	return min(score, 1), reasons
+}
+
+// normalizeName lower-cases a name, drops everything but letters and sorts
+// its words, so "Ivanova  Anna" and "anna ivanova" are the same
+func normalizeName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for i, word := range words {
+		words[i] = strings.ReplaceAll(word, "ё", "е")
+	}
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// nameSimilarity is 1 minus the edit distance of two names relative to the
+// longer one
+func nameSimilarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func clientMergeRecordResponse(merge *domain.ClientMerge) dto.ClientMergeRecordResponse {
+	return dto.ClientMergeRecordResponse{
+		ID:             merge.ID,
+		MergedClientID: merge.MergedClientID,
+		MergedClient:   clientResponse(&merge.MergedClient),
+		BookingsMoved:  merge.BookingsMoved,
+		MergedBy:       merge.MergedBy,
+		CreatedAt:      merge.CreatedAt,
+	}
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+)
+
+// duplicateClientRepository serves a fixed client list to FindDuplicates
+type duplicateClientRepository struct {
+	domain.ClientRepository
+	clients []*domain.Client
+}
+
+func (r *duplicateClientRepository) ListClientsByBusiness(ctx context.Context, businessID string) ([]*domain.Client, error) {
+	return r.clients, nil
+}
+
+func TestFindDuplicates(t *testing.T) {
+	created := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+	client := func(id, firstName, lastName, email, phone string) *domain.Client {
+		created = created.Add(time.Hour)
+		return &domain.Client{
+			ID:        id,
+			FirstName: firstName,
+			LastName:  lastName,
+			Email:     email,
+			Phone:     phone,
+			CreatedAt: created,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		clients []*domain.Client
+		reasons []string // of the only pair found, nil when none is
+	}{
+		{
+			name: "same phone",
+			clients: []*domain.Client{
+				client("a", "Anna", "Ivanova", "", "+79991234567"),
+				client("b", "Maria", "Petrova", "", "+79991234567"),
+			},
+			reasons: []string{"phone"},
+		},
+		{
+			name: "same email in another case",
+			clients: []*domain.Client{
+				client("a", "Anna", "Ivanova", "anna@example.com", ""),
+				client("b", "Maria", "Petrova", "Anna@Example.com ", ""),
+			},
+			reasons: []string{"email"},
+		},
+		{
+			name: "name only",
+			clients: []*domain.Client{
+				client("a", "Anna", "Ivanova", "anna@example.com", "+79991234567"),
+				client("b", "Ivanova", "Anna", "", ""),
+			},
+			reasons: []string{"name"},
+		},
+		{
+			name: "similar name only",
+			clients: []*domain.Client{
+				client("a", "Anna", "Ivanova", "", "+79991234567"),
+				client("b", "Ana", "Ivanova", "ana@example.com", ""),
+			},
+			reasons: []string{"name"},
+		},
+		{
+			name: "different names",
+			clients: []*domain.Client{
+				client("a", "Anna", "Ivanova", "", "+79991234567"),
+				client("b", "Anton", "Smirnov", "", "+79997654321"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ClientMergeService{clientRepo: &duplicateClientRepository{clients: tt.clients}}
+			duplicates, err := s.FindDuplicates(context.Background(), "business", 0, 0)
+			if err != nil {
+				t.Fatalf("FindDuplicates: %v", err)
+			}
+
+			if tt.reasons == nil {
+				if len(duplicates) != 0 {
+					t.Fatalf("got %d pairs, want none", len(duplicates))
+				}
+				return
+			}
+			if len(duplicates) != 1 {
+				t.Fatalf("got %d pairs, want 1", len(duplicates))
+			}
+			got := duplicates[0]
+			if got.Client.ID != "a" || got.Duplicate.ID != "b" {
+				t.Errorf("got pair %s, %s, want a, b", got.Client.ID, got.Duplicate.ID)
+			}
+			if len(got.Reasons) != len(tt.reasons) || got.Reasons[0] != tt.reasons[0] {
+				t.Errorf("got reasons %v, want %v", got.Reasons, tt.reasons)
+			}
+			if got.Score < defaultDuplicateMinScore || got.Score > 1 {
+				t.Errorf("got score %v, want from %v to 1", got.Score, defaultDuplicateMinScore)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrClientNoteNotFound = errors.New("client note not found")
+
+// ClientNoteService manages the notes staff keep on clients
+type ClientNoteService struct {
+	noteRepo   domain.ClientNoteRepository
+	clientRepo domain.ClientRepository
+}
+
+func NewClientNoteService(noteRepo domain.ClientNoteRepository, clientRepo domain.ClientRepository) *ClientNoteService {
+	return &ClientNoteService{
+		noteRepo:   noteRepo,
+		clientRepo: clientRepo,
+	}
+}
+
+// ListNotes returns the notes of a client, latest first
+func (s *ClientNoteService) ListNotes(ctx context.Context, clientID string) ([]dto.ClientNoteResponse, error) {
+	notes, err := s.noteRepo.ListByClient(ctx, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client notes: %w", err)
+	}
+
+	resp := make([]dto.ClientNoteResponse, 0, len(notes))
+	for _, note := range notes {
+		resp = append(resp, clientNoteResponse(note))
+	}
+	return resp, nil
+}
+
+// CreateNote adds a note to a client. authorID is the user writing it, empty
+// for API keys.
+func (s *ClientNoteService) CreateNote(ctx context.Context, clientID, authorID string, req dto.ClientNoteRequest) (*dto.ClientNoteResponse, error) {
+	client, err := s.clientRepo.GetClientByID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrClientNotFound
+		}
+		return nil, fmt.Errorf("failed to get client: %w", err)
+	}
+	if client.ErasedAt != nil {
+		return nil, ErrClientErased
+	}
+
+	note := &domain.ClientNote{
+		BusinessID: client.BusinessID,
+		ClientID:   client.ID,
+		AuthorID:   authorID,
+		Body:       strings.TrimSpace(req.Body),
+	}
+	if err := s.noteRepo.Create(ctx, note); err != nil {
+		return nil, fmt.Errorf("failed to create client note: %w", err)
+	}
+
+	// Read it back for the name of the author
+	return s.getNote(ctx, client.ID, note.ID)
+}
+
+// UpdateNote changes the text of a note. A non-empty authorID limits it to
+// the notes written by that user.
+func (s *ClientNoteService) UpdateNote(ctx context.Context, clientID, authorID, noteID string, req dto.ClientNoteRequest) (*dto.ClientNoteResponse, error) {
+	note, err := s.note(ctx, clientID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if authorID != "" && note.AuthorID != authorID {
+		return nil, ErrClientNoteNotFound
+	}
+
+	note.Body = strings.TrimSpace(req.Body)
+	if err := s.noteRepo.Update(ctx, note); err != nil {
+		return nil, fmt.Errorf("failed to update client note: %w", err)
+	}
+
+	resp := clientNoteResponse(note)
+	return &resp, nil
+}
+
+// DeleteNote deletes a note. A non-empty authorID limits it to the notes
+// written by that user.
+func (s *ClientNoteService) DeleteNote(ctx context.Context, clientID, authorID, noteID string) error {
+	note, err := s.note(ctx, clientID, noteID)
+	if err != nil {
+		return err
+	}
+	if authorID != "" && note.AuthorID != authorID {
+		return ErrClientNoteNotFound
+	}
+
+	if err := s.noteRepo.Delete(ctx, note.ID); err != nil {
+		return fmt.Errorf("failed to delete client note: %w", err)
+	}
+	return nil
+}
+
+func (s *ClientNoteService) getNote(ctx context.Context, clientID, noteID string) (*dto.ClientNoteResponse, error) {
+	note, err := s.note(ctx, clientID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	resp := clientNoteResponse(note)
+	return &resp, nil
+}
+
+// note returns a note of the client, ErrClientNoteNotFound when the note
+// belongs to another one
+func (s *ClientNoteService) note(ctx context.Context, clientID, noteID string) (*domain.ClientNote, error) {
+	note, err := s.noteRepo.GetByID(ctx, noteID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrClientNoteNotFound
+		}
+		return nil, fmt.Errorf("failed to get client note: %w", err)
+	}
+	if note.ClientID != clientID {
+		return nil, ErrClientNoteNotFound
+	}
+	return note, nil
+}
+
+func clientNoteResponse(note *domain.ClientNote) dto.ClientNoteResponse {
+	return dto.ClientNoteResponse{
+		ID:         note.ID,
+		ClientID:   note.ClientID,
+		AuthorID:   note.AuthorID,
+		AuthorName: note.AuthorName,
+		Body:       note.Body,
+		CreatedAt:  note.CreatedAt,
+		UpdatedAt:  note.UpdatedAt,
+	}
+}
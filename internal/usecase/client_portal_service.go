@@ -0,0 +1,432 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/pkg/jwtkeys"
+	"github.com/ialekseychuk/my-place/pkg/mailer"
+	"github.com/ialekseychuk/my-place/pkg/sms"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrInvalidLoginCode   = errors.New("login code is invalid or expired")
+	ErrInvalidClientToken = errors.New("invalid client token")
+	ErrBookingNotFound    = errors.New("booking not found")
+	ErrPhoneAlreadyInUse  = errors.New("phone number is already in use")
+)
+
+// ClientPortalService lets clients log in with a one-time code or magic link
+// and manage their own bookings. Client tokens are signed with the same keys
+// as staff tokens but carry their own audience, so neither is accepted in
+// place of the other.
+type ClientPortalService struct {
+	businessRepo  domain.BusinessRepository
+	clientRepo    domain.ClientRepository
+	bookingRepo   domain.BookingRepository
+	serviceRepo   domain.ServiceRepository
+	loginCodeRepo domain.ClientLoginCodeRepository
+	publicBooking *PublicBookingService
+	keys          *jwtkeys.KeySet
+	mailSender    mailer.Sender
+	smsSender     sms.Sender
+	appURL        string
+
+	loginCodeTTL    time.Duration
+	maxCodeAttempts int
+	clientTokenTTL  time.Duration
+	loginCodeDigits int
+}
+
+func NewClientPortalService(
+	businessRepo domain.BusinessRepository,
+	clientRepo domain.ClientRepository,
+	bookingRepo domain.BookingRepository,
+	serviceRepo domain.ServiceRepository,
+	loginCodeRepo domain.ClientLoginCodeRepository,
+	publicBooking *PublicBookingService,
+	keys *jwtkeys.KeySet,
+	mailSender mailer.Sender,
+	smsSender sms.Sender,
+	appURL string,
+) *ClientPortalService {
+	return &ClientPortalService{
+		businessRepo:    businessRepo,
+		clientRepo:      clientRepo,
+		bookingRepo:     bookingRepo,
+		serviceRepo:     serviceRepo,
+		loginCodeRepo:   loginCodeRepo,
+		publicBooking:   publicBooking,
+		keys:            keys,
+		mailSender:      mailSender,
+		smsSender:       smsSender,
+		appURL:          strings.TrimRight(appURL, "/"),
+		loginCodeTTL:    15 * time.Minute,
+		maxCodeAttempts: 5,
+		clientTokenTTL:  24 * time.Hour,
+		loginCodeDigits: 6,
+	}
+}
+
+// RequestLogin sends a login code and magic link to a client of the business.
+// Unknown emails and phone numbers are ignored silently so the endpoint can't
+// be used to probe who is a client.
+func (s *ClientPortalService) RequestLogin(ctx context.Context, slug string, req dto.ClientLoginRequest) error {
+	business, err := s.businessBySlug(ctx, slug)
+	if err != nil {
+		return err
+	}
+
+	client, channel, err := s.findClient(ctx, business, req.Email, req.Phone)
+	if err != nil {
+		return nil
+	}
+
+	code, err := generateNumericCode(s.loginCodeDigits)
+	if err != nil {
+		return err
+	}
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	loginCode := &domain.ClientLoginCode{
+		ClientID:  client.ID,
+		Channel:   channel,
+		CodeHash:  hashLoginCode(client.ID, code),
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.loginCodeTTL),
+	}
+	if err := s.loginCodeRepo.Create(ctx, loginCode); err != nil {
+		return fmt.Errorf("failed to store login code: %w", err)
+	}
+
+	minutes := int(s.loginCodeTTL.Minutes())
+	if channel == domain.ClientLoginChannelSMS {
+		err = s.smsSender.Send(ctx, sms.Message{
+			To:   client.Phone,
+			Body: fmt.Sprintf("%s is your %s login code. It expires in %d minutes.", code, business.Name, minutes),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to send sms: %w", err)
+		}
+		return nil
+	}
+
+	err = s.mailSender.Send(ctx, mailer.Message{
+		To:      client.Email,
+		Subject: fmt.Sprintf("Your login code for %s", business.Name),
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nyour login code is %s. You can also sign in by opening the link below:\n\n%s/book/%s/login?token=%s\n\nThe code and the link are valid for %d minutes. If you didn't request them, just ignore this email.\n",
+			client.FirstName, code, s.appURL, business.Slug, token, minutes),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// VerifyLogin redeems a magic link token or a login code and returns a client
+// access token
+func (s *ClientPortalService) VerifyLogin(ctx context.Context, slug string, req dto.ClientLoginVerifyRequest) (*dto.ClientLoginResponse, error) {
+	business, err := s.businessBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		loginCode *domain.ClientLoginCode
+		client    *domain.Client
+	)
+	if req.Token != "" {
+		loginCode, err = s.loginCodeRepo.GetByTokenHash(ctx, hashToken(req.Token))
+		if err != nil || !loginCode.IsUsable() {
+			return nil, ErrInvalidLoginCode
+		}
+		client, err = s.clientRepo.GetClientByID(ctx, loginCode.ClientID)
+		if err != nil || client.BusinessID != business.ID {
+			return nil, ErrInvalidLoginCode
+		}
+	} else {
+		client, _, err = s.findClient(ctx, business, req.Email, req.Phone)
+		if err != nil {
+			return nil, ErrInvalidLoginCode
+		}
+		loginCode, err = s.loginCodeRepo.GetLatestByClient(ctx, client.ID)
+		if err != nil || !loginCode.IsUsable() || loginCode.Attempts >= s.maxCodeAttempts {
+			return nil, ErrInvalidLoginCode
+		}
+		if subtle.ConstantTimeCompare([]byte(hashLoginCode(client.ID, req.Code)), []byte(loginCode.CodeHash)) != 1 {
+			if _, err := s.loginCodeRepo.RegisterFailedAttempt(ctx, loginCode.ID); err != nil {
+				return nil, fmt.Errorf("failed to register attempt: %w", err)
+			}
+			return nil, ErrInvalidLoginCode
+		}
+	}
+
+	// Consuming fails when the code was redeemed concurrently
+	if err := s.loginCodeRepo.Consume(ctx, loginCode.ID); err != nil {
+		return nil, ErrInvalidLoginCode
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.clientTokenTTL)
+	token, err := s.keys.Sign(&domain.ClientClaims{
+		ClientID:   client.ID,
+		BusinessID: client.BusinessID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   client.ID,
+			Audience:  jwt.ClaimStrings{domain.ClientTokenAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return &dto.ClientLoginResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		Client:    clientResponse(client),
+	}, nil
+}
+
+// ValidateToken checks a client access token and returns its client
+func (s *ClientPortalService) ValidateToken(ctx context.Context, tokenString string) (*domain.Client, *domain.ClientClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &domain.ClientClaims{}, s.keys.Keyfunc,
+		jwt.WithValidMethods(s.keys.ValidMethods()),
+		jwt.WithAudience(domain.ClientTokenAudience),
+		jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, nil, ErrInvalidClientToken
+	}
+
+	claims, ok := token.Claims.(*domain.ClientClaims)
+	if !ok || !token.Valid {
+		return nil, nil, ErrInvalidClientToken
+	}
+
+	client, err := s.clientRepo.GetClientByID(ctx, claims.ClientID)
+	if err != nil || client.BusinessID != claims.BusinessID {
+		return nil, nil, ErrInvalidClientToken
+	}
+
+	return client, claims, nil
+}
+
+// UpdateProfile changes the contact details of a client
+func (s *ClientPortalService) UpdateProfile(ctx context.Context, client *domain.Client, req dto.UpdateClientProfileRequest) (*dto.ClientResponse, error) {
+	if req.Phone != "" {
+		business, err := s.businessRepo.GetById(ctx, client.BusinessID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get business: %w", err)
+		}
+		phone, err := normalizePhone(req.Phone, business)
+		if err != nil {
+			return nil, err
+		}
+		if phone != client.Phone {
+			// Bookings find their client by phone, so it has to stay unique
+			existing, err := s.clientRepo.GetClientByPhone(ctx, client.BusinessID, phone)
+			if err == nil && existing.ID != client.ID {
+				return nil, ErrPhoneAlreadyInUse
+			}
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return nil, fmt.Errorf("failed to check phone: %w", err)
+			}
+			client.Phone = phone
+		}
+	}
+	if req.FirstName != "" {
+		client.FirstName = req.FirstName
+	}
+	if req.LastName != "" {
+		client.LastName = req.LastName
+	}
+	if req.Email != "" {
+		client.Email = req.Email
+	}
+
+	if err := s.clientRepo.UpdateClient(ctx, client); err != nil {
+		if errors.Is(err, domain.ErrClientPhoneTaken) {
+			return nil, ErrPhoneAlreadyInUse
+		}
+		return nil, fmt.Errorf("failed to update client: %w", err)
+	}
+
+	resp := clientResponse(client)
+	return &resp, nil
+}
+
+// ListBookings returns the bookings of a client across all locations, split
+// into upcoming ones (soonest first) and past or cancelled ones (latest first)
+func (s *ClientPortalService) ListBookings(ctx context.Context, client *domain.Client) (*dto.ClientBookingsResponse, error) {
+	business, err := s.businessRepo.GetById(ctx, client.BusinessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get business: %w", err)
+	}
+
+	bookings, err := s.bookingRepo.ListByClient(ctx, client.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bookings: %w", err)
+	}
+
+	resp := &dto.ClientBookingsResponse{
+		Upcoming: []*dto.PublicBookingResponse{},
+		Past:     []*dto.PublicBookingResponse{},
+	}
+	now := time.Now()
+	for _, booking := range bookings {
+		service, err := s.serviceRepo.GetById(ctx, booking.ServiceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service: %w", err)
+		}
+		item, err := s.publicBooking.bookingResponse(ctx, business, booking, service)
+		if err != nil {
+			return nil, err
+		}
+
+		if !booking.IsCancelled() && booking.StartAt.After(now) {
+			// bookings come latest first, upcoming ones are shown soonest first
+			resp.Upcoming = append([]*dto.PublicBookingResponse{item}, resp.Upcoming...)
+		} else {
+			resp.Past = append(resp.Past, item)
+		}
+	}
+
+	return resp, nil
+}
+
+// CancelBooking cancels a booking of the client within the cancellation
+// window of the business
+func (s *ClientPortalService) CancelBooking(ctx context.Context, client *domain.Client, bookingID string) (*dto.PublicBookingResponse, error) {
+	business, booking, service, err := s.clientBooking(ctx, client, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.publicBooking.cancel(ctx, business, booking); err != nil {
+		return nil, err
+	}
+	return s.publicBooking.bookingResponse(ctx, business, booking, service)
+}
+
+// Rebook books the service of an earlier booking of the client again
+func (s *ClientPortalService) Rebook(ctx context.Context, client *domain.Client, bookingID string, req dto.RebookRequest) (*dto.PublicBookingResponse, error) {
+	business, booking, service, err := s.clientBooking(ctx, client, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	staffID := req.StaffID
+	if staffID == "" {
+		staffID = booking.StaffID
+	}
+
+	return s.publicBooking.CreateBooking(ctx, business.Slug, dto.PublicCreateBookingRequest{
+		ServiceID:     service.ID,
+		StaffID:       staffID,
+		StartAt:       req.StartAt,
+		CustomerName:  strings.TrimSpace(client.FirstName + " " + client.LastName),
+		CustomerPhone: client.Phone,
+		CustomerEmail: client.Email,
+	})
+}
+
+// clientBooking loads a booking that has to belong to the client
+func (s *ClientPortalService) clientBooking(ctx context.Context, client *domain.Client, bookingID string) (*domain.Business, *domain.Booking, *domain.Service, error) {
+	booking, err := s.bookingRepo.GetById(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, nil, ErrBookingNotFound
+		}
+		return nil, nil, nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+	if booking.ClientID != client.ID {
+		return nil, nil, nil, ErrBookingNotFound
+	}
+
+	service, err := s.serviceRepo.GetById(ctx, booking.ServiceID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	business, err := s.businessRepo.GetById(ctx, client.BusinessID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get business: %w", err)
+	}
+
+	return business, booking, service, nil
+}
+
+func (s *ClientPortalService) businessBySlug(ctx context.Context, slug string) (*domain.Business, error) {
+	business, err := s.businessRepo.GetBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBusinessNotFound
+		}
+		return nil, fmt.Errorf("failed to get business: %w", err)
+	}
+	return business, nil
+}
+
+// findClient looks a client up by email, or by phone when no email is given,
+// and returns the channel to reach them on
+func (s *ClientPortalService) findClient(ctx context.Context, business *domain.Business, email, phone string) (*domain.Client, string, error) {
+	if email != "" {
+		client, err := s.clientRepo.GetClientByEmail(ctx, business.ID, email)
+		return client, domain.ClientLoginChannelEmail, err
+	}
+	if phone != "" {
+		normalized, err := normalizePhone(phone, business)
+		if err != nil {
+			return nil, "", err
+		}
+		client, err := s.clientRepo.GetClientByPhone(ctx, business.ID, normalized)
+		return client, domain.ClientLoginChannelSMS, err
+	}
+	return nil, "", ErrInvalidLoginCode
+}
+
+// hashLoginCode binds a short numeric code to its client before hashing it
+func hashLoginCode(clientID, code string) string {
+	return hashToken(clientID + ":" + code)
+}
+
+// generateNumericCode returns a random code of the given number of digits
+func generateNumericCode(digits int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code: %w", err)
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
+
+func clientResponse(client *domain.Client) dto.ClientResponse {
+	return dto.ClientResponse{
+		ID:                    client.ID,
+		FirstName:             client.FirstName,
+		LastName:              client.LastName,
+		Email:                 client.Email,
+		Phone:                 client.Phone,
+		MarketingConsent:      client.MarketingConsent,
+		MarketingConsentAt:    client.MarketingConsentAt,
+		NotificationConsent:   client.NotificationConsent,
+		NotificationConsentAt: client.NotificationConsentAt,
+		ErasedAt:              client.ErasedAt,
+		CreatedAt:             client.CreatedAt,
+		UpdatedAt:             client.UpdatedAt,
+	}
+}
@@ -0,0 +1,498 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/jackc/pgx/v5"
+)
+
+// Erased clients keep their row, and so their bookings, under this name
+const (
+	erasedClientFirstName = "Erased"
+	erasedClientLastName  = "client"
+)
+
+// ClientPrivacyService answers data subject requests: exporting everything
+// stored about a client, erasing their personal data and tracking their
+// marketing and notification consent.
+type ClientPrivacyService struct {
+	clientRepo       domain.ClientRepository
+	bookingRepo      domain.BookingRepository
+	serviceRepo      domain.ServiceRepository
+	staffRepo        domain.StaffRepository
+	noteRepo         domain.ClientNoteRepository
+	tagRepo          domain.ClientTagRepository
+	fieldRepo        domain.ClientFieldRepository
+	mergeRepo        domain.ClientMergeRepository
+	consentRepo      domain.ClientConsentRepository
+	notificationRepo domain.NotificationRepository
+	eventRepo        domain.EventRepository
+	transactor       domain.Transactor
+}
+
+func NewClientPrivacyService(clientRepo domain.ClientRepository, bookingRepo domain.BookingRepository,
+	serviceRepo domain.ServiceRepository, staffRepo domain.StaffRepository, noteRepo domain.ClientNoteRepository,
+	tagRepo domain.ClientTagRepository, fieldRepo domain.ClientFieldRepository, mergeRepo domain.ClientMergeRepository,
+	consentRepo domain.ClientConsentRepository, notificationRepo domain.NotificationRepository,
+	eventRepo domain.EventRepository, transactor domain.Transactor) *ClientPrivacyService {
+	return &ClientPrivacyService{
+		clientRepo:       clientRepo,
+		bookingRepo:      bookingRepo,
+		serviceRepo:      serviceRepo,
+		staffRepo:        staffRepo,
+		noteRepo:         noteRepo,
+		tagRepo:          tagRepo,
+		fieldRepo:        fieldRepo,
+		mergeRepo:        mergeRepo,
+		consentRepo:      consentRepo,
+		notificationRepo: notificationRepo,
+		eventRepo:        eventRepo,
+		transactor:       transactor,
+	}
+}
+
+// ExportClientData collects everything stored about a client: the client
+// record, bookings, notes, tags, custom field values, consent history,
+// notifications and the clients merged into it.
+func (s *ClientPrivacyService) ExportClientData(ctx context.Context, clientID string) (*dto.ClientDataExport, error) {
+	client, err := s.client(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &dto.ClientDataExport{
+		ExportedAt:    time.Now(),
+		Client:        clientResponse(client),
+		Notes:         []dto.ClientNoteResponse{},
+		Consents:      []dto.ClientConsentResponse{},
+		Notifications: []dto.ClientNotificationResponse{},
+		Merges:        []dto.ClientMergeRecordResponse{},
+	}
+
+	if export.Bookings, err = s.bookingSummaries(ctx, client); err != nil {
+		return nil, err
+	}
+
+	notes, err := s.noteRepo.ListByClient(ctx, client.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client notes: %w", err)
+	}
+	for _, note := range notes {
+		export.Notes = append(export.Notes, clientNoteResponse(note))
+	}
+
+	tags, err := s.tagRepo.ListByClient(ctx, client.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client tags: %w", err)
+	}
+	export.Tags = clientTagResponses(tags)
+
+	if export.CustomFields, err = clientFieldValues(ctx, s.fieldRepo, client); err != nil {
+		return nil, err
+	}
+
+	consents, err := s.consentRepo.ListByClient(ctx, client.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client consents: %w", err)
+	}
+	for _, consent := range consents {
+		export.Consents = append(export.Consents, clientConsentResponse(consent))
+	}
+
+	notifications, err := s.notificationRepo.ListByClient(ctx, client.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	for _, n := range notifications {
+		export.Notifications = append(export.Notifications, dto.ClientNotificationResponse{
+			ID:        n.ID,
+			Event:     string(n.Event),
+			Channel:   n.Channel,
+			Recipient: n.Recipient,
+			Subject:   n.Subject,
+			Body:      n.Body,
+			Status:    n.Status,
+			SentAt:    n.SentAt,
+			CreatedAt: n.CreatedAt,
+		})
+	}
+
+	merges, err := s.mergeRepo.ListByClient(ctx, client.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merges: %w", err)
+	}
+	for _, merge := range merges {
+		export.Merges = append(export.Merges, clientMergeRecordResponse(merge))
+	}
+
+	return export, nil
+}
+
+// ExportClientDataCSV renders the data export of a client as a zip archive
+// with one CSV file per kind of record
+func (s *ClientPrivacyService) ExportClientDataCSV(ctx context.Context, clientID string) ([]byte, error) {
+	export, err := s.ExportClientData(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name string
+		rows [][]string
+	}{
+		{"client.csv", clientExportRows(export.Client)},
+		{"bookings.csv", bookingExportRows(export.Bookings)},
+		{"notes.csv", noteExportRows(export.Notes)},
+		{"tags.csv", tagExportRows(export.Tags)},
+		{"custom_fields.csv", fieldExportRows(export.CustomFields)},
+		{"consents.csv", consentExportRows(export.Consents)},
+		{"notifications.csv", notificationExportRows(export.Notifications)},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		f, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to write client export: %w", err)
+		}
+		w := csv.NewWriter(f)
+		w.WriteAll(file.rows)
+		if err := w.Error(); err != nil {
+			return nil, fmt.Errorf("failed to write client export: %w", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write client export: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// EraseClient anonymises a client for the right to be forgotten. Name,
+// contact details and consent are cleared, notes, tags, custom field values
+// and merge snapshots deleted and the recipients and content of their
+// notifications blanked. The client row and its bookings are kept so the
+// statistics of the business stay right. Clients with upcoming bookings
+// can't be erased; cancel those first.
+func (s *ClientPrivacyService) EraseClient(ctx context.Context, clientID string) (*dto.ClientResponse, error) {
+	client, err := s.client(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client.ErasedAt != nil {
+		return nil, ErrClientErased
+	}
+
+	bookings, err := s.bookingRepo.ListByClient(ctx, client.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bookings: %w", err)
+	}
+	now := time.Now()
+	for _, booking := range bookings {
+		if booking.IsConfirmed() && booking.StartAt.After(now) {
+			return nil, ErrClientHasUpcomingBookings
+		}
+	}
+
+	client.FirstName = erasedClientFirstName
+	client.LastName = erasedClientLastName
+	client.Email = ""
+	client.Phone = ""
+	client.MarketingConsent = false
+	client.MarketingConsentAt = nil
+	client.NotificationConsent = false
+	client.NotificationConsentAt = nil
+	client.ErasedAt = &now
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.clientRepo.EraseClient(ctx, client); err != nil {
+			return fmt.Errorf("failed to erase client: %w", err)
+		}
+		if err := s.noteRepo.DeleteByClient(ctx, client.ID); err != nil {
+			return fmt.Errorf("failed to delete client notes: %w", err)
+		}
+		if err := s.tagRepo.SetClientTags(ctx, client.ID, nil); err != nil {
+			return fmt.Errorf("failed to delete client tags: %w", err)
+		}
+		if err := s.fieldRepo.DeleteValues(ctx, client.ID); err != nil {
+			return fmt.Errorf("failed to delete custom field values: %w", err)
+		}
+		if err := s.mergeRepo.DeleteByClient(ctx, client.ID); err != nil {
+			return fmt.Errorf("failed to delete merge history: %w", err)
+		}
+		if err := s.notificationRepo.AnonymiseClient(ctx, client.ID); err != nil {
+			return fmt.Errorf("failed to anonymise notifications: %w", err)
+		}
+		return recordEvent(ctx, s.eventRepo, domain.EventClientErased, client.BusinessID, client.ID,
+			domain.ClientErasedPayload{ClientID: client.ID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := clientResponse(client)
+	return &response, nil
+}
+
+// GetConsents returns the current consent of a client with its history
+func (s *ClientPrivacyService) GetConsents(ctx context.Context, clientID string) (*dto.ClientConsentsResponse, error) {
+	client, err := s.client(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	return s.consents(ctx, client)
+}
+
+// UpdateConsents records the consent a client gave or withdrew. Each change
+// is kept in the consent history, even when it repeats the current state, as
+// proof of when it was confirmed.
+func (s *ClientPrivacyService) UpdateConsents(ctx context.Context, clientID, recordedBy string, req dto.UpdateClientConsentRequest) (*dto.ClientConsentsResponse, error) {
+	client, err := s.client(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client.ErasedAt != nil {
+		return nil, ErrClientErased
+	}
+
+	now := time.Now()
+	client.UpdatedAt = now
+	var changes []*domain.ClientConsent
+	if req.Marketing != nil {
+		changes = append(changes, &domain.ClientConsent{Kind: domain.ClientConsentMarketing, Granted: *req.Marketing})
+		client.MarketingConsent = *req.Marketing
+		client.MarketingConsentAt = &now
+	}
+	if req.Notifications != nil {
+		changes = append(changes, &domain.ClientConsent{Kind: domain.ClientConsentNotifications, Granted: *req.Notifications})
+		client.NotificationConsent = *req.Notifications
+		client.NotificationConsentAt = &now
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		for _, consent := range changes {
+			consent.BusinessID = client.BusinessID
+			consent.ClientID = client.ID
+			consent.Source = strings.TrimSpace(req.Source)
+			consent.RecordedBy = recordedBy
+			consent.CreatedAt = now
+			if err := s.consentRepo.Create(ctx, consent); err != nil {
+				return fmt.Errorf("failed to record consent: %w", err)
+			}
+			if err := s.clientRepo.SetConsent(ctx, client.ID, consent.Kind, consent.Granted, now); err != nil {
+				return fmt.Errorf("failed to update consent: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.consents(ctx, client)
+}
+
+func (s *ClientPrivacyService) consents(ctx context.Context, client *domain.Client) (*dto.ClientConsentsResponse, error) {
+	consents, err := s.consentRepo.ListByClient(ctx, client.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client consents: %w", err)
+	}
+
+	resp := &dto.ClientConsentsResponse{
+		Client:  clientResponse(client),
+		History: make([]dto.ClientConsentResponse, 0, len(consents)),
+	}
+	for _, consent := range consents {
+		resp.History = append(resp.History, clientConsentResponse(consent))
+	}
+	return resp, nil
+}
+
+// bookingSummaries returns all bookings of a client, latest first
+func (s *ClientPrivacyService) bookingSummaries(ctx context.Context, client *domain.Client) ([]dto.ClientBookingSummary, error) {
+	bookings, err := s.bookingRepo.ListByClient(ctx, client.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bookings: %w", err)
+	}
+	services := make(map[string]*domain.Service)
+	staff := make(map[string]*domain.Staff)
+	summaries := make([]dto.ClientBookingSummary, 0, len(bookings))
+	for _, booking := range bookings {
+		service, ok := services[booking.ServiceID]
+		if !ok {
+			if service, err = s.serviceRepo.GetById(ctx, booking.ServiceID); err != nil {
+				return nil, fmt.Errorf("failed to get service: %w", err)
+			}
+			services[service.ID] = service
+		}
+		staffMember, ok := staff[booking.StaffID]
+		if !ok {
+			if staffMember, err = s.staffRepo.GetById(ctx, booking.StaffID); err != nil {
+				return nil, fmt.Errorf("failed to get staff: %w", err)
+			}
+			staff[staffMember.ID] = staffMember
+		}
+
+		summaries = append(summaries, dto.ClientBookingSummary{
+			ID:          booking.ID,
+			ServiceID:   service.ID,
+			ServiceName: service.Name,
+			StaffID:     staffMember.ID,
+			StaffName:   staffName(staffMember),
+			StartAt:     booking.StartAt,
+			EndAt:       booking.EndAt,
+			Status:      booking.Status,
+			PriceCents:  booking.PriceCents,
+		})
+	}
+	return summaries, nil
+}
+
+func (s *ClientPrivacyService) client(ctx context.Context, clientID string) (*domain.Client, error) {
+	client, err := s.clientRepo.GetClientByID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrClientNotFound
+		}
+		return nil, fmt.Errorf("failed to get client: %w", err)
+	}
+	return client, nil
+}
+
+func clientConsentResponse(consent *domain.ClientConsent) dto.ClientConsentResponse {
+	return dto.ClientConsentResponse{
+		ID:             consent.ID,
+		Kind:           consent.Kind,
+		Granted:        consent.Granted,
+		Source:         consent.Source,
+		RecordedBy:     consent.RecordedBy,
+		RecordedByName: consent.RecordedByName,
+		CreatedAt:      consent.CreatedAt,
+	}
+}
+
+func clientExportRows(client dto.ClientResponse) [][]string {
+	return [][]string{
+		{"id", "first_name", "last_name", "email", "phone", "marketing_consent", "marketing_consent_at",
+			"notification_consent", "notification_consent_at", "erased_at", "created_at", "updated_at"},
+		{
+			client.ID,
+			csvText(client.FirstName),
+			csvText(client.LastName),
+			csvText(client.Email),
+			client.Phone,
+			strconv.FormatBool(client.MarketingConsent),
+			csvTime(client.MarketingConsentAt),
+			strconv.FormatBool(client.NotificationConsent),
+			csvTime(client.NotificationConsentAt),
+			csvTime(client.ErasedAt),
+			client.CreatedAt.Format(time.RFC3339),
+			client.UpdatedAt.Format(time.RFC3339),
+		},
+	}
+}
+
+func bookingExportRows(bookings []dto.ClientBookingSummary) [][]string {
+	rows := [][]string{{"id", "service", "staff", "start_at", "end_at", "status", "price_cents"}}
+	for _, booking := range bookings {
+		rows = append(rows, []string{
+			booking.ID,
+			csvText(booking.ServiceName),
+			csvText(booking.StaffName),
+			booking.StartAt.Format(time.RFC3339),
+			booking.EndAt.Format(time.RFC3339),
+			booking.Status,
+			strconv.Itoa(booking.PriceCents),
+		})
+	}
+	return rows
+}
+
+func noteExportRows(notes []dto.ClientNoteResponse) [][]string {
+	rows := [][]string{{"id", "author", "body", "created_at", "updated_at"}}
+	for _, note := range notes {
+		rows = append(rows, []string{
+			note.ID,
+			csvText(note.AuthorName),
+			csvText(note.Body),
+			note.CreatedAt.Format(time.RFC3339),
+			note.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return rows
+}
+
+func tagExportRows(tags []dto.ClientTagResponse) [][]string {
+	rows := [][]string{{"id", "name"}}
+	for _, tag := range tags {
+		rows = append(rows, []string{tag.ID, csvText(tag.Name)})
+	}
+	return rows
+}
+
+func fieldExportRows(fields []dto.ClientFieldValueResponse) [][]string {
+	rows := [][]string{{"key", "label", "value", "updated_at"}}
+	for _, field := range fields {
+		rows = append(rows, []string{
+			field.Key,
+			csvText(field.Label),
+			csvText(field.Value),
+			field.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return rows
+}
+
+func consentExportRows(consents []dto.ClientConsentResponse) [][]string {
+	rows := [][]string{{"kind", "granted", "source", "recorded_by", "created_at"}}
+	for _, consent := range consents {
+		rows = append(rows, []string{
+			consent.Kind,
+			strconv.FormatBool(consent.Granted),
+			csvText(consent.Source),
+			csvText(consent.RecordedByName),
+			consent.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return rows
+}
+
+func notificationExportRows(notifications []dto.ClientNotificationResponse) [][]string {
+	rows := [][]string{{"id", "event", "channel", "recipient", "subject", "body", "status", "sent_at", "created_at"}}
+	for _, n := range notifications {
+		rows = append(rows, []string{
+			n.ID,
+			n.Event,
+			n.Channel,
+			csvText(n.Recipient),
+			csvText(n.Subject),
+			csvText(n.Body),
+			n.Status,
+			csvTime(n.SentAt),
+			n.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return rows
+}
+
+// csvTime formats an optional time for CSV, empty when unset
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
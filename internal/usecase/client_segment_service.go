@@ -0,0 +1,347 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrClientSegmentNotFound = errors.New("client segment not found")
+	ErrInvalidSegment        = errors.New("invalid segment definition")
+)
+
+const (
+	segmentMaxDepth      = 5
+	segmentMaxNodes      = 50
+	segmentMaxDays       = 3650
+	segmentMaxServiceIDs = 20
+	segmentPreviewSize   = 20
+)
+
+var segmentIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ClientSegmentService manages saved client segments: filter trees over
+// clients and their bookings that marketing lists are built from
+type ClientSegmentService struct {
+	segmentRepo domain.ClientSegmentRepository
+	clientRepo  domain.ClientRepository
+	serviceRepo domain.ServiceRepository
+	tagRepo     domain.ClientTagRepository
+	fieldRepo   domain.ClientFieldRepository
+}
+
+func NewClientSegmentService(segmentRepo domain.ClientSegmentRepository, clientRepo domain.ClientRepository,
+	serviceRepo domain.ServiceRepository, tagRepo domain.ClientTagRepository, fieldRepo domain.ClientFieldRepository) *ClientSegmentService {
+	return &ClientSegmentService{
+		segmentRepo: segmentRepo,
+		clientRepo:  clientRepo,
+		serviceRepo: serviceRepo,
+		tagRepo:     tagRepo,
+		fieldRepo:   fieldRepo,
+	}
+}
+
+func (s *ClientSegmentService) CreateSegment(ctx context.Context, businessID string, req dto.ClientSegmentRequest) (*dto.ClientSegmentResponse, error) {
+	definition := req.Definition
+	if err := s.checkDefinition(ctx, businessID, &definition); err != nil {
+		return nil, err
+	}
+
+	segment := &domain.ClientSegment{
+		BusinessID:  businessID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		Definition:  definition,
+	}
+	if err := s.segmentRepo.Create(ctx, segment); err != nil {
+		return nil, fmt.Errorf("failed to create client segment: %w", err)
+	}
+
+	resp := clientSegmentResponse(segment)
+	return &resp, nil
+}
+
+func (s *ClientSegmentService) ListSegments(ctx context.Context, businessID string) ([]dto.ClientSegmentResponse, error) {
+	segments, err := s.segmentRepo.ListByBusiness(ctx, businessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client segments: %w", err)
+	}
+
+	resp := make([]dto.ClientSegmentResponse, 0, len(segments))
+	for _, segment := range segments {
+		resp = append(resp, clientSegmentResponse(segment))
+	}
+	return resp, nil
+}
+
+func (s *ClientSegmentService) GetSegment(ctx context.Context, segmentID string) (*dto.ClientSegmentResponse, error) {
+	segment, err := s.segment(ctx, segmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := clientSegmentResponse(segment)
+	return &resp, nil
+}
+
+func (s *ClientSegmentService) UpdateSegment(ctx context.Context, segmentID string, req dto.ClientSegmentRequest) (*dto.ClientSegmentResponse, error) {
+	segment, err := s.segment(ctx, segmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	definition := req.Definition
+	if err := s.checkDefinition(ctx, segment.BusinessID, &definition); err != nil {
+		return nil, err
+	}
+	segment.Name = strings.TrimSpace(req.Name)
+	segment.Description = strings.TrimSpace(req.Description)
+	segment.Definition = definition
+
+	if err := s.segmentRepo.Update(ctx, segment); err != nil {
+		return nil, fmt.Errorf("failed to update client segment: %w", err)
+	}
+
+	resp := clientSegmentResponse(segment)
+	return &resp, nil
+}
+
+func (s *ClientSegmentService) DeleteSegment(ctx context.Context, segmentID string) error {
+	if err := s.segmentRepo.Delete(ctx, segmentID); err != nil {
+		return fmt.Errorf("failed to delete client segment: %w", err)
+	}
+	return nil
+}
+
+// PreviewSegment counts the clients matching a definition without saving it
+// and returns the newest of them
+func (s *ClientSegmentService) PreviewSegment(ctx context.Context, businessID string, req dto.ClientSegmentPreviewRequest) (*dto.ClientSegmentPreviewResponse, error) {
+	definition := req.Definition
+	if err := s.checkDefinition(ctx, businessID, &definition); err != nil {
+		return nil, err
+	}
+
+	clients, total, err := s.clientRepo.GetClientsByBusiness(ctx, businessID,
+		domain.ClientFilter{Segment: &definition}, 0, segmentPreviewSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get segment clients: %w", err)
+	}
+
+	resp := &dto.ClientSegmentPreviewResponse{
+		Total:   total,
+		Clients: make([]dto.ClientResponse, 0, len(clients)),
+	}
+	for _, client := range clients {
+		resp.Clients = append(resp.Clients, clientResponse(client))
+	}
+	return resp, nil
+}
+
+// GetSegmentClients returns a page of the clients of a saved segment, newest
+// first
+func (s *ClientSegmentService) GetSegmentClients(ctx context.Context, segmentID string, page, limit int) (*dto.ClientListResponse, error) {
+	segment, err := s.segment(ctx, segmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	clients, total, err := s.clientRepo.GetClientsByBusiness(ctx, segment.BusinessID,
+		domain.ClientFilter{Segment: &segment.Definition}, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get segment clients: %w", err)
+	}
+
+	clientResponses := make([]dto.ClientResponse, 0, len(clients))
+	for _, client := range clients {
+		clientResponses = append(clientResponses, clientResponse(client))
+	}
+
+	pages := total / limit
+	if total%limit > 0 {
+		pages++
+	}
+
+	return &dto.ClientListResponse{
+		Clients: clientResponses,
+		Pagination: dto.PaginationInfo{
+			Page:  page,
+			Limit: limit,
+			Total: total,
+			Pages: pages,
+		},
+	}, nil
+}
+
+// ExportSegment renders all clients of a saved segment as CSV
+func (s *ClientSegmentService) ExportSegment(ctx context.Context, segmentID string) ([]byte, error) {
+	segment, err := s.segment(ctx, segmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	filter := domain.ClientFilter{Segment: &segment.Definition}
+	if err := writeClientsCSV(ctx, s.clientRepo, segment.BusinessID, filter, &buf, nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *ClientSegmentService) segment(ctx context.Context, segmentID string) (*domain.ClientSegment, error) {
+	segment, err := s.segmentRepo.GetByID(ctx, segmentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrClientSegmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get client segment: %w", err)
+	}
+	return segment, nil
+}
+
+// checkDefinition validates a segment definition for a business and brings
+// the values in it into the form they are matched in
+func (s *ClientSegmentService) checkDefinition(ctx context.Context, businessID string, definition *domain.SegmentNode) error {
+	nodes := 0
+	return s.checkNode(ctx, businessID, definition, 1, &nodes)
+}
+
+func (s *ClientSegmentService) checkNode(ctx context.Context, businessID string, node *domain.SegmentNode, depth int, nodes *int) error {
+	*nodes++
+	if *nodes > segmentMaxNodes {
+		return fmt.Errorf("%w: more than %d nodes", ErrInvalidSegment, segmentMaxNodes)
+	}
+	if depth > segmentMaxDepth {
+		return fmt.Errorf("%w: nested deeper than %d levels", ErrInvalidSegment, segmentMaxDepth)
+	}
+
+	switch node.Op {
+	case domain.SegmentAnd, domain.SegmentOr, domain.SegmentNot:
+		if node.Op == domain.SegmentNot && len(node.Nodes) != 1 {
+			return fmt.Errorf("%w: %q needs exactly one node", ErrInvalidSegment, node.Op)
+		}
+		if len(node.Nodes) == 0 {
+			return fmt.Errorf("%w: %q needs nodes", ErrInvalidSegment, node.Op)
+		}
+		for i := range node.Nodes {
+			if err := s.checkNode(ctx, businessID, &node.Nodes[i], depth+1, nodes); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(node.Nodes) > 0 {
+		return fmt.Errorf("%w: %q takes no nodes", ErrInvalidSegment, node.Op)
+	}
+
+	switch node.Op {
+	case domain.SegmentVisits, domain.SegmentUpcoming, domain.SegmentNoShows, domain.SegmentSpent:
+		if node.Days < 0 || node.Days > segmentMaxDays {
+			return fmt.Errorf("%w: days of %q must be between 0 and %d", ErrInvalidSegment, node.Op, segmentMaxDays)
+		}
+		if (node.Min != nil && *node.Min < 0) || (node.Max != nil && *node.Max < 0) ||
+			(node.Min != nil && node.Max != nil && *node.Min > *node.Max) {
+			return fmt.Errorf("%w: invalid range of %q", ErrInvalidSegment, node.Op)
+		}
+		return s.checkServices(ctx, businessID, node)
+	case domain.SegmentCreated:
+		if node.Days < 1 || node.Days > segmentMaxDays {
+			return fmt.Errorf("%w: days of %q must be between 1 and %d", ErrInvalidSegment, node.Op, segmentMaxDays)
+		}
+	case domain.SegmentTag:
+		if !segmentIDPattern.MatchString(node.TagID) {
+			return fmt.Errorf("%w: %q needs a tag_id", ErrInvalidSegment, node.Op)
+		}
+		tag, err := s.tagRepo.GetByID(ctx, node.TagID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("failed to get client tag: %w", err)
+		}
+		if err != nil || tag.BusinessID != businessID {
+			return fmt.Errorf("%w: unknown tag %s", ErrInvalidSegment, node.TagID)
+		}
+	case domain.SegmentField:
+		field, err := s.fieldRepo.GetByKey(ctx, businessID, node.Key)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("%w: unknown field %q", ErrInvalidSegment, node.Key)
+			}
+			return fmt.Errorf("failed to get client field: %w", err)
+		}
+		value, err := canonicalFieldValue(field, node.Value)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidSegment, err.Error())
+		}
+		node.Value = value
+	case domain.SegmentSearch:
+		node.Value = strings.TrimSpace(node.Value)
+		if node.Value == "" {
+			return fmt.Errorf("%w: %q needs a value", ErrInvalidSegment, node.Op)
+		}
+	case domain.SegmentHasEmail, domain.SegmentHasPhone, domain.SegmentMarketingConsent:
+	default:
+		return fmt.Errorf("%w: unknown operator %q", ErrInvalidSegment, node.Op)
+	}
+	return nil
+}
+
+// checkServices checks that the services of a counting condition belong to
+// the business and drops repeated ones
+func (s *ClientSegmentService) checkServices(ctx context.Context, businessID string, node *domain.SegmentNode) error {
+	if len(node.ServiceIDs) > segmentMaxServiceIDs {
+		return fmt.Errorf("%w: more than %d services", ErrInvalidSegment, segmentMaxServiceIDs)
+	}
+
+	serviceIDs := make([]string, 0, len(node.ServiceIDs))
+	for _, serviceID := range node.ServiceIDs {
+		if containsString(serviceIDs, serviceID) {
+			continue
+		}
+		if !segmentIDPattern.MatchString(serviceID) {
+			return fmt.Errorf("%w: unknown service %s", ErrInvalidSegment, serviceID)
+		}
+		service, err := s.serviceRepo.GetById(ctx, serviceID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("failed to get service: %w", err)
+		}
+		if err != nil || service.BusinessID != businessID {
+			return fmt.Errorf("%w: unknown service %s", ErrInvalidSegment, serviceID)
+		}
+		serviceIDs = append(serviceIDs, serviceID)
+	}
+	node.ServiceIDs = serviceIDs
+	return nil
+}
+
+// csvText keeps spreadsheet apps from reading a text cell as a formula
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func clientSegmentResponse(segment *domain.ClientSegment) dto.ClientSegmentResponse {
+	return dto.ClientSegmentResponse{
+		ID:          segment.ID,
+		Name:        segment.Name,
+		Description: segment.Description,
+		Definition:  segment.Definition,
+		CreatedAt:   segment.CreatedAt,
+		UpdatedAt:   segment.UpdatedAt,
+	}
+}
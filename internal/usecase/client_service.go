@@ -2,23 +2,98 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/ialekseychuk/my-place/internal/domain"
 	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/pkg/phone"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrClientNotFound            = errors.New("client not found")
+	ErrClientPhoneTaken          = domain.ErrClientPhoneTaken
+	ErrClientHasUpcomingBookings = errors.New("client has upcoming bookings")
+	ErrInvalidPhone              = errors.New("invalid phone number")
+	ErrClientErased              = errors.New("client has been erased")
+	// ErrPhoneCountryCodeRequired is an ErrInvalidPhone for national numbers
+	// of businesses whose country is not set or unknown
+	ErrPhoneCountryCodeRequired = fmt.Errorf("%w: enter it in international format starting with + or set the business country", ErrInvalidPhone)
+)
+
+// clientProfilePastBookings caps the past bookings listed in a profile; the
+// stats still cover all of them
+const clientProfilePastBookings = 50
+
+// phoneBackfillPageSize is the number of clients NormalizePhones reads at once
+const phoneBackfillPageSize = 500
+
+// Autocompletion starts at clientSearchMinChars and lists clientSearchLimit
+// clients unless asked for up to clientSearchMaxLimit
+const (
+	clientSearchMinChars = 2
+	clientSearchLimit    = 10
+	clientSearchMaxLimit = 20
 )
 
 type ClientService struct {
-	clientRepo domain.ClientRepository
+	clientRepo   domain.ClientRepository
+	businessRepo domain.BusinessRepository
+	bookingRepo  domain.BookingRepository
+	serviceRepo  domain.ServiceRepository
+	staffRepo    domain.StaffRepository
+	tagRepo      domain.ClientTagRepository
+	fieldRepo    domain.ClientFieldRepository
 }
 
-func NewClientService(clientRepo domain.ClientRepository) *ClientService {
+func NewClientService(clientRepo domain.ClientRepository, businessRepo domain.BusinessRepository, bookingRepo domain.BookingRepository,
+	serviceRepo domain.ServiceRepository, staffRepo domain.StaffRepository,
+	tagRepo domain.ClientTagRepository, fieldRepo domain.ClientFieldRepository) *ClientService {
 	return &ClientService{
-		clientRepo: clientRepo,
+		clientRepo:   clientRepo,
+		businessRepo: businessRepo,
+		bookingRepo:  bookingRepo,
+		serviceRepo:  serviceRepo,
+		staffRepo:    staffRepo,
+		tagRepo:      tagRepo,
+		fieldRepo:    fieldRepo,
+	}
+}
+
+// SearchClients returns the clients of a business best matching a search
+// term as the user types it, e.g. in the booking form. A non-empty staffID
+// limits it to the clients with bookings with that staff member.
+func (s *ClientService) SearchClients(ctx context.Context, businessID, staffID, term string, limit int) ([]dto.ClientResponse, error) {
+	term = strings.TrimSpace(term)
+	if utf8.RuneCountInString(term) < clientSearchMinChars {
+		return []dto.ClientResponse{}, nil
+	}
+	if limit < 1 || limit > clientSearchMaxLimit {
+		limit = clientSearchLimit
+	}
+
+	clients, err := s.clientRepo.SearchClients(ctx, businessID, staffID, term, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search clients: %w", err)
+	}
+
+	resp := make([]dto.ClientResponse, 0, len(clients))
+	for _, client := range clients {
+		resp = append(resp, clientResponse(client))
 	}
+	return resp, nil
 }
 
-func (s *ClientService) GetClients(ctx context.Context, businessID string, page, limit int, search string) (*dto.ClientListResponse, error) {
+// GetClients returns a page of the clients of a business matching the
+// filter. Custom field values are compared in the canonical form of their
+// type, text case-insensitively. A non-empty staffID limits it to the
+// clients with bookings with that staff member.
+func (s *ClientService) GetClients(ctx context.Context, businessID, staffID string, page, limit int, listFilter dto.ClientListFilter) (*dto.ClientListResponse, error) {
 
 	if page < 1 {
 		page = 1
@@ -29,22 +104,20 @@ func (s *ClientService) GetClients(ctx context.Context, businessID string, page,
 
 	offset := (page - 1) * limit
 
-	clients, total, err := s.clientRepo.GetClientsByBusiness(ctx, businessID, offset, limit, search)
+	filter, err := clientFilter(ctx, s.fieldRepo, businessID, listFilter)
+	if err != nil {
+		return nil, err
+	}
+	filter.StaffID = staffID
+
+	clients, total, err := s.clientRepo.GetClientsByBusiness(ctx, businessID, filter, offset, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get clients: %w", err)
 	}
 
 	var clientResponses []dto.ClientResponse
 	for _, client := range clients {
-		clientResponses = append(clientResponses, dto.ClientResponse{
-			ID:        client.ID,
-			FirstName: client.FirstName,
-			LastName:  client.LastName,
-			Email:     client.Email,
-			Phone:     client.Phone,
-			CreatedAt: client.CreatedAt,
-			UpdatedAt: client.UpdatedAt,
-		})
+		clientResponses = append(clientResponses, clientResponse(client))
 	}
 
 	pages := total / limit
@@ -64,3 +137,431 @@ func (s *ClientService) GetClients(ctx context.Context, businessID string, page,
 
 	return response, nil
 }
+
+func (s *ClientService) CreateClient(ctx context.Context, businessID string, req dto.CreateClientRequest) (*dto.ClientResponse, error) {
+	clientPhone, err := s.normalizePhone(ctx, businessID, req.Phone)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkPhoneFree(ctx, businessID, clientPhone, ""); err != nil {
+		return nil, err
+	}
+
+	client := &domain.Client{
+		BusinessID: businessID,
+		FirstName:  strings.TrimSpace(req.FirstName),
+		LastName:   strings.TrimSpace(req.LastName),
+		Email:      strings.TrimSpace(req.Email),
+		Phone:      clientPhone,
+	}
+	if err := s.clientRepo.CreateClient(ctx, client); err != nil {
+		if errors.Is(err, ErrClientPhoneTaken) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	response := clientResponse(client)
+	return &response, nil
+}
+
+func (s *ClientService) GetClient(ctx context.Context, clientID string) (*dto.ClientResponse, error) {
+	client, err := s.client(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	response := clientResponse(client)
+	return &response, nil
+}
+
+func (s *ClientService) UpdateClient(ctx context.Context, clientID string, req dto.UpdateClientRequest) (*dto.ClientResponse, error) {
+	client, err := s.client(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client.ErasedAt != nil {
+		return nil, ErrClientErased
+	}
+
+	if req.FirstName != nil {
+		client.FirstName = strings.TrimSpace(*req.FirstName)
+	}
+	if req.LastName != nil {
+		client.LastName = strings.TrimSpace(*req.LastName)
+	}
+	if req.Email != nil {
+		client.Email = strings.TrimSpace(*req.Email)
+	}
+	if req.Phone != nil {
+		clientPhone, err := s.normalizePhone(ctx, client.BusinessID, *req.Phone)
+		if err != nil {
+			return nil, err
+		}
+		if clientPhone != client.Phone {
+			if err := s.checkPhoneFree(ctx, client.BusinessID, clientPhone, client.ID); err != nil {
+				return nil, err
+			}
+			client.Phone = clientPhone
+		}
+	}
+
+	if err := s.clientRepo.UpdateClient(ctx, client); err != nil {
+		if errors.Is(err, ErrClientPhoneTaken) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update client: %w", err)
+	}
+	response := clientResponse(client)
+	return &response, nil
+}
+
+// DeleteClient deletes a client together with their booking history. Clients
+// with upcoming bookings can't be deleted; cancel those first.
+func (s *ClientService) DeleteClient(ctx context.Context, clientID string) error {
+	client, err := s.client(ctx, clientID)
+	if err != nil {
+		return err
+	}
+
+	bookings, err := s.bookingRepo.ListByClient(ctx, client.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list bookings: %w", err)
+	}
+	now := time.Now()
+	for _, booking := range bookings {
+		if booking.IsConfirmed() && booking.StartAt.After(now) {
+			return ErrClientHasUpcomingBookings
+		}
+	}
+
+	if err := s.clientRepo.DeleteClient(ctx, client.ID); err != nil {
+		return fmt.Errorf("failed to delete client: %w", err)
+	}
+	return nil
+}
+
+// GetClientProfile returns a client with their visit history: upcoming and
+// past bookings, the services they used, the staff member they visited most
+// and their spending.
+func (s *ClientService) GetClientProfile(ctx context.Context, clientID string) (*dto.ClientProfileResponse, error) {
+	client, err := s.client(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	bookings, err := s.bookingRepo.ListByClient(ctx, client.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bookings: %w", err)
+	}
+
+	tags, err := s.tagRepo.ListByClient(ctx, client.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client tags: %w", err)
+	}
+	fields, err := clientFieldValues(ctx, s.fieldRepo, client)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &dto.ClientProfileResponse{
+		Client:           clientResponse(client),
+		Tags:             clientTagResponses(tags),
+		CustomFields:     fields,
+		Services:         []dto.ClientServiceSummary{},
+		UpcomingBookings: []dto.ClientBookingSummary{},
+		PastBookings:     []dto.ClientBookingSummary{},
+	}
+	services := make(map[string]*domain.Service)
+	staff := make(map[string]*domain.Staff)
+	serviceVisits := make(map[string]*dto.ClientServiceSummary)
+	staffVisits := make(map[string]int)
+	now := time.Now()
+
+	// Bookings come latest first
+	for _, booking := range bookings {
+		service, ok := services[booking.ServiceID]
+		if !ok {
+			if service, err = s.serviceRepo.GetById(ctx, booking.ServiceID); err != nil {
+				return nil, fmt.Errorf("failed to get service: %w", err)
+			}
+			services[service.ID] = service
+		}
+		staffMember, ok := staff[booking.StaffID]
+		if !ok {
+			if staffMember, err = s.staffRepo.GetById(ctx, booking.StaffID); err != nil {
+				return nil, fmt.Errorf("failed to get staff: %w", err)
+			}
+			staff[staffMember.ID] = staffMember
+		}
+
+		summary := dto.ClientBookingSummary{
+			ID:          booking.ID,
+			ServiceID:   service.ID,
+			ServiceName: service.Name,
+			StaffID:     staffMember.ID,
+			StaffName:   staffName(staffMember),
+			StartAt:     booking.StartAt,
+			EndAt:       booking.EndAt,
+			Status:      booking.Status,
+			PriceCents:  booking.PriceCents,
+		}
+
+		upcoming := booking.IsConfirmed() && booking.StartAt.After(now)
+		if upcoming {
+			profile.Stats.Upcoming++
+			profile.UpcomingBookings = append(profile.UpcomingBookings, summary)
+			continue
+		}
+		if len(profile.PastBookings) < clientProfilePastBookings {
+			profile.PastBookings = append(profile.PastBookings, summary)
+		}
+
+		switch booking.Status {
+		case domain.BookingStatusCancelled:
+			profile.Stats.Cancelled++
+		case domain.BookingStatusNoShow:
+			profile.Stats.NoShows++
+		case domain.BookingStatusConfirmed:
+			profile.Stats.Visits++
+			profile.Stats.TotalSpentCents += booking.PriceCents
+			startAt := booking.StartAt
+			if profile.Stats.LastVisitAt == nil {
+				profile.Stats.LastVisitAt = &startAt
+			}
+			profile.Stats.FirstVisitAt = &startAt
+
+			staffVisits[staffMember.ID]++
+			if visits, ok := serviceVisits[service.ID]; ok {
+				visits.Visits++
+			} else {
+				serviceVisits[service.ID] = &dto.ClientServiceSummary{
+					ServiceID:  service.ID,
+					Name:       service.Name,
+					Visits:     1,
+					LastUsedAt: booking.StartAt,
+				}
+			}
+		}
+	}
+
+	// Upcoming bookings soonest first
+	sort.Slice(profile.UpcomingBookings, func(i, j int) bool {
+		return profile.UpcomingBookings[i].StartAt.Before(profile.UpcomingBookings[j].StartAt)
+	})
+
+	for _, visits := range serviceVisits {
+		profile.Services = append(profile.Services, *visits)
+	}
+	sort.Slice(profile.Services, func(i, j int) bool {
+		a, b := profile.Services[i], profile.Services[j]
+		if a.Visits != b.Visits {
+			return a.Visits > b.Visits
+		}
+		return a.LastUsedAt.After(b.LastUsedAt)
+	})
+
+	for staffID, visits := range staffVisits {
+		favourite := profile.FavouriteStaff
+		if favourite == nil || visits > favourite.Visits || (visits == favourite.Visits && staffID < favourite.StaffID) {
+			profile.FavouriteStaff = &dto.ClientStaffSummary{
+				StaffID: staffID,
+				Name:    staffName(staff[staffID]),
+				Visits:  visits,
+			}
+		}
+	}
+
+	return profile, nil
+}
+
+// clientFilter turns the filter of a client list request into the filter of
+// the repository
+func clientFilter(ctx context.Context, fieldRepo domain.ClientFieldRepository, businessID string, listFilter dto.ClientListFilter) (domain.ClientFilter, error) {
+	filter := domain.ClientFilter{Search: strings.TrimSpace(listFilter.Search)}
+	for _, tagID := range listFilter.TagIDs {
+		if !containsString(filter.TagIDs, tagID) {
+			filter.TagIDs = append(filter.TagIDs, tagID)
+		}
+	}
+	for key, raw := range listFilter.Fields {
+		field, err := fieldRepo.GetByKey(ctx, businessID, key)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return filter, fmt.Errorf("%w: %s", ErrClientFieldNotFound, key)
+			}
+			return filter, fmt.Errorf("failed to get client field: %w", err)
+		}
+		value, err := canonicalFieldValue(field, raw)
+		if err != nil {
+			return filter, err
+		}
+		filter.Fields = append(filter.Fields, domain.ClientFieldMatch{
+			FieldID:    field.ID,
+			Value:      value,
+			IgnoreCase: field.Type == domain.ClientFieldText,
+		})
+	}
+	return filter, nil
+}
+
+func (s *ClientService) client(ctx context.Context, clientID string) (*domain.Client, error) {
+	client, err := s.clientRepo.GetClientByID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrClientNotFound
+		}
+		return nil, fmt.Errorf("failed to get client: %w", err)
+	}
+	return client, nil
+}
+
+// CheckStaffClient returns ErrClientNotFound unless the client has a booking
+// with the staff member, for users limited to the clients they serve
+func (s *ClientService) CheckStaffClient(ctx context.Context, clientID, staffID string) error {
+	ok, err := s.clientRepo.HasBookingWithStaff(ctx, clientID, staffID)
+	if err != nil {
+		return fmt.Errorf("failed to check client bookings: %w", err)
+	}
+	if !ok {
+		return ErrClientNotFound
+	}
+	return nil
+}
+
+// checkPhoneFree returns ErrClientPhoneTaken when a client of the business
+// other than exceptID has the normalised phone number, as bookings find
+// clients by it
+func (s *ClientService) checkPhoneFree(ctx context.Context, businessID, clientPhone, exceptID string) error {
+	existing, err := s.clientRepo.GetClientByPhone(ctx, businessID, clientPhone)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to check client phone: %w", err)
+	}
+	if existing.ID != exceptID {
+		return ErrClientPhoneTaken
+	}
+	return nil
+}
+
+func (s *ClientService) normalizePhone(ctx context.Context, businessID, raw string) (string, error) {
+	business, err := s.businessRepo.GetById(ctx, businessID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get business: %w", err)
+	}
+	return normalizePhone(raw, business)
+}
+
+// NormalizePhones rewrites the phone numbers of all clients in E.164.
+// Businesses without a country get defaultCountry first, unless it is empty.
+// Numbers that can't be read are left as they are; numbers several clients
+// of a business end up sharing are reported as collisions; the duplicate
+// finder scores those pairs for merging. With dryRun nothing is written.
+func (s *ClientService) NormalizePhones(ctx context.Context, defaultCountry string, dryRun bool) (*dto.PhoneBackfillReport, error) {
+	defaultCountry = strings.ToUpper(strings.TrimSpace(defaultCountry))
+	if defaultCountry != "" && !phone.IsKnownRegion(defaultCountry) {
+		return nil, fmt.Errorf("unknown country %q", defaultCountry)
+	}
+
+	businesses, err := s.businessRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list businesses: %w", err)
+	}
+
+	report := &dto.PhoneBackfillReport{
+		Invalid:    []dto.PhoneBackfillInvalid{},
+		Collisions: []dto.PhoneBackfillCollision{},
+	}
+	for _, business := range businesses {
+		if business.Country == "" && defaultCountry != "" {
+			business.Country = defaultCountry
+			if !dryRun {
+				if err := s.businessRepo.SetCountry(ctx, business.ID, defaultCountry); err != nil {
+					return nil, fmt.Errorf("failed to set business country: %w", err)
+				}
+			}
+		}
+		report.Businesses++
+
+		byPhone := make(map[string][]string)
+		for offset := 0; ; offset += phoneBackfillPageSize {
+			clients, _, err := s.clientRepo.GetClientsByBusiness(ctx, business.ID, domain.ClientFilter{}, offset, phoneBackfillPageSize)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list clients: %w", err)
+			}
+
+			for _, client := range clients {
+				report.Clients++
+				normalized, err := normalizePhone(client.Phone, business)
+				if err != nil {
+					report.Invalid = append(report.Invalid, dto.PhoneBackfillInvalid{
+						BusinessID: business.ID,
+						ClientID:   client.ID,
+						Phone:      client.Phone,
+					})
+					continue
+				}
+				byPhone[normalized] = append(byPhone[normalized], client.ID)
+
+				if normalized == client.Phone {
+					continue
+				}
+				if dryRun {
+					report.Updated++
+					continue
+				}
+				client.Phone = normalized
+				if err := s.clientRepo.UpdateClient(ctx, client); err != nil {
+					// Another client has the number already; it's reported
+					// as a collision below and stays as it is until merged
+					if errors.Is(err, ErrClientPhoneTaken) {
+						continue
+					}
+					return nil, fmt.Errorf("failed to update client: %w", err)
+				}
+				report.Updated++
+			}
+
+			if len(clients) < phoneBackfillPageSize {
+				break
+			}
+		}
+
+		for normalized, clientIDs := range byPhone {
+			if len(clientIDs) > 1 {
+				report.Collisions = append(report.Collisions, dto.PhoneBackfillCollision{
+					BusinessID: business.ID,
+					Phone:      normalized,
+					ClientIDs:  clientIDs,
+				})
+			}
+		}
+	}
+
+	sort.Slice(report.Collisions, func(i, j int) bool {
+		a, b := report.Collisions[i], report.Collisions[j]
+		if a.BusinessID != b.BusinessID {
+			return a.BusinessID < b.BusinessID
+		}
+		return a.Phone < b.Phone
+	})
+	return report, nil
+}
+
+// normalizePhone returns raw in E.164, reading numbers without a country code
+// in the country of the business
+func normalizePhone(raw string, business *domain.Business) (string, error) {
+	region := business.Country
+	if !phone.IsKnownRegion(region) {
+		region = ""
+	}
+	normalized, err := phone.Normalize(raw, region)
+	if err != nil {
+		if errors.Is(err, phone.ErrNoRegion) {
+			return "", ErrPhoneCountryCodeRequired
+		}
+		return "", ErrInvalidPhone
+	}
+	return normalized, nil
+}
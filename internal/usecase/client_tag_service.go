@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrClientTagNotFound  = errors.New("client tag not found")
+	ErrClientTagNameTaken = errors.New("a client tag with this name already exists")
+)
+
+// ClientTagService manages the tags a business defines and the tags of its
+// clients
+type ClientTagService struct {
+	tagRepo    domain.ClientTagRepository
+	clientRepo domain.ClientRepository
+	transactor domain.Transactor
+}
+
+func NewClientTagService(tagRepo domain.ClientTagRepository, clientRepo domain.ClientRepository, transactor domain.Transactor) *ClientTagService {
+	return &ClientTagService{
+		tagRepo:    tagRepo,
+		clientRepo: clientRepo,
+		transactor: transactor,
+	}
+}
+
+func (s *ClientTagService) CreateTag(ctx context.Context, businessID string, req dto.ClientTagRequest) (*dto.ClientTagResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if err := s.checkNameFree(ctx, businessID, name, ""); err != nil {
+		return nil, err
+	}
+
+	tag := &domain.ClientTag{
+		BusinessID: businessID,
+		Name:       name,
+		Color:      strings.ToLower(req.Color),
+	}
+	if err := s.tagRepo.Create(ctx, tag); err != nil {
+		return nil, fmt.Errorf("failed to create client tag: %w", err)
+	}
+
+	resp := clientTagResponse(tag)
+	return &resp, nil
+}
+
+func (s *ClientTagService) ListTags(ctx context.Context, businessID string) ([]dto.ClientTagResponse, error) {
+	tags, err := s.tagRepo.ListByBusiness(ctx, businessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client tags: %w", err)
+	}
+	return clientTagResponses(tags), nil
+}
+
+func (s *ClientTagService) UpdateTag(ctx context.Context, tagID string, req dto.ClientTagRequest) (*dto.ClientTagResponse, error) {
+	tag, err := s.tag(ctx, tagID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if err := s.checkNameFree(ctx, tag.BusinessID, name, tag.ID); err != nil {
+		return nil, err
+	}
+	tag.Name = name
+	tag.Color = strings.ToLower(req.Color)
+
+	if err := s.tagRepo.Update(ctx, tag); err != nil {
+		return nil, fmt.Errorf("failed to update client tag: %w", err)
+	}
+
+	resp := clientTagResponse(tag)
+	return &resp, nil
+}
+
+// DeleteTag deletes a tag and removes it from all clients
+func (s *ClientTagService) DeleteTag(ctx context.Context, tagID string) error {
+	if err := s.tagRepo.Delete(ctx, tagID); err != nil {
+		return fmt.Errorf("failed to delete client tag: %w", err)
+	}
+	return nil
+}
+
+func (s *ClientTagService) ListClientTags(ctx context.Context, clientID string) ([]dto.ClientTagResponse, error) {
+	tags, err := s.tagRepo.ListByClient(ctx, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client tags: %w", err)
+	}
+	return clientTagResponses(tags), nil
+}
+
+// SetClientTags replaces the tags of a client. The tags have to be defined by
+// the business of the client.
+func (s *ClientTagService) SetClientTags(ctx context.Context, clientID string, req dto.SetClientTagsRequest) ([]dto.ClientTagResponse, error) {
+	client, err := s.clientRepo.GetClientByID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrClientNotFound
+		}
+		return nil, fmt.Errorf("failed to get client: %w", err)
+	}
+	if client.ErasedAt != nil {
+		return nil, ErrClientErased
+	}
+
+	tagIDs := make([]string, 0, len(req.TagIDs))
+	for _, tagID := range req.TagIDs {
+		if containsString(tagIDs, tagID) {
+			continue
+		}
+		tag, err := s.tag(ctx, tagID)
+		if err != nil {
+			return nil, err
+		}
+		if tag.BusinessID != client.BusinessID {
+			return nil, ErrClientTagNotFound
+		}
+		tagIDs = append(tagIDs, tag.ID)
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		return s.tagRepo.SetClientTags(ctx, client.ID, tagIDs)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set client tags: %w", err)
+	}
+	return s.ListClientTags(ctx, client.ID)
+}
+
+func (s *ClientTagService) tag(ctx context.Context, tagID string) (*domain.ClientTag, error) {
+	tag, err := s.tagRepo.GetByID(ctx, tagID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrClientTagNotFound
+		}
+		return nil, fmt.Errorf("failed to get client tag: %w", err)
+	}
+	return tag, nil
+}
+
+// checkNameFree returns ErrClientTagNameTaken when a tag of the business
+// other than exceptID has the name, ignoring case
+func (s *ClientTagService) checkNameFree(ctx context.Context, businessID, name, exceptID string) error {
+	existing, err := s.tagRepo.GetByName(ctx, businessID, name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to check client tag name: %w", err)
+	}
+	if existing.ID != exceptID {
+		return ErrClientTagNameTaken
+	}
+	return nil
+}
+
+func clientTagResponse(tag *domain.ClientTag) dto.ClientTagResponse {
+	return dto.ClientTagResponse{
+		ID:        tag.ID,
+		Name:      tag.Name,
+		Color:     tag.Color,
+		CreatedAt: tag.CreatedAt,
+	}
+}
+
+func clientTagResponses(tags []*domain.ClientTag) []dto.ClientTagResponse {
+	resp := make([]dto.ClientTagResponse, 0, len(tags))
+	for _, tag := range tags {
+		resp = append(resp, clientTagResponse(tag))
+	}
+	return resp
+}
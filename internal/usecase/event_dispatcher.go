@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+)
+
+const (
+	eventBatchSize     = 100
+	eventLease         = 5 * time.Minute
+	eventMaxAttempts   = 15
+	eventRetryBase     = 10 * time.Second
+	eventRetryMax      = 6 * time.Hour
+	eventErrorMaxBytes = 500
+)
+
+// EventHandler handles a domain event. Events are delivered at least once,
+// so handlers must cope with seeing the same event again.
+type EventHandler func(ctx context.Context, event *domain.Event) error
+
+type eventSubscriber struct {
+	name    string
+	handler EventHandler
+}
+
+// EventDispatcher delivers the events of the outbox to in-process
+// subscribers. An event is retried, for all its subscribers, until every
+// subscriber handled it.
+type EventDispatcher struct {
+	eventRepo domain.EventRepository
+
+	mu          sync.RWMutex
+	subscribers map[domain.EventType][]eventSubscriber
+}
+
+func NewEventDispatcher(eventRepo domain.EventRepository) *EventDispatcher {
+	return &EventDispatcher{
+		eventRepo:   eventRepo,
+		subscribers: make(map[domain.EventType][]eventSubscriber),
+	}
+}
+
+// Subscribe registers handler for events of the given types. The name shows
+// up in the error recorded when the handler fails.
+func (d *EventDispatcher) Subscribe(name string, handler EventHandler, eventTypes ...domain.EventType) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, eventType := range eventTypes {
+		d.subscribers[eventType] = append(d.subscribers[eventType], eventSubscriber{name: name, handler: handler})
+	}
+}
+
+// DispatchDue delivers due events and returns how many were claimed
+func (d *EventDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	events, err := d.eventRepo.ClaimDue(ctx, eventBatchSize, eventLease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim events: %w", err)
+	}
+
+	var errs []error
+	for _, event := range events {
+		deliverErr := d.deliver(ctx, event)
+		if deliverErr == nil {
+			if err := d.eventRepo.MarkDelivered(ctx, event.ID); err != nil {
+				errs = append(errs, fmt.Errorf("failed to mark event delivered: %w", err))
+			}
+			continue
+		}
+
+		var retryAt *time.Time
+		if attempts := event.Attempts + 1; attempts < eventMaxAttempts {
+			next := time.Now().Add(retryDelay(attempts, eventRetryBase, eventRetryMax))
+			retryAt = &next
+		} else {
+			errs = append(errs, fmt.Errorf("giving up event %s: %w", event.ID, deliverErr))
+		}
+		if err := d.eventRepo.MarkFailed(ctx, event.ID, truncateError(deliverErr, eventErrorMaxBytes), retryAt); err != nil {
+			errs = append(errs, fmt.Errorf("failed to mark event failed: %w", err))
+		}
+	}
+	return len(events), errors.Join(errs...)
+}
+
+// Run delivers the outbox every interval until ctx is cancelled. Errors are
+// handed to onError and don't stop the dispatcher.
+func (d *EventDispatcher) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			claimed, err := d.DispatchDue(ctx)
+			if err != nil {
+				onError(err)
+			}
+			if claimed < eventBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *EventDispatcher) deliver(ctx context.Context, event *domain.Event) error {
+	d.mu.RLock()
+	subscribers := d.subscribers[event.Type]
+	d.mu.RUnlock()
+
+	var errs []error
+	for _, subscriber := range subscribers {
+		if err := subscriber.handler(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", subscriber.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// newEvent builds an event for the outbox with payload encoded as JSON
+func newEvent(eventType domain.EventType, businessID, aggregateID string, payload any) (*domain.Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	return &domain.Event{
+		Type:        eventType,
+		BusinessID:  businessID,
+		AggregateID: aggregateID,
+		Payload:     data,
+		OccurredAt:  time.Now(),
+	}, nil
+}
+
+// recordEvent appends an event to the outbox, as part of the transaction
+// in ctx
+func recordEvent(ctx context.Context, eventRepo domain.EventRepository, eventType domain.EventType, businessID, aggregateID string, payload any) error {
+	event, err := newEvent(eventType, businessID, aggregateID, payload)
+	if err != nil {
+		return err
+	}
+	if err := eventRepo.Append(ctx, event); err != nil {
+		return fmt.Errorf("failed to record %s event: %w", eventType, err)
+	}
+	return nil
+}
+
+// retryDelay is the exponential backoff after attempts failed attempts
+func retryDelay(attempts int, base, maxDelay time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+// truncateError returns the message of err cut to at most maxBytes
+func truncateError(err error, maxBytes int) string {
+	msg := err.Error()
+	if len(msg) > maxBytes {
+		msg = strings.ToValidUTF8(msg[:maxBytes], "")
+	}
+	return msg
+}
@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+)
+
+var ErrTooManyLoginAttempts = errors.New("too many failed login attempts, try again later")
+
+// LoginThrottledError is returned while an email or IP address is locked out.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return ErrTooManyLoginAttempts.Error()
+}
+
+func (e *LoginThrottledError) Is(target error) bool {
+	return target == ErrTooManyLoginAttempts
+}
+
+// loginLimit describes how failures of one key are punished: the first
+// freeAttempts failures are free, every further one locks the key for an
+// exponentially growing delay and lockoutAfter failures lock it for lockoutFor.
+type loginLimit struct {
+	freeAttempts int
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+	lockoutAfter int
+	lockoutFor   time.Duration
+}
+
+func (l loginLimit) lockFor(failures int) time.Duration {
+	if failures >= l.lockoutAfter {
+		return l.lockoutFor
+	}
+	if failures <= l.freeAttempts {
+		return 0
+	}
+
+	delay := l.baseDelay
+	for i := l.freeAttempts + 1; i < failures && delay < l.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > l.maxDelay {
+		delay = l.maxDelay
+	}
+	return delay
+}
+
+// LoginGuard throttles failed logins per email and per IP address and keeps
+// an audit log of login attempts.
+type LoginGuard struct {
+	store      domain.LoginThrottleStore
+	auditRepo  domain.LoginAuditRepository
+	emailLimit loginLimit
+	ipLimit    loginLimit
+	resetAfter time.Duration
+}
+
+func NewLoginGuard(store domain.LoginThrottleStore, auditRepo domain.LoginAuditRepository) *LoginGuard {
+	return &LoginGuard{
+		store:     store,
+		auditRepo: auditRepo,
+		emailLimit: loginLimit{
+			freeAttempts: 3,
+			baseDelay:    2 * time.Second,
+			maxDelay:     5 * time.Minute,
+			lockoutAfter: 10,
+			lockoutFor:   30 * time.Minute,
+		},
+		// Offices and mobile carriers share addresses, so IPs get more slack
+		ipLimit: loginLimit{
+			freeAttempts: 20,
+			baseDelay:    time.Second,
+			maxDelay:     5 * time.Minute,
+			lockoutAfter: 100,
+			lockoutFor:   time.Hour,
+		},
+		resetAfter: 24 * time.Hour,
+	}
+}
+
+// Check fails with a LoginThrottledError when the email or the IP address is
+// currently locked.
+func (g *LoginGuard) Check(ctx context.Context, email string, client domain.ClientInfo) error {
+	now := time.Now()
+	var retryAfter time.Duration
+	for _, key := range g.limits(email, client) {
+		throttle, err := g.store.Get(ctx, key.key)
+		if err != nil {
+			return fmt.Errorf("failed to get login throttle: %w", err)
+		}
+		if throttle != nil && throttle.LockedUntil != nil && throttle.LockedUntil.After(now) {
+			if wait := throttle.LockedUntil.Sub(now); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+
+	if retryAfter > 0 {
+		g.audit(ctx, &domain.LoginAttempt{Email: email, Reason: domain.LoginFailureLocked}, client)
+		return &LoginThrottledError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// Fail records a failed login and locks the email and IP address according
+// to their limits.
+func (g *LoginGuard) Fail(ctx context.Context, email, userID, reason string, client domain.ClientInfo) error {
+	g.audit(ctx, &domain.LoginAttempt{UserID: userID, Email: email, Reason: reason}, client)
+
+	for _, key := range g.limits(email, client) {
+		throttle, err := g.store.RecordFailure(ctx, key.key, g.resetAfter)
+		if err != nil {
+			return fmt.Errorf("failed to record login failure: %w", err)
+		}
+		if lockFor := key.limit.lockFor(throttle.Failures); lockFor > 0 {
+			if err := g.store.Lock(ctx, key.key, time.Now().Add(lockFor)); err != nil {
+				return fmt.Errorf("failed to lock login: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Succeed records a successful login and clears the failures of the email.
+// The IP counter is kept, otherwise an attacker owning one account could
+// reset it at will.
+func (g *LoginGuard) Succeed(ctx context.Context, email, userID string, client domain.ClientInfo) error {
+	g.audit(ctx, &domain.LoginAttempt{UserID: userID, Email: email, Success: true}, client)
+
+	if err := g.store.Reset(ctx, emailThrottleKey(email)); err != nil {
+		return fmt.Errorf("failed to reset login throttle: %w", err)
+	}
+	return nil
+}
+
+type throttleKey struct {
+	key   string
+	limit loginLimit
+}
+
+// limits returns the throttle keys a login attempt counts against.
+func (g *LoginGuard) limits(email string, client domain.ClientInfo) []throttleKey {
+	keys := []throttleKey{{key: emailThrottleKey(email), limit: g.emailLimit}}
+	if client.IPAddress != "" {
+		keys = append(keys, throttleKey{key: "ip:" + client.IPAddress, limit: g.ipLimit})
+	}
+	return keys
+}
+
+func emailThrottleKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// audit writes the attempt to the audit log. A failing audit write must not
+// lock users out, so errors are dropped.
+func (g *LoginGuard) audit(ctx context.Context, attempt *domain.LoginAttempt, client domain.ClientInfo) {
+	attempt.IPAddress = client.IPAddress
+	attempt.UserAgent = client.UserAgent
+	_ = g.auditRepo.Record(ctx, attempt)
+}
@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/pkg/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMFANotStarted     = errors.New("two-factor authentication setup was not started")
+	ErrInvalidMFACode    = errors.New("invalid authentication code")
+)
+
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789" // no look-alike characters
+	// totpSkew accepts codes of the neighbouring time steps to allow for
+	// clock drift between server and phone
+	totpSkew = 1
+)
+
+// MFAService manages TOTP enrollment and recovery codes and verifies second
+// factor codes.
+type MFAService struct {
+	mfaRepo  domain.MFARepository
+	userRepo domain.UserRepository
+	issuer   string
+}
+
+func NewMFAService(mfaRepo domain.MFARepository, userRepo domain.UserRepository, issuer string) *MFAService {
+	return &MFAService{
+		mfaRepo:  mfaRepo,
+		userRepo: userRepo,
+		issuer:   issuer,
+	}
+}
+
+func (s *MFAService) GetStatus(ctx context.Context, userID string) (*dto.MFAStatusResponse, error) {
+	mfa, err := s.mfaRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mfa settings: %w", err)
+	}
+	if !mfa.IsEnabled() {
+		return &dto.MFAStatusResponse{Enabled: false}, nil
+	}
+
+	left, err := s.mfaRepo.CountUnusedRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return &dto.MFAStatusResponse{
+		Enabled:           true,
+		EnabledAt:         mfa.EnabledAt,
+		RecoveryCodesLeft: left,
+	}, nil
+}
+
+// SetupTOTP generates a new secret for the user. It only becomes active after
+// ConfirmTOTP verified a code generated from it.
+func (s *MFAService) SetupTOTP(ctx context.Context, user *domain.User) (*dto.TOTPSetupResponse, error) {
+	mfa, err := s.mfaRepo.Get(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mfa settings: %w", err)
+	}
+	if mfa.IsEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaRepo.SaveSecret(ctx, user.ID, secret); err != nil {
+		return nil, fmt.Errorf("failed to save mfa secret: %w", err)
+	}
+
+	return &dto.TOTPSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication and returns the recovery
+// codes. They are shown only once.
+func (s *MFAService) ConfirmTOTP(ctx context.Context, userID string, req dto.ConfirmTOTPRequest) (*dto.RecoveryCodesResponse, error) {
+	mfa, err := s.mfaRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mfa settings: %w", err)
+	}
+	if mfa == nil {
+		return nil, ErrMFANotStarted
+	}
+	if mfa.IsEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, ok := totp.Validate(mfa.TOTPSecret, req.Code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaRepo.Enable(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to enable mfa: %w", err)
+	}
+	if err := s.mfaRepo.UseStep(ctx, userID, step); err != nil {
+		return nil, fmt.Errorf("failed to record used code: %w", err)
+	}
+
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP turns two-factor authentication off. It requires the password
+// and a valid code so a stolen session alone can't remove the second factor.
+func (s *MFAService) DisableTOTP(ctx context.Context, userID string, req dto.DisableTOTPRequest) error {
+	user, err := s.userRepo.GetById(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
+		return ErrInvalidCurrentPassword
+	}
+
+	if err := s.Verify(ctx, userID, req.Code); err != nil {
+		return err
+	}
+
+	if err := s.mfaRepo.Disable(ctx, userID); err != nil {
+		return fmt.Errorf("failed to disable mfa: %w", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID string, req dto.RegenerateRecoveryCodesRequest) (*dto.RecoveryCodesResponse, error) {
+	if err := s.Verify(ctx, userID, req.Code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// IsEnabled reports whether the user has to pass a second factor on login.
+func (s *MFAService) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	mfa, err := s.mfaRepo.Get(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get mfa settings: %w", err)
+	}
+	return mfa.IsEnabled(), nil
+}
+
+// Verify checks a TOTP code or, failing that, a recovery code. Both are
+// single use.
+func (s *MFAService) Verify(ctx context.Context, userID, code string) error {
+	mfa, err := s.mfaRepo.Get(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get mfa settings: %w", err)
+	}
+	if !mfa.IsEnabled() {
+		return ErrMFANotEnabled
+	}
+
+	if step, ok := totp.Validate(mfa.TOTPSecret, code, time.Now(), totpSkew); ok {
+		// Rejects a code that was already used within its validity window
+		if err := s.mfaRepo.UseStep(ctx, userID, step); err != nil {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	if err := s.mfaRepo.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code))); err != nil {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// generateRecoveryCodes returns the plain codes formatted as xxxxx-xxxxx and
+// their hashes for storage.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		// Drawn per character, so every one is equally likely
+		raw := make([]byte, 10)
+		for j := range raw {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryCodeAlphabet))))
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+			}
+			raw[j] = recoveryCodeAlphabet[n.Int64()]
+		}
+		code := string(raw)
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashToken(code)
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
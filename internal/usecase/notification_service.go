@@ -0,0 +1,387 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/pkg/mailer"
+	"github.com/ialekseychuk/my-place/pkg/sms"
+)
+
+const (
+	notificationBatchSize     = 50
+	notificationLease         = 5 * time.Minute
+	notificationMaxAttempts   = 8
+	notificationRetryBase     = time.Minute
+	notificationRetryMax      = 6 * time.Hour
+	reminderScanInterval      = 5 * time.Minute
+	notificationTimeFormat    = "Mon, 2 Jan 2006 at 15:04"
+	notificationDateFormat    = "Mon, 2 Jan 2006"
+	notificationErrorMaxBytes = 500
+)
+
+// bookingReminders are sent the given time before a booking starts. A
+// reminder is only due until the next one takes over, and bookings made
+// after a reminder would have been sent skip it, so a booking made three
+// hours ahead only gets the 2 hour reminder.
+var bookingReminders = []struct {
+	event  domain.NotificationEvent
+	before time.Duration
+	until  time.Duration
+}{
+	{domain.NotificationBookingReminder24h, 24 * time.Hour, 2 * time.Hour},
+	{domain.NotificationBookingReminder2h, 2 * time.Hour, 0},
+}
+
+// NotificationService renders notifications into the outbox and delivers
+// them from there, so sends survive restarts and failed ones are retried.
+type NotificationService struct {
+	notificationRepo domain.NotificationRepository
+	businessRepo     domain.BusinessRepository
+	bookingRepo      domain.BookingRepository
+	serviceRepo      domain.ServiceRepository
+	staffRepo        domain.StaffRepository
+	clientRepo       domain.ClientRepository
+	locationRepo     domain.LocationRepository
+	userRepo         domain.UserRepository
+	mailSender       mailer.Sender
+	smsSender        sms.Sender
+}
+
+func NewNotificationService(
+	notificationRepo domain.NotificationRepository,
+	businessRepo domain.BusinessRepository,
+	bookingRepo domain.BookingRepository,
+	serviceRepo domain.ServiceRepository,
+	staffRepo domain.StaffRepository,
+	clientRepo domain.ClientRepository,
+	locationRepo domain.LocationRepository,
+	userRepo domain.UserRepository,
+	mailSender mailer.Sender,
+	smsSender sms.Sender,
+) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		businessRepo:     businessRepo,
+		bookingRepo:      bookingRepo,
+		serviceRepo:      serviceRepo,
+		staffRepo:        staffRepo,
+		clientRepo:       clientRepo,
+		locationRepo:     locationRepo,
+		userRepo:         userRepo,
+		mailSender:       mailSender,
+		smsSender:        smsSender,
+	}
+}
+
+// notificationRecipient is who a notification is rendered for. Channels
+// without an address are skipped.
+type notificationRecipient struct {
+	clientID string // set when notifying a client
+	email    string
+	phone    string
+}
+
+// Subscribe registers the service for the domain events it notifies about
+func (s *NotificationService) Subscribe(dispatcher *EventDispatcher) {
+	dispatcher.Subscribe("notifications", s.HandleEvent,
+		domain.EventBookingCreated, domain.EventBookingRescheduled, domain.EventBookingCancelled, domain.EventTimeOffApproved)
+}
+
+// HandleEvent queues the notifications of a domain event. Handling an event
+// twice queues nothing new, as notifications are deduplicated.
+func (s *NotificationService) HandleEvent(ctx context.Context, event *domain.Event) error {
+	switch event.Type {
+	case domain.EventBookingCreated, domain.EventBookingRescheduled, domain.EventBookingCancelled:
+		var payload domain.BookingEventPayload
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+		}
+		booking := payload.Booking()
+		if err := s.failStaleReminders(ctx, event.Type, booking); err != nil {
+			return err
+		}
+		return s.notifyBooking(ctx, bookingNotificationEvents[event.Type], booking, payload.PreviousStartAt)
+	case domain.EventTimeOffApproved:
+		var payload domain.TimeOffEventPayload
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+		}
+		return s.notifyTimeOffApproved(ctx, &domain.TimeOffRequest{
+			ID:        payload.RequestID,
+			StaffID:   payload.StaffID,
+			StartDate: payload.StartDate,
+			EndDate:   payload.EndDate,
+		})
+	}
+	return nil
+}
+
+var bookingNotificationEvents = map[domain.EventType]domain.NotificationEvent{
+	domain.EventBookingCreated:     domain.NotificationBookingCreated,
+	domain.EventBookingRescheduled: domain.NotificationBookingRescheduled,
+	domain.EventBookingCancelled:   domain.NotificationBookingCancelled,
+}
+
+// failStaleReminders gives up the pending reminders of a cancelled booking,
+// and those of a rescheduled booking for its previous start
+func (s *NotificationService) failStaleReminders(ctx context.Context, eventType domain.EventType, booking *domain.Booking) error {
+	var err error
+	switch eventType {
+	case domain.EventBookingCancelled:
+		err = s.notificationRepo.FailBookingReminders(ctx, booking.ID, nil, "booking cancelled")
+	case domain.EventBookingRescheduled:
+		err = s.notificationRepo.FailBookingReminders(ctx, booking.ID, &booking.StartAt, "booking rescheduled")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to give up reminders: %w", err)
+	}
+	return nil
+}
+
+// notifyTimeOffApproved tells a staff member their time off was approved,
+// by text message to the staff phone and by email to the linked user.
+func (s *NotificationService) notifyTimeOffApproved(ctx context.Context, request *domain.TimeOffRequest) error {
+	staff, err := s.staffRepo.GetById(ctx, request.StaffID)
+	if err != nil {
+		return fmt.Errorf("failed to get staff: %w", err)
+	}
+	business, err := s.businessRepo.GetById(ctx, staff.BusinessID)
+	if err != nil {
+		return fmt.Errorf("failed to get business: %w", err)
+	}
+	if !business.EnableEmailNotifications && !business.EnableSMSNotifications {
+		return nil
+	}
+
+	recipient := notificationRecipient{phone: staff.Phone}
+	users, err := s.userRepo.GetByBusinessID(ctx, business.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get users: %w", err)
+	}
+	for _, user := range users {
+		if user.StaffID == staff.ID && user.IsActive {
+			recipient.email = user.Email
+			break
+		}
+	}
+
+	rendered, err := renderNotification(domain.NotificationTimeOffApproved, notificationData{
+		BusinessName:  business.Name,
+		BusinessPhone: business.Phone,
+		RecipientName: staff.FirstName,
+		TimeOffStart:  request.StartDate.Format(notificationDateFormat),
+		TimeOffEnd:    request.EndDate.Format(notificationDateFormat),
+	})
+	if err != nil {
+		return err
+	}
+
+	dedupeKey := fmt.Sprintf("%s:%s", domain.NotificationTimeOffApproved, request.ID)
+	return s.enqueue(ctx, business, &domain.Notification{Event: domain.NotificationTimeOffApproved}, recipient, rendered, dedupeKey)
+}
+
+// EnqueueDueReminders queues the reminders of confirmed bookings whose
+// reminder time has come. Reminders already queued are skipped.
+func (s *NotificationService) EnqueueDueReminders(ctx context.Context, now time.Time) error {
+	var errs []error
+	for _, reminder := range bookingReminders {
+		bookings, err := s.bookingRepo.ListStartingBetween(ctx, now.Add(reminder.until), now.Add(reminder.before))
+		if err != nil {
+			return fmt.Errorf("failed to list upcoming bookings: %w", err)
+		}
+
+		for _, booking := range bookings {
+			if booking.CreatedAt.After(booking.StartAt.Add(-reminder.before)) {
+				continue
+			}
+			if err := s.notifyBooking(ctx, reminder.event, booking, nil); err != nil {
+				errs = append(errs, fmt.Errorf("booking %s: %w", booking.ID, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SendDue delivers due notifications from the outbox and returns how many
+// were claimed. Failed sends are retried with exponential backoff until
+// notificationMaxAttempts is reached.
+func (s *NotificationService) SendDue(ctx context.Context) (int, error) {
+	notifications, err := s.notificationRepo.ClaimDue(ctx, notificationBatchSize, notificationLease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim notifications: %w", err)
+	}
+
+	var errs []error
+	for _, n := range notifications {
+		sendErr := s.deliver(ctx, n)
+		if sendErr == nil {
+			if err := s.notificationRepo.MarkSent(ctx, n.ID); err != nil {
+				errs = append(errs, fmt.Errorf("failed to mark notification sent: %w", err))
+			}
+			continue
+		}
+
+		var retryAt *time.Time
+		if attempts := n.Attempts + 1; attempts < notificationMaxAttempts {
+			next := time.Now().Add(retryDelay(attempts, notificationRetryBase, notificationRetryMax))
+			retryAt = &next
+		}
+		if err := s.notificationRepo.MarkFailed(ctx, n.ID, truncateError(sendErr, notificationErrorMaxBytes), retryAt); err != nil {
+			errs = append(errs, fmt.Errorf("failed to mark notification failed: %w", err))
+		}
+	}
+	return len(notifications), errors.Join(errs...)
+}
+
+// RunWorker queues reminders and delivers the outbox every interval until
+// ctx is cancelled. Errors are handed to onError and don't stop the worker.
+func (s *NotificationService) RunWorker(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastReminderScan time.Time
+	for {
+		if now := time.Now(); now.Sub(lastReminderScan) >= reminderScanInterval {
+			if err := s.EnqueueDueReminders(ctx, now); err != nil {
+				onError(fmt.Errorf("failed to enqueue reminders: %w", err))
+			}
+			lastReminderScan = now
+		}
+
+		for ctx.Err() == nil {
+			claimed, err := s.SendDue(ctx)
+			if err != nil {
+				onError(err)
+			}
+			if claimed < notificationBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *NotificationService) notifyBooking(ctx context.Context, event domain.NotificationEvent, booking *domain.Booking, previousStartAt *time.Time) error {
+	service, err := s.serviceRepo.GetById(ctx, booking.ServiceID)
+	if err != nil {
+		return fmt.Errorf("failed to get service: %w", err)
+	}
+	business, err := s.businessRepo.GetById(ctx, service.BusinessID)
+	if err != nil {
+		return fmt.Errorf("failed to get business: %w", err)
+	}
+	if !business.EnableEmailNotifications && !business.EnableSMSNotifications {
+		return nil
+	}
+
+	client, err := s.clientRepo.GetClientByID(ctx, booking.ClientID)
+	if err != nil {
+		return fmt.Errorf("failed to get client: %w", err)
+	}
+	if !client.NotificationConsent || client.ErasedAt != nil {
+		return nil
+	}
+	staff, err := s.staffRepo.GetById(ctx, booking.StaffID)
+	if err != nil {
+		return fmt.Errorf("failed to get staff: %w", err)
+	}
+
+	loc := businessLocation(business)
+	data := notificationData{
+		BusinessName:  business.Name,
+		BusinessPhone: business.Phone,
+		RecipientName: client.FirstName,
+		ServiceName:   service.Name,
+		StaffName:     strings.TrimSpace(staff.FirstName + " " + staff.LastName),
+		StartAt:       booking.StartAt.In(loc).Format(notificationTimeFormat),
+	}
+	if previousStartAt != nil {
+		data.PreviousStartAt = previousStartAt.In(loc).Format(notificationTimeFormat)
+	}
+	if booking.LocationID != "" {
+		if location, err := s.locationRepo.GetByID(ctx, booking.LocationID); err == nil {
+			data.LocationName = location.Name
+			data.LocationAddress = location.Address
+		}
+	}
+
+	rendered, err := renderNotification(event, data)
+	if err != nil {
+		return err
+	}
+
+	// The start time is part of the key so a rescheduled booking is
+	// reminded again at its new time
+	dedupeKey := fmt.Sprintf("%s:%s:%d", event, booking.ID, booking.StartAt.Unix())
+	recipient := notificationRecipient{clientID: client.ID, email: client.Email, phone: client.Phone}
+	template := &domain.Notification{Event: event, BookingID: booking.ID}
+	// Reminders are pointless once the booking started
+	if event == domain.NotificationBookingReminder24h || event == domain.NotificationBookingReminder2h {
+		startAt := booking.StartAt
+		template.ExpiresAt = &startAt
+	}
+	return s.enqueue(ctx, business, template, recipient, rendered, dedupeKey)
+}
+
+// enqueue stores a rendered notification for every channel the business
+// enabled and the recipient has an address for. The event, booking and
+// expiry are taken from template.
+func (s *NotificationService) enqueue(ctx context.Context, business *domain.Business, template *domain.Notification, recipient notificationRecipient, rendered *renderedNotification, dedupeKey string) error {
+	var notifications []*domain.Notification
+	if business.EnableEmailNotifications && recipient.email != "" {
+		notifications = append(notifications, &domain.Notification{
+			BusinessID: business.ID,
+			ClientID:   recipient.clientID,
+			BookingID:  template.BookingID,
+			Event:      template.Event,
+			Channel:    domain.NotificationChannelEmail,
+			Recipient:  recipient.email,
+			Subject:    rendered.subject,
+			Body:       rendered.email,
+			DedupeKey:  dedupeKey + ":" + domain.NotificationChannelEmail,
+			ExpiresAt:  template.ExpiresAt,
+		})
+	}
+	if business.EnableSMSNotifications && recipient.phone != "" {
+		notifications = append(notifications, &domain.Notification{
+			BusinessID: business.ID,
+			ClientID:   recipient.clientID,
+			BookingID:  template.BookingID,
+			Event:      template.Event,
+			Channel:    domain.NotificationChannelSMS,
+			Recipient:  recipient.phone,
+			Body:       rendered.sms,
+			DedupeKey:  dedupeKey + ":" + domain.NotificationChannelSMS,
+			ExpiresAt:  template.ExpiresAt,
+		})
+	}
+
+	for _, n := range notifications {
+		if err := s.notificationRepo.Enqueue(ctx, n); err != nil {
+			return fmt.Errorf("failed to enqueue notification: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *NotificationService) deliver(ctx context.Context, n *domain.Notification) error {
+	switch n.Channel {
+	case domain.NotificationChannelEmail:
+		return s.mailSender.Send(ctx, mailer.Message{To: n.Recipient, Subject: n.Subject, Body: n.Body})
+	case domain.NotificationChannelSMS:
+		return s.smsSender.Send(ctx, sms.Message{To: n.Recipient, Body: n.Body})
+	default:
+		return fmt.Errorf("unknown notification channel %q", n.Channel)
+	}
+}
@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+)
+
+// notificationData is what the notification templates are rendered with.
+// Times are already formatted in the timezone of the business.
+type notificationData struct {
+	BusinessName    string
+	BusinessPhone   string
+	RecipientName   string
+	ServiceName     string
+	StaffName       string
+	LocationName    string
+	LocationAddress string
+	StartAt         string
+	PreviousStartAt string
+	TimeOffStart    string
+	TimeOffEnd      string
+}
+
+type notificationTemplate struct {
+	subject *template.Template
+	email   *template.Template
+	sms     *template.Template
+}
+
+// renderedNotification is a template rendered for one recipient
+type renderedNotification struct {
+	subject string
+	email   string
+	sms     string
+}
+
+const bookingDetailsTemplate = `{{.ServiceName}} with {{.StaffName}}
+{{.StartAt}}{{if .LocationAddress}}
+{{.LocationName}}, {{.LocationAddress}}{{end}}`
+
+const emailSignatureTemplate = `
+
+Kind regards,
+{{.BusinessName}}{{if .BusinessPhone}}
+{{.BusinessPhone}}{{end}}
+`
+
+var notificationTemplates = map[domain.NotificationEvent]notificationTemplate{
+	domain.NotificationBookingCreated: newNotificationTemplate(
+		`Your booking at {{.BusinessName}} is confirmed`,
+		`Hello{{with .RecipientName}} {{.}}{{end}},
+
+your booking is confirmed:
+
+`+bookingDetailsTemplate+emailSignatureTemplate,
+		`{{.BusinessName}}: your booking for {{.ServiceName}} on {{.StartAt}} is confirmed.`,
+	),
+	domain.NotificationBookingRescheduled: newNotificationTemplate(
+		`Your booking at {{.BusinessName}} was moved`,
+		`Hello{{with .RecipientName}} {{.}}{{end}},
+
+your booking on {{.PreviousStartAt}} was moved. The new appointment is:
+
+`+bookingDetailsTemplate+emailSignatureTemplate,
+		`{{.BusinessName}}: your booking for {{.ServiceName}} was moved from {{.PreviousStartAt}} to {{.StartAt}}.`,
+	),
+	domain.NotificationBookingCancelled: newNotificationTemplate(
+		`Your booking at {{.BusinessName}} was cancelled`,
+		`Hello{{with .RecipientName}} {{.}}{{end}},
+
+the following booking was cancelled:
+
+`+bookingDetailsTemplate+emailSignatureTemplate,
+		`{{.BusinessName}}: your booking for {{.ServiceName}} on {{.StartAt}} was cancelled.`,
+	),
+	domain.NotificationBookingReminder24h: newNotificationTemplate(
+		`Reminder: your booking at {{.BusinessName}} tomorrow`,
+		`Hello{{with .RecipientName}} {{.}}{{end}},
+
+this is a reminder of your upcoming booking:
+
+`+bookingDetailsTemplate+emailSignatureTemplate,
+		`{{.BusinessName}}: reminder of your booking for {{.ServiceName}} on {{.StartAt}}.`,
+	),
+	domain.NotificationBookingReminder2h: newNotificationTemplate(
+		`Reminder: your booking at {{.BusinessName}} is soon`,
+		`Hello{{with .RecipientName}} {{.}}{{end}},
+
+your booking starts soon:
+
+`+bookingDetailsTemplate+emailSignatureTemplate,
+		`{{.BusinessName}}: see you at {{.StartAt}} for {{.ServiceName}}.`,
+	),
+	domain.NotificationTimeOffApproved: newNotificationTemplate(
+		`Your time off was approved`,
+		`Hello{{with .RecipientName}} {{.}}{{end}},
+
+your time off from {{.TimeOffStart}} to {{.TimeOffEnd}} was approved.`+emailSignatureTemplate,
+		`{{.BusinessName}}: your time off from {{.TimeOffStart}} to {{.TimeOffEnd}} was approved.`,
+	),
+}
+
+func newNotificationTemplate(subject, email, sms string) notificationTemplate {
+	return notificationTemplate{
+		subject: template.Must(template.New("subject").Parse(subject)),
+		email:   template.Must(template.New("email").Parse(email)),
+		sms:     template.Must(template.New("sms").Parse(sms)),
+	}
+}
+
+func renderNotification(event domain.NotificationEvent, data notificationData) (*renderedNotification, error) {
+	tmpl, ok := notificationTemplates[event]
+	if !ok {
+		return nil, fmt.Errorf("no template for notification event %q", event)
+	}
+
+	var rendered renderedNotification
+	for _, part := range []struct {
+		tmpl *template.Template
+		out  *string
+	}{
+		{tmpl.subject, &rendered.subject},
+		{tmpl.email, &rendered.email},
+		{tmpl.sms, &rendered.sms},
+	} {
+		var b strings.Builder
+		if err := part.tmpl.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("failed to render %s notification: %w", event, err)
+		}
+		*part.out = b.String()
+	}
+	return &rendered, nil
+}
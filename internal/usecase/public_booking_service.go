@@ -0,0 +1,580 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/pkg/signedtoken"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrBusinessNotFound        = errors.New("business not found")
+	ErrOnlineBookingDisabled   = errors.New("online booking is disabled for this business")
+	ErrServiceNotBookable      = errors.New("service is not available for online booking")
+	ErrStaffNotBookable        = errors.New("staff member doesn't provide this service")
+	ErrBookingInPast           = errors.New("booking must start in the future")
+	ErrInvalidManageToken      = errors.New("booking link is invalid or expired")
+	ErrBookingAlreadyCancelled = errors.New("booking is already cancelled")
+	ErrBookingAlreadyStarted   = errors.New("booking has already started")
+	ErrCancellationWindowOver  = errors.New("booking can no longer be cancelled online, please contact the business")
+)
+
+const manageTokenPurpose = "booking_manage"
+
+// PublicBookingService backs the unauthenticated booking pages of businesses
+// with online booking enabled. Clients manage their bookings with a signed
+// link instead of an account.
+type PublicBookingService struct {
+	businessRepo     domain.BusinessRepository
+	serviceRepo      domain.ServiceRepository
+	categoryRepo     domain.ServiceCategoryRepository
+	staffRepo        domain.StaffRepository
+	staffServiceRepo domain.StaffServiceRepository
+	bookingRepo      domain.BookingRepository
+	locationRepo     domain.LocationRepository
+	bookingService   *BookingService
+	linkSigner       *signedtoken.Signer
+	appURL           string
+
+	// manage links stay valid this long after the appointment ended
+	manageLinkTTL time.Duration
+}
+
+func NewPublicBookingService(
+	businessRepo domain.BusinessRepository,
+	serviceRepo domain.ServiceRepository,
+	categoryRepo domain.ServiceCategoryRepository,
+	staffRepo domain.StaffRepository,
+	staffServiceRepo domain.StaffServiceRepository,
+	bookingRepo domain.BookingRepository,
+	locationRepo domain.LocationRepository,
+	bookingService *BookingService,
+	linkSigner *signedtoken.Signer,
+	appURL string,
+) *PublicBookingService {
+	return &PublicBookingService{
+		businessRepo:     businessRepo,
+		serviceRepo:      serviceRepo,
+		categoryRepo:     categoryRepo,
+		staffRepo:        staffRepo,
+		staffServiceRepo: staffServiceRepo,
+		bookingRepo:      bookingRepo,
+		locationRepo:     locationRepo,
+		bookingService:   bookingService,
+		linkSigner:       linkSigner,
+		appURL:           strings.TrimRight(appURL, "/"),
+		manageLinkTTL:    30 * 24 * time.Hour,
+	}
+}
+
+// GetBusiness returns the public profile of a business
+func (s *PublicBookingService) GetBusiness(ctx context.Context, slug string) (*dto.PublicBusinessResponse, error) {
+	business, err := s.bookableBusiness(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PublicBusinessResponse{
+		Name:         business.Name,
+		Slug:         business.Slug,
+		BusinessType: business.BusinessType,
+		Description:  business.Description,
+		Address:      business.Address,
+		City:         business.City,
+		Phone:        business.Phone,
+		Email:        business.Email,
+		Website:      business.Website,
+		Timezone:     business.Timezone,
+		Currency:     business.Currency,
+	}, nil
+}
+
+// ListServices returns the active services shown online that at least one
+// active staff member provides
+func (s *PublicBookingService) ListServices(ctx context.Context, slug string) ([]*dto.PublicServiceResponse, error) {
+	business, err := s.bookableBusiness(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := s.serviceRepo.ListByBusinessId(ctx, business.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	staffByService, _, err := s.assignments(ctx, business.ID)
+	if err != nil {
+		return nil, err
+	}
+	return menuServices(services, staffByService), nil
+}
+
+// ListServiceGroups returns the services of ListServices grouped by
+// category, for the booking menu
+func (s *PublicBookingService) ListServiceGroups(ctx context.Context, slug string) ([]*dto.PublicServiceGroupResponse, error) {
+	business, err := s.bookableBusiness(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.categoryRepo.ListByBusiness(ctx, business.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service categories: %w", err)
+	}
+	services, err := s.serviceRepo.ListByBusinessId(ctx, business.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	staffByService, _, err := s.assignments(ctx, business.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*dto.PublicServiceGroupResponse{}
+	for _, group := range groupServices(categories, services) {
+		offered := menuServices(group.Services, staffByService)
+		if len(offered) == 0 {
+			continue
+		}
+		resp := &dto.PublicServiceGroupResponse{Services: offered}
+		if group.Category != nil {
+			resp.Category = &dto.PublicServiceCategoryResponse{ID: group.Category.ID, Name: group.Category.Name}
+		}
+		result = append(result, resp)
+	}
+	return result, nil
+}
+
+// menuServices picks the services offered online out of services
+func menuServices(services []domain.Service, staffByService map[string][]*domain.StaffService) []*dto.PublicServiceResponse {
+	result := []*dto.PublicServiceResponse{}
+	for i := range services {
+		service := &services[i]
+		providers := staffByService[service.ID]
+		if len(providers) == 0 || !service.Active || !service.VisibleOnline {
+			continue
+		}
+		staffIDs := make([]string, 0, len(providers))
+		staff := make([]dto.PublicServiceStaffResponse, 0, len(providers))
+		for _, link := range providers {
+			staffIDs = append(staffIDs, link.StaffID)
+			staff = append(staff, dto.PublicServiceStaffResponse{
+				StaffID:     link.StaffID,
+				DurationMin: int(link.EffectiveDuration(service) / time.Minute),
+				PriceCents:  link.EffectivePriceCents(service),
+			})
+		}
+		result = append(result, &dto.PublicServiceResponse{
+			ID:          service.ID,
+			CategoryID:  service.CategoryID,
+			Name:        service.Name,
+			Description: service.Description,
+			ImageURL:    service.ImageURL,
+			DurationMin: service.DurationMin,
+			PriceCents:  service.PriceCents,
+			LocationID:  service.LocationID,
+			StaffIDs:    staffIDs,
+			Staff:       staff,
+		})
+	}
+
+	return result
+}
+
+// ListStaff returns the active staff members providing at least one service
+// offered online, limited to the providers of serviceID when it is not empty
+func (s *PublicBookingService) ListStaff(ctx context.Context, slug, serviceID string) ([]*dto.PublicStaffResponse, error) {
+	business, err := s.bookableBusiness(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	staff, err := s.staffRepo.ListByBusinessId(ctx, business.ID, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staff: %w", err)
+	}
+	_, servicesByStaff, err := s.assignments(ctx, business.ID)
+	if err != nil {
+		return nil, err
+	}
+	services, err := s.serviceRepo.ListByBusinessId(ctx, business.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	offered := make(map[string]bool, len(services))
+	for _, service := range services {
+		offered[service.ID] = service.Active && service.VisibleOnline
+	}
+
+	result := []*dto.PublicStaffResponse{}
+	for _, member := range staff {
+		var serviceIDs []string
+		for _, id := range servicesByStaff[member.ID] {
+			if offered[id] {
+				serviceIDs = append(serviceIDs, id)
+			}
+		}
+		if len(serviceIDs) == 0 || (serviceID != "" && !containsString(serviceIDs, serviceID)) {
+			continue
+		}
+		result = append(result, &dto.PublicStaffResponse{
+			ID:             member.ID,
+			FirstName:      member.FirstName,
+			LastName:       member.LastName,
+			Position:       member.Position,
+			Specialization: member.Specialization,
+			Description:    member.Description,
+			ServiceIDs:     serviceIDs,
+		})
+	}
+
+	return result, nil
+}
+
+// GetAvailability returns the start times on the calendar date of day, in the
+// business timezone, at which the service can be booked, per staff member.
+// staffID limits the search to one staff member.
+func (s *PublicBookingService) GetAvailability(ctx context.Context, slug, serviceID, staffID string, day time.Time) ([]*dto.SlotResponse, error) {
+	business, err := s.bookableBusiness(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := s.bookableService(ctx, business, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, businessLocation(business))
+	return s.availableSlots(ctx, business, service, staffID, day)
+}
+
+// CreateBooking books a free slot for a client and returns the booking
+// together with its manage link
+func (s *PublicBookingService) CreateBooking(ctx context.Context, slug string, req dto.PublicCreateBookingRequest) (*dto.PublicBookingResponse, error) {
+	business, err := s.bookableBusiness(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := s.bookableService(ctx, business, req.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.StartAt.After(time.Now()) {
+		return nil, ErrBookingInPast
+	}
+
+	// Only the offered slots can be booked, not arbitrary times
+	local := req.StartAt.In(businessLocation(business))
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	slots, err := s.availableSlots(ctx, business, service, req.StaffID, day)
+	if err != nil {
+		return nil, err
+	}
+	offered := false
+	for _, slot := range slots {
+		if slot.Start.Equal(req.StartAt) {
+			offered = true
+			break
+		}
+	}
+	if !offered {
+		return nil, ErrTimeSlotUnavailable
+	}
+
+	booking, err := s.bookingService.CreateBooking(ctx, business.ID, &dto.CreateBookingRequest{
+		ServiceID:     service.ID,
+		StaffID:       req.StaffID,
+		StartAt:       req.StartAt,
+		CustomerPhone: req.CustomerPhone,
+		CustomerName:  req.CustomerName,
+		CustomerEmail: req.CustomerEmail,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.bookingResponse(ctx, business, booking, service)
+	if err != nil {
+		return nil, err
+	}
+	resp.ManageToken = s.linkSigner.Sign(manageTokenPurpose, booking.ID, booking.EndAt.Add(s.manageLinkTTL))
+	resp.ManageURL = fmt.Sprintf("%s/book/%s/manage?token=%s", s.appURL, business.Slug, resp.ManageToken)
+
+	return resp, nil
+}
+
+// GetBooking returns the booking referenced by a manage token
+func (s *PublicBookingService) GetBooking(ctx context.Context, slug, token string) (*dto.PublicBookingResponse, error) {
+	business, err := s.bookableBusiness(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	booking, service, err := s.bookingByToken(ctx, business, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.bookingResponse(ctx, business, booking, service)
+}
+
+// CancelBooking cancels the booking referenced by a manage token as long as
+// the cancellation window of the business isn't over
+func (s *PublicBookingService) CancelBooking(ctx context.Context, slug, token string) (*dto.PublicBookingResponse, error) {
+	business, err := s.bookableBusiness(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	booking, service, err := s.bookingByToken(ctx, business, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cancel(ctx, business, booking); err != nil {
+		return nil, err
+	}
+	return s.bookingResponse(ctx, business, booking, service)
+}
+
+// cancel cancels a booking on behalf of the client
+func (s *PublicBookingService) cancel(ctx context.Context, business *domain.Business, booking *domain.Booking) error {
+	if booking.IsCancelled() {
+		return ErrBookingAlreadyCancelled
+	}
+	now := time.Now()
+	if !booking.StartAt.After(now) {
+		return ErrBookingAlreadyStarted
+	}
+	if now.After(business.CancellationDeadline(booking.StartAt)) {
+		return ErrCancellationWindowOver
+	}
+
+	return s.bookingService.cancel(ctx, business.ID, booking)
+}
+
+// bookableBusiness resolves a slug to a business that accepts online bookings
+func (s *PublicBookingService) bookableBusiness(ctx context.Context, slug string) (*domain.Business, error) {
+	business, err := s.businessRepo.GetBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBusinessNotFound
+		}
+		return nil, fmt.Errorf("failed to get business: %w", err)
+	}
+	if !business.EnableOnlineBooking {
+		return nil, ErrOnlineBookingDisabled
+	}
+	return business, nil
+}
+
+func (s *PublicBookingService) bookableService(ctx context.Context, business *domain.Business, serviceID string) (*domain.Service, error) {
+	service, err := s.serviceRepo.GetById(ctx, serviceID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrServiceNotBookable
+		}
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	if service.BusinessID != business.ID || !service.Active || !service.VisibleOnline {
+		return nil, ErrServiceNotBookable
+	}
+	return service, nil
+}
+
+// assignments maps the services of a business to their active providers,
+// with the price and duration each provides them at, and back
+func (s *PublicBookingService) assignments(ctx context.Context, businessID string) (map[string][]*domain.StaffService, map[string][]string, error) {
+	rows, err := s.staffServiceRepo.GetStaffServicesByBusiness(ctx, businessID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get staff services: %w", err)
+	}
+
+	staffByService := make(map[string][]*domain.StaffService)
+	servicesByStaff := make(map[string][]string)
+	for _, row := range rows {
+		staffByService[row.ServiceID] = append(staffByService[row.ServiceID], &domain.StaffService{
+			ID:          row.ID,
+			StaffID:     row.StaffID,
+			ServiceID:   row.ServiceID,
+			PriceCents:  row.PriceCents,
+			DurationMin: row.DurationMin,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+		})
+		servicesByStaff[row.StaffID] = append(servicesByStaff[row.StaffID], row.ServiceID)
+	}
+	return staffByService, servicesByStaff, nil
+}
+
+// availableSlots returns the future start times on day at which the
+// providers of service are free for as long as each takes to provide it
+func (s *PublicBookingService) availableSlots(ctx context.Context, business *domain.Business, service *domain.Service, staffID string, day time.Time) ([]*dto.SlotResponse, error) {
+	staffByService, _, err := s.assignments(ctx, business.ID)
+	if err != nil {
+		return nil, err
+	}
+	providers := staffByService[service.ID]
+	if staffID != "" {
+		var provider *domain.StaffService
+		for _, link := range providers {
+			if link.StaffID == staffID {
+				provider = link
+				break
+			}
+		}
+		if provider == nil {
+			return nil, ErrStaffNotBookable
+		}
+		providers = []*domain.StaffService{provider}
+	}
+	if len(providers) == 0 {
+		return []*dto.SlotResponse{}, nil
+	}
+
+	var staffFilter *string
+	if staffID != "" {
+		staffFilter = &staffID
+	}
+	freeSlots, err := s.bookingRepo.GetAvailableSlots(ctx, business.ID, staffFilter, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get available slots: %w", err)
+	}
+
+	// Index the free grid slots per staff member by start time
+	free := make(map[string]map[int64]bool)
+	var step time.Duration
+	for _, slot := range freeSlots {
+		if free[slot.StaffID] == nil {
+			free[slot.StaffID] = make(map[int64]bool)
+		}
+		free[slot.StaffID][slot.Start.Unix()] = true
+		step = slot.End.Sub(slot.Start)
+	}
+	if step <= 0 {
+		return []*dto.SlotResponse{}, nil
+	}
+
+	// A start time is bookable when all grid slots covering the service are free
+	now := time.Now()
+
+	result := []*dto.SlotResponse{}
+	for _, provider := range providers {
+		providerID := provider.StaffID
+		duration := provider.EffectiveDuration(service)
+		needed := int((duration + step - 1) / step)
+		for _, slot := range freeSlots {
+			if slot.StaffID != providerID || !slot.Start.After(now) {
+				continue
+			}
+			covered := true
+			for i := 1; i < needed; i++ {
+				if !free[providerID][slot.Start.Add(time.Duration(i)*step).Unix()] {
+					covered = false
+					break
+				}
+			}
+			if covered {
+				result = append(result, &dto.SlotResponse{
+					StaffID: providerID,
+					Start:   slot.Start,
+					End:     slot.Start.Add(duration),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
+	return result, nil
+}
+
+// bookingByToken verifies a manage token and loads its booking, which has to
+// belong to the business
+func (s *PublicBookingService) bookingByToken(ctx context.Context, business *domain.Business, token string) (*domain.Booking, *domain.Service, error) {
+	bookingID, err := s.linkSigner.Verify(manageTokenPurpose, token, time.Now())
+	if err != nil {
+		return nil, nil, ErrInvalidManageToken
+	}
+
+	booking, err := s.bookingRepo.GetById(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrInvalidManageToken
+		}
+		return nil, nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+
+	service, err := s.serviceRepo.GetById(ctx, booking.ServiceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	if service.BusinessID != business.ID {
+		return nil, nil, ErrInvalidManageToken
+	}
+
+	return booking, service, nil
+}
+
+func (s *PublicBookingService) bookingResponse(ctx context.Context, business *domain.Business, booking *domain.Booking, service *domain.Service) (*dto.PublicBookingResponse, error) {
+	staff, err := s.staffRepo.GetById(ctx, booking.StaffID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get staff: %w", err)
+	}
+
+	resp := &dto.PublicBookingResponse{
+		ID:           booking.ID,
+		Status:       booking.Status,
+		BusinessName: business.Name,
+		ServiceID:    service.ID,
+		ServiceName:  service.Name,
+		StaffID:      staff.ID,
+		StaffName:    strings.TrimSpace(staff.FirstName + " " + staff.LastName),
+		StartAt:      booking.StartAt,
+		EndAt:        booking.EndAt,
+		PriceCents:   booking.PriceCents,
+		Currency:     business.Currency,
+		CancelledAt:  booking.CancelledAt,
+		LocationID:   booking.LocationID,
+	}
+	if !booking.IsCancelled() {
+		deadline := business.CancellationDeadline(booking.StartAt)
+		resp.CancellableUntil = &deadline
+	}
+	if booking.LocationID != "" {
+		if location, err := s.locationRepo.GetByID(ctx, booking.LocationID); err == nil {
+			resp.LocationName = location.Name
+			resp.LocationAddress = location.Address
+		}
+	}
+
+	return resp, nil
+}
+
+// businessLocation returns the timezone of the business, UTC when it is unknown
+func businessLocation(business *domain.Business) *time.Location {
+	loc, err := time.LoadLocation(business.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+)
+
+var (
+	ErrUnknownPermission = errors.New("unknown permission")
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrOwnerRoleFixed    = errors.New("owners always hold every permission")
+	ErrRoleOwnerOnly     = errors.New("only owners can grant this permission")
+	ErrRoleBeyondCaller  = errors.New("roles can't grant permissions you don't hold")
+)
+
+// roleOwnerOnlyPermissions can only be granted by owners: holders of
+// roles:manage could grant themselves anything, and API keys and webhooks
+// hand business data to other systems.
+var roleOwnerOnlyPermissions = []domain.Permission{domain.PermRolesManage, domain.PermAPIKeysManage, domain.PermWebhooksManage}
+
+type RoleService struct {
+	roleRepo domain.RoleRepository
+	userRepo domain.UserRepository
+}
+
+func NewRoleService(roleRepo domain.RoleRepository, userRepo domain.UserRepository) *RoleService {
+	return &RoleService{
+		roleRepo: roleRepo,
+		userRepo: userRepo,
+	}
+}
+
+// ListRoles returns the built-in roles followed by the custom roles of the business.
+func (s *RoleService) ListRoles(ctx context.Context, businessID string) ([]dto.RoleResponse, error) {
+	roles, err := s.roleRepo.ListByBusiness(ctx, businessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+
+	responses := make([]dto.RoleResponse, 0, len(roles)+len(domain.BuiltinRoles()))
+	for _, name := range domain.BuiltinRoles() {
+		responses = append(responses, dto.RoleResponse{
+			Name:        name,
+			Builtin:     true,
+			Permissions: permissionStrings(domain.BuiltinRolePermissions(name)),
+		})
+	}
+	for _, role := range roles {
+		responses = append(responses, convertRoleToResponse(role))
+	}
+	return responses, nil
+}
+
+// CreateRole creates a custom role. Callers can only grant permissions they
+// hold themselves.
+func (s *RoleService) CreateRole(ctx context.Context, businessID, callerRole string, callerPermissions []domain.Permission, req dto.CreateRoleRequest) (*dto.RoleResponse, error) {
+	permissions, err := parsePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRoleGrant(callerRole, callerPermissions, permissions); err != nil {
+		return nil, err
+	}
+
+	role := &domain.Role{
+		BusinessID:  businessID,
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: permissions,
+	}
+
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+
+	response := convertRoleToResponse(role)
+	return &response, nil
+}
+
+// UpdateRole replaces a custom role. Callers can neither change a role
+// granting more than they hold nor make it grant more.
+func (s *RoleService) UpdateRole(ctx context.Context, roleID, callerRole string, callerPermissions []domain.Permission, req dto.UpdateRoleRequest) (*dto.RoleResponse, error) {
+	permissions, err := parsePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.roleRepo.GetByID(ctx, roleID)
+	if err != nil {
+		return nil, ErrRoleNotFound
+	}
+	if err := checkRoleGrant(callerRole, callerPermissions, role.Permissions); err != nil {
+		return nil, err
+	}
+	if err := checkRoleGrant(callerRole, callerPermissions, permissions); err != nil {
+		return nil, err
+	}
+
+	role.Name = req.Name
+	role.Description = req.Description
+	role.Permissions = permissions
+
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+
+	response := convertRoleToResponse(role)
+	return &response, nil
+}
+
+// DeleteRole removes a custom role. Users holding it fall back to their
+// built-in role. Callers can't delete roles granting more than they hold.
+func (s *RoleService) DeleteRole(ctx context.Context, roleID, callerRole string, callerPermissions []domain.Permission) error {
+	role, err := s.roleRepo.GetByID(ctx, roleID)
+	if err != nil {
+		return ErrRoleNotFound
+	}
+	if err := checkRoleGrant(callerRole, callerPermissions, role.Permissions); err != nil {
+		return err
+	}
+
+	if err := s.roleRepo.Delete(ctx, roleID); err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	return nil
+}
+
+// AssignRole sets or clears the custom role of a user of the business.
+// Callers can only assign roles granting what they hold themselves.
+func (s *RoleService) AssignRole(ctx context.Context, businessID, userID, callerRole string, callerPermissions []domain.Permission, req dto.AssignRoleRequest) error {
+	user, err := s.userRepo.GetById(ctx, userID)
+	if err != nil || user.BusinessID != businessID {
+		return ErrUserNotFound
+	}
+	if user.Role == domain.RoleOwner {
+		return ErrOwnerRoleFixed
+	}
+
+	if req.RoleID != "" {
+		role, err := s.roleRepo.GetByID(ctx, req.RoleID)
+		if err != nil || role.BusinessID != businessID {
+			return ErrRoleNotFound
+		}
+		if err := checkRoleGrant(callerRole, callerPermissions, role.Permissions); err != nil {
+			return err
+		}
+	}
+
+	if err := s.roleRepo.AssignToUser(ctx, userID, req.RoleID); err != nil {
+		return fmt.Errorf("failed to assign role: %w", err)
+	}
+	return nil
+}
+
+// checkRoleGrant reports whether a caller with the given built-in role and
+// permissions may grant permissions through a custom role
+func checkRoleGrant(callerRole string, callerPermissions, permissions []domain.Permission) error {
+	for _, permission := range permissions {
+		if callerRole != domain.RoleOwner && domain.HasPermission(roleOwnerOnlyPermissions, permission) {
+			return fmt.Errorf("%w: %s", ErrRoleOwnerOnly, permission)
+		}
+		if !domain.HasPermission(callerPermissions, permission) {
+			return fmt.Errorf("%w: %s", ErrRoleBeyondCaller, permission)
+		}
+	}
+	return nil
+}
+
+func parsePermissions(values []string) ([]domain.Permission, error) {
+	seen := make(map[domain.Permission]bool, len(values))
+	permissions := make([]domain.Permission, 0, len(values))
+	for _, value := range values {
+		permission := domain.Permission(value)
+		if !domain.IsValidPermission(permission) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPermission, value)
+		}
+		if seen[permission] {
+			continue
+		}
+		seen[permission] = true
+		permissions = append(permissions, permission)
+	}
+	return permissions, nil
+}
+
+func permissionStrings(permissions []domain.Permission) []string {
+	out := make([]string, len(permissions))
+	for i, p := range permissions {
+		out[i] = string(p)
+	}
+	return out
+}
+
+func convertRoleToResponse(role *domain.Role) dto.RoleResponse {
+	return dto.RoleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissionStrings(role.Permissions),
+		CreatedAt:   role.CreatedAt,
+		UpdatedAt:   role.UpdatedAt,
+	}
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,15 +10,26 @@ import (
 	"github.com/ialekseychuk/my-place/internal/dto"
 )
 
+var (
+	ErrShiftNotFound            = errors.New("shift not found")
+	ErrScheduleTemplateNotFound = errors.New("schedule template not found")
+)
+
 type ScheduleService struct {
-	scheduleRepo domain.ScheduleRepository
-	staffRepo    domain.StaffRepository
+	scheduleRepo  domain.ScheduleRepository
+	staffRepo     domain.StaffRepository
+	ownershipRepo domain.OwnershipRepository
+	eventRepo     domain.EventRepository
+	transactor    domain.Transactor
 }
 
-func NewScheduleService(scheduleRepo domain.ScheduleRepository, staffRepo domain.StaffRepository) *ScheduleService {
+func NewScheduleService(scheduleRepo domain.ScheduleRepository, staffRepo domain.StaffRepository, ownershipRepo domain.OwnershipRepository, eventRepo domain.EventRepository, transactor domain.Transactor) *ScheduleService {
 	return &ScheduleService{
-		scheduleRepo: scheduleRepo,
-		staffRepo:    staffRepo,
+		scheduleRepo:  scheduleRepo,
+		staffRepo:     staffRepo,
+		ownershipRepo: ownershipRepo,
+		eventRepo:     eventRepo,
+		transactor:    transactor,
 	}
 }
 
@@ -25,7 +37,11 @@ func NewScheduleService(scheduleRepo domain.ScheduleRepository, staffRepo domain
 // Schedule Template Management
 // =======================
 
-func (s *ScheduleService) CreateScheduleTemplate(ctx context.Context, req dto.CreateScheduleTemplateRequest) (*dto.ScheduleTemplateResponse, error) {
+func (s *ScheduleService) CreateScheduleTemplate(ctx context.Context, businessID string, req dto.CreateScheduleTemplateRequest) (*dto.ScheduleTemplateResponse, error) {
+	if err := s.owned(ctx, businessID, domain.ResourceStaff, ErrStaffNotFound, req.StaffID); err != nil {
+		return nil, err
+	}
+
 	// Validate that staff exists
 	staff, err := s.staffRepo.GetById(ctx, req.StaffID)
 	if err != nil {
@@ -100,7 +116,11 @@ func (s *ScheduleService) GetScheduleTemplatesByStaff(ctx context.Context, staff
 // Shift Management
 // =======================
 
-func (s *ScheduleService) CreateShift(ctx context.Context, req dto.CreateShiftRequest) (*dto.ShiftResponse, error) {
+func (s *ScheduleService) CreateShift(ctx context.Context, businessID string, req dto.CreateShiftRequest) (*dto.ShiftResponse, error) {
+	if err := s.owned(ctx, businessID, domain.ResourceStaff, ErrStaffNotFound, req.StaffID); err != nil {
+		return nil, err
+	}
+
 	// Validate staff exists
 	staff, err := s.staffRepo.GetById(ctx, req.StaffID)
 	if err != nil {
@@ -139,7 +159,7 @@ func (s *ScheduleService) CreateShift(ctx context.Context, req dto.CreateShiftRe
 	}
 
 	// Create shift
-	if err := s.scheduleRepo.CreateShift(ctx, shift); err != nil {
+	if err := s.saveShift(ctx, shift, domain.ShiftCreated, s.scheduleRepo.CreateShift); err != nil {
 		return nil, fmt.Errorf("failed to create shift: %w", err)
 	}
 
@@ -212,7 +232,16 @@ func (s *ScheduleService) GetStaffShifts(ctx context.Context, staffID string, st
 // Schedule Generation
 // =======================
 
-func (s *ScheduleService) GenerateSchedule(ctx context.Context, req dto.GenerateScheduleRequest) error {
+func (s *ScheduleService) GenerateSchedule(ctx context.Context, businessID string, req dto.GenerateScheduleRequest) error {
+	if err := s.owned(ctx, businessID, domain.ResourceStaff, ErrStaffNotFound, req.StaffIDs...); err != nil {
+		return err
+	}
+	if req.TemplateID != "" {
+		if err := s.owned(ctx, businessID, domain.ResourceScheduleTemplate, ErrScheduleTemplateNotFound, req.TemplateID); err != nil {
+			return err
+		}
+	}
+
 	startDate, err := time.Parse("2006-01-02", req.StartDate)
 	if err != nil {
 		return fmt.Errorf("invalid start date format: %w", err)
@@ -288,7 +317,11 @@ func (s *ScheduleService) UpdateShiftAvailability(ctx context.Context, shiftID s
 	previousStatus := !shift.IsManuallyDisabled
 
 	// Update availability
-	if err := s.scheduleRepo.UpdateShiftAvailability(ctx, shiftID, isAvailable, reason, updatedBy); err != nil {
+	shift.IsAvailable = isAvailable
+	updateAvailability := func(ctx context.Context, shift *domain.StaffShift) error {
+		return s.scheduleRepo.UpdateShiftAvailability(ctx, shift.ID, isAvailable, reason, updatedBy)
+	}
+	if err := s.saveShift(ctx, shift, domain.ShiftUpdated, updateAvailability); err != nil {
 		return fmt.Errorf("failed to update shift availability: %w", err)
 	}
 
@@ -313,7 +346,11 @@ func (s *ScheduleService) CheckStaffAvailability(ctx context.Context, staffID st
 // Time Off Management
 // =======================
 
-func (s *ScheduleService) CreateTimeOffRequest(ctx context.Context, req dto.CreateTimeOffRequest) (*dto.TimeOffResponse, error) {
+func (s *ScheduleService) CreateTimeOffRequest(ctx context.Context, businessID string, req dto.CreateTimeOffRequest) (*dto.TimeOffResponse, error) {
+	if err := s.owned(ctx, businessID, domain.ResourceStaff, ErrStaffNotFound, req.StaffID); err != nil {
+		return nil, err
+	}
+
 	// Validate staff exists
 	staff, err := s.staffRepo.GetById(ctx, req.StaffID)
 	if err != nil {
@@ -539,7 +576,7 @@ func (s *ScheduleService) UpdateShift(ctx context.Context, shiftID string, req d
 		}
 	}
 
-	if err := s.scheduleRepo.UpdateShift(ctx, shift); err != nil {
+	if err := s.saveShift(ctx, shift, domain.ShiftUpdated, s.scheduleRepo.UpdateShift); err != nil {
 		return nil, fmt.Errorf("failed to update shift: %w", err)
 	}
 
@@ -547,7 +584,15 @@ func (s *ScheduleService) UpdateShift(ctx context.Context, shiftID string, req d
 }
 
 func (s *ScheduleService) DeleteShift(ctx context.Context, shiftID string) error {
-	if err := s.scheduleRepo.DeleteShift(ctx, shiftID); err != nil {
+	shift, err := s.scheduleRepo.GetShift(ctx, shiftID)
+	if err != nil {
+		return fmt.Errorf("shift not found: %w", err)
+	}
+
+	deleteShift := func(ctx context.Context, shift *domain.StaffShift) error {
+		return s.scheduleRepo.DeleteShift(ctx, shift.ID)
+	}
+	if err := s.saveShift(ctx, shift, domain.ShiftDeleted, deleteShift); err != nil {
 		return fmt.Errorf("failed to delete shift: %w", err)
 	}
 	return nil
@@ -557,47 +602,86 @@ func (s *ScheduleService) DeleteShift(ctx context.Context, shiftID string) error
 // Bulk Operations
 // =======================
 
-func (s *ScheduleService) BulkCreateShifts(ctx context.Context, req dto.BulkCreateShiftsRequest) ([]dto.ShiftResponse, error) {
+// BulkCreateShifts creates all shifts or none, every staff member has to
+// belong to the business
+func (s *ScheduleService) BulkCreateShifts(ctx context.Context, businessID string, req dto.BulkCreateShiftsRequest) ([]dto.ShiftResponse, error) {
 	var responses []dto.ShiftResponse
 
-	for _, shiftReq := range req.Shifts {
-		shift, err := s.CreateShift(ctx, shiftReq)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create shift for staff %s on %s: %w", shiftReq.StaffID, shiftReq.ShiftDate, err)
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		for _, shiftReq := range req.Shifts {
+			shift, err := s.CreateShift(ctx, businessID, shiftReq)
+			if err != nil {
+				return fmt.Errorf("failed to create shift for staff %s on %s: %w", shiftReq.StaffID, shiftReq.ShiftDate, err)
+			}
+			responses = append(responses, *shift)
 		}
-		responses = append(responses, *shift)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return responses, nil
 }
 
-func (s *ScheduleService) BulkUpdateShifts(ctx context.Context, req dto.BulkUpdateShiftsRequest) ([]dto.ShiftResponse, error) {
+// BulkUpdateShifts updates all shifts or none, every shift has to belong to
+// the business
+func (s *ScheduleService) BulkUpdateShifts(ctx context.Context, businessID string, req dto.BulkUpdateShiftsRequest) ([]dto.ShiftResponse, error) {
 	var responses []dto.ShiftResponse
 
-	for _, update := range req.Updates {
-		shift, err := s.UpdateShift(ctx, update.ShiftID, update.Update)
-		if err != nil {
-			return nil, fmt.Errorf("failed to update shift %s: %w", update.ShiftID, err)
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		for _, update := range req.Updates {
+			if err := s.owned(ctx, businessID, domain.ResourceShift, ErrShiftNotFound, update.ShiftID); err != nil {
+				return fmt.Errorf("failed to update shift %s: %w", update.ShiftID, err)
+			}
+			shift, err := s.UpdateShift(ctx, update.ShiftID, update.Update)
+			if err != nil {
+				return fmt.Errorf("failed to update shift %s: %w", update.ShiftID, err)
+			}
+			responses = append(responses, *shift)
 		}
-		responses = append(responses, *shift)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return responses, nil
 }
 
-func (s *ScheduleService) BulkDeleteShifts(ctx context.Context, req dto.BulkDeleteShiftsRequest) error {
-	for _, shiftID := range req.ShiftIDs {
-		if err := s.DeleteShift(ctx, shiftID); err != nil {
-			return fmt.Errorf("failed to delete shift %s: %w", shiftID, err)
+// BulkDeleteShifts deletes all shifts or none, every shift has to belong to
+// the business
+func (s *ScheduleService) BulkDeleteShifts(ctx context.Context, businessID string, req dto.BulkDeleteShiftsRequest) error {
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.owned(ctx, businessID, domain.ResourceShift, ErrShiftNotFound, req.ShiftIDs...); err != nil {
+			return err
 		}
-	}
-	return nil
+		for _, shiftID := range req.ShiftIDs {
+			if err := s.DeleteShift(ctx, shiftID); err != nil {
+				return fmt.Errorf("failed to delete shift %s: %w", shiftID, err)
+			}
+		}
+		return nil
+	})
 }
 
 // =======================
 // Helper Methods
 // =======================
 
+// owned checks that the resources referenced in a request body belong to
+// the business, returning notFound for the first one that doesn't
+func (s *ScheduleService) owned(ctx context.Context, businessID string, resource domain.Resource, notFound error, ids ...string) error {
+	for _, id := range ids {
+		ok, err := s.ownershipRepo.BelongsToBusiness(ctx, businessID, resource, id)
+		if err != nil {
+			return fmt.Errorf("failed to check %s ownership: %w", resource, err)
+		}
+		if !ok {
+			return notFound
+		}
+	}
+	return nil
+}
+
 func (s *ScheduleService) clearDefaultTemplate(ctx context.Context, staffID string) error {
 	// This would require a repository method to clear default flags
 	// For now, we'll implement this as a placeholder
@@ -665,6 +749,8 @@ func (s *ScheduleService) UpdateTimeOffRequest(ctx context.Context, requestID st
 		return nil, fmt.Errorf("time off request not found: %w", err)
 	}
 
+	wasApproved := timeOff.IsApproved()
+
 	// Update fields if provided
 	if req.Status != "" {
 		timeOff.Status = req.Status
@@ -678,8 +764,28 @@ func (s *ScheduleService) UpdateTimeOffRequest(ctx context.Context, requestID st
 		timeOff.Comments = req.Comments
 	}
 
-	if err := s.scheduleRepo.UpdateTimeOffRequest(ctx, timeOff); err != nil {
-		return nil, fmt.Errorf("failed to update time off request: %w", err)
+	staff, err := s.staffRepo.GetById(ctx, timeOff.StaffID)
+	if err != nil {
+		return nil, fmt.Errorf("staff not found: %w", err)
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.scheduleRepo.UpdateTimeOffRequest(ctx, timeOff); err != nil {
+			return fmt.Errorf("failed to update time off request: %w", err)
+		}
+		if wasApproved || !timeOff.IsApproved() {
+			return nil
+		}
+		return recordEvent(ctx, s.eventRepo, domain.EventTimeOffApproved, staff.BusinessID, timeOff.ID, domain.TimeOffEventPayload{
+			RequestID: timeOff.ID,
+			StaffID:   timeOff.StaffID,
+			Type:      timeOff.Type,
+			StartDate: timeOff.StartDate,
+			EndDate:   timeOff.EndDate,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return s.GetTimeOffRequest(ctx, requestID)
@@ -913,7 +1019,7 @@ func (s *ScheduleService) generateShiftsForDay(ctx context.Context, staffID stri
 				UpdatedBy:      generatedBy,
 			}
 
-			if err := s.scheduleRepo.CreateShift(ctx, shift); err != nil {
+			if err := s.saveShift(ctx, shift, domain.ShiftCreated, s.scheduleRepo.CreateShift); err != nil {
 				return err
 			}
 		}
@@ -932,7 +1038,7 @@ func (s *ScheduleService) generateShiftsForDay(ctx context.Context, staffID stri
 			UpdatedBy:      generatedBy,
 		}
 
-		if err := s.scheduleRepo.CreateShift(ctx, shift); err != nil {
+		if err := s.saveShift(ctx, shift, domain.ShiftCreated, s.scheduleRepo.CreateShift); err != nil {
 			return err
 		}
 	}
@@ -940,6 +1046,30 @@ func (s *ScheduleService) generateShiftsForDay(ctx context.Context, staffID stri
 	return nil
 }
 
+// saveShift runs write for a shift and records the change as
+// EventShiftChanged in the same transaction
+func (s *ScheduleService) saveShift(ctx context.Context, shift *domain.StaffShift, change string, write func(ctx context.Context, shift *domain.StaffShift) error) error {
+	staff, err := s.staffRepo.GetById(ctx, shift.StaffID)
+	if err != nil {
+		return fmt.Errorf("staff not found: %w", err)
+	}
+
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := write(ctx, shift); err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.eventRepo, domain.EventShiftChanged, staff.BusinessID, shift.ID, domain.ShiftEventPayload{
+			ShiftID:     shift.ID,
+			StaffID:     shift.StaffID,
+			Change:      change,
+			ShiftDate:   shift.ShiftDate,
+			StartTime:   shift.StartTime,
+			EndTime:     shift.EndTime,
+			IsAvailable: shift.IsAvailable,
+		})
+	})
+}
+
 func (s *ScheduleService) logAvailabilityAction(ctx context.Context, staffID, shiftID, action string, previousStatus, newStatus bool, reason, changedBy string) error {
 	log := &domain.StaffAvailabilityLog{
 		StaffID:        staffID,
@@ -1279,6 +1409,10 @@ func (s *ScheduleService) CopySchedule(ctx context.Context, businessID string, r
 		return 0, fmt.Errorf("invalid target start date: %w", err)
 	}
 
+	if err := s.owned(ctx, businessID, domain.ResourceStaff, ErrStaffNotFound, req.StaffIDs...); err != nil {
+		return 0, err
+	}
+
 	copiedCount := 0
 	for _, staffID := range req.StaffIDs {
 		shifts, err := s.scheduleRepo.GetShiftsByStaff(ctx, staffID, sourceStart, sourceEnd)
@@ -1311,7 +1445,7 @@ func (s *ScheduleService) CopySchedule(ctx context.Context, businessID string, r
 				UpdatedBy:      req.ActionBy,
 			}
 
-			if err := s.scheduleRepo.CreateShift(ctx, newShift); err != nil {
+			if err := s.saveShift(ctx, newShift, domain.ShiftCreated, s.scheduleRepo.CreateShift); err != nil {
 				return copiedCount, fmt.Errorf("failed to copy shift: %w", err)
 			}
 			copiedCount++
@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrServiceCategoryNotFound  = errors.New("service category not found")
+	ErrServiceCategoryNameTaken = errors.New("a service category with this name already exists")
+)
+
+// ServiceCategoryService manages the categories grouping the services of a
+// business on its booking menus
+type ServiceCategoryService struct {
+	categoryRepo domain.ServiceCategoryRepository
+}
+
+func NewServiceCategoryService(categoryRepo domain.ServiceCategoryRepository) *ServiceCategoryService {
+	return &ServiceCategoryService{
+		categoryRepo: categoryRepo,
+	}
+}
+
+func (s *ServiceCategoryService) CreateCategory(ctx context.Context, businessID string, req dto.CreateServiceCategoryRequest) (*dto.ServiceCategoryResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if err := s.checkNameFree(ctx, businessID, name, ""); err != nil {
+		return nil, err
+	}
+
+	category := &domain.ServiceCategory{
+		BusinessID: businessID,
+		Name:       name,
+		Position:   req.Position,
+	}
+	if err := s.categoryRepo.Create(ctx, category); err != nil {
+		return nil, fmt.Errorf("failed to create service category: %w", err)
+	}
+
+	resp := serviceCategoryResponse(category)
+	return &resp, nil
+}
+
+// ListCategories returns the categories of a business by position
+func (s *ServiceCategoryService) ListCategories(ctx context.Context, businessID string) ([]dto.ServiceCategoryResponse, error) {
+	categories, err := s.categoryRepo.ListByBusiness(ctx, businessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service categories: %w", err)
+	}
+
+	resp := make([]dto.ServiceCategoryResponse, 0, len(categories))
+	for _, category := range categories {
+		resp = append(resp, serviceCategoryResponse(category))
+	}
+	return resp, nil
+}
+
+func (s *ServiceCategoryService) UpdateCategory(ctx context.Context, categoryID string, req dto.UpdateServiceCategoryRequest) (*dto.ServiceCategoryResponse, error) {
+	category, err := s.category(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if err := s.checkNameFree(ctx, category.BusinessID, name, category.ID); err != nil {
+			return nil, err
+		}
+		category.Name = name
+	}
+	if req.Position != nil {
+		category.Position = *req.Position
+	}
+
+	if err := s.categoryRepo.Update(ctx, category); err != nil {
+		return nil, fmt.Errorf("failed to update service category: %w", err)
+	}
+
+	resp := serviceCategoryResponse(category)
+	return &resp, nil
+}
+
+// DeleteCategory deletes a category, leaving its services uncategorized
+func (s *ServiceCategoryService) DeleteCategory(ctx context.Context, categoryID string) error {
+	if err := s.categoryRepo.Delete(ctx, categoryID); err != nil {
+		return fmt.Errorf("failed to delete service category: %w", err)
+	}
+	return nil
+}
+
+func (s *ServiceCategoryService) category(ctx context.Context, categoryID string) (*domain.ServiceCategory, error) {
+	category, err := s.categoryRepo.GetByID(ctx, categoryID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrServiceCategoryNotFound
+		}
+		return nil, fmt.Errorf("failed to get service category: %w", err)
+	}
+	return category, nil
+}
+
+// checkNameFree returns ErrServiceCategoryNameTaken when a category of the
+// business other than exceptID has the name, ignoring case
+func (s *ServiceCategoryService) checkNameFree(ctx context.Context, businessID, name, exceptID string) error {
+	existing, err := s.categoryRepo.GetByName(ctx, businessID, name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to check service category name: %w", err)
+	}
+	if existing.ID != exceptID {
+		return ErrServiceCategoryNameTaken
+	}
+	return nil
+}
+
+// groupServices sorts services into their categories, keeping the order of
+// both. Categories without services are left out; services without a
+// category come last.
+func groupServices(categories []*domain.ServiceCategory, services []domain.Service) []domain.ServiceCategoryGroup {
+	byCategory := make(map[string][]domain.Service)
+	for _, service := range services {
+		byCategory[service.CategoryID] = append(byCategory[service.CategoryID], service)
+	}
+
+	groups := []domain.ServiceCategoryGroup{}
+	for _, category := range categories {
+		if categoryServices := byCategory[category.ID]; len(categoryServices) > 0 {
+			groups = append(groups, domain.ServiceCategoryGroup{Category: category, Services: categoryServices})
+		}
+	}
+	if uncategorized := byCategory[""]; len(uncategorized) > 0 {
+		groups = append(groups, domain.ServiceCategoryGroup{Services: uncategorized})
+	}
+	return groups
+}
+
+func serviceCategoryResponse(category *domain.ServiceCategory) dto.ServiceCategoryResponse {
+	return dto.ServiceCategoryResponse{
+		ID:        category.ID,
+		Name:      category.Name,
+		Position:  category.Position,
+		CreatedAt: category.CreatedAt,
+		UpdatedAt: category.UpdatedAt,
+	}
+}
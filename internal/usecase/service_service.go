@@ -2,21 +2,29 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
 )
 
 type ServiceService struct {
-	repo domain.ServiceRepository
+	repo         domain.ServiceRepository
+	categoryRepo domain.ServiceCategoryRepository
 }
 
-func NewServiceUseCase(repo domain.ServiceRepository) *ServiceService {
+func NewServiceUseCase(repo domain.ServiceRepository, categoryRepo domain.ServiceCategoryRepository) *ServiceService {
 	return &ServiceService{
-		repo: repo,
+		repo:         repo,
+		categoryRepo: categoryRepo,
 	}
 }
 
 func (s *ServiceService) CreateService(ctx context.Context, service *domain.Service) error {
+	if err := s.checkCategory(ctx, service); err != nil {
+		return err
+	}
 	return s.repo.Create(ctx, service)
 }
 
@@ -24,14 +32,50 @@ func (s *ServiceService) ListByBusinessId(ctx context.Context, businessId string
 	return s.repo.ListByBusinessId(ctx, businessId)
 }
 
+// ListByCategory returns the services of a business grouped by category, in
+// the order of the categories
+func (s *ServiceService) ListByCategory(ctx context.Context, businessID string) ([]domain.ServiceCategoryGroup, error) {
+	categories, err := s.categoryRepo.ListByBusiness(ctx, businessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service categories: %w", err)
+	}
+	services, err := s.repo.ListByBusinessId(ctx, businessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	return groupServices(categories, services), nil
+}
+
 func (s *ServiceService) GetServiceById(ctx context.Context, id string) (*domain.Service, error) {
 	return s.repo.GetById(ctx, id)
 }
 
 func (s *ServiceService) UpdateService(ctx context.Context, service *domain.Service) error {
+	if err := s.checkCategory(ctx, service); err != nil {
+		return err
+	}
 	return s.repo.Update(ctx, service)
 }
 
 func (s *ServiceService) DeleteService(ctx context.Context, id string) error {
 	return s.repo.Delete(ctx, id)
 }
+
+// checkCategory returns ErrServiceCategoryNotFound unless the category of
+// the service, if any, belongs to its business
+func (s *ServiceService) checkCategory(ctx context.Context, service *domain.Service) error {
+	if service.CategoryID == "" {
+		return nil
+	}
+	category, err := s.categoryRepo.GetByID(ctx, service.CategoryID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrServiceCategoryNotFound
+		}
+		return fmt.Errorf("failed to get service category: %w", err)
+	}
+	if category.BusinessID != service.BusinessID {
+		return ErrServiceCategoryNotFound
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidInvitation  = errors.New("invitation is invalid or expired")
+	ErrStaffAlreadyLinked = domain.ErrStaffAlreadyLinked
+	ErrEmailAlreadyInUse  = domain.ErrEmailAlreadyInUse
+)
+
+type StaffInvitationService struct {
+	invitationRepo domain.StaffInvitationRepository
+	staffRepo      domain.StaffRepository
+	userRepo       domain.UserRepository
+	invitationTTL  time.Duration
+}
+
+func NewStaffInvitationService(
+	invitationRepo domain.StaffInvitationRepository,
+	staffRepo domain.StaffRepository,
+	userRepo domain.UserRepository,
+) *StaffInvitationService {
+	return &StaffInvitationService{
+		invitationRepo: invitationRepo,
+		staffRepo:      staffRepo,
+		userRepo:       userRepo,
+		invitationTTL:  7 * 24 * time.Hour, // 7 days
+	}
+}
+
+// InviteStaff issues a new invitation for a staff member. Previous pending
+// invitations of the same staff member are revoked. The plain token is only
+// returned here; just its hash is persisted.
+func (s *StaffInvitationService) InviteStaff(ctx context.Context, businessID, invitedBy string, req dto.CreateStaffInvitationRequest) (*dto.StaffInvitationResponse, error) {
+	staff, err := s.staffRepo.GetById(ctx, req.StaffID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrStaffNotFound
+		}
+		return nil, fmt.Errorf("failed to get staff: %w", err)
+	}
+	if staff.BusinessID != businessID {
+		return nil, ErrStaffNotFound
+	}
+
+	if _, err := s.userRepo.GetByStaffID(ctx, staff.ID); err == nil {
+		return nil, ErrStaffAlreadyLinked
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to check staff account: %w", err)
+	}
+
+	if _, err := s.userRepo.GetByEmail(ctx, req.Email); err == nil {
+		return nil, ErrEmailAlreadyInUse
+	}
+
+	if err := s.invitationRepo.RevokePendingByStaff(ctx, staff.ID); err != nil {
+		return nil, fmt.Errorf("failed to revoke previous invitations: %w", err)
+	}
+
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &domain.StaffInvitation{
+		BusinessID: businessID,
+		StaffID:    staff.ID,
+		Email:      req.Email,
+		TokenHash:  hashToken(token),
+		InvitedBy:  invitedBy,
+		ExpiresAt:  time.Now().Add(s.invitationTTL),
+	}
+
+	if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	response := convertInvitationToResponse(invitation)
+	response.Token = token
+	return &response, nil
+}
+
+func (s *StaffInvitationService) ListInvitations(ctx context.Context, businessID string) ([]dto.StaffInvitationResponse, error) {
+	invitations, err := s.invitationRepo.ListByBusiness(ctx, businessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitations: %w", err)
+	}
+
+	responses := make([]dto.StaffInvitationResponse, len(invitations))
+	for i, invitation := range invitations {
+		responses[i] = convertInvitationToResponse(invitation)
+	}
+	return responses, nil
+}
+
+// AcceptInvitation consumes the invite token and creates a staff role user
+// linked to the invited Staff record.
+func (s *StaffInvitationService) AcceptInvitation(ctx context.Context, req dto.AcceptStaffInvitationRequest) (*domain.User, error) {
+	invitation, err := s.invitationRepo.GetByTokenHash(ctx, hashToken(req.Token))
+	if err != nil || !invitation.IsPending() {
+		return nil, ErrInvalidInvitation
+	}
+
+	staff, err := s.staffRepo.GetById(ctx, invitation.StaffID)
+	if err != nil || !staff.IsActive {
+		return nil, ErrInvalidInvitation
+	}
+
+	if _, err := s.userRepo.GetByEmail(ctx, invitation.Email); err == nil {
+		return nil, ErrEmailAlreadyInUse
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	phone := req.Phone
+	if phone == "" {
+		phone = staff.Phone
+	}
+
+	user := &domain.User{
+		ID:         generateID(),
+		BusinessID: invitation.BusinessID,
+		StaffID:    staff.ID,
+		FirstName:  staff.FirstName,
+		LastName:   staff.LastName,
+		Email:      invitation.Email,
+		Phone:      phone,
+		Password:   string(hashedPassword),
+		Role:       domain.RoleStaff,
+		IsActive:   true,
+	}
+
+	if err := s.invitationRepo.Accept(ctx, invitation.ID, user); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			// The token was consumed or revoked concurrently
+			return nil, ErrInvalidInvitation
+		case errors.Is(err, ErrStaffAlreadyLinked), errors.Is(err, ErrEmailAlreadyInUse):
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	user.Password = ""
+	return user, nil
+}
+
+func convertInvitationToResponse(invitation *domain.StaffInvitation) dto.StaffInvitationResponse {
+	status := "pending"
+	switch {
+	case invitation.AcceptedAt != nil:
+		status = "accepted"
+	case invitation.RevokedAt != nil:
+		status = "revoked"
+	case invitation.ExpiresAt.Before(time.Now()):
+		status = "expired"
+	}
+
+	return dto.StaffInvitationResponse{
+		ID:         invitation.ID,
+		StaffID:    invitation.StaffID,
+		Email:      invitation.Email,
+		Status:     status,
+		ExpiresAt:  invitation.ExpiresAt,
+		AcceptedAt: invitation.AcceptedAt,
+		CreatedAt:  invitation.CreatedAt,
+	}
+}
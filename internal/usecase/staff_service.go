@@ -3,21 +3,31 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrStaffNotFound      = errors.New("staff not found")
+	ErrServiceNotAssigned = errors.New("service is not assigned to this staff member")
 )
 
 type StaffService struct {
 	repo             domain.StaffRepository
 	staffServiceRepo domain.StaffServiceRepository
 	serviceRepo      domain.ServiceRepository
+	transactor       domain.Transactor
 }
 
-func NewStaffUseCase(repo domain.StaffRepository, staffServiceRepo domain.StaffServiceRepository, serviceRepo domain.ServiceRepository) *StaffService {
+func NewStaffUseCase(repo domain.StaffRepository, staffServiceRepo domain.StaffServiceRepository, serviceRepo domain.ServiceRepository,
+	transactor domain.Transactor) *StaffService {
 	return &StaffService{
 		repo:             repo,
 		staffServiceRepo: staffServiceRepo,
 		serviceRepo:      serviceRepo,
+		transactor:       transactor,
 	}
 }
 
@@ -37,7 +47,10 @@ func (s *StaffService) UpdateStaff(ctx context.Context, staff *domain.Staff) err
 	return s.repo.Update(ctx, staff)
 }
 
-func (s *StaffService) AssignServiceToStaff(ctx context.Context, staffID, serviceID string) error {
+// AssignServiceToStaff assigns a service to a staff member, at their own
+// price and duration when given. The assignment and its overrides are saved
+// together or not at all
+func (s *StaffService) AssignServiceToStaff(ctx context.Context, staffID, serviceID string, priceCents, durationMin *int) error {
 	staff, err := s.repo.GetById(ctx, staffID)
 	if err != nil {
 		return err
@@ -52,7 +65,27 @@ func (s *StaffService) AssignServiceToStaff(ctx context.Context, staffID, servic
 		return errors.New("staff and service must belong to the same business")
 	}
 
-	return s.staffServiceRepo.AssignServiceToStaff(ctx, staffID, serviceID)
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.staffServiceRepo.AssignServiceToStaff(ctx, staffID, serviceID); err != nil {
+			return err
+		}
+		if priceCents == nil && durationMin == nil {
+			return nil
+		}
+		return s.SetServiceOverrides(ctx, staffID, serviceID, priceCents, durationMin)
+	})
+}
+
+// SetServiceOverrides sets the price and duration a staff member provides an
+// assigned service at, nil falls back to the service's own
+func (s *StaffService) SetServiceOverrides(ctx context.Context, staffID, serviceID string, priceCents, durationMin *int) error {
+	if err := s.staffServiceRepo.SetOverrides(ctx, staffID, serviceID, priceCents, durationMin); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrServiceNotAssigned
+		}
+		return fmt.Errorf("failed to set service overrides: %w", err)
+	}
+	return nil
 }
 
 func (s *StaffService) UnassignServiceFromStaff(ctx context.Context, staffID, serviceID string) error {
@@ -108,3 +141,16 @@ func (s *StaffService) ReplaceStaffServices(ctx context.Context, staffID string,
 
 	return s.staffServiceRepo.ReplaceStaffServices(ctx, staffID, serviceIDs)
 }
+
+// staffServiceLink returns the assignment of a service to a staff member,
+// nil when the service isn't assigned to them
+func staffServiceLink(ctx context.Context, repo domain.StaffServiceRepository, staffID, serviceID string) (*domain.StaffService, error) {
+	link, err := repo.GetStaffService(ctx, staffID, serviceID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get staff service: %w", err)
+	}
+	return link, nil
+}
@@ -0,0 +1,554 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/internal/dto"
+	"github.com/ialekseychuk/my-place/pkg/webhooksig"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook endpoint not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrUnknownEventType        = errors.New("unknown event type")
+	ErrInvalidWebhookURL       = errors.New("webhook url must be an absolute http or https url")
+	errWebhookTargetForbidden  = errors.New("webhook target address is not allowed")
+)
+
+const (
+	webhookSecretPrefix      = "whsec_"
+	webhookBatchSize         = 50
+	webhookLease             = 2 * time.Minute
+	webhookTimeout           = 10 * time.Second
+	webhookMaxAttempts       = 10
+	webhookRetryBase         = 30 * time.Second
+	webhookRetryMax          = 12 * time.Hour
+	webhookResponseMaxBytes  = 1024
+	webhookErrorMaxBytes     = 500
+	webhookDeliveryListLimit = 100
+	webhookUserAgent         = "MyPlace-Webhooks/1.0"
+)
+
+// WebhookService manages the webhook endpoints of businesses and delivers
+// the domain events they subscribed to, signed with the endpoint secret.
+type WebhookService struct {
+	webhookRepo domain.WebhookRepository
+	client      *http.Client
+}
+
+// NewWebhookService returns a service delivering with client, see
+// NewWebhookHTTPClient.
+func NewWebhookService(webhookRepo domain.WebhookRepository, client *http.Client) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		client:      client,
+	}
+}
+
+// NewWebhookHTTPClient returns the client webhooks are delivered with. It
+// doesn't follow redirects and, unless allowPrivate is set, refuses to
+// connect to loopback, private and link-local addresses, so endpoints can't
+// be used to reach internal services. Local test receivers need
+// allowPrivate.
+func NewWebhookHTTPClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+				ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+				return errWebhookTargetForbidden
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// CreateEndpoint adds an endpoint. Its signing secret is only part of this
+// response and of RotateSecret.
+func (s *WebhookService) CreateEndpoint(ctx context.Context, businessID string, req dto.CreateWebhookEndpointRequest) (*dto.WebhookEndpointResponse, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	eventTypes, err := parseWebhookEventTypes(req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := &domain.WebhookEndpoint{
+		BusinessID:  businessID,
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  eventTypes,
+		Secret:      secret,
+		IsActive:    true,
+	}
+	if err := s.webhookRepo.CreateEndpoint(ctx, endpoint); err != nil {
+		return nil, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+
+	response := webhookEndpointResponse(endpoint)
+	response.Secret = endpoint.Secret
+	return &response, nil
+}
+
+func (s *WebhookService) ListEndpoints(ctx context.Context, businessID string) ([]dto.WebhookEndpointResponse, error) {
+	endpoints, err := s.webhookRepo.ListEndpoints(ctx, businessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+
+	responses := make([]dto.WebhookEndpointResponse, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		responses = append(responses, webhookEndpointResponse(endpoint))
+	}
+	return responses, nil
+}
+
+func (s *WebhookService) GetEndpoint(ctx context.Context, endpointID string) (*dto.WebhookEndpointResponse, error) {
+	endpoint, err := s.endpoint(ctx, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	response := webhookEndpointResponse(endpoint)
+	return &response, nil
+}
+
+func (s *WebhookService) UpdateEndpoint(ctx context.Context, endpointID string, req dto.UpdateWebhookEndpointRequest) (*dto.WebhookEndpointResponse, error) {
+	endpoint, err := s.endpoint(ctx, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return nil, err
+		}
+		endpoint.URL = *req.URL
+	}
+	if req.Description != nil {
+		endpoint.Description = *req.Description
+	}
+	if req.EventTypes != nil {
+		if endpoint.EventTypes, err = parseWebhookEventTypes(req.EventTypes); err != nil {
+			return nil, err
+		}
+	}
+	if req.IsActive != nil {
+		endpoint.IsActive = *req.IsActive
+	}
+
+	if err := s.webhookRepo.UpdateEndpoint(ctx, endpoint); err != nil {
+		return nil, fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	response := webhookEndpointResponse(endpoint)
+	return &response, nil
+}
+
+// RotateSecret replaces the signing secret of an endpoint. Deliveries made
+// from now on are signed with the new secret.
+func (s *WebhookService) RotateSecret(ctx context.Context, endpointID string) (*dto.WebhookEndpointResponse, error) {
+	endpoint, err := s.endpoint(ctx, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Secret, err = generateWebhookSecret(); err != nil {
+		return nil, err
+	}
+
+	if err := s.webhookRepo.UpdateEndpoint(ctx, endpoint); err != nil {
+		return nil, fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	response := webhookEndpointResponse(endpoint)
+	response.Secret = endpoint.Secret
+	return &response, nil
+}
+
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, endpointID string) error {
+	if _, err := s.endpoint(ctx, endpointID); err != nil {
+		return err
+	}
+	if err := s.webhookRepo.DeleteEndpoint(ctx, endpointID); err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// SendTestEvent delivers a webhook.test event to an endpoint right away and
+// returns the result. Test events aren't retried.
+func (s *WebhookService) SendTestEvent(ctx context.Context, endpointID string) (*dto.WebhookDeliveryResponse, error) {
+	endpoint, err := s.endpoint(ctx, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(map[string]string{
+		"message":     "This is a test event, sent to check the endpoint receives and verifies webhooks.",
+		"endpoint_id": endpoint.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode test event: %w", err)
+	}
+	payload, err := json.Marshal(dto.WebhookEventPayload{
+		ID:         generateID(),
+		Type:       string(domain.EventWebhookTest),
+		BusinessID: endpoint.BusinessID,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode test event: %w", err)
+	}
+
+	// Not due before the lease ran out, so the worker leaves it to us
+	delivery := &domain.WebhookDelivery{
+		EndpointID:    endpoint.ID,
+		EventType:     domain.EventWebhookTest,
+		Payload:       payload,
+		NextAttemptAt: time.Now().Add(webhookLease),
+	}
+	if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+	if err := s.attempt(ctx, endpoint, delivery, false); err != nil {
+		return nil, err
+	}
+
+	return s.GetDelivery(ctx, endpoint.ID, delivery.ID)
+}
+
+// ListDeliveries returns the latest deliveries of an endpoint
+func (s *WebhookService) ListDeliveries(ctx context.Context, endpointID string) ([]dto.WebhookDeliveryResponse, error) {
+	if _, err := s.endpoint(ctx, endpointID); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.webhookRepo.ListDeliveries(ctx, endpointID, webhookDeliveryListLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	responses := make([]dto.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		responses = append(responses, webhookDeliveryResponse(delivery))
+	}
+	return responses, nil
+}
+
+// GetDelivery returns a delivery with its payload and attempt log
+func (s *WebhookService) GetDelivery(ctx context.Context, endpointID, deliveryID string) (*dto.WebhookDeliveryResponse, error) {
+	delivery, err := s.delivery(ctx, endpointID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := s.webhookRepo.ListAttempts(ctx, delivery.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook delivery attempts: %w", err)
+	}
+
+	response := webhookDeliveryResponse(delivery)
+	response.Payload = delivery.Payload
+	for _, a := range attempts {
+		response.AttemptLog = append(response.AttemptLog, dto.WebhookDeliveryAttemptResponse{
+			Attempt:      a.Attempt,
+			StatusCode:   a.StatusCode,
+			Error:        a.Error,
+			ResponseBody: a.ResponseBody,
+			DurationMs:   a.DurationMs,
+			AttemptedAt:  a.AttemptedAt,
+		})
+	}
+	return &response, nil
+}
+
+// RetryDelivery queues a delivery again, typically a dead one. A dead
+// delivery gets a single further attempt.
+func (s *WebhookService) RetryDelivery(ctx context.Context, endpointID, deliveryID string) (*dto.WebhookDeliveryResponse, error) {
+	delivery, err := s.delivery(ctx, endpointID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.webhookRepo.Redeliver(ctx, delivery.ID); err != nil {
+		return nil, fmt.Errorf("failed to retry webhook delivery: %w", err)
+	}
+	return s.GetDelivery(ctx, endpointID, deliveryID)
+}
+
+// Subscribe registers the service for every event endpoints can subscribe to
+func (s *WebhookService) Subscribe(dispatcher *EventDispatcher) {
+	dispatcher.Subscribe("webhooks", s.HandleEvent, domain.WebhookEventTypes...)
+}
+
+// HandleEvent queues a delivery of the event for every subscribed endpoint
+// of the business. An event handled twice is only delivered once.
+func (s *WebhookService) HandleEvent(ctx context.Context, event *domain.Event) error {
+	endpoints, err := s.webhookRepo.ListSubscribedEndpoints(ctx, event.BusinessID, event.Type)
+	if err != nil {
+		return fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(dto.WebhookEventPayload{
+		ID:         event.ID,
+		Type:       string(event.Type),
+		BusinessID: event.BusinessID,
+		OccurredAt: event.OccurredAt.UTC(),
+		Data:       event.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	for _, endpoint := range endpoints {
+		delivery := &domain.WebhookDelivery{
+			EndpointID: endpoint.ID,
+			EventID:    event.ID,
+			EventType:  event.Type,
+			Payload:    payload,
+		}
+		if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+			return fmt.Errorf("failed to create webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeliverDue attempts due deliveries and returns how many were claimed.
+// Failed deliveries are retried with exponential backoff and end up dead
+// after webhookMaxAttempts.
+func (s *WebhookService) DeliverDue(ctx context.Context) (int, error) {
+	deliveries, err := s.webhookRepo.ClaimDueDeliveries(ctx, webhookBatchSize, webhookLease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	endpoints := make(map[string]*domain.WebhookEndpoint)
+	var errs []error
+	for _, delivery := range deliveries {
+		endpoint, ok := endpoints[delivery.EndpointID]
+		if !ok {
+			if endpoint, err = s.webhookRepo.GetEndpoint(ctx, delivery.EndpointID); err != nil {
+				errs = append(errs, fmt.Errorf("failed to get webhook endpoint: %w", err))
+				continue
+			}
+			endpoints[endpoint.ID] = endpoint
+		}
+
+		if err := s.attempt(ctx, endpoint, delivery, true); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(deliveries), errors.Join(errs...)
+}
+
+// RunWorker delivers due webhooks every interval until ctx is cancelled.
+// Errors are handed to onError and don't stop the worker.
+func (s *WebhookService) RunWorker(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			claimed, err := s.DeliverDue(ctx)
+			if err != nil {
+				onError(err)
+			}
+			if claimed < webhookBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// attempt posts a delivery to its endpoint and records the outcome. Without
+// retry a failed delivery is dead right away.
+func (s *WebhookService) attempt(ctx context.Context, endpoint *domain.WebhookEndpoint, delivery *domain.WebhookDelivery, retry bool) error {
+	now := time.Now()
+	attempt := &domain.WebhookDeliveryAttempt{
+		Attempt:     delivery.Attempts + 1,
+		AttemptedAt: now,
+	}
+
+	statusCode, responseBody, sendErr := s.post(ctx, endpoint, delivery, now)
+	attempt.DurationMs = time.Since(now).Milliseconds()
+	attempt.StatusCode = statusCode
+	attempt.ResponseBody = responseBody
+	if sendErr == nil && (statusCode < 200 || statusCode > 299) {
+		sendErr = fmt.Errorf("endpoint answered %d", statusCode)
+	}
+
+	delivery.Attempts = attempt.Attempt
+	delivery.LastStatusCode = statusCode
+	delivery.LastAttemptAt = &now
+	switch {
+	case sendErr == nil:
+		delivery.Status = domain.WebhookDeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	case retry && delivery.Attempts < webhookMaxAttempts:
+		attempt.Error = truncateError(sendErr, webhookErrorMaxBytes)
+		delivery.LastError = attempt.Error
+		delivery.NextAttemptAt = now.Add(retryDelay(delivery.Attempts, webhookRetryBase, webhookRetryMax))
+	default:
+		attempt.Error = truncateError(sendErr, webhookErrorMaxBytes)
+		delivery.LastError = attempt.Error
+		delivery.Status = domain.WebhookDeliveryDead
+	}
+
+	if err := s.webhookRepo.RecordAttempt(ctx, delivery, attempt); err != nil {
+		return fmt.Errorf("failed to record webhook delivery attempt: %w", err)
+	}
+	return nil
+}
+
+// post sends the signed payload and returns the status code and the start
+// of the response body
+func (s *WebhookService) post(ctx context.Context, endpoint *domain.WebhookEndpoint, delivery *domain.WebhookDelivery, now time.Time) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set("X-MyPlace-Event", string(delivery.EventType))
+	req.Header.Set("X-MyPlace-Delivery", delivery.ID)
+	req.Header.Set(webhooksig.Header, webhooksig.Sign(endpoint.Secret, now, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseMaxBytes))
+	return resp.StatusCode, strings.ToValidUTF8(string(body), ""), nil
+}
+
+func (s *WebhookService) endpoint(ctx context.Context, endpointID string) (*domain.WebhookEndpoint, error) {
+	endpoint, err := s.webhookRepo.GetEndpoint(ctx, endpointID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+func (s *WebhookService) delivery(ctx context.Context, endpointID, deliveryID string) (*domain.WebhookDelivery, error) {
+	delivery, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	if delivery.EndpointID != endpointID {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return delivery, nil
+}
+
+func validateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+func parseWebhookEventTypes(values []string) ([]domain.EventType, error) {
+	eventTypes := make([]domain.EventType, 0, len(values))
+	for _, value := range values {
+		eventType := domain.EventType(value)
+		if !domain.IsWebhookEventType(eventType) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, value)
+		}
+		eventTypes = append(eventTypes, eventType)
+	}
+	return eventTypes, nil
+}
+
+func generateWebhookSecret() (string, error) {
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + token, nil
+}
+
+func webhookEndpointResponse(endpoint *domain.WebhookEndpoint) dto.WebhookEndpointResponse {
+	eventTypes := make([]string, 0, len(endpoint.EventTypes))
+	for _, t := range endpoint.EventTypes {
+		eventTypes = append(eventTypes, string(t))
+	}
+	return dto.WebhookEndpointResponse{
+		ID:          endpoint.ID,
+		URL:         endpoint.URL,
+		Description: endpoint.Description,
+		EventTypes:  eventTypes,
+		IsActive:    endpoint.IsActive,
+		CreatedAt:   endpoint.CreatedAt,
+		UpdatedAt:   endpoint.UpdatedAt,
+	}
+}
+
+func webhookDeliveryResponse(delivery *domain.WebhookDelivery) dto.WebhookDeliveryResponse {
+	response := dto.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		EventID:        delivery.EventID,
+		EventType:      string(delivery.EventType),
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+	if delivery.Status == domain.WebhookDeliveryPending {
+		nextAttemptAt := delivery.NextAttemptAt
+		response.NextAttemptAt = &nextAttemptAt
+	}
+	return response
+}
@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ialekseychuk/my-place/internal/domain"
+	"github.com/ialekseychuk/my-place/pkg/webhooksig"
+)
+
+// deliveryWebhookRepository holds one endpoint and its deliveries in memory.
+// Pending deliveries are always claimed, as if their retry delay had passed.
+type deliveryWebhookRepository struct {
+	domain.WebhookRepository
+	endpoint   *domain.WebhookEndpoint
+	deliveries []*domain.WebhookDelivery
+	attempts   []*domain.WebhookDeliveryAttempt
+}
+
+func (r *deliveryWebhookRepository) GetEndpoint(ctx context.Context, id string) (*domain.WebhookEndpoint, error) {
+	return r.endpoint, nil
+}
+
+func (r *deliveryWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	var due []*domain.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status == domain.WebhookDeliveryPending {
+			due = append(due, delivery)
+		}
+	}
+	return due, nil
+}
+
+func (r *deliveryWebhookRepository) RecordAttempt(ctx context.Context, delivery *domain.WebhookDelivery, attempt *domain.WebhookDeliveryAttempt) error {
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+func newDeliveryWebhookRepository(url string) *deliveryWebhookRepository {
+	return &deliveryWebhookRepository{
+		endpoint: &domain.WebhookEndpoint{
+			ID:         "endpoint",
+			BusinessID: "business",
+			URL:        url,
+			Secret:     "whsec_test",
+			IsActive:   true,
+		},
+		deliveries: []*domain.WebhookDelivery{{
+			ID:         "delivery",
+			EndpointID: "endpoint",
+			EventID:    "event",
+			EventType:  domain.EventBookingCreated,
+			Payload:    []byte(`{"id":"event","type":"booking.created"}`),
+			Status:     domain.WebhookDeliveryPending,
+		}},
+	}
+}
+
+func TestDeliverDueSignsPayload(t *testing.T) {
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		if err := webhooksig.Verify("whsec_test", r.Header.Get(webhooksig.Header), body, time.Now(), time.Minute); err != nil {
+			t.Errorf("signature: %v", err)
+		}
+		if got := r.Header.Get("X-MyPlace-Event"); got != string(domain.EventBookingCreated) {
+			t.Errorf("got event header %q", got)
+		}
+		if got := r.Header.Get("X-MyPlace-Delivery"); got != "delivery" {
+			t.Errorf("got delivery header %q", got)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("got content type %q", got)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	repo := newDeliveryWebhookRepository(server.URL)
+	s := NewWebhookService(repo, NewWebhookHTTPClient(true))
+	if _, err := s.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+
+	delivery := repo.deliveries[0]
+	if received.Load() != 1 {
+		t.Fatalf("endpoint received %d requests, want 1", received.Load())
+	}
+	if delivery.Status != domain.WebhookDeliverySucceeded || delivery.Attempts != 1 || delivery.DeliveredAt == nil {
+		t.Errorf("got status %s after %d attempts, want succeeded after 1", delivery.Status, delivery.Attempts)
+	}
+	if delivery.LastStatusCode != http.StatusNoContent {
+		t.Errorf("got last status code %d, want %d", delivery.LastStatusCode, http.StatusNoContent)
+	}
+}
+
+func TestDeliverDueRetriesWithBackoffUntilDead(t *testing.T) {
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	repo := newDeliveryWebhookRepository(server.URL)
+	s := NewWebhookService(repo, NewWebhookHTTPClient(true))
+	delivery := repo.deliveries[0]
+
+	var lastDelay time.Duration
+	for attempt := 1; attempt < webhookMaxAttempts; attempt++ {
+		if _, err := s.DeliverDue(context.Background()); err != nil {
+			t.Fatalf("DeliverDue: %v", err)
+		}
+		if delivery.Status != domain.WebhookDeliveryPending || delivery.Attempts != attempt {
+			t.Fatalf("got status %s after %d attempts, want pending after %d", delivery.Status, delivery.Attempts, attempt)
+		}
+
+		delay := delivery.NextAttemptAt.Sub(*delivery.LastAttemptAt)
+		if want := retryDelay(attempt, webhookRetryBase, webhookRetryMax); delay != want {
+			t.Errorf("attempt %d: got retry delay %s, want %s", attempt, delay, want)
+		}
+		if delay <= lastDelay && delay < webhookRetryMax {
+			t.Errorf("attempt %d: retry delay %s doesn't back off from %s", attempt, delay, lastDelay)
+		}
+		lastDelay = delay
+	}
+
+	if _, err := s.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+	if delivery.Status != domain.WebhookDeliveryDead || delivery.Attempts != webhookMaxAttempts {
+		t.Errorf("got status %s after %d attempts, want dead after %d", delivery.Status, delivery.Attempts, webhookMaxAttempts)
+	}
+	if delivery.LastStatusCode != http.StatusServiceUnavailable || delivery.LastError == "" {
+		t.Errorf("got last status code %d and error %q", delivery.LastStatusCode, delivery.LastError)
+	}
+
+	// Dead deliveries are no longer claimed
+	if _, err := s.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+	if received.Load() != webhookMaxAttempts || len(repo.attempts) != webhookMaxAttempts {
+		t.Errorf("endpoint received %d requests in %d attempts, want %d", received.Load(), len(repo.attempts), webhookMaxAttempts)
+	}
+}
+
+func TestWebhookHTTPClientRejectsPrivateTargets(t *testing.T) {
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+	}))
+	defer server.Close()
+
+	client := NewWebhookHTTPClient(false)
+	for _, target := range []string{
+		server.URL,                           // loopback
+		"http://[::1]:1/",                    // IPv6 loopback
+		"http://10.0.0.1:1/",                 // private
+		"http://192.168.1.1:1/",              // private
+		"http://169.254.169.254/latest/meta", // link-local, cloud metadata
+		"http://0.0.0.0:1/",                  // unspecified
+	} {
+		t.Run(target, func(t *testing.T) {
+			resp, err := client.Post(target, "application/json", nil)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if !errors.Is(err, errWebhookTargetForbidden) {
+				t.Errorf("got %v, want %v", err, errWebhookTargetForbidden)
+			}
+		})
+	}
+	if received.Load() != 0 {
+		t.Errorf("private endpoint received %d requests", received.Load())
+	}
+
+	// Deliveries to private targets fail and are retried like other errors
+	repo := newDeliveryWebhookRepository(server.URL)
+	s := NewWebhookService(repo, client)
+	if _, err := s.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+	delivery := repo.deliveries[0]
+	if delivery.Status != domain.WebhookDeliveryPending || delivery.LastStatusCode != 0 || delivery.LastError == "" {
+		t.Errorf("got status %s, status code %d and error %q", delivery.Status, delivery.LastStatusCode, delivery.LastError)
+	}
+	if received.Load() != 0 {
+		t.Errorf("private endpoint received %d requests", received.Load())
+	}
+}
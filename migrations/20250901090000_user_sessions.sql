@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_sessions (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id uuid NOT NULL,
+    refresh_token_hash TEXT NOT NULL UNIQUE, -- SHA-256 of the refresh token, never the token itself
+    replaced_by uuid, -- session issued when this one was rotated
+    user_agent TEXT,
+    ip_address TEXT,
+    expires_at TIMESTAMP NOT NULL,
+    is_revoked BOOLEAN NOT NULL DEFAULT false,
+    revoked_at TIMESTAMP,
+    last_used_at TIMESTAMP NOT NULL DEFAULT now(),
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_user_sessions_user_id ON user_sessions(user_id, is_revoked);
+CREATE INDEX idx_user_sessions_family_id ON user_sessions(family_id);
+
+CREATE TRIGGER trg_user_sessions_updated
+    BEFORE UPDATE ON user_sessions
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_user_sessions_updated ON user_sessions;
+DROP TABLE IF EXISTS user_sessions;
+-- +goose StatementEnd
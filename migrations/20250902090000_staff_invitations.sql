@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN staff_id uuid REFERENCES staff(id) ON DELETE SET NULL;
+CREATE UNIQUE INDEX idx_users_staff_id ON users(staff_id) WHERE staff_id IS NOT NULL;
+
+CREATE TABLE staff_invitations (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    business_id uuid NOT NULL REFERENCES businesses(id) ON DELETE CASCADE,
+    staff_id uuid NOT NULL REFERENCES staff(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE, -- SHA-256 of the invite token
+    invited_by TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_staff_invitations_staff_id ON staff_invitations(staff_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS staff_invitations;
+DROP INDEX IF EXISTS idx_users_staff_id;
+ALTER TABLE users DROP COLUMN staff_id;
+-- +goose StatementEnd
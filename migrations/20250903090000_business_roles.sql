@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE business_roles (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    business_id uuid NOT NULL REFERENCES businesses(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT,
+    permissions TEXT[] NOT NULL DEFAULT '{}', -- e.g. {bookings:write,clients:read}
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    UNIQUE(business_id, name)
+);
+
+-- A custom role replaces the permission bundle of the user's built-in role
+ALTER TABLE users ADD COLUMN role_id uuid REFERENCES business_roles(id) ON DELETE SET NULL;
+
+CREATE TRIGGER trg_business_roles_updated
+    BEFORE UPDATE ON business_roles
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN role_id;
+DROP TRIGGER IF EXISTS trg_business_roles_updated ON business_roles;
+DROP TABLE IF EXISTS business_roles;
+-- +goose StatementEnd
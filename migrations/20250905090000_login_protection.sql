@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Failed login counters, keyed by "email:<email>" or "ip:<address>"
+CREATE TABLE login_throttles (
+    key TEXT PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP
+);
+
+CREATE TABLE login_audit (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+    email TEXT NOT NULL,
+    ip_address TEXT,
+    user_agent TEXT,
+    success BOOLEAN NOT NULL,
+    reason TEXT, -- why a login failed: invalid_credentials, account_disabled, locked
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_login_audit_user ON login_audit(user_id, created_at DESC);
+CREATE INDEX idx_login_audit_email ON login_audit(email, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS login_audit;
+DROP TABLE IF EXISTS login_throttles;
+-- +goose StatementEnd
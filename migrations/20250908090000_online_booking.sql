@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Public booking pages are addressed by a slug instead of the business id
+ALTER TABLE businesses ADD COLUMN slug TEXT;
+
+UPDATE businesses
+SET slug = trim(both '-' from lower(regexp_replace(name, '[^a-zA-Z0-9]+', '-', 'g'))) || '-' || left(id::text, 8);
+
+ALTER TABLE businesses ALTER COLUMN slug SET NOT NULL;
+CREATE UNIQUE INDEX idx_businesses_slug ON businesses(slug);
+
+-- Bookings can be cancelled by staff or by the client through the manage link
+ALTER TABLE bookings
+ADD COLUMN status TEXT NOT NULL DEFAULT 'confirmed' CHECK (status IN ('confirmed', 'cancelled')),
+ADD COLUMN cancelled_at TIMESTAMP;
+
+CREATE INDEX idx_bookings_staff_id_start_at ON bookings(staff_id, start_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_bookings_staff_id_start_at;
+ALTER TABLE bookings DROP COLUMN cancelled_at;
+ALTER TABLE bookings DROP COLUMN status;
+
+DROP INDEX IF EXISTS idx_businesses_slug;
+ALTER TABLE businesses DROP COLUMN slug;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Clients can cancel online until this many hours before the appointment
+ALTER TABLE businesses ADD COLUMN cancellation_window_hours INT NOT NULL DEFAULT 24 CHECK (cancellation_window_hours >= 0);
+
+-- One-time codes and magic links of the client portal login
+CREATE TABLE client_login_codes (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    client_id uuid NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+    channel TEXT NOT NULL CHECK (channel IN ('email', 'sms')),
+    code_hash TEXT NOT NULL, -- SHA-256 of the numeric code
+    token_hash TEXT NOT NULL UNIQUE, -- SHA-256 of the magic link token
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_client_login_codes_client_id ON client_login_codes(client_id);
+CREATE INDEX idx_clients_business_id_email ON clients(business_id, lower(email));
+CREATE INDEX idx_bookings_client_id ON bookings(client_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_bookings_client_id;
+DROP INDEX IF EXISTS idx_clients_business_id_email;
+DROP TABLE IF EXISTS client_login_codes;
+ALTER TABLE businesses DROP COLUMN cancellation_window_hours;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Outbox of domain events. Events are written in the transaction of the
+-- state change they describe and delivered to subscribers afterwards.
+CREATE TABLE domain_events (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    type TEXT NOT NULL,
+    business_id uuid NOT NULL REFERENCES businesses(id) ON DELETE CASCADE,
+    aggregate_id TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT now(),
+    delivered_at TIMESTAMP,
+    occurred_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_domain_events_due ON domain_events(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_domain_events_business_id ON domain_events(business_id, occurred_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS domain_events;
+-- +goose StatementEnd
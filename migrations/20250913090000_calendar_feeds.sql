@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE calendar_feeds (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    business_id uuid NOT NULL REFERENCES businesses(id) ON DELETE CASCADE,
+    staff_id uuid REFERENCES staff(id) ON DELETE CASCADE,
+    location_id uuid REFERENCES locations(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE, -- SHA-256 of the token in the feed URL
+    last_accessed_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    CHECK ((staff_id IS NULL) <> (location_id IS NULL))
+);
+
+CREATE INDEX idx_calendar_feeds_business_id ON calendar_feeds(business_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS calendar_feeds;
+-- +goose StatementEnd
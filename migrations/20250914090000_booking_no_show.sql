@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Staff mark bookings the client didn't turn up for as no-shows
+ALTER TABLE bookings DROP CONSTRAINT bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check CHECK (status IN ('confirmed', 'cancelled', 'no_show'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE bookings SET status = 'confirmed' WHERE status = 'no_show';
+ALTER TABLE bookings DROP CONSTRAINT bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check CHECK (status IN ('confirmed', 'cancelled'));
+-- +goose StatementEnd
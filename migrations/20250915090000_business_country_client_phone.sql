@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- National phone numbers are read in the country of the business and stored
+-- in E.164; existing numbers are normalised by cmd/backfill-phones
+ALTER TABLE businesses ADD COLUMN country TEXT NOT NULL DEFAULT '';
+CREATE INDEX idx_clients_business_phone ON clients(business_id, phone);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_clients_business_phone;
+ALTER TABLE businesses DROP COLUMN country;
+-- +goose StatementEnd
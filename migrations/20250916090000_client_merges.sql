@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Audit trail of duplicate clients merged into another one. The merged
+-- client is deleted, so its details are kept as a snapshot.
+CREATE TABLE client_merges (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    business_id uuid NOT NULL REFERENCES businesses(id) ON DELETE CASCADE,
+    client_id uuid NOT NULL REFERENCES clients(id) ON DELETE CASCADE, -- the client that was kept
+    merged_client_id uuid NOT NULL,
+    merged_client JSONB NOT NULL,
+    bookings_moved INT NOT NULL DEFAULT 0,
+    merged_by uuid REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_client_merges_client_id ON client_merges(client_id);
+CREATE INDEX idx_client_merges_merged_client_id ON client_merges(business_id, merged_client_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS client_merges;
+-- +goose StatementEnd
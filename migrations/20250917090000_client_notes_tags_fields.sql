@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE client_notes (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    business_id uuid NOT NULL REFERENCES businesses(id) ON DELETE CASCADE,
+    client_id uuid NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+    author_id uuid REFERENCES users(id) ON DELETE SET NULL, -- NULL when written with an API key
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_client_notes_client_id ON client_notes(client_id, created_at DESC);
+
+CREATE TABLE client_tags (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    business_id uuid NOT NULL REFERENCES businesses(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    color TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_client_tags_business_name ON client_tags(business_id, lower(name));
+
+CREATE TABLE client_tag_assignments (
+    client_id uuid NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+    tag_id uuid NOT NULL REFERENCES client_tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (client_id, tag_id)
+);
+
+CREATE INDEX idx_client_tag_assignments_tag_id ON client_tag_assignments(tag_id);
+
+-- Values are stored as text in a canonical form of their type: numbers as
+-- formatted by Go's strconv, dates as YYYY-MM-DD and selects as one of the
+-- options
+CREATE TABLE client_custom_fields (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    business_id uuid NOT NULL REFERENCES businesses(id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    label TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('text', 'number', 'date', 'select')),
+    options TEXT[] NOT NULL DEFAULT '{}',
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    UNIQUE (business_id, key)
+);
+
+CREATE TABLE client_custom_field_values (
+    client_id uuid NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+    field_id uuid NOT NULL REFERENCES client_custom_fields(id) ON DELETE CASCADE,
+    value TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (client_id, field_id)
+);
+
+CREATE INDEX idx_client_custom_field_values_field_value ON client_custom_field_values(field_id, value);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS client_custom_field_values;
+DROP TABLE IF EXISTS client_custom_fields;
+DROP TABLE IF EXISTS client_tag_assignments;
+DROP TABLE IF EXISTS client_tags;
+DROP TABLE IF EXISTS client_notes;
+-- +goose StatementEnd
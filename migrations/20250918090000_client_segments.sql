@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE client_segments (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    business_id uuid NOT NULL REFERENCES businesses(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    definition JSONB NOT NULL, -- filter tree, see domain.SegmentNode
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_client_segments_business_id ON client_segments(business_id);
+
+-- Visit and spend conditions aggregate the bookings of each client by time
+CREATE INDEX idx_bookings_client_id_start_at ON bookings(client_id, start_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_bookings_client_id_start_at;
+DROP TABLE IF EXISTS client_segments;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Kept up to date by Postgres so search never goes stale
+ALTER TABLE clients
+    ADD COLUMN search_text TEXT GENERATED ALWAYS AS (
+        lower(first_name || ' ' || COALESCE(last_name, '') || ' ' || COALESCE(email, ''))
+    ) STORED,
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        to_tsvector('simple', first_name || ' ' || COALESCE(last_name, '') || ' ' || COALESCE(email, ''))
+    ) STORED,
+    ADD COLUMN phone_digits TEXT GENERATED ALWAYS AS (regexp_replace(phone, '[^0-9]', '', 'g')) STORED;
+
+CREATE INDEX idx_clients_search_text_trgm ON clients USING GIN (search_text gin_trgm_ops);
+CREATE INDEX idx_clients_search_vector ON clients USING GIN (search_vector);
+CREATE INDEX idx_clients_phone_digits_trgm ON clients USING GIN (phone_digits gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_clients_phone_digits_trgm;
+DROP INDEX IF EXISTS idx_clients_search_vector;
+DROP INDEX IF EXISTS idx_clients_search_text_trgm;
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS phone_digits,
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS search_text;
+-- +goose StatementEnd
@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Current consent of a client; client_consents keeps how it came about.
+-- Booking notifications are sent unless withdrawn, marketing needs opting in.
+ALTER TABLE clients
+    ADD COLUMN marketing_consent BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN marketing_consent_at TIMESTAMP,
+    ADD COLUMN notification_consent BOOLEAN NOT NULL DEFAULT true,
+    ADD COLUMN notification_consent_at TIMESTAMP,
+    ADD COLUMN erased_at TIMESTAMP; -- personal data anonymised, bookings kept
+
+CREATE TABLE client_consents (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    business_id uuid NOT NULL REFERENCES businesses(id) ON DELETE CASCADE,
+    client_id uuid NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('marketing', 'notifications')),
+    granted BOOLEAN NOT NULL,
+    source TEXT NOT NULL DEFAULT '', -- where it was given or withdrawn, e.g. "front desk"
+    recorded_by uuid REFERENCES users(id) ON DELETE SET NULL, -- NULL for API keys
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_client_consents_client_id ON client_consents(client_id, created_at DESC);
+
+-- Notifications of a client for data exports and erasure. Booking
+-- notifications queued so far are linked through their dedupe key,
+-- "<event>:<booking id>:<start>:<channel>".
+ALTER TABLE notifications ADD COLUMN client_id uuid REFERENCES clients(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_notifications_client_id ON notifications(client_id);
+
+UPDATE notifications n SET client_id = b.client_id
+FROM bookings b
+WHERE n.event LIKE 'booking\_%' AND b.id::text = split_part(n.dedupe_key, ':', 2);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_notifications_client_id;
+ALTER TABLE notifications DROP COLUMN IF EXISTS client_id;
+DROP TABLE IF EXISTS client_consents;
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS erased_at,
+    DROP COLUMN IF EXISTS notification_consent_at,
+    DROP COLUMN IF EXISTS notification_consent,
+    DROP COLUMN IF EXISTS marketing_consent_at,
+    DROP COLUMN IF EXISTS marketing_consent;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Bulk client imports and exports. Files are kept in the table, inputs until
+-- the job is done and results until cmd/api deletes finished jobs after a
+-- week, as they hold the personal data of clients.
+CREATE TABLE client_jobs (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    business_id uuid NOT NULL REFERENCES businesses(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('import', 'export')),
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'failed')),
+    import_options JSONB,
+    filter JSONB,
+    input BYTEA,
+    result BYTEA,
+    report JSONB,
+    total_rows INT NOT NULL DEFAULT 0,
+    done_rows INT NOT NULL DEFAULT 0,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    lease_until TIMESTAMP, -- a worker holds the running job until then
+    created_by uuid REFERENCES users(id) ON DELETE SET NULL, -- NULL for API keys
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX idx_client_jobs_business_id ON client_jobs(business_id, created_at DESC);
+CREATE INDEX idx_client_jobs_unfinished ON client_jobs(created_at) WHERE status IN ('pending', 'running');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS client_jobs;
+-- +goose StatementEnd
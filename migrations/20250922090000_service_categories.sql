@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE service_categories (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    business_id uuid NOT NULL REFERENCES businesses(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_service_categories_business_name ON service_categories(business_id, lower(name));
+
+-- Services of a deleted category become uncategorized
+ALTER TABLE services
+    ADD COLUMN category_id uuid REFERENCES service_categories(id) ON DELETE SET NULL,
+    ADD COLUMN description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN image_url TEXT NOT NULL DEFAULT '',
+    ADD COLUMN visible_online BOOLEAN NOT NULL DEFAULT true,
+    ADD COLUMN active BOOLEAN NOT NULL DEFAULT true;
+
+CREATE INDEX idx_services_category_id ON services(category_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_services_category_id;
+ALTER TABLE services
+    DROP COLUMN category_id,
+    DROP COLUMN description,
+    DROP COLUMN image_url,
+    DROP COLUMN visible_online,
+    DROP COLUMN active;
+DROP TABLE IF EXISTS service_categories;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A staff member may provide a service at their own price and duration,
+-- NULL falls back to the service's
+ALTER TABLE staff_services
+    ADD COLUMN price_cents INT CHECK (price_cents > 0),
+    ADD COLUMN duration_min INT CHECK (duration_min > 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE staff_services
+    DROP COLUMN price_cents,
+    DROP COLUMN duration_min;
+-- +goose StatementEnd
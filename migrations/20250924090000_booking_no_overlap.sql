@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A staff member can't be booked twice at the same time. The check before
+-- inserting a booking doesn't hold against concurrent requests, this does.
+-- Overlapping bookings already stored have to be resolved before migrating.
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE bookings ADD CONSTRAINT bookings_staff_no_overlap
+    EXCLUDE USING gist (staff_id WITH =, tsrange(start_at, end_at) WITH &&)
+    WHERE (status <> 'cancelled');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_staff_no_overlap;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Bookings find their client by phone, so a business can't have two clients
+-- with the same number. Run cmd/backfill-phones and merge the collisions it
+-- reports before migrating. Erased clients have no phone and are left out.
+DROP INDEX IF EXISTS idx_clients_business_phone;
+CREATE UNIQUE INDEX clients_business_phone_key ON clients(business_id, phone) WHERE phone <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS clients_business_phone_key;
+CREATE INDEX idx_clients_business_phone ON clients(business_id, phone);
+-- +goose StatementEnd
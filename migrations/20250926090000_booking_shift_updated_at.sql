@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Calendar feeds are versioned by the latest updated_at of their bookings and
+-- shifts, so every change has to bump it
+CREATE TRIGGER trg_bookings_updated
+    BEFORE UPDATE ON bookings
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+CREATE TRIGGER trg_staff_shifts_updated
+    BEFORE UPDATE ON staff_shifts
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_staff_shifts_updated ON staff_shifts;
+DROP TRIGGER IF EXISTS trg_bookings_updated ON bookings;
+-- +goose StatementEnd
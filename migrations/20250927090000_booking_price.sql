@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Bookings keep the price and duration agreed when they were made, so later
+-- changes to services and staff overrides don't rewrite what clients spent.
+-- Existing bookings get the current price and their own length.
+ALTER TABLE bookings ADD COLUMN price_cents INTEGER, ADD COLUMN duration_min INTEGER;
+
+ALTER TABLE bookings DISABLE TRIGGER trg_bookings_updated;
+UPDATE bookings b
+SET price_cents = COALESCE(
+        (SELECT ss.price_cents FROM staff_services ss WHERE ss.staff_id = b.staff_id AND ss.service_id = b.service_id),
+        (SELECT s.price_cents FROM services s WHERE s.id = b.service_id)),
+    duration_min = (EXTRACT(EPOCH FROM b.end_at - b.start_at) / 60)::INTEGER;
+ALTER TABLE bookings ENABLE TRIGGER trg_bookings_updated;
+
+ALTER TABLE bookings ALTER COLUMN price_cents SET NOT NULL, ALTER COLUMN duration_min SET NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE bookings DROP COLUMN duration_min, DROP COLUMN price_cents;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Booking notifications record their booking, and reminders expire when the
+-- booking starts, so retries never remind of a cancelled, moved or started
+-- booking.
+ALTER TABLE notifications
+    ADD COLUMN booking_id uuid REFERENCES bookings(id) ON DELETE SET NULL,
+    ADD COLUMN expires_at TIMESTAMP;
+
+-- Booking notifications are keyed <event>:<booking id>:<start>:<channel>
+UPDATE notifications n
+SET booking_id = b.id,
+    expires_at = CASE WHEN n.event IN ('booking_reminder_24h', 'booking_reminder_2h') THEN b.start_at END
+FROM bookings b
+WHERE n.event LIKE 'booking_%' AND b.id::text = split_part(n.dedupe_key, ':', 2);
+
+UPDATE notifications n
+SET status = 'failed', last_error = 'booking cancelled'
+FROM bookings b
+WHERE n.booking_id = b.id AND n.status = 'pending' AND n.expires_at IS NOT NULL AND b.status <> 'confirmed';
+
+CREATE INDEX idx_notifications_booking_id ON notifications(booking_id) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE notifications DROP COLUMN expires_at, DROP COLUMN booking_id;
+-- +goose StatementEnd
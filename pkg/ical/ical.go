@@ -0,0 +1,223 @@
+// Package ical writes RFC 5545 iCalendar feeds.
+//
+// Events are written in the timezone of the calendar. The VTIMEZONE of that
+// zone is derived from the Go tz database and lists the offset changes
+// within the span of the events, which is all clients need to place them.
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+
+	lineMaxOctets = 75
+	localFormat   = "20060102T150405"
+	utcFormat     = "20060102T150405Z"
+)
+
+// Event is a VEVENT. Stamp should only change when the event does, so an
+// unchanged calendar renders to the same bytes.
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	Status       string
+	Start        time.Time
+	End          time.Time
+	Stamp        time.Time
+	LastModified time.Time
+}
+
+// Calendar is a VCALENDAR whose events are written in Location
+type Calendar struct {
+	ProdID   string
+	Name     string
+	Location *time.Location
+	Events   []Event
+}
+
+// Marshal renders the calendar with CRLF line endings and folded lines
+func (c *Calendar) Marshal() []byte {
+	loc := c.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	w := &writer{}
+
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:" + c.ProdID)
+	w.line("CALSCALE:GREGORIAN")
+	w.line("METHOD:PUBLISH")
+	if c.Name != "" {
+		w.line("X-WR-CALNAME:" + escape(c.Name))
+	}
+	if loc != time.UTC {
+		w.line("X-WR-TIMEZONE:" + loc.String())
+		c.writeTimezone(w, loc)
+	}
+
+	for _, e := range c.Events {
+		w.line("BEGIN:VEVENT")
+		w.line("UID:" + e.UID)
+		w.line("DTSTAMP:" + e.Stamp.UTC().Format(utcFormat))
+		w.line(dateTime("DTSTART", e.Start, loc))
+		w.line(dateTime("DTEND", e.End, loc))
+		w.line("SUMMARY:" + escape(e.Summary))
+		if e.Description != "" {
+			w.line("DESCRIPTION:" + escape(e.Description))
+		}
+		if e.Location != "" {
+			w.line("LOCATION:" + escape(e.Location))
+		}
+		if e.Status != "" {
+			w.line("STATUS:" + e.Status)
+		}
+		if !e.LastModified.IsZero() {
+			w.line("LAST-MODIFIED:" + e.LastModified.UTC().Format(utcFormat))
+		}
+		w.line("END:VEVENT")
+	}
+
+	w.line("END:VCALENDAR")
+	return w.buf.Bytes()
+}
+
+// writeTimezone writes the VTIMEZONE of loc covering the events: the offset
+// in effect at the first event, then every change up to the last one
+func (c *Calendar) writeTimezone(w *writer, loc *time.Location) {
+	var from, to time.Time
+	for _, e := range c.Events {
+		if from.IsZero() || e.Start.Before(from) {
+			from = e.Start
+		}
+		if to.IsZero() || e.End.After(to) {
+			to = e.End
+		}
+	}
+	if from.IsZero() {
+		from = time.Now()
+		to = from
+	}
+
+	w.line("BEGIN:VTIMEZONE")
+	w.line("TZID:" + loc.String())
+
+	start := from.In(loc)
+	name, offset := start.Zone()
+	w.observance(start.IsDST(), time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), offset, offset, name)
+	for _, t := range transitions(from, to, loc) {
+		after := t.In(loc)
+		toName, toOffset := after.Zone()
+		_, fromOffset := t.Add(-time.Second).In(loc).Zone()
+		// DTSTART of an observance is the local time before the change
+		onset := t.Add(time.Duration(fromOffset) * time.Second).UTC()
+		w.observance(after.IsDST(), onset, fromOffset, toOffset, toName)
+	}
+
+	w.line("END:VTIMEZONE")
+}
+
+// transitions returns the instants within [from, to] at which the UTC
+// offset of loc changes
+func transitions(from, to time.Time, loc *time.Location) []time.Time {
+	var out []time.Time
+	_, offset := from.In(loc).Zone()
+	for t := from; t.Before(to); {
+		next := t.Add(time.Hour)
+		if _, o := next.In(loc).Zone(); o != offset {
+			// Narrow the change down to the second
+			lo, hi := t, next
+			for hi.Sub(lo) > time.Second {
+				mid := lo.Add(hi.Sub(lo) / 2)
+				if _, o := mid.In(loc).Zone(); o == offset {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			out = append(out, hi.Truncate(time.Second))
+			offset = o
+		}
+		t = next
+	}
+	return out
+}
+
+func dateTime(name string, t time.Time, loc *time.Location) string {
+	if loc == time.UTC {
+		return name + ":" + t.UTC().Format(utcFormat)
+	}
+	return name + ";TZID=" + loc.String() + ":" + t.In(loc).Format(localFormat)
+}
+
+func offsetString(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	s := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+	if seconds%60 != 0 {
+		s += fmt.Sprintf("%02d", seconds%60)
+	}
+	return s
+}
+
+// escape escapes a TEXT value
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
+
+type writer struct {
+	buf bytes.Buffer
+}
+
+func (w *writer) observance(dst bool, onset time.Time, fromOffset, toOffset int, name string) {
+	kind := "STANDARD"
+	if dst {
+		kind = "DAYLIGHT"
+	}
+	w.line("BEGIN:" + kind)
+	w.line("DTSTART:" + onset.Format(localFormat))
+	w.line("TZOFFSETFROM:" + offsetString(fromOffset))
+	w.line("TZOFFSETTO:" + offsetString(toOffset))
+	if name != "" {
+		w.line("TZNAME:" + escape(name))
+	}
+	w.line("END:" + kind)
+}
+
+// line writes a content line, folded so no line exceeds 75 octets and no
+// UTF-8 sequence is split
+func (w *writer) line(s string) {
+	limit := lineMaxOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.buf.WriteString(s[:cut])
+		w.buf.WriteString("\r\n ")
+		s = s[cut:]
+		// The leading space of a continuation line counts towards its length
+		limit = lineMaxOctets - 1
+	}
+	w.buf.WriteString(s)
+	w.buf.WriteString("\r\n")
+}
@@ -0,0 +1,245 @@
+// Package jwtkeys manages the keys access tokens are signed and verified with.
+//
+// A KeySet signs with one active key and verifies with every key it knows,
+// selected by the "kid" header, so keys can be rotated without invalidating
+// tokens that are still in flight. Public keys are published as a JWK Set.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MaxLegacyHMACWindow bounds how long HS256 tokens stay accepted next to
+// asymmetric keys: long enough for access tokens issued before the switch to
+// expire.
+const MaxLegacyHMACWindow = 7 * 24 * time.Hour
+
+// Key is a signing key pair or, for retired keys, just a public key.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer // nil for verification-only keys
+	Public  crypto.PublicKey
+}
+
+// KeySet holds the active signing key and all verification keys.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	// hmacSecret signs and verifies when no asymmetric key is configured, and
+	// verifies HS256 tokens issued before the switch until hmacUntil
+	hmacSecret []byte
+	hmacUntil  time.Time
+}
+
+// NewHMAC returns a key set that signs and verifies with a shared secret.
+func NewHMAC(secret string) *KeySet {
+	return &KeySet{
+		keys:       make(map[string]*Key),
+		hmacSecret: []byte(secret),
+	}
+}
+
+// LoadDir reads every <kid>.pem file in dir. Files may contain a private key
+// (PKCS#8, or PKCS#1 for RSA) or a public key (PKIX). The key named activeKID
+// must be a private key and becomes the signing key.
+func LoadDir(dir, activeKID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &KeySet{keys: make(map[string]*Key)}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key %s: %w", path, err)
+		}
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := ParsePEM(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key %s: %w", path, err)
+		}
+		ks.keys[kid] = key
+	}
+
+	active, ok := ks.keys[activeKID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q not found in %s", activeKID, dir)
+	}
+	if active.Private == nil {
+		return nil, fmt.Errorf("signing key %q has no private key", activeKID)
+	}
+	ks.signing = active
+	return ks, nil
+}
+
+// AcceptLegacyHMAC keeps HS256 tokens signed with secret verifiable until the
+// given time, at most MaxLegacyHMACWindow from now, while clients switch to
+// tokens signed with the asymmetric keys.
+func (ks *KeySet) AcceptLegacyHMAC(secret string, until time.Time) error {
+	if secret == "" {
+		return errors.New("legacy HMAC secret is empty")
+	}
+	if until.After(time.Now().Add(MaxLegacyHMACWindow)) {
+		return fmt.Errorf("legacy HMAC tokens can be accepted for at most %s", MaxLegacyHMACWindow)
+	}
+	ks.hmacSecret = []byte(secret)
+	ks.hmacUntil = until
+	return nil
+}
+
+// ParsePEM parses a PEM encoded RSA or Ed25519 key.
+func ParsePEM(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return key, nil
+}
+
+// Sign signs the claims with the active key and sets its kid header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if ks.signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.hmacSecret)
+	}
+
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.Private)
+}
+
+// Keyfunc resolves the verification key of a token for jwt.Parse. The
+// algorithm must match the key, so a public key can't be abused as an HMAC
+// secret.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(ks.hmacSecret) == 0 || !ks.acceptsHMAC() {
+			return nil, errors.New("HMAC signed tokens are not accepted")
+		}
+		return ks.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for key %q", token.Method.Alg(), kid)
+	}
+	return key.Public, nil
+}
+
+// ValidMethods lists the algorithms the set can verify.
+func (ks *KeySet) ValidMethods() []string {
+	seen := make(map[string]bool)
+	if len(ks.hmacSecret) > 0 && ks.acceptsHMAC() {
+		seen[jwt.SigningMethodHS256.Alg()] = true
+	}
+	for _, key := range ks.keys {
+		seen[key.Method.Alg()] = true
+	}
+
+	methods := make([]string, 0, len(seen))
+	for alg := range seen {
+		methods = append(methods, alg)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// acceptsHMAC reports whether HS256 tokens are still verified: always when
+// signing with the secret, otherwise only during the rotation window
+func (ks *KeySet) acceptsHMAC() bool {
+	return ks.signing == nil || time.Now().Before(ks.hmacUntil)
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 (RFC 8037)
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public verification keys, ordered by kid. The HMAC secret
+// is never published.
+func (ks *KeySet) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, key := range ks.keys {
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyID < set.Keys[j].KeyID })
+	return set
+}
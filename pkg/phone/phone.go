@@ -0,0 +1,183 @@
+// Package phone normalises phone numbers to E.164 ("+79991234567").
+//
+// Numbers in international format ("+", "00" or the international prefix
+// of the default region) are taken as they are. National numbers are read
+// in the default region: its trunk prefix is dropped and its country code
+// prepended. Only the regions in the table below are known; numbers of
+// other regions have to be entered in international format.
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalid       = errors.New("invalid phone number")
+	ErrUnknownRegion = errors.New("unknown phone region")
+	// ErrNoRegion is returned for a national number without a default region
+	ErrNoRegion = errors.New("phone number has no country code")
+)
+
+const (
+	minE164Digits = 8
+	maxE164Digits = 15
+)
+
+// region is the numbering plan of a country, as far as normalising needs it
+type region struct {
+	countryCode string
+	trunk       string // national prefix dropped from national numbers
+	idd         string // international prefix besides "00"
+	minLen      int    // length of the national significant number
+	maxLen      int
+}
+
+// regions by ISO 3166-1 alpha-2 code
+var regions = map[string]region{
+	"RU": {countryCode: "7", trunk: "8", idd: "810", minLen: 10, maxLen: 10},
+	"KZ": {countryCode: "7", trunk: "8", idd: "810", minLen: 10, maxLen: 10},
+	"BY": {countryCode: "375", trunk: "80", idd: "810", minLen: 9, maxLen: 9},
+	"UA": {countryCode: "380", trunk: "0", minLen: 9, maxLen: 9},
+	"UZ": {countryCode: "998", minLen: 9, maxLen: 9},
+	"KG": {countryCode: "996", trunk: "0", minLen: 9, maxLen: 9},
+	"AM": {countryCode: "374", trunk: "0", minLen: 8, maxLen: 8},
+	"GE": {countryCode: "995", trunk: "0", minLen: 9, maxLen: 9},
+	"AZ": {countryCode: "994", trunk: "0", minLen: 9, maxLen: 9},
+	"MD": {countryCode: "373", trunk: "0", minLen: 8, maxLen: 8},
+	"US": {countryCode: "1", trunk: "1", idd: "011", minLen: 10, maxLen: 10},
+	"CA": {countryCode: "1", trunk: "1", idd: "011", minLen: 10, maxLen: 10},
+	"GB": {countryCode: "44", trunk: "0", minLen: 9, maxLen: 10},
+	"IE": {countryCode: "353", trunk: "0", minLen: 7, maxLen: 9},
+	"DE": {countryCode: "49", trunk: "0", minLen: 6, maxLen: 13},
+	"AT": {countryCode: "43", trunk: "0", minLen: 4, maxLen: 13},
+	"CH": {countryCode: "41", trunk: "0", minLen: 9, maxLen: 9},
+	"FR": {countryCode: "33", trunk: "0", minLen: 9, maxLen: 9},
+	"BE": {countryCode: "32", trunk: "0", minLen: 8, maxLen: 9},
+	"NL": {countryCode: "31", trunk: "0", minLen: 9, maxLen: 9},
+	"LU": {countryCode: "352", minLen: 4, maxLen: 11},
+	"ES": {countryCode: "34", minLen: 9, maxLen: 9},
+	"PT": {countryCode: "351", minLen: 9, maxLen: 9},
+	"IT": {countryCode: "39", minLen: 6, maxLen: 11}, // the leading 0 of landlines is kept
+	"PL": {countryCode: "48", minLen: 9, maxLen: 9},
+	"CZ": {countryCode: "420", minLen: 9, maxLen: 9},
+	"SK": {countryCode: "421", trunk: "0", minLen: 9, maxLen: 9},
+	"HU": {countryCode: "36", trunk: "06", minLen: 8, maxLen: 9},
+	"RO": {countryCode: "40", trunk: "0", minLen: 9, maxLen: 9},
+	"BG": {countryCode: "359", trunk: "0", minLen: 8, maxLen: 9},
+	"GR": {countryCode: "30", minLen: 10, maxLen: 10},
+	"TR": {countryCode: "90", trunk: "0", minLen: 10, maxLen: 10},
+	"IL": {countryCode: "972", trunk: "0", minLen: 8, maxLen: 9},
+	"AE": {countryCode: "971", trunk: "0", minLen: 8, maxLen: 9},
+	"SE": {countryCode: "46", trunk: "0", minLen: 7, maxLen: 9},
+	"NO": {countryCode: "47", minLen: 8, maxLen: 8},
+	"DK": {countryCode: "45", minLen: 8, maxLen: 8},
+	"FI": {countryCode: "358", trunk: "0", minLen: 5, maxLen: 12},
+	"EE": {countryCode: "372", minLen: 7, maxLen: 8},
+	"LV": {countryCode: "371", minLen: 8, maxLen: 8},
+	"LT": {countryCode: "370", trunk: "8", minLen: 8, maxLen: 8},
+	"RS": {countryCode: "381", trunk: "0", minLen: 8, maxLen: 9},
+	"HR": {countryCode: "385", trunk: "0", minLen: 8, maxLen: 9},
+	"CY": {countryCode: "357", minLen: 8, maxLen: 8},
+	"AU": {countryCode: "61", trunk: "0", idd: "0011", minLen: 9, maxLen: 9},
+	"NZ": {countryCode: "64", trunk: "0", minLen: 8, maxLen: 10},
+	"IN": {countryCode: "91", trunk: "0", minLen: 10, maxLen: 10},
+	"CN": {countryCode: "86", trunk: "0", minLen: 10, maxLen: 11},
+	"JP": {countryCode: "81", trunk: "0", idd: "010", minLen: 9, maxLen: 10},
+	"KR": {countryCode: "82", trunk: "0", idd: "001", minLen: 8, maxLen: 10},
+	"TH": {countryCode: "66", trunk: "0", idd: "001", minLen: 8, maxLen: 9},
+	"BR": {countryCode: "55", trunk: "0", minLen: 10, maxLen: 11},
+	"MX": {countryCode: "52", minLen: 10, maxLen: 10},
+	"AR": {countryCode: "54", trunk: "0", minLen: 10, maxLen: 10},
+	"ZA": {countryCode: "27", trunk: "0", minLen: 9, maxLen: 9},
+}
+
+// IsKnownRegion reports whether numbers of the region can be read in
+// national format
+func IsKnownRegion(code string) bool {
+	_, ok := regions[strings.ToUpper(code)]
+	return ok
+}
+
+// Normalize returns raw in E.164. National numbers are read in
+// defaultRegion, an ISO 3166-1 alpha-2 code; without one only numbers in
+// international format are accepted.
+func Normalize(raw, defaultRegion string) (string, error) {
+	digits, international, err := strip(raw)
+	if err != nil {
+		return "", err
+	}
+
+	var r region
+	var known bool
+	if defaultRegion != "" {
+		if r, known = regions[strings.ToUpper(defaultRegion)]; !known {
+			return "", ErrUnknownRegion
+		}
+	}
+
+	if !international {
+		switch {
+		case strings.HasPrefix(digits, "00"):
+			digits, international = digits[2:], true
+		case known && r.idd != "" && strings.HasPrefix(digits, r.idd):
+			digits, international = digits[len(r.idd):], true
+		}
+	}
+	if international {
+		return e164(digits)
+	}
+	if !known {
+		return "", ErrNoRegion
+	}
+
+	// A national number, possibly with the trunk prefix
+	national := digits
+	if r.trunk != "" && strings.HasPrefix(national, r.trunk) && r.validLen(len(national)-len(r.trunk)) {
+		national = national[len(r.trunk):]
+	}
+	if r.validLen(len(national)) {
+		return e164(r.countryCode + national)
+	}
+	// The country code without "+", as in "79991234567"
+	if strings.HasPrefix(digits, r.countryCode) && r.validLen(len(digits)-len(r.countryCode)) {
+		return e164(digits)
+	}
+	return "", ErrInvalid
+}
+
+func (r region) validLen(n int) bool {
+	return n >= r.minLen && n <= r.maxLen
+}
+
+// strip removes formatting from raw and reports whether it started with
+// "+". Letters and other symbols make the number invalid.
+func strip(raw string) (string, bool, error) {
+	raw = strings.TrimSpace(raw)
+	international := strings.HasPrefix(raw, "+")
+	if international {
+		raw = raw[1:]
+	}
+
+	var b strings.Builder
+	for _, c := range raw {
+		switch {
+		case c >= '0' && c <= '9':
+			b.WriteRune(c)
+		case c == ' ' || c == '-' || c == '(' || c == ')' || c == '.' || c == '/' || c == '\u00a0':
+		default:
+			return "", false, ErrInvalid
+		}
+	}
+	if b.Len() == 0 {
+		return "", false, ErrInvalid
+	}
+	return b.String(), international, nil
+}
+
+func e164(digits string) (string, error) {
+	if len(digits) < minE164Digits || len(digits) > maxE164Digits || digits[0] == '0' {
+		return "", ErrInvalid
+	}
+	return "+" + digits, nil
+}
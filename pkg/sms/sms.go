@@ -0,0 +1,95 @@
+// Package sms delivers text messages through a pluggable Sender.
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Message is a text message to a phone number.
+type Message struct {
+	To   string
+	Body string
+}
+
+// Sender delivers text messages.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogSender writes text messages to the application log instead of
+// delivering them. Meant for local development.
+type LogSender struct {
+	logger *zap.Logger
+}
+
+func NewLogSender(logger *zap.Logger) *LogSender {
+	return &LogSender{logger: logger}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	s.logger.Info("sms",
+		zap.String("to", msg.To),
+		zap.String("body", msg.Body),
+	)
+	return nil
+}
+
+// HTTPSender delivers text messages through a generic HTTP gateway. Every
+// message is POSTed as JSON {"from", "to", "body"} to the gateway URL, with
+// the token as bearer authorization when set. Any 2xx answer counts as sent.
+type HTTPSender struct {
+	url    string
+	token  string
+	from   string
+	client *http.Client
+}
+
+func NewHTTPSender(url, token, from string) *HTTPSender {
+	return &HTTPSender{
+		url:    url,
+		token:  token,
+		from:   from,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *HTTPSender) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]string{
+		"from": s.from,
+		"to":   msg.To,
+		"body": msg.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode sms: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send sms: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sms gateway answered %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+// Package xlsx reads the cells of Office Open XML spreadsheets as text.
+//
+// Only the first worksheet is read. Cells come as they are stored: shared
+// and inline strings as their text, booleans as TRUE or FALSE and numbers
+// and dates as the number Excel keeps, so dates read as serial numbers.
+// Formatting, formulas and merged cells are ignored.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalid     = errors.New("invalid xlsx file")
+	ErrTooManyRows = errors.New("xlsx file has too many rows")
+)
+
+// maxPartBytes caps the unpacked size of a part of the file, so a small
+// upload can't unpack to gigabytes
+const maxPartBytes = 256 << 20
+
+// Read returns the rows of the first worksheet of data, row i of the sheet
+// at index i-1. Empty rows between filled ones are empty slices, trailing
+// empty rows are left out. More than maxRows rows fail with ErrTooManyRows.
+func Read(data []byte, maxRows int) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	sheet, err := firstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+	var strs []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if strs, err = sharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+	return readSheet(sheet, strs, maxRows)
+}
+
+// firstSheet finds the part of the first sheet of the workbook
+func firstSheet(files map[string]*zip.File) (*zip.File, error) {
+	var workbook struct {
+		Sheets []struct {
+			RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := unmarshalPart(files["xl/workbook.xml"], &workbook); err != nil {
+		return nil, err
+	}
+	if err := unmarshalPart(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, ErrInvalid
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RelID {
+			continue
+		}
+		// Targets are relative to the workbook part unless absolute
+		name := path.Join("xl", rel.Target)
+		if strings.HasPrefix(rel.Target, "/") {
+			name = strings.TrimPrefix(rel.Target, "/")
+		}
+		if f, ok := files[name]; ok {
+			return f, nil
+		}
+	}
+	return nil, ErrInvalid
+}
+
+func sharedStrings(f *zip.File) ([]string, error) {
+	var sst struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := unmarshalPart(f, &sst); err != nil {
+		return nil, err
+	}
+
+	strs := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		if len(item.Runs) == 0 {
+			strs[i] = item.Text
+			continue
+		}
+		// Rich text is split into runs of differently formatted text
+		var b strings.Builder
+		for _, run := range item.Runs {
+			b.WriteString(run.Text)
+		}
+		strs[i] = b.String()
+	}
+	return strs, nil
+}
+
+// readSheet streams the rows of a worksheet, which may be much larger than
+// the rest of the file
+func readSheet(f *zip.File, strs []string, maxRows int) ([][]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	defer rc.Close()
+
+	var (
+		rows     [][]string
+		row      []string
+		cellType string
+		cellRef  string
+		value    strings.Builder
+		inValue  bool
+	)
+	dec := xml.NewDecoder(io.LimitReader(rc, maxPartBytes))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrInvalid
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				index := len(rows) + 1
+				if r := attr(t, "r"); r != "" {
+					if index, err = strconv.Atoi(r); err != nil || index <= len(rows) {
+						return nil, ErrInvalid
+					}
+				}
+				if index > maxRows {
+					return nil, ErrTooManyRows
+				}
+				for len(rows) < index-1 {
+					rows = append(rows, nil)
+				}
+				row = nil
+			case "c":
+				cellType, cellRef = attr(t, "t"), attr(t, "r")
+				value.Reset()
+			case "v", "t":
+				// Text of inline strings sits in <is><t>, of the others in <v>
+				inValue = true
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				text, err := cellText(cellType, value.String(), strs)
+				if err != nil {
+					return nil, err
+				}
+				col := len(row)
+				if cellRef != "" {
+					if col, err = column(cellRef); err != nil {
+						return nil, err
+					}
+				}
+				for len(row) < col {
+					row = append(row, "")
+				}
+				if col < len(row) {
+					row[col] = text
+				} else {
+					row = append(row, text)
+				}
+			case "row":
+				rows = append(rows, row)
+			}
+		}
+	}
+
+	for len(rows) > 0 && isEmpty(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+	return rows, nil
+}
+
+func cellText(cellType, value string, strs []string) (string, error) {
+	switch cellType {
+	case "s":
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 || i >= len(strs) {
+			return "", ErrInvalid
+		}
+		return strs[i], nil
+	case "b":
+		if value == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	default:
+		return value, nil
+	}
+}
+
+// column returns the zero-based column of a cell reference, e.g. 27 for AB3
+func column(ref string) (int, error) {
+	col := 0
+	for i, r := range ref {
+		if r >= 'A' && r <= 'Z' {
+			col = col*26 + int(r-'A') + 1
+			continue
+		}
+		if i == 0 || r < '0' || r > '9' {
+			return 0, ErrInvalid
+		}
+		break
+	}
+	if col == 0 || col > 16384 {
+		return 0, ErrInvalid
+	}
+	return col - 1, nil
+}
+
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func isEmpty(row []string) bool {
+	for _, cell := range row {
+		if cell != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func unmarshalPart(f *zip.File, v any) error {
+	if f == nil {
+		return ErrInvalid
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return ErrInvalid
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(rc, maxPartBytes)).Decode(v); err != nil {
+		return ErrInvalid
+	}
+	return nil
+}